@@ -2,5 +2,9 @@ package auth
 
 type Authorizer interface {
 	Admin() (bool, error)
+	// AdminWrite is true only for the cloud_controller.admin scope, read
+	// only admins and global auditors are not allowed to change anything
+	AdminWrite() (bool, error)
 	HasBillingAccess([]string) (bool, error)
+	Username() (string, error)
 }
@@ -26,6 +26,17 @@ func (sa *SimpleAuthorizer) Admin() (bool, error) {
 	return sa.admin, nil
 }
 
+func (sa *SimpleAuthorizer) AdminWrite() (bool, error) {
+	return sa.admin, nil
+}
+
+func (sa *SimpleAuthorizer) Username() (string, error) {
+	if sa.admin {
+		return "admin", nil
+	}
+	return "user", nil
+}
+
 type SimpleAuthenticator struct {
 	admin              bool
 	authorizedOrgGUIDs []string
@@ -47,6 +47,7 @@ type UAAClaims struct {
 	Scope     []string `json:"scope"`
 	Email     string   `json:"email"`
 	UserName  string   `json:"user_name"`
+	ClientID  string   `json:"client_id"`
 	IssuedAt  int64    `json:"iat"`
 	ExpiresAt int64    `json:"exp"`
 }
@@ -121,6 +122,22 @@ func (a *ClientAuthorizer) Admin() (bool, error) {
 	return false, nil
 }
 
+func (a *ClientAuthorizer) AdminWrite() (bool, error) {
+	return a.hasScope("cloud_controller.admin")
+}
+
+// Username returns the user_name claim of the token, or the client_id for
+// client credentials tokens which have no user
+func (a *ClientAuthorizer) Username() (string, error) {
+	if err := a.composeClaims(); err != nil {
+		return "", err
+	}
+	if a.claims.UserName != "" {
+		return a.claims.UserName, nil
+	}
+	return a.claims.ClientID, nil
+}
+
 func (a *ClientAuthorizer) hasScope(scope string) (bool, error) {
 	if a.scopes == nil {
 		var err error
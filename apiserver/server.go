@@ -22,10 +22,55 @@ type Config struct {
 	Logger lager.Logger
 	// EnablePanic will cause the server to crash on panic if set to true
 	EnablePanic bool
+	// HealthChecks are run by GET /healthz
+	HealthChecks HealthChecks
+	// ReadinessChecks are run by GET /readyz
+	ReadinessChecks HealthChecks
+	// BillableEventsMaxOpenMonths caps the number of months each request to
+	// GET /billable_events queries at the same time, each of which holds a
+	// database connection while it is read. Defaults to
+	// DefaultMaxOpenBillableEventMonths.
+	BillableEventsMaxOpenMonths int
 }
 
 // New creates a new server. Use ListenAndServe to start accepting connections.
 func New(cfg Config) *echo.Echo {
+	e := NewHealthServer(cfg)
+
+	e.GET("/vat_rates", VATRatesHandler(cfg.Store))
+	e.GET("/currency_rates", CurrencyRatesHandler(cfg.Store))
+	e.GET("/pricing_plans", PricingPlansHandler(cfg.Store))
+	e.GET("/forecast_events", ForecastEventsHandler(cfg.Store, cfg.Authenticator))
+	e.POST("/forecast_events", ForecastEventsPostHandler(cfg.Store, cfg.Authenticator), middleware.BodyLimit(DefaultForecastBodyLimit))
+	e.POST("/forecast_events/diff", ForecastDiffHandler(cfg.Store, cfg.Authenticator), middleware.BodyLimit(DefaultForecastBodyLimit))
+	e.GET("/usage_events", UsageEventsHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/billable_events", BillableEventsHandler(cfg.Store, cfg.Store, cfg.Authenticator, cfg.BillableEventsMaxOpenMonths))
+	e.GET("/totals", TotalCostHandler(cfg.Store))
+	e.GET("/projected_costs", CostProjectionHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/costs/daily", DailyCostsHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/cost_anomalies", CostAnomaliesHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/idle_resources", IdleResourcesHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/resources/:guid/timeline", ResourceTimelineHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/raw_events", RawEventsHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/contract_statements", ContractStatementsHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/jobs", JobsHandler(cfg.Store, cfg.Authenticator))
+	e.POST("/jobs", JobsPostHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/jobs/:id", JobHandler(cfg.Store, cfg.Authenticator))
+	e.POST("/jobs/:id/cancel", JobCancelHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/billing_contacts", BillingContactsHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/usage_and_adoption", UsageAndAdoptionHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/adjustments", AdjustmentsHandler(cfg.Store, cfg.Authenticator))
+	e.POST("/adjustments", AdjustmentsPostHandler(cfg.Store, cfg.Authenticator))
+	e.POST("/adjustments/:id/revoke", AdjustmentRevokeHandler(cfg.Store, cfg.Authenticator))
+	e.GET("/adjustments/:id/audit", AdjustmentAuditHandler(cfg.Store, cfg.Authenticator))
+
+	return e
+}
+
+// NewHealthServer creates a server that only responds to the status and
+// health endpoints. It is used directly by processes that do not serve the
+// API.
+func NewHealthServer(cfg Config) *echo.Echo {
 	e := echo.New()
 	e.HTTPErrorHandler = errorHandler
 
@@ -41,15 +86,9 @@ func New(cfg Config) *echo.Echo {
 		}))
 	}
 
-	e.GET("/vat_rates", VATRatesHandler(cfg.Store))
-	e.GET("/currency_rates", CurrencyRatesHandler(cfg.Store))
-	e.GET("/pricing_plans", PricingPlansHandler(cfg.Store))
-	e.GET("/forecast_events", ForecastEventsHandler(cfg.Store))
-	e.GET("/usage_events", UsageEventsHandler(cfg.Store, cfg.Authenticator))
-	e.GET("/billable_events", BillableEventsHandler(cfg.Store, cfg.Store, cfg.Authenticator))
-	e.GET("/totals", TotalCostHandler(cfg.Store))
-
 	e.GET("/", status)
+	e.GET("/healthz", HealthHandler(cfg.HealthChecks))
+	e.GET("/readyz", HealthHandler(cfg.ReadinessChecks))
 
 	return e
 }
@@ -0,0 +1,116 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AdjustmentsHandler lists the adjustments of the requested orgs, or of every
+// org if none are requested
+func AdjustmentsHandler(store eventio.AdjustmentStore, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		adjustments, err := store.GetAdjustments(eventio.AdjustmentFilter{
+			OrgGUIDs:       c.Request().URL.Query()["org_guid"],
+			IncludeRevoked: c.QueryParam("include_revoked") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, adjustments)
+	}
+}
+
+// AdjustmentsPostHandler creates the adjustment in the body on behalf of the
+// requesting user
+func AdjustmentsPostHandler(store eventio.AdjustmentStore, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdminWrite(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		actor, err := requestUsername(c, uaa)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		var adjustment eventio.Adjustment
+		if err := json.NewDecoder(c.Request().Body).Decode(&adjustment); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid adjustment request body: %s", err))
+		}
+		if err := adjustment.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		adjustment, err = store.CreateAdjustment(adjustment, actor)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusCreated, adjustment)
+	}
+}
+
+// AdjustmentRevokeHandler stops an adjustment from being applied to any month
+// that has not been consolidated yet
+func AdjustmentRevokeHandler(store eventio.AdjustmentStore, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdminWrite(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		actor, err := requestUsername(c, uaa)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		id, err := adjustmentID(c)
+		if err != nil {
+			return err
+		}
+		adjustment, err := store.RevokeAdjustment(id, actor)
+		if err != nil {
+			return err
+		}
+		if adjustment == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "adjustment not found")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, adjustment)
+	}
+}
+
+// AdjustmentAuditHandler returns who created and revoked an adjustment
+func AdjustmentAuditHandler(store eventio.AdjustmentStore, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		id, err := adjustmentID(c)
+		if err != nil {
+			return err
+		}
+		entries, err := store.GetAdjustmentAudit(id)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return echo.NewHTTPError(http.StatusNotFound, "adjustment not found")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, entries)
+	}
+}
+
+func adjustmentID(c echo.Context) (string, error) {
+	id, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, errors.New("adjustment id must be a uuid"))
+	}
+	return id.String(), nil
+}
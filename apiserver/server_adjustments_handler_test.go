@@ -0,0 +1,199 @@
+package apiserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdjustmentsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		adjustmentID      = "7b4f3a2c-1d5e-4f6a-8b9c-0d1e2f3a4b5c"
+		adjustment        eventio.Adjustment
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		fakeAuthorizer.AdminWriteReturns(true, nil)
+		fakeAuthorizer.UsernameReturns("ops@example.com", nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		adjustment = eventio.Adjustment{
+			ID:          adjustmentID,
+			OrgGUID:     "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+			Kind:        eventio.CreditAdjustment,
+			Description: "outage",
+			AmountExVAT: "10",
+			VATCode:     "Standard",
+			ValidFrom:   "2001-01-01T00:00:00+00:00",
+			CreatedBy:   "ops@example.com",
+			CreatedAt:   "2001-01-02T00:00:00+00:00",
+		}
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to use the adjustments endpoints", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+		fakeAuthorizer.AdminWriteReturns(false, nil)
+
+		Expect(serve(echo.GET, "/adjustments", "").Code).To(Equal(401))
+		Expect(serve(echo.POST, "/adjustments", `{}`).Code).To(Equal(401))
+		Expect(serve(echo.POST, "/adjustments/"+adjustmentID+"/revoke", "").Code).To(Equal(401))
+		Expect(serve(echo.GET, "/adjustments/"+adjustmentID+"/audit", "").Code).To(Equal(401))
+
+		Expect(fakeStore.GetAdjustmentsCallCount()).To(Equal(0))
+		Expect(fakeStore.CreateAdjustmentCallCount()).To(Equal(0))
+		Expect(fakeStore.RevokeAdjustmentCallCount()).To(Equal(0))
+		Expect(fakeStore.GetAdjustmentAuditCallCount()).To(Equal(0))
+	})
+
+	It("should not allow read only administrators to create or revoke adjustments", func() {
+		fakeAuthorizer.AdminWriteReturns(false, nil)
+
+		Expect(serve(echo.GET, "/adjustments", "").Code).To(Equal(200))
+		Expect(serve(echo.POST, "/adjustments", `{}`).Code).To(Equal(401))
+		Expect(serve(echo.POST, "/adjustments/"+adjustmentID+"/revoke", "").Code).To(Equal(401))
+
+		Expect(fakeStore.CreateAdjustmentCallCount()).To(Equal(0))
+		Expect(fakeStore.RevokeAdjustmentCallCount()).To(Equal(0))
+	})
+
+	It("should list the adjustments of the requested orgs", func() {
+		fakeStore.GetAdjustmentsReturns([]eventio.Adjustment{adjustment}, nil)
+
+		res := serve(echo.GET, "/adjustments?org_guid="+adjustment.OrgGUID+"&include_revoked=true", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetAdjustmentsArgsForCall(0)).To(Equal(eventio.AdjustmentFilter{
+			OrgGUIDs:       []string{adjustment.OrgGUID},
+			IncludeRevoked: true,
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"id": "` + adjustmentID + `",
+			"org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+			"kind": "credit",
+			"description": "outage",
+			"amount_ex_vat": "10",
+			"vat_code": "Standard",
+			"valid_from": "2001-01-01T00:00:00+00:00",
+			"created_by": "ops@example.com",
+			"created_at": "2001-01-02T00:00:00+00:00"
+		}]`))
+	})
+
+	It("should create an adjustment on behalf of the requesting user", func() {
+		fakeStore.CreateAdjustmentReturns(adjustment, nil)
+
+		res := serve(echo.POST, "/adjustments", `{
+			"org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+			"kind": "credit",
+			"description": "outage",
+			"amount_ex_vat": "10",
+			"valid_from": "2001-01-01"
+		}`)
+
+		Expect(res.Code).To(Equal(201))
+		Expect(fakeStore.CreateAdjustmentCallCount()).To(Equal(1))
+		requested, actor := fakeStore.CreateAdjustmentArgsForCall(0)
+		Expect(requested).To(Equal(eventio.Adjustment{
+			OrgGUID:     "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+			Kind:        eventio.CreditAdjustment,
+			Description: "outage",
+			AmountExVAT: "10",
+			ValidFrom:   "2001-01-01",
+		}))
+		Expect(actor).To(Equal("ops@example.com"))
+	})
+
+	It("should reject an invalid adjustment", func() {
+		res := serve(echo.POST, "/adjustments", `{"kind": "discount", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "description": "x", "valid_from": "2001-01-01", "percentage": "120"}`)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.CreateAdjustmentCallCount()).To(Equal(0))
+	})
+
+	It("should revoke an adjustment on behalf of the requesting user", func() {
+		adjustment.RevokedBy = "ops@example.com"
+		adjustment.RevokedAt = "2001-01-03T00:00:00+00:00"
+		fakeStore.RevokeAdjustmentReturns(&adjustment, nil)
+
+		res := serve(echo.POST, "/adjustments/"+adjustmentID+"/revoke", "")
+
+		Expect(res.Code).To(Equal(200))
+		id, actor := fakeStore.RevokeAdjustmentArgsForCall(0)
+		Expect(id).To(Equal(adjustmentID))
+		Expect(actor).To(Equal("ops@example.com"))
+	})
+
+	It("should return 404 when revoking an unknown adjustment", func() {
+		Expect(serve(echo.POST, "/adjustments/"+adjustmentID+"/revoke", "").Code).To(Equal(404))
+	})
+
+	It("should reject an adjustment id that is not a uuid", func() {
+		Expect(serve(echo.POST, "/adjustments/42/revoke", "").Code).To(Equal(400))
+		Expect(fakeStore.RevokeAdjustmentCallCount()).To(Equal(0))
+	})
+
+	It("should return the audit trail of an adjustment", func() {
+		fakeStore.GetAdjustmentAuditReturns([]eventio.AdjustmentAuditEntry{
+			{
+				AdjustmentID: adjustmentID,
+				Action:       eventio.AdjustmentCreated,
+				Actor:        "ops@example.com",
+				OccurredAt:   "2001-01-02T00:00:00+00:00",
+				Adjustment:   adjustment,
+			},
+		}, nil)
+
+		res := serve(echo.GET, "/adjustments/"+adjustmentID+"/audit", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetAdjustmentAuditArgsForCall(0)).To(Equal(adjustmentID))
+	})
+
+	It("should return an error if the store fails", func() {
+		fakeStore.GetAdjustmentsReturns(nil, errors.New("store-error"))
+
+		Expect(serve(echo.GET, "/adjustments", "").Code).To(Equal(500))
+	})
+})
@@ -40,3 +40,64 @@ func authorize(c echo.Context, uaa auth.Authenticator, orgs []string) (bool, err
 	}
 	return false, errors.New("you need to be billing_manager or an administrator to retrieve the billing data")
 }
+
+// authorizeAdmin returns an error unless the request was made by an
+// administrator
+func authorizeAdmin(c echo.Context, uaa auth.Authenticator) error {
+	token, err := auth.GetTokenFromRequest(c)
+	if err != nil {
+		return err
+	}
+	authorizer, err := uaa.NewAuthorizer(token)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := authorizer.Admin()
+	if err != nil {
+		return fmt.Errorf("invalid credentials: %s", err)
+	}
+	if !isAdmin {
+		return errors.New("you need to be an administrator to use this endpoint")
+	}
+	return nil
+}
+
+// authorizeAdminWrite returns an error unless the request was made by an
+// administrator that is allowed to make changes, which read only admins and
+// global auditors are not
+func authorizeAdminWrite(c echo.Context, uaa auth.Authenticator) error {
+	token, err := auth.GetTokenFromRequest(c)
+	if err != nil {
+		return err
+	}
+	authorizer, err := uaa.NewAuthorizer(token)
+	if err != nil {
+		return err
+	}
+	isAdmin, err := authorizer.AdminWrite()
+	if err != nil {
+		return fmt.Errorf("invalid credentials: %s", err)
+	}
+	if !isAdmin {
+		return errors.New("you need to be an administrator with cloud_controller.admin to use this endpoint")
+	}
+	return nil
+}
+
+// requestUsername returns the name of the user (or client) that made the
+// request, for recording in audit trails
+func requestUsername(c echo.Context, uaa auth.Authenticator) (string, error) {
+	token, err := auth.GetTokenFromRequest(c)
+	if err != nil {
+		return "", err
+	}
+	authorizer, err := uaa.NewAuthorizer(token)
+	if err != nil {
+		return "", err
+	}
+	username, err := authorizer.Username()
+	if err != nil {
+		return "", fmt.Errorf("invalid credentials: %s", err)
+	}
+	return username, nil
+}
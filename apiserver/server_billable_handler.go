@@ -2,11 +2,14 @@ package apiserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"io"
 
+	"strconv"
 	"strings"
 
 	"github.com/alphagov/paas-billing/apiserver/auth"
@@ -14,7 +17,15 @@ import (
 	"github.com/labstack/echo"
 )
 
-func BillableEventsHandler(store eventio.BillableEventReader, consolidatedStore eventio.ConsolidatedBillableEventReader, uaa auth.Authenticator) echo.HandlerFunc {
+// DefaultMaxOpenBillableEventMonths is the number of months of billable
+// events each request to BillableEventsHandler queries at the same time if
+// not configured
+const DefaultMaxOpenBillableEventMonths = 3
+
+func BillableEventsHandler(store eventio.BillableEventReader, consolidatedStore eventio.ConsolidatedBillableEventReader, uaa auth.Authenticator, maxOpenMonths int) echo.HandlerFunc {
+	if maxOpenMonths <= 0 {
+		maxOpenMonths = DefaultMaxOpenBillableEventMonths
+	}
 	return func(c echo.Context) error {
 		requestedOrgs := c.Request().URL.Query()["org_guid"]
 		if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
@@ -31,39 +42,55 @@ func BillableEventsHandler(store eventio.BillableEventReader, consolidatedStore
 		if err := filter.Validate(); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, err)
 		}
-
-		storeCtx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		// query the store
-		rowOfRows := RowOfRows{}
-		defer rowOfRows.Close()
+		if explain := c.QueryParam("explain"); explain != "" {
+			var err error
+			if filter.Explain, err = strconv.ParseBool(explain); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("explain must be true or false - got %s", explain))
+			}
+		}
 
 		months, err := filter.SplitByMonth()
 		if err != nil {
 			return err
 		}
-		for _, monthFilter := range months {
+
+		// consolidated months are stored without explanations, and pricing
+		// them again could explain a different price to the one charged
+		if filter.Explain {
+			for _, monthFilter := range months {
+				isConsolidated, err := consolidatedStore.IsRangeConsolidated(monthFilter)
+				if err != nil {
+					return err
+				}
+				if isConsolidated {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("explain is not available for the consolidated month starting %s", monthFilter.RangeStart))
+				}
+			}
+		}
+
+		// query the store, opening upcoming months while earlier ones stream
+		rows := NewPrefetchedRows(context.Background(), months, maxOpenMonths, func(storeCtx context.Context, monthFilter eventio.EventFilter) (eventio.BillableEventRows, error) {
 			isConsolidated, err := consolidatedStore.IsRangeConsolidated(monthFilter)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			var rows eventio.BillableEventRows
 			if isConsolidated {
-				rows, err = consolidatedStore.GetConsolidatedBillableEventRows(storeCtx, monthFilter)
-			} else {
-				rows, err = store.GetBillableEventRows(storeCtx, monthFilter)
+				return consolidatedStore.GetConsolidatedBillableEventRows(storeCtx, monthFilter)
 			}
-			if err != nil {
-				return err
-			}
-			rowOfRows.RowsCollection = append(rowOfRows.RowsCollection, rows)
+			return store.GetBillableEventRows(storeCtx, monthFilter)
+		})
+		defer rows.Close()
+
+		// fail the request rather than the stream if the first month
+		// cannot be queried
+		if err := rows.Ready(); err != nil {
+			return err
 		}
 
 		// stream response to client
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		c.Response().WriteHeader(http.StatusOK)
-		return WriteRowsAsJson(c.Response(), c.Response(), &rowOfRows)
+		return WriteRowsAsJson(c.Response(), c.Response(), rows)
 	}
 }
 
@@ -100,53 +127,197 @@ func WriteRowsAsJson(writer io.Writer, flusher http.Flusher, rows eventio.Billab
 	return rows.Err()
 }
 
-type RowOfRows struct {
-	RowsCollection []eventio.BillableEventRows
-	index          int
+// errPrefetchStopped is the result of the months that were not opened
+// because the PrefetchedRows were closed first
+var errPrefetchStopped = errors.New("prefetching stopped")
+
+type prefetchedMonth struct {
+	rows eventio.BillableEventRows
+	err  error
+}
+
+// PrefetchedRows reads the billable event rows of a series of months in
+// order. Months are opened in the background, at most maxOpen at a time,
+// so that upcoming months are ready by the time the earlier ones have been
+// read. A month's rows are closed as soon as they have been read, which
+// frees a slot for the next month to be opened.
+type PrefetchedRows struct {
+	cancel    context.CancelFunc
+	months    []chan prefetchedMonth
+	slots     chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	index     int
+	current   eventio.BillableEventRows
+	err       error
+	closeErrs []string
 }
 
-func (r *RowOfRows) Next() bool {
-	if r.index >= len(r.RowsCollection) {
+var _ eventio.BillableEventRows = &PrefetchedRows{}
+
+// NewPrefetchedRows starts opening the rows of each of the months with open,
+// which must be safe to call concurrently. open is passed a context that is
+// cancelled when Close is called, so that months still being opened are
+// abandoned. Close must be called to release any months that have not been
+// read.
+func NewPrefetchedRows(ctx context.Context, months []eventio.EventFilter, maxOpen int, open func(context.Context, eventio.EventFilter) (eventio.BillableEventRows, error)) *PrefetchedRows {
+	if maxOpen < 1 {
+		maxOpen = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &PrefetchedRows{
+		cancel: cancel,
+		months: make([]chan prefetchedMonth, len(months)),
+		slots:  make(chan struct{}, maxOpen),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for i := range r.months {
+		r.months[i] = make(chan prefetchedMonth, 1)
+	}
+	go r.prefetch(ctx, months, open)
+	return r
+}
+
+// prefetch opens the months in order as slots become free. Each month's
+// channel receives exactly one result.
+func (r *PrefetchedRows) prefetch(ctx context.Context, months []eventio.EventFilter, open func(context.Context, eventio.EventFilter) (eventio.BillableEventRows, error)) {
+	defer close(r.done)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, month := range months {
+		// a free slot and stop can both be ready, and select picks between
+		// them at random, so stop is checked first
+		if r.stopped() {
+			r.skip(i)
+			return
+		}
+		select {
+		case r.slots <- struct{}{}:
+		case <-r.stop:
+			r.skip(i)
+			return
+		}
+		wg.Add(1)
+		go func(result chan prefetchedMonth, month eventio.EventFilter) {
+			defer wg.Done()
+			rows, err := open(ctx, month)
+			result <- prefetchedMonth{rows: rows, err: err}
+		}(r.months[i], month)
+	}
+}
+
+func (r *PrefetchedRows) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// skip gives the months from index i onwards errPrefetchStopped
+func (r *PrefetchedRows) skip(i int) {
+	for _, result := range r.months[i:] {
+		result <- prefetchedMonth{err: errPrefetchStopped}
+	}
+}
+
+// Ready waits for the first month to be opened and returns the error opening
+// it, if any
+func (r *PrefetchedRows) Ready() error {
+	r.openCurrent()
+	return r.err
+}
+
+// openCurrent waits for the month being read to be opened, and returns false
+// if there are no more months or opening one failed
+func (r *PrefetchedRows) openCurrent() bool {
+	if r.current != nil {
+		return true
+	}
+	if r.err != nil || r.index >= len(r.months) {
+		return false
+	}
+	result := <-r.months[r.index]
+	if result.err != nil {
+		r.err = result.err
+		r.index = r.index + 1
+		<-r.slots
 		return false
 	}
-	if r.RowsCollection[r.index].Next() {
-		return r.Err() == nil
+	r.current = result.rows
+	return true
+}
+
+// closeCurrent closes the month being read and frees its slot
+func (r *PrefetchedRows) closeCurrent() {
+	if err := r.current.Close(); err != nil {
+		r.closeErrs = append(r.closeErrs, err.Error())
 	}
+	r.current = nil
 	r.index = r.index + 1
-	return r.Next()
+	<-r.slots
 }
 
-func (r *RowOfRows) Close() error {
-	errs := []string{}
-	for _, r := range r.RowsCollection {
-		err := r.Close()
-		if err != nil {
-			errs = append(errs, err.Error())
+func (r *PrefetchedRows) Next() bool {
+	for r.openCurrent() {
+		if r.current.Next() {
+			return true
 		}
+		if err := r.current.Err(); err != nil {
+			r.err = err
+			return false
+		}
+		r.closeCurrent()
 	}
-	if len(errs) == 0 {
-		return nil
-	}
-	return fmt.Errorf("errors happened calling RowOfRows.Close(): %s", strings.Join(errs, " | "))
+	return false
 }
 
-func (r *RowOfRows) Err() error {
-	if r.index >= len(r.RowsCollection) {
+// Close stops opening months, cancels any that are still being opened and
+// closes every month that has been opened but not read
+func (r *PrefetchedRows) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		r.cancel()
+	})
+	if r.current != nil {
+		r.closeCurrent()
+	}
+	for ; r.index < len(r.months); r.index++ {
+		result := <-r.months[r.index]
+		if result.err == errPrefetchStopped {
+			continue
+		}
+		if result.rows != nil {
+			if err := result.rows.Close(); err != nil {
+				r.closeErrs = append(r.closeErrs, err.Error())
+			}
+		}
+		<-r.slots
+	}
+	<-r.done
+	if len(r.closeErrs) == 0 {
 		return nil
 	}
-	return r.RowsCollection[r.index].Err()
+	return fmt.Errorf("errors happened calling PrefetchedRows.Close(): %s", strings.Join(r.closeErrs, " | "))
+}
+
+func (r *PrefetchedRows) Err() error {
+	return r.err
 }
 
-func (r *RowOfRows) EventJSON() ([]byte, error) {
-	if r.index >= len(r.RowsCollection) {
-		return nil, fmt.Errorf("no more data in RowsCollection")
+func (r *PrefetchedRows) EventJSON() ([]byte, error) {
+	if r.current == nil {
+		return nil, fmt.Errorf("no more data in PrefetchedRows")
 	}
-	return r.RowsCollection[r.index].EventJSON()
+	return r.current.EventJSON()
 }
 
-func (r *RowOfRows) Event() (*eventio.BillableEvent, error) {
-	if r.index >= len(r.RowsCollection) {
-		return nil, fmt.Errorf("no more data in RowsCollection")
+func (r *PrefetchedRows) Event() (*eventio.BillableEvent, error) {
+	if r.current == nil {
+		return nil, fmt.Errorf("no more data in PrefetchedRows")
 	}
-	return r.RowsCollection[r.index].Event()
+	return r.current.Event()
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-billing/fakes"
@@ -233,10 +234,10 @@ var _ = Describe("BillableEventsHandler", func() {
 		fakeRows := &fakes.FakeBillableEventRows{}
 		fakeRows.CloseReturns(nil)
 		fakeRows.NextReturns(false)
-		fakeStore.IsRangeConsolidatedReturnsOnCall(0, false, nil)
-		fakeStore.IsRangeConsolidatedReturnsOnCall(1, true, nil)
-		fakeStore.IsRangeConsolidatedReturnsOnCall(2, true, nil)
-		fakeStore.IsRangeConsolidatedReturnsOnCall(3, false, nil)
+		// months are queried concurrently so the answer depends on the month
+		fakeStore.IsRangeConsolidatedStub = func(filter eventio.EventFilter) (bool, error) {
+			return filter.RangeStart == "2001-02-01" || filter.RangeStart == "2001-03-01", nil
+		}
 		fakeStore.GetBillableEventRowsReturns(fakeRows, nil)
 		fakeStore.GetConsolidatedBillableEventRowsReturns(fakeRows, nil)
 
@@ -261,18 +262,15 @@ var _ = Describe("BillableEventsHandler", func() {
 		_, filter2 := fakeStore.GetConsolidatedBillableEventRowsArgsForCall(0)
 		_, filter3 := fakeStore.GetConsolidatedBillableEventRowsArgsForCall(1)
 		_, filter4 := fakeStore.GetBillableEventRowsArgsForCall(1)
-		receivedFilters := []eventio.EventFilter{
-			filter1,
-			filter2,
-			filter3,
-			filter4,
-		}
-		Expect(receivedFilters).To(Equal([]eventio.EventFilter{
-			{RangeStart: "2001-01-15", RangeStop: "2001-02-01", OrgGUIDs: []string{orgGUID1}},
-			{RangeStart: "2001-02-01", RangeStop: "2001-03-01", OrgGUIDs: []string{orgGUID1}},
-			{RangeStart: "2001-03-01", RangeStop: "2001-04-01", OrgGUIDs: []string{orgGUID1}},
-			{RangeStart: "2001-04-01", RangeStop: "2001-04-15", OrgGUIDs: []string{orgGUID1}},
-		}))
+		Expect([]eventio.EventFilter{filter1, filter4}).To(ConsistOf(
+			eventio.EventFilter{RangeStart: "2001-01-15", RangeStop: "2001-02-01", OrgGUIDs: []string{orgGUID1}},
+			eventio.EventFilter{RangeStart: "2001-04-01", RangeStop: "2001-04-15", OrgGUIDs: []string{orgGUID1}},
+		))
+		Expect([]eventio.EventFilter{filter2, filter3}).To(ConsistOf(
+			eventio.EventFilter{RangeStart: "2001-02-01", RangeStop: "2001-03-01", OrgGUIDs: []string{orgGUID1}},
+			eventio.EventFilter{RangeStart: "2001-03-01", RangeStop: "2001-04-01", OrgGUIDs: []string{orgGUID1}},
+		))
+		Expect(fakeRows.CloseCallCount()).To(Equal(4))
 
 		Expect(res.Code).To(Equal(200))
 		Expect(res.Header().Get("Content-Type")).To(Equal("application/json; charset=UTF-8"))
@@ -328,6 +326,79 @@ var _ = Describe("BillableEventsHandler", func() {
 		Expect(res.Header().Get("Content-Type")).To(Equal("application/json; charset=UTF-8"))
 	})
 
+	It("should reject explanations of consolidated months", func() {
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		fakeStore.IsRangeConsolidatedReturns(true, nil)
+
+		u := url.URL{}
+		u.Path = "/billable_events"
+		q := u.Query()
+		q.Set("org_guid", orgGUID1)
+		q.Set("range_start", "2001-01-01")
+		q.Set("range_stop", "2001-02-01")
+		q.Set("explain", "true")
+		u.RawQuery = q.Encode()
+		req := httptest.NewRequest(echo.GET, u.String(), nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(res.Body.String()).To(ContainSubstring("explain is not available for the consolidated month starting 2001-01-01"))
+		Expect(fakeStore.GetConsolidatedBillableEventRowsCallCount()).To(Equal(0))
+		Expect(fakeStore.GetBillableEventRowsCallCount()).To(Equal(0))
+	})
+
+	It("should explain the prices of months that have not been consolidated", func() {
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		fakeRows := &fakes.FakeBillableEventRows{}
+		fakeStore.IsRangeConsolidatedReturns(false, nil)
+		fakeStore.GetBillableEventRowsReturns(fakeRows, nil)
+
+		u := url.URL{}
+		u.Path = "/billable_events"
+		q := u.Query()
+		q.Set("org_guid", orgGUID1)
+		q.Set("range_start", "2001-01-01")
+		q.Set("range_stop", "2001-02-01")
+		q.Set("explain", "true")
+		u.RawQuery = q.Encode()
+		req := httptest.NewRequest(echo.GET, u.String(), nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetConsolidatedBillableEventRowsCallCount()).To(Equal(0))
+		Expect(fakeStore.GetBillableEventRowsCallCount()).To(Equal(1))
+		_, filter := fakeStore.GetBillableEventRowsArgsForCall(0)
+		Expect(filter.Explain).To(BeTrue())
+	})
+
+	It("should reject an invalid explain parameter", func() {
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+
+		req := httptest.NewRequest(echo.GET, "/billable_events?org_guid="+orgGUID1+"&range_start=2001-01-01&range_stop=2001-02-01&explain=please", nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetBillableEventRowsCallCount()).To(Equal(0))
+	})
+
 	It("should return error if GetBillableEventRows returns error", func() {
 		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
 		fakeAuthorizer.AdminReturns(true, nil)
@@ -363,7 +434,7 @@ var _ = Describe("BillableEventsHandler", func() {
 var _ = Describe("WriteRowsAsJson", func() {
 	It("Should write out an empty array when there are no rows", func() {
 		b := &FlushyBuffer{bytes.Buffer{}}
-		Expect(WriteRowsAsJson(b, b, &RowOfRows{})).To(Succeed())
+		Expect(WriteRowsAsJson(b, b, prefetched())).To(Succeed())
 		Expect(b.String()).To(MatchJSON(`[]`))
 	})
 
@@ -372,7 +443,7 @@ var _ = Describe("WriteRowsAsJson", func() {
 		events := []eventio.BillableEvent{{EventGUID: "some-event-guid"}}
 		rows := FakeRows{contents: events}
 		rowsCollection := []eventio.BillableEventRows{&rows}
-		Expect(WriteRowsAsJson(b, b, &RowOfRows{RowsCollection: rowsCollection})).To(Succeed())
+		Expect(WriteRowsAsJson(b, b, prefetched(rowsCollection...))).To(Succeed())
 		writtenEvents := []eventio.BillableEvent{}
 		Expect(json.Unmarshal(b.Bytes(), &writtenEvents)).To(Succeed())
 		Expect(writtenEvents).To(Equal(events))
@@ -383,7 +454,7 @@ var _ = Describe("WriteRowsAsJson", func() {
 		events := []eventio.BillableEvent{{EventGUID: "some-event-guid"}, {EventGUID: "some-other-event-guid"}}
 		rows := FakeRows{contents: events}
 		rowsCollection := []eventio.BillableEventRows{&rows}
-		Expect(WriteRowsAsJson(b, b, &RowOfRows{RowsCollection: rowsCollection})).To(Succeed())
+		Expect(WriteRowsAsJson(b, b, prefetched(rowsCollection...))).To(Succeed())
 		writtenEvents := []eventio.BillableEvent{}
 		Expect(json.Unmarshal(b.Bytes(), &writtenEvents)).To(Succeed())
 		Expect(writtenEvents).To(Equal(events))
@@ -395,7 +466,7 @@ var _ = Describe("WriteRowsAsJson", func() {
 		rowsOne := FakeRows{contents: events}
 		rowsTwo := FakeRows{contents: events}
 		rowsCollection := []eventio.BillableEventRows{&rowsOne, &rowsTwo}
-		Expect(WriteRowsAsJson(b, b, &RowOfRows{RowsCollection: rowsCollection})).To(Succeed())
+		Expect(WriteRowsAsJson(b, b, prefetched(rowsCollection...))).To(Succeed())
 		writtenEvents := []eventio.BillableEvent{}
 		bytes := b.Bytes()
 		Expect(json.Unmarshal(bytes, &writtenEvents)).To(Succeed(), "Couldn't parse JSON: "+string(bytes))
@@ -403,6 +474,136 @@ var _ = Describe("WriteRowsAsJson", func() {
 	})
 })
 
+var _ = Describe("PrefetchedRows", func() {
+	var (
+		mu       sync.Mutex
+		open     int
+		maxOpen  int
+		closed   []string
+		months   []eventio.EventFilter
+		openErrs map[string]error
+	)
+
+	opener := func(ctx context.Context, month eventio.EventFilter) (eventio.BillableEventRows, error) {
+		if err := openErrs[month.RangeStart]; err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		open++
+		if open > maxOpen {
+			maxOpen = open
+		}
+		return &countedRows{
+			FakeRows: FakeRows{contents: []eventio.BillableEvent{{EventGUID: month.RangeStart}}},
+			onClose: func() {
+				mu.Lock()
+				defer mu.Unlock()
+				open--
+				closed = append(closed, month.RangeStart)
+			},
+		}, nil
+	}
+
+	BeforeEach(func() {
+		open, maxOpen, closed = 0, 0, nil
+		openErrs = map[string]error{}
+		months = []eventio.EventFilter{}
+		for month := 1; month <= 6; month++ {
+			months = append(months, eventio.EventFilter{RangeStart: fmt.Sprintf("2001-%02d-01", month)})
+		}
+	})
+
+	It("should read the months in order without opening more than maxOpen at a time", func() {
+		rows := NewPrefetchedRows(context.Background(), months, 2, opener)
+		guids := []string{}
+		for rows.Next() {
+			ev, err := rows.Event()
+			Expect(err).ToNot(HaveOccurred())
+			guids = append(guids, ev.EventGUID)
+		}
+		Expect(rows.Err()).ToNot(HaveOccurred())
+		Expect(rows.Close()).To(Succeed())
+
+		Expect(guids).To(Equal([]string{"2001-01-01", "2001-02-01", "2001-03-01", "2001-04-01", "2001-05-01", "2001-06-01"}))
+		Expect(maxOpen).To(BeNumerically("<=", 2))
+		Expect(open).To(Equal(0))
+	})
+
+	It("should stop at a month that fails to open and close the others", func() {
+		openErrs["2001-03-01"] = errors.New("query-error")
+		rows := NewPrefetchedRows(context.Background(), months, 3, opener)
+		Expect(rows.Ready()).To(Succeed())
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		Expect(count).To(Equal(2))
+		Expect(rows.Err()).To(MatchError("query-error"))
+		Expect(rows.Close()).To(Succeed())
+
+		Expect(open).To(Equal(0))
+		Expect(closed).To(ContainElement("2001-01-01"))
+		Expect(closed).To(ContainElement("2001-02-01"))
+	})
+
+	It("should cancel the months being opened when closed", func() {
+		opening := make(chan struct{})
+		rows := NewPrefetchedRows(context.Background(), months, 2, func(ctx context.Context, month eventio.EventFilter) (eventio.BillableEventRows, error) {
+			if month.RangeStart == "2001-01-01" {
+				return opener(ctx, month)
+			}
+			close(opening)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		Expect(rows.Next()).To(BeTrue())
+		Eventually(opening).Should(BeClosed())
+
+		closeErr := make(chan error, 1)
+		go func() {
+			closeErr <- rows.Close()
+		}()
+		Eventually(closeErr).Should(Receive(BeNil()))
+		Expect(open).To(Equal(0))
+	})
+
+	It("should report the error opening the first month when ready", func() {
+		openErrs["2001-01-01"] = errors.New("query-error")
+		rows := NewPrefetchedRows(context.Background(), months, 3, opener)
+		Expect(rows.Ready()).To(MatchError("query-error"))
+		Expect(rows.Next()).To(BeFalse())
+		Expect(rows.Close()).To(Succeed())
+		Expect(open).To(Equal(0))
+	})
+})
+
+type countedRows struct {
+	FakeRows
+	onClose func()
+}
+
+func (r *countedRows) Close() error {
+	r.onClose()
+	return nil
+}
+
+// prefetched returns PrefetchedRows reading each of rows as a month
+func prefetched(rows ...eventio.BillableEventRows) *PrefetchedRows {
+	months := make([]eventio.EventFilter, len(rows))
+	for i := range months {
+		months[i] = eventio.EventFilter{RangeStart: fmt.Sprintf("2001-%02d-01", i+1)}
+	}
+	return NewPrefetchedRows(context.Background(), months, 1, func(ctx context.Context, month eventio.EventFilter) (eventio.BillableEventRows, error) {
+		for i := range months {
+			if months[i].RangeStart == month.RangeStart {
+				return rows[i], nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected month %s", month.RangeStart)
+	})
+}
+
 type FlushyBuffer struct {
 	bytes.Buffer
 }
@@ -0,0 +1,36 @@
+package apiserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// BillingContactsHandler lists the billing managers and org managers of each
+// org on the given date, which defaults to today
+func BillingContactsHandler(store eventio.BillingContactReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		filter := eventio.BillingContactFilter{
+			Date:     c.QueryParam("date"),
+			OrgGUIDs: c.Request().URL.Query()["org_guid"],
+		}
+		if filter.Date == "" {
+			filter.Date = time.Now().Format("2006-01-02")
+		}
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		contacts, err := store.GetBillingContacts(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, contacts)
+	}
+}
@@ -0,0 +1,116 @@
+package apiserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BillingContactsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to list billing contacts", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+
+		Expect(serve("/billing_contacts").Code).To(Equal(401))
+		Expect(fakeStore.GetBillingContactsCallCount()).To(Equal(0))
+	})
+
+	It("should list the billing contacts of the requested orgs on the given date", func() {
+		fakeStore.GetBillingContactsReturns([]eventio.OrgBillingContacts{
+			{
+				OrgGUID: "00000001-0000-0000-0000-000000000000",
+				OrgName: "my-org",
+				Contacts: []eventio.BillingContact{
+					{UserGUID: "00000002-0000-0000-0000-000000000000", Username: "billing@example.com", Role: eventio.BillingManagerRole},
+					{UserGUID: "00000003-0000-0000-0000-000000000000", Username: "manager@example.com", Role: eventio.OrgManagerRole},
+				},
+			},
+		}, nil)
+
+		res := serve("/billing_contacts?date=2001-02-03&org_guid=00000001-0000-0000-0000-000000000000")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetBillingContactsCallCount()).To(Equal(1))
+		Expect(fakeStore.GetBillingContactsArgsForCall(0)).To(Equal(eventio.BillingContactFilter{
+			Date:     "2001-02-03",
+			OrgGUIDs: []string{"00000001-0000-0000-0000-000000000000"},
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"org_guid": "00000001-0000-0000-0000-000000000000",
+			"org_name": "my-org",
+			"contacts": [
+				{"user_guid": "00000002-0000-0000-0000-000000000000", "username": "billing@example.com", "role": "billing_manager"},
+				{"user_guid": "00000003-0000-0000-0000-000000000000", "username": "manager@example.com", "role": "org_manager"}
+			]
+		}]`))
+	})
+
+	It("should default to today", func() {
+		res := serve("/billing_contacts")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetBillingContactsArgsForCall(0).Date).To(Equal(time.Now().Format("2006-01-02")))
+	})
+
+	It("should reject an invalid date", func() {
+		res := serve("/billing_contacts?date=yesterday")
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetBillingContactsCallCount()).To(Equal(0))
+	})
+
+	It("should return an error if the store fails", func() {
+		fakeStore.GetBillingContactsReturns(nil, errors.New("store-error"))
+
+		Expect(serve("/billing_contacts").Code).To(Equal(500))
+	})
+})
@@ -0,0 +1,41 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// ContractStatementsHandler returns the monthly contract statements of the
+// requested orgs, comparing their actual charges with their minimum fee and
+// drawing them down from their commitment
+func ContractStatementsHandler(store eventio.ContractStatementReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestedOrgs := c.Request().URL.Query()["org_guid"]
+		if len(requestedOrgs) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("org_guid param is required"))
+		}
+		if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		} else if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+		filter := eventio.EventFilter{
+			RangeStart: c.QueryParam("range_start"),
+			RangeStop:  c.QueryParam("range_stop"),
+			OrgGUIDs:   requestedOrgs,
+		}
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		statements, err := store.GetContractStatements(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, statements)
+	}
+}
@@ -0,0 +1,117 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContractStatementsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should require an org_guid", func() {
+		Expect(serve("/contract_statements?range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(400))
+		Expect(fakeStore.GetContractStatementsCallCount()).To(Equal(0))
+	})
+
+	It("should require billing access to the org", func() {
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+
+		Expect(serve("/contract_statements?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(401))
+		Expect(fakeStore.GetContractStatementsCallCount()).To(Equal(0))
+	})
+
+	It("should require a valid range", func() {
+		Expect(serve("/contract_statements?org_guid=" + orgGUID + "&range_start=2001-01-01").Code).To(Equal(400))
+		Expect(fakeStore.GetContractStatementsCallCount()).To(Equal(0))
+	})
+
+	It("should return the contract statements of the org", func() {
+		fakeStore.GetContractStatementsReturns([]eventio.ContractStatement{
+			{
+				OrgGUID:                  orgGUID,
+				OrgName:                  "my-org",
+				Month:                    "2001-01-01",
+				ContractValidFrom:        "2001-01-01",
+				ActualExVAT:              "80",
+				MinimumFeeExVAT:          "100",
+				ShortfallExVAT:           "20",
+				CommittedExVAT:           "0",
+				DrawdownExVAT:            "0",
+				RemainingCommitmentExVAT: "0",
+				OverageExVAT:             "80",
+				AmountDueExVAT:           "100",
+			},
+		}, nil)
+
+		res := serve("/contract_statements?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetContractStatementsArgsForCall(0)).To(Equal(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{orgGUID},
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"org_guid": "` + orgGUID + `",
+			"org_name": "my-org",
+			"month": "2001-01-01",
+			"contract_valid_from": "2001-01-01",
+			"actual_ex_vat": "80",
+			"minimum_fee_ex_vat": "100",
+			"shortfall_ex_vat": "20",
+			"committed_ex_vat": "0",
+			"drawdown_ex_vat": "0",
+			"remaining_commitment_ex_vat": "0",
+			"overage_ex_vat": "80",
+			"amount_due_ex_vat": "100"
+		}]`))
+	})
+})
@@ -0,0 +1,44 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// CostAnomaliesHandler lists the cost anomalies found for the requested orgs.
+// Only administrators can list the anomalies of every org by not requesting
+// any.
+func CostAnomaliesHandler(store eventio.CostAnomalyReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestedOrgs := c.Request().URL.Query()["org_guid"]
+		if len(requestedOrgs) == 0 {
+			if err := authorizeAdmin(c, uaa); err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, err)
+			}
+		} else if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		} else if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+		filter := eventio.CostAnomalyFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: c.QueryParam("range_start"),
+				RangeStop:  c.QueryParam("range_stop"),
+				OrgGUIDs:   requestedOrgs,
+			},
+			Scope: eventio.CostAnomalyScope(c.QueryParam("scope")),
+		}
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		anomalies, err := store.GetCostAnomalies(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, anomalies)
+	}
+}
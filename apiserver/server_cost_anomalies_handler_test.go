@@ -0,0 +1,120 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CostAnomaliesHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to list the anomalies of every org", func() {
+		Expect(serve("/cost_anomalies?range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(401))
+		Expect(fakeStore.GetCostAnomaliesCallCount()).To(Equal(0))
+
+		fakeAuthorizer.AdminReturns(true, nil)
+		Expect(serve("/cost_anomalies?range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(200))
+		Expect(fakeStore.GetCostAnomaliesCallCount()).To(Equal(1))
+	})
+
+	It("should require billing access to the requested orgs", func() {
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+
+		Expect(serve("/cost_anomalies?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(401))
+		Expect(fakeStore.GetCostAnomaliesCallCount()).To(Equal(0))
+	})
+
+	It("should reject unknown scopes", func() {
+		Expect(serve("/cost_anomalies?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01&scope=space").Code).To(Equal(400))
+		Expect(fakeStore.GetCostAnomaliesCallCount()).To(Equal(0))
+	})
+
+	It("should return the anomalies of the requested orgs", func() {
+		fakeStore.GetCostAnomaliesReturns([]eventio.CostAnomaly{
+			{
+				Day:                 "2001-01-20",
+				Scope:               eventio.ResourceAnomalyScope,
+				OrgGUID:             orgGUID,
+				OrgName:             "my-org",
+				SubjectGUID:         "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+				SubjectName:         "my-db",
+				CostExVAT:           "96.00",
+				BaselineMeanExVAT:   "0.00",
+				BaselineStddevExVAT: "0.00",
+				DetectedAt:          "2001-01-21T02:00:00Z",
+			},
+		}, nil)
+
+		res := serve("/cost_anomalies?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01&scope=resource")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetCostAnomaliesArgsForCall(0)).To(Equal(eventio.CostAnomalyFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+				OrgGUIDs:   []string{orgGUID},
+			},
+			Scope: eventio.ResourceAnomalyScope,
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"day": "2001-01-20",
+			"scope": "resource",
+			"org_guid": "` + orgGUID + `",
+			"org_name": "my-org",
+			"subject_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+			"subject_name": "my-db",
+			"cost_ex_vat": "96.00",
+			"baseline_mean_ex_vat": "0.00",
+			"baseline_stddev_ex_vat": "0.00",
+			"detected_at": "2001-01-21T02:00:00Z"
+		}]`))
+	})
+})
@@ -9,7 +9,14 @@ import (
 
 func TotalCostHandler(store eventio.TotalCostReader) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		costTotals, err := store.GetTotalCost()
+		labels, err := parseLabelFilters(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		costTotals, err := store.GetTotalCost(eventio.TotalCostFilter{
+			Labels:       labels,
+			GroupByLabel: c.QueryParam("group_by_label"),
+		})
 		if err != nil {
 			return err
 		}
@@ -17,3 +24,16 @@ func TotalCostHandler(store eventio.TotalCostReader) echo.HandlerFunc {
 		return c.JSON(http.StatusOK, costTotals)
 	}
 }
+
+// parseLabelFilters parses each label=key:value query parameter
+func parseLabelFilters(c echo.Context) ([]eventio.LabelFilter, error) {
+	var labels []eventio.LabelFilter
+	for _, s := range c.Request().URL.Query()["label"] {
+		label, err := eventio.ParseLabelFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
@@ -70,6 +70,7 @@ var _ = Describe("TotalCostHandler", func() {
 		defer e.Shutdown(ctx)
 
 		Expect(fakeStore.GetTotalCostCallCount()).To(Equal(1))
+		Expect(fakeStore.GetTotalCostArgsForCall(0)).To(Equal(eventio.TotalCostFilter{}))
 
 		Expect(res.Body).To(MatchJSON(`[
             {
@@ -89,4 +90,42 @@ var _ = Describe("TotalCostHandler", func() {
 		Expect(res.Header().Get("Content-Type")).To(Equal("application/json; charset=UTF-8"))
 	})
 
+	It("should split the totals by the value of a label", func() {
+		fakeStore.GetTotalCostReturns([]eventio.TotalCost{
+			{PlanGUID: "b1341aba-63f9-4747-9abd-d48313483044", LabelValue: "billing", Cost: 1.5},
+			{PlanGUID: "b1341aba-63f9-4747-9abd-d48313483044", Cost: 2},
+		}, nil)
+
+		req := httptest.NewRequest(echo.GET, "/totals?group_by_label=team&label=env:prod&label=tier:web", nil)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetTotalCostArgsForCall(0)).To(Equal(eventio.TotalCostFilter{
+			Labels: []eventio.LabelFilter{
+				{Key: "env", Value: "prod"},
+				{Key: "tier", Value: "web"},
+			},
+			GroupByLabel: "team",
+		}))
+		Expect(res.Body).To(MatchJSON(`[
+			{"plan_guid": "b1341aba-63f9-4747-9abd-d48313483044", "label_value": "billing", "cost": 1.5},
+			{"plan_guid": "b1341aba-63f9-4747-9abd-d48313483044", "cost": 2}
+		]`))
+	})
+
+	It("should reject a malformed label filter", func() {
+		req := httptest.NewRequest(echo.GET, "/totals?label=env", nil)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetTotalCostCallCount()).To(Equal(0))
+	})
 })
@@ -0,0 +1,47 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// DailyCostsHandler returns the cost of each day of the requested range for
+// the requested orgs, optionally split by org, space, plan or component
+func DailyCostsHandler(store eventio.DailyCostReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestedOrgs := c.Request().URL.Query()["org_guid"]
+		if len(requestedOrgs) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("org_guid param is required"))
+		}
+		if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		} else if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+		groupBy, err := eventio.ParseDailyCostGroupings(c.QueryParam("group_by"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		filter := eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: c.QueryParam("range_start"),
+				RangeStop:  c.QueryParam("range_stop"),
+				OrgGUIDs:   requestedOrgs,
+			},
+			GroupBy: groupBy,
+		}
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		costs, err := store.GetDailyCosts(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, costs)
+	}
+}
@@ -0,0 +1,111 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DailyCostsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should require an org_guid", func() {
+		Expect(serve("/costs/daily?range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(400))
+		Expect(fakeStore.GetDailyCostsCallCount()).To(Equal(0))
+	})
+
+	It("should require billing access to the org", func() {
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+
+		Expect(serve("/costs/daily?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01").Code).To(Equal(401))
+		Expect(fakeStore.GetDailyCostsCallCount()).To(Equal(0))
+	})
+
+	It("should require a valid range", func() {
+		Expect(serve("/costs/daily?org_guid=" + orgGUID + "&range_start=2001-01-01").Code).To(Equal(400))
+		Expect(fakeStore.GetDailyCostsCallCount()).To(Equal(0))
+	})
+
+	It("should reject unknown groupings", func() {
+		Expect(serve("/costs/daily?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01&group_by=resource").Code).To(Equal(400))
+		Expect(fakeStore.GetDailyCostsCallCount()).To(Equal(0))
+	})
+
+	It("should return the daily costs split by the requested groupings", func() {
+		fakeStore.GetDailyCostsReturns([]eventio.DailyCost{
+			{
+				Day:        "2001-01-01",
+				PlanGUID:   "f4d4b95a-f55e-4593-8d54-3364c25798c4",
+				PlanName:   "app",
+				CostExVAT:  "24",
+				CostIncVAT: "28.8",
+			},
+		}, nil)
+
+		res := serve("/costs/daily?org_guid=" + orgGUID + "&range_start=2001-01-01&range_stop=2001-02-01&group_by=plan")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetDailyCostsArgsForCall(0)).To(Equal(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+				OrgGUIDs:   []string{orgGUID},
+			},
+			GroupBy: []eventio.DailyCostGrouping{eventio.GroupByPlan},
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"day": "2001-01-01",
+			"plan_guid": "f4d4b95a-f55e-4593-8d54-3364c25798c4",
+			"plan_name": "app",
+			"cost_ex_vat": "24",
+			"cost_inc_vat": "28.8"
+		}]`))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/alphagov/paas-billing/eventio"
 	"github.com/labstack/echo"
 	"github.com/lib/pq"
 )
@@ -23,6 +24,15 @@ func errorHandler(err error, c echo.Context) {
 	case *echo.HTTPError:
 		code = v.Code
 		resp.Error = fmt.Sprintf("%s", v.Message)
+	case *eventio.ArchivedRangeError:
+		code = http.StatusBadRequest
+		resp.Error = v.Error()
+	case *eventio.ConsolidatedAdjustmentError:
+		code = http.StatusBadRequest
+		resp.Error = v.Error()
+	case *eventio.UnknownAfterGUIDError:
+		code = http.StatusNotFound
+		resp.Error = v.Error()
 	case *pq.Error:
 		if v.Code.Name() == "check_violation" {
 			code = http.StatusBadRequest
@@ -12,6 +12,7 @@ import (
 	"github.com/labstack/echo"
 
 	. "github.com/alphagov/paas-billing/apiserver"
+	"github.com/alphagov/paas-billing/eventio"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -83,4 +84,36 @@ var _ = Describe("Errors", func() {
 		Expect(res.Body.String()).To(MatchJSON(`{"error":"friendly-error-message"}`))
 	})
 
+	It("should return 400 for ranges that need archived raw events", func() {
+		e.GET("/archived", func(c echo.Context) error {
+			return &eventio.ArchivedRangeError{RangeStart: "2001-01-01", UnarchivedSince: "2001-02-01"}
+		})
+		req := httptest.NewRequest(echo.GET, "/archived", nil)
+		res := httptest.NewRecorder()
+		e.ServeHTTP(res, req)
+		Expect(res.Code).To(Equal(http.StatusBadRequest))
+		Expect(res.Body.String()).To(MatchJSON(`{"error":"the raw events before 2001-02-01 have been archived so the range cannot start at 2001-01-01"}`))
+	})
+
+	It("should return 400 for adjustments to consolidated months", func() {
+		e.GET("/adjustments", func(c echo.Context) error {
+			return &eventio.ConsolidatedAdjustmentError{MonthStart: "2001-01-01"}
+		})
+		req := httptest.NewRequest(echo.GET, "/adjustments", nil)
+		res := httptest.NewRecorder()
+		e.ServeHTTP(res, req)
+		Expect(res.Code).To(Equal(http.StatusBadRequest))
+		Expect(res.Body.String()).To(MatchJSON(`{"error":"the adjustment applies to the consolidated month starting 2001-01-01, which cannot be changed unless the month is detached"}`))
+	})
+
+	It("should return 404 when paging after an unknown event", func() {
+		e.GET("/raw", func(c echo.Context) error {
+			return &eventio.UnknownAfterGUIDError{Kind: "app", GUID: "00000000-0000-0000-0000-000000000001"}
+		})
+		req := httptest.NewRequest(echo.GET, "/raw", nil)
+		res := httptest.NewRecorder()
+		e.ServeHTTP(res, req)
+		Expect(res.Code).To(Equal(http.StatusNotFound))
+		Expect(res.Body.String()).To(MatchJSON(`{"error":"there is no app event with guid 00000000-0000-0000-0000-000000000001 to continue after"}`))
+	})
 })
@@ -7,79 +7,148 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/alphagov/paas-billing/apiserver/auth"
 	"github.com/alphagov/paas-billing/eventio"
 	"github.com/alphagov/paas-billing/eventstore"
 	"github.com/labstack/echo"
 )
 
-func ForecastEventsHandler(store eventio.BillableEventForecaster) echo.HandlerFunc {
+const (
+	// DefaultForecastBodyLimit is the max size of a POST /forecast_events body
+	DefaultForecastBodyLimit = "4M"
+)
+
+// ForecastRequest is the body accepted by POST /forecast_events and POST
+// /forecast_events/diff
+type ForecastRequest struct {
+	RangeStart  string                       `json:"range_start"`
+	RangeStop   string                       `json:"range_stop"`
+	OrgGUIDs    []string                     `json:"org_guid"`
+	Events      []eventio.UsageEvent         `json:"events"`
+	Adjustments []eventio.ResourceAdjustment `json:"adjustments"`
+}
+
+func (r *ForecastRequest) filter() eventio.EventFilter {
+	orgGUIDs := r.OrgGUIDs
+	if len(orgGUIDs) == 0 {
+		orgGUIDs = []string{eventstore.DummyOrgGUID}
+	}
+	return eventio.EventFilter{
+		RangeStart: r.RangeStart,
+		RangeStop:  r.RangeStop,
+		OrgGUIDs:   orgGUIDs,
+	}
+}
+
+// ForecastEventsHandler accepts the events to simulate as a JSON encoded
+// "events" query parameter. Use ForecastEventsPostHandler for larger inputs.
+func ForecastEventsHandler(store eventio.BillableEventForecaster, uaa auth.Authenticator) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		requestedOrgGUIDs := c.Request().URL.Query()["org_guid"]
-		for _, guid := range requestedOrgGUIDs {
-			if guid != eventstore.DummyOrgGUID {
-				return echo.NewHTTPError(http.StatusForbidden, fmt.Errorf("you are not authorized to forecast events for org '%s'", guid))
-			}
-		}
-		// parse params
-		filter := eventio.EventFilter{
+		req := ForecastRequest{
 			RangeStart: c.QueryParam("range_start"),
 			RangeStop:  c.QueryParam("range_stop"),
-			OrgGUIDs:   []string{eventstore.DummyOrgGUID},
-		}
-		if err := filter.Validate(); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err)
+			OrgGUIDs:   c.Request().URL.Query()["org_guid"],
 		}
 		inputEventData := c.QueryParam("events")
 		if inputEventData == "" {
 			return echo.NewHTTPError(http.StatusBadRequest, errors.New("events param is required"))
 		}
-		var inputEvents []eventio.UsageEvent
-		if err := json.Unmarshal([]byte(inputEventData), &inputEvents); err != nil {
+		if err := json.Unmarshal([]byte(inputEventData), &req.Events); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, err)
 		}
+		return forecastEvents(c, store, uaa, req)
+	}
+}
 
-		storeCtx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+// ForecastEventsPostHandler accepts a ForecastRequest as the JSON body
+func ForecastEventsPostHandler(store eventio.BillableEventForecaster, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req ForecastRequest
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid forecast request body: %s", err))
+		}
+		if req.Events == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("events field is required"))
+		}
+		return forecastEvents(c, store, uaa, req)
+	}
+}
 
-		// query the store
-		rows, err := store.ForecastBillableEventRows(storeCtx, inputEvents, filter)
-		if err != nil {
-			return err
+// ForecastDiffHandler accepts a ForecastRequest with a list of adjustments to
+// the existing resources of the requested orgs and responds with the
+// difference between the actual and forecasted cost of each resource
+func ForecastDiffHandler(store eventio.BillableEventForecaster, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req ForecastRequest
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid forecast request body: %s", err))
 		}
-		defer rows.Close()
-		// stream response to client
-		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
-		c.Response().WriteHeader(http.StatusOK)
-		if _, err := c.Response().Write([]byte("[\n")); err != nil {
-			return err
+		if len(req.OrgGUIDs) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("org_guid field is required"))
 		}
-		c.Response().Flush()
-		next := rows.Next()
-		delim := "\n"
-		for next {
-			b, err := rows.EventJSON()
-			if err != nil {
-				return err
-			}
-			if _, err := c.Response().Write(b); err != nil {
-				return err
-			}
-			next = rows.Next()
-			if next {
-				delim = ",\n"
-			} else {
-				delim = "\n"
-			}
-			if _, err := c.Response().Write([]byte(delim)); err != nil {
-				return err
+		if len(req.Adjustments) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("adjustments field is required"))
+		}
+		for _, adjustment := range req.Adjustments {
+			if err := adjustment.Validate(); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err)
 			}
-			c.Response().Flush()
 		}
-		if _, err := c.Response().Write([]byte("]\n")); err != nil {
+		filter := req.filter()
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		if err := authorizeForecast(c, uaa, filter.OrgGUIDs); err != nil {
+			return err
+		}
+
+		diffs, err := store.ForecastBillableEventDiffs(c.Request().Context(), req.Adjustments, filter)
+		if err != nil {
 			return err
 		}
-		c.Response().Flush()
-		return rows.Err()
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, diffs)
+	}
+}
+
+func forecastEvents(c echo.Context, store eventio.BillableEventForecaster, uaa auth.Authenticator, req ForecastRequest) error {
+	filter := req.filter()
+	if err := authorizeForecast(c, uaa, filter.OrgGUIDs); err != nil {
+		return err
+	}
+	if err := filter.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	storeCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
+	// query the store
+	rows, err := store.ForecastBillableEventRows(storeCtx, req.Events, filter)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	// stream response to client
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	return WriteRowsAsJson(c.Response(), c.Response(), rows)
+}
+
+// authorizeForecast allows anyone to forecast against the dummy org, but
+// requires billing access for any real org
+func authorizeForecast(c echo.Context, uaa auth.Authenticator, orgGUIDs []string) error {
+	realOrgGUIDs := []string{}
+	for _, guid := range orgGUIDs {
+		if guid != eventstore.DummyOrgGUID {
+			realOrgGUIDs = append(realOrgGUIDs, guid)
+		}
+	}
+	if len(realOrgGUIDs) == 0 {
+		return nil
+	}
+	if ok, _ := authorize(c, uaa, realOrgGUIDs); !ok {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Errorf("you are not authorized to forecast events for org '%s'", realOrgGUIDs[0]))
 	}
+	return nil
 }
@@ -0,0 +1,86 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// HealthCheck returns details about the state of a dependency and an error
+// if the dependency is unavailable or stale
+type HealthCheck func() (interface{}, error)
+
+// HealthChecks is a set of named HealthCheck
+type HealthChecks map[string]HealthCheck
+
+// HealthCheckResult is the outcome of a single HealthCheck
+type HealthCheckResult struct {
+	OK      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// HealthResponse is the body returned by HealthHandler
+type HealthResponse struct {
+	OK     bool                         `json:"ok"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+// HealthHandler runs every check and responds with 503 if any of them fail
+func HealthHandler(checks HealthChecks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res := HealthResponse{
+			OK:     true,
+			Checks: map[string]HealthCheckResult{},
+		}
+		for name, check := range checks {
+			details, err := check()
+			result := HealthCheckResult{
+				OK:      err == nil,
+				Details: details,
+			}
+			if err != nil {
+				result.Error = err.Error()
+				res.OK = false
+			}
+			res.Checks[name] = result
+		}
+		code := http.StatusOK
+		if !res.OK {
+			code = http.StatusServiceUnavailable
+		}
+		return c.JSONPretty(code, res, "  ")
+	}
+}
+
+// DatabaseHealthCheck fails if the store's database is unreachable
+func DatabaseHealthCheck(store eventio.StoreHealthChecker) HealthCheck {
+	return func() (interface{}, error) {
+		return nil, store.Ping()
+	}
+}
+
+// RefreshHealthCheck fails if the store's events have not been refreshed
+// within maxAge
+func RefreshHealthCheck(store eventio.StoreHealthChecker, maxAge time.Duration) HealthCheck {
+	return func() (interface{}, error) {
+		lastRefresh, err := store.GetLastRefresh()
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{
+			"last_refresh": lastRefresh,
+			"max_age":      maxAge.String(),
+		}
+		if lastRefresh.IsZero() {
+			return details, fmt.Errorf("events have never been refreshed")
+		}
+		if age := time.Since(lastRefresh); age > maxAge {
+			return details, fmt.Errorf("events were last refreshed %s ago", age.Round(time.Second))
+		}
+		return details, nil
+	}
+}
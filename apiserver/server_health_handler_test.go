@@ -0,0 +1,140 @@
+package apiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthHandler", func() {
+
+	var (
+		ctx       context.Context
+		cancel    context.CancelFunc
+		cfg       Config
+		fakeStore *fakes.FakeEventStore
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		cfg = Config{
+			Logger:      lager.NewLogger("test"),
+			EnablePanic: true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	get := func(e *echo.Echo, path string) (int, HealthResponse) {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		res := httptest.NewRecorder()
+		e.ServeHTTP(res, req)
+		var body HealthResponse
+		Expect(json.Unmarshal(res.Body.Bytes(), &body)).To(Succeed())
+		return res.Code, body
+	}
+
+	It("should respond ok when every check passes", func() {
+		fakeStore.GetLastRefreshReturns(time.Now().Add(-1*time.Hour), nil)
+		cfg.HealthChecks = HealthChecks{
+			"database": DatabaseHealthCheck(fakeStore),
+			"refresh":  RefreshHealthCheck(fakeStore, 2*time.Hour),
+		}
+
+		e := NewHealthServer(cfg)
+		defer e.Shutdown(ctx)
+
+		code, body := get(e, "/healthz")
+		Expect(code).To(Equal(200))
+		Expect(body.OK).To(BeTrue())
+		Expect(body.Checks).To(HaveKeyWithValue("database", HealthCheckResult{OK: true}))
+		Expect(body.Checks).To(HaveKey("refresh"))
+		Expect(body.Checks["refresh"].OK).To(BeTrue())
+		Expect(fakeStore.PingCallCount()).To(Equal(1))
+	})
+
+	It("should respond 503 with details when the database is unreachable", func() {
+		fakeStore.PingReturns(errors.New("connection refused"))
+		cfg.ReadinessChecks = HealthChecks{
+			"database": DatabaseHealthCheck(fakeStore),
+		}
+
+		e := NewHealthServer(cfg)
+		defer e.Shutdown(ctx)
+
+		code, body := get(e, "/readyz")
+		Expect(code).To(Equal(503))
+		Expect(body.OK).To(BeFalse())
+		Expect(body.Checks).To(HaveKeyWithValue("database", HealthCheckResult{
+			OK:    false,
+			Error: "connection refused",
+		}))
+	})
+
+	It("should respond 503 when the last refresh is too old", func() {
+		fakeStore.GetLastRefreshReturns(time.Now().Add(-3*time.Hour), nil)
+		cfg.HealthChecks = HealthChecks{
+			"refresh": RefreshHealthCheck(fakeStore, 2*time.Hour),
+		}
+
+		e := NewHealthServer(cfg)
+		defer e.Shutdown(ctx)
+
+		code, body := get(e, "/healthz")
+		Expect(code).To(Equal(503))
+		Expect(body.OK).To(BeFalse())
+		Expect(body.Checks["refresh"].Error).To(ContainSubstring("events were last refreshed 3h0m"))
+		Expect(body.Checks["refresh"].Details).To(HaveKeyWithValue("max_age", "2h0m0s"))
+	})
+
+	It("should respond 503 when the events have never been refreshed", func() {
+		fakeStore.GetLastRefreshReturns(time.Time{}, nil)
+		cfg.HealthChecks = HealthChecks{
+			"refresh": RefreshHealthCheck(fakeStore, 2*time.Hour),
+		}
+
+		e := NewHealthServer(cfg)
+		defer e.Shutdown(ctx)
+
+		code, body := get(e, "/healthz")
+		Expect(code).To(Equal(503))
+		Expect(body.Checks["refresh"].Error).To(Equal("events have never been refreshed"))
+	})
+
+	It("should respond ok when there are no checks", func() {
+		e := NewHealthServer(cfg)
+		defer e.Shutdown(ctx)
+
+		code, body := get(e, "/readyz")
+		Expect(code).To(Equal(200))
+		Expect(body.OK).To(BeTrue())
+	})
+
+	It("should serve the health endpoints alongside the API", func() {
+		fakeStore.PingReturns(errors.New("connection refused"))
+		cfg.Store = fakeStore
+		cfg.Authenticator = &fakes.FakeAuthenticator{}
+		cfg.ReadinessChecks = HealthChecks{
+			"database": DatabaseHealthCheck(fakeStore),
+		}
+
+		e := New(cfg)
+		defer e.Shutdown(ctx)
+
+		code, _ := get(e, "/readyz")
+		Expect(code).To(Equal(503))
+	})
+})
@@ -0,0 +1,51 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// DefaultIdleResourceMinAgeDays is how old resources must be to be listed by
+// the IdleResourcesHandler unless min_age_days is given
+const DefaultIdleResourceMinAgeDays = 7
+
+// IdleResourcesHandler lists the resources of the requested orgs that have
+// cost money this month, most expensive first, to show where money could be
+// saved. Only administrators can list the resources of every org by not
+// requesting any.
+func IdleResourcesHandler(store eventio.IdleResourceReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestedOrgs := c.Request().URL.Query()["org_guid"]
+		if len(requestedOrgs) == 0 {
+			if err := authorizeAdmin(c, uaa); err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, err)
+			}
+		} else if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		} else if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+		filter := eventio.IdleResourceFilter{
+			OrgGUIDs:   requestedOrgs,
+			MinAgeDays: DefaultIdleResourceMinAgeDays,
+		}
+		if s := c.QueryParam("min_age_days"); s != "" {
+			days, err := strconv.Atoi(s)
+			if err != nil || days < 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("min_age_days must be a whole number of days - got %s", s))
+			}
+			filter.MinAgeDays = days
+		}
+		resources, err := store.GetIdleResources(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, resources)
+	}
+}
@@ -0,0 +1,132 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IdleResourcesHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to list the resources of every org", func() {
+		Expect(serve("/idle_resources").Code).To(Equal(401))
+		Expect(fakeStore.GetIdleResourcesCallCount()).To(Equal(0))
+
+		fakeAuthorizer.AdminReturns(true, nil)
+		Expect(serve("/idle_resources").Code).To(Equal(200))
+		Expect(fakeStore.GetIdleResourcesCallCount()).To(Equal(1))
+	})
+
+	It("should require billing access to the requested orgs", func() {
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+
+		Expect(serve("/idle_resources?org_guid=" + orgGUID).Code).To(Equal(401))
+		Expect(fakeStore.GetIdleResourcesCallCount()).To(Equal(0))
+	})
+
+	It("should reject an invalid minimum age", func() {
+		Expect(serve("/idle_resources?org_guid=" + orgGUID + "&min_age_days=-1").Code).To(Equal(400))
+		Expect(serve("/idle_resources?org_guid=" + orgGUID + "&min_age_days=week").Code).To(Equal(400))
+		Expect(fakeStore.GetIdleResourcesCallCount()).To(Equal(0))
+	})
+
+	It("should default the minimum age", func() {
+		Expect(serve("/idle_resources?org_guid=" + orgGUID).Code).To(Equal(200))
+		Expect(fakeStore.GetIdleResourcesArgsForCall(0)).To(Equal(eventio.IdleResourceFilter{
+			OrgGUIDs:   []string{orgGUID},
+			MinAgeDays: DefaultIdleResourceMinAgeDays,
+		}))
+	})
+
+	It("should return the resources ranked by spend", func() {
+		fakeStore.GetIdleResourcesReturns([]eventio.IdleResource{
+			{
+				ResourceGUID:     "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+				ResourceName:     "my-db",
+				ResourceType:     "postgres",
+				OrgGUID:          orgGUID,
+				OrgName:          "my-org",
+				SpaceGUID:        "276f4886-ac40-492d-a8cd-b2646637ba76",
+				SpaceName:        "my-space",
+				PlanGUID:         "efb5f1ce-0a8a-435d-a8b2-6b2b61c6dbe5",
+				PlanName:         "medium",
+				Running:          true,
+				FirstSeen:        "2001-01-01T00:00:00Z",
+				AgeDays:          45,
+				LastStateChange:  "2001-01-01T00:00:00Z",
+				MonthToDateExVAT: "150.5",
+				Warnings:         []eventio.ResourceWarning{eventio.StoppedOrgWarning},
+			},
+		}, nil)
+
+		res := serve("/idle_resources?org_guid=" + orgGUID + "&min_age_days=30")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetIdleResourcesArgsForCall(0).MinAgeDays).To(Equal(30))
+		Expect(res.Body).To(MatchJSON(`[{
+			"resource_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+			"resource_name": "my-db",
+			"resource_type": "postgres",
+			"org_guid": "` + orgGUID + `",
+			"org_name": "my-org",
+			"space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76",
+			"space_name": "my-space",
+			"plan_guid": "efb5f1ce-0a8a-435d-a8b2-6b2b61c6dbe5",
+			"plan_name": "medium",
+			"running": true,
+			"first_seen": "2001-01-01T00:00:00Z",
+			"age_days": 45,
+			"last_state_change": "2001-01-01T00:00:00Z",
+			"month_to_date_ex_vat": "150.5",
+			"warnings": ["org_has_no_running_apps"]
+		}]`))
+	})
+})
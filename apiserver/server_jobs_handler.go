@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+const (
+	// DefaultJobsLimit is the number of jobs returned by GET /jobs if no
+	// limit is given
+	DefaultJobsLimit = 100
+)
+
+// JobRequest is the body accepted by POST /jobs
+type JobRequest struct {
+	Kind   eventio.JobKind   `json:"kind"`
+	Params eventio.JobParams `json:"params"`
+}
+
+// JobsHandler lists the most recent jobs, optionally filtered by kind and
+// state
+func JobsHandler(store eventio.JobQueue, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		filter := eventio.JobFilter{
+			Kind:  eventio.JobKind(c.QueryParam("kind")),
+			State: eventio.JobState(c.QueryParam("state")),
+			Limit: DefaultJobsLimit,
+		}
+		if limit := c.QueryParam("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 {
+				return echo.NewHTTPError(http.StatusBadRequest, errors.New("limit must be a positive integer"))
+			}
+			filter.Limit = n
+		}
+		jobs, err := store.GetJobs(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, jobs)
+	}
+}
+
+// JobHandler returns a single job
+func JobHandler(store eventio.JobQueue, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		id, err := jobID(c)
+		if err != nil {
+			return err
+		}
+		job, err := store.GetJob(id)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "job not found")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, job)
+	}
+}
+
+// JobsPostHandler queues the JobRequest in the body to be run by the
+// collector's job worker
+func JobsPostHandler(store eventio.JobQueue, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdminWrite(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		requestedBy, err := requestUsername(c, uaa)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		var req JobRequest
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid job request body: %s", err))
+		}
+		job := eventio.Job{
+			Kind:        req.Kind,
+			Params:      req.Params,
+			RequestedBy: requestedBy,
+		}
+		if err := job.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		job, err = store.EnqueueJob(job)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusCreated, job)
+	}
+}
+
+// JobCancelHandler cancels a queued job or asks the worker to stop a running
+// one. Jobs that have already finished are returned unchanged.
+func JobCancelHandler(store eventio.JobQueue, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdminWrite(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		id, err := jobID(c)
+		if err != nil {
+			return err
+		}
+		job, err := store.CancelJob(id)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "job not found")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, job)
+	}
+}
+
+func jobID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, errors.New("job id must be an integer"))
+	}
+	return id, nil
+}
@@ -0,0 +1,201 @@
+package apiserver_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		createdAt         = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+		job               eventio.Job
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		fakeAuthorizer.AdminWriteReturns(true, nil)
+		fakeAuthorizer.UsernameReturns("admin@example.com", nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		job = eventio.Job{
+			ID:          1,
+			Kind:        eventio.ConsolidateJob,
+			Params:      eventio.JobParams{Month: "2001-01-01"},
+			State:       eventio.JobQueued,
+			RequestedBy: "admin@example.com",
+			CreatedAt:   createdAt,
+		}
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to use the jobs endpoints", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+		fakeAuthorizer.AdminWriteReturns(false, nil)
+
+		Expect(serve(echo.GET, "/jobs", "").Code).To(Equal(401))
+		Expect(serve(echo.GET, "/jobs/1", "").Code).To(Equal(401))
+		Expect(serve(echo.POST, "/jobs", `{"kind": "refresh"}`).Code).To(Equal(401))
+		Expect(serve(echo.POST, "/jobs/1/cancel", "").Code).To(Equal(401))
+
+		Expect(fakeStore.GetJobsCallCount()).To(Equal(0))
+		Expect(fakeStore.GetJobCallCount()).To(Equal(0))
+		Expect(fakeStore.EnqueueJobCallCount()).To(Equal(0))
+		Expect(fakeStore.CancelJobCallCount()).To(Equal(0))
+	})
+
+	It("should only allow read only administrators to view jobs", func() {
+		fakeAuthorizer.AdminWriteReturns(false, nil)
+		fakeStore.GetJobReturns(&job, nil)
+
+		Expect(serve(echo.GET, "/jobs", "").Code).To(Equal(200))
+		Expect(serve(echo.GET, "/jobs/1", "").Code).To(Equal(200))
+		Expect(serve(echo.POST, "/jobs", `{"kind": "refresh"}`).Code).To(Equal(401))
+		Expect(serve(echo.POST, "/jobs/1/cancel", "").Code).To(Equal(401))
+
+		Expect(fakeStore.EnqueueJobCallCount()).To(Equal(0))
+		Expect(fakeStore.CancelJobCallCount()).To(Equal(0))
+	})
+
+	It("should list jobs filtered by kind and state", func() {
+		fakeStore.GetJobsReturns([]eventio.Job{job}, nil)
+
+		res := serve(echo.GET, "/jobs?kind=consolidate&state=queued&limit=5", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetJobsCallCount()).To(Equal(1))
+		Expect(fakeStore.GetJobsArgsForCall(0)).To(Equal(eventio.JobFilter{
+			Kind:  eventio.ConsolidateJob,
+			State: eventio.JobQueued,
+			Limit: 5,
+		}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"id": 1,
+			"kind": "consolidate",
+			"params": {"month": "2001-01-01"},
+			"state": "queued",
+			"requested_by": "admin@example.com",
+			"cancel_requested": false,
+			"created_at": "2001-01-01T00:00:00Z",
+			"started_at": null,
+			"finished_at": null
+		}]`))
+	})
+
+	It("should apply a default limit when listing jobs", func() {
+		res := serve(echo.GET, "/jobs", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetJobsArgsForCall(0).Limit).To(Equal(DefaultJobsLimit))
+	})
+
+	It("should reject an invalid limit", func() {
+		res := serve(echo.GET, "/jobs?limit=nope", "")
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetJobsCallCount()).To(Equal(0))
+	})
+
+	It("should return a single job", func() {
+		fakeStore.GetJobReturns(&job, nil)
+
+		res := serve(echo.GET, "/jobs/1", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetJobArgsForCall(0)).To(Equal(int64(1)))
+		Expect(res.Body.String()).To(ContainSubstring(`"kind":"consolidate"`))
+	})
+
+	It("should return 404 if the job does not exist", func() {
+		fakeStore.GetJobReturns(nil, nil)
+
+		Expect(serve(echo.GET, "/jobs/99", "").Code).To(Equal(404))
+		Expect(serve(echo.POST, "/jobs/99/cancel", "").Code).To(Equal(404))
+	})
+
+	It("should return 400 if the job id is not an integer", func() {
+		Expect(serve(echo.GET, "/jobs/abc", "").Code).To(Equal(400))
+		Expect(fakeStore.GetJobCallCount()).To(Equal(0))
+	})
+
+	It("should queue a job requested by an administrator", func() {
+		fakeStore.EnqueueJobReturns(job, nil)
+
+		res := serve(echo.POST, "/jobs", `{"kind": "consolidate", "params": {"month": "2001-01-01"}}`)
+
+		Expect(res.Code).To(Equal(201))
+		Expect(fakeStore.EnqueueJobCallCount()).To(Equal(1))
+		queued := fakeStore.EnqueueJobArgsForCall(0)
+		Expect(queued.Kind).To(Equal(eventio.ConsolidateJob))
+		Expect(queued.Params.Month).To(Equal("2001-01-01"))
+		Expect(queued.RequestedBy).To(Equal("admin@example.com"))
+		Expect(res.Body.String()).To(ContainSubstring(`"id":1`))
+	})
+
+	It("should reject invalid jobs", func() {
+		Expect(serve(echo.POST, "/jobs", `not json`).Code).To(Equal(400))
+		Expect(serve(echo.POST, "/jobs", `{"kind": "explode"}`).Code).To(Equal(400))
+		Expect(serve(echo.POST, "/jobs", `{"kind": "backfill", "params": {"event_kind": "app"}}`).Code).To(Equal(400))
+		Expect(fakeStore.EnqueueJobCallCount()).To(Equal(0))
+	})
+
+	It("should cancel a job", func() {
+		cancelled := job
+		cancelled.State = eventio.JobCancelled
+		cancelled.CancelRequested = true
+		fakeStore.CancelJobReturns(&cancelled, nil)
+
+		res := serve(echo.POST, "/jobs/1/cancel", "")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.CancelJobArgsForCall(0)).To(Equal(int64(1)))
+		Expect(res.Body.String()).To(ContainSubstring(`"state":"cancelled"`))
+	})
+
+	It("should return 500 if the store fails", func() {
+		fakeStore.GetJobsReturns(nil, errors.New("db-error"))
+
+		Expect(serve(echo.GET, "/jobs", "").Code).To(Equal(500))
+	})
+})
@@ -0,0 +1,47 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// CostProjectionHandler returns the month-to-date cost of the requested orgs
+// and projects the cost of currently running resources until the end of the
+// current month
+func CostProjectionHandler(store eventio.CostProjectionReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestedOrgs := c.Request().URL.Query()["org_guid"]
+		if len(requestedOrgs) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("org_guid param is required"))
+		}
+		if ok, err := authorize(c, uaa, requestedOrgs); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		} else if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+		labels, err := parseLabelFilters(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		filter := eventio.EventFilter{
+			RangeStart:   monthStart.Format("2006-01-02"),
+			RangeStop:    monthStart.AddDate(0, 1, 0).Format("2006-01-02"),
+			OrgGUIDs:     requestedOrgs,
+			Labels:       labels,
+			GroupByLabel: c.QueryParam("group_by_label"),
+		}
+		projections, err := store.GetCostProjections(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, projections)
+	}
+}
@@ -0,0 +1,161 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CostProjectionHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID1          = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	It("should require an org_guid", func() {
+		req := httptest.NewRequest(echo.GET, "/projected_costs", nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetCostProjectionsCallCount()).To(Equal(0))
+	})
+
+	It("should require billing access to the org", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+		req := httptest.NewRequest(echo.GET, "/projected_costs?org_guid="+orgGUID1, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(401))
+		Expect(fakeStore.GetCostProjectionsCallCount()).To(Equal(0))
+	})
+
+	It("should return projections for the current month", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		fakeStore.GetCostProjectionsReturns([]eventio.CostProjection{
+			{
+				OrgGUID:                  orgGUID1,
+				SpaceGUID:                "space-guid",
+				PlanGUID:                 "plan-guid",
+				ActualExVAT:              "1",
+				ActualIncVAT:             "1.2",
+				ProjectedRemainderExVAT:  "2",
+				ProjectedRemainderIncVAT: "2.4",
+				ProjectedTotalExVAT:      "3",
+				ProjectedTotalIncVAT:     "3.6",
+			},
+		}, nil)
+		u := url.URL{Path: "/projected_costs"}
+		q := u.Query()
+		q.Set("org_guid", orgGUID1)
+		u.RawQuery = q.Encode()
+		req := httptest.NewRequest(echo.GET, u.String(), nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetCostProjectionsCallCount()).To(Equal(1))
+		filter := fakeStore.GetCostProjectionsArgsForCall(0)
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		Expect(filter.RangeStart).To(Equal(monthStart.Format("2006-01-02")))
+		Expect(filter.RangeStop).To(Equal(monthStart.AddDate(0, 1, 0).Format("2006-01-02")))
+		Expect(filter.OrgGUIDs).To(Equal([]string{orgGUID1}))
+		Expect(res.Body).To(MatchJSON(`[{
+			"org_guid": "` + orgGUID1 + `",
+			"org_name": "",
+			"space_guid": "space-guid",
+			"space_name": "",
+			"plan_guid": "plan-guid",
+			"plan_name": "",
+			"projected_until": "",
+			"actual_ex_vat": "1",
+			"actual_inc_vat": "1.2",
+			"projected_remainder_ex_vat": "2",
+			"projected_remainder_inc_vat": "2.4",
+			"projected_total_ex_vat": "3",
+			"projected_total_inc_vat": "3.6"
+		}]`))
+	})
+
+	It("should filter and group the projections by label", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		req := httptest.NewRequest(echo.GET, "/projected_costs?org_guid="+orgGUID1+"&label=team:billing&group_by_label=cost-centre", nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(200))
+		filter := fakeStore.GetCostProjectionsArgsForCall(0)
+		Expect(filter.Labels).To(Equal([]eventio.LabelFilter{{Key: "team", Value: "billing"}}))
+		Expect(filter.GroupByLabel).To(Equal("cost-centre"))
+	})
+
+	It("should reject a malformed label filter", func() {
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		req := httptest.NewRequest(echo.GET, "/projected_costs?org_guid="+orgGUID1+"&label=team", nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetCostProjectionsCallCount()).To(Equal(0))
+	})
+})
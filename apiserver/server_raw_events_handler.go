@@ -0,0 +1,105 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DefaultRawEventsLimit is the number of events returned by
+	// GET /raw_events if no limit is given
+	DefaultRawEventsLimit = 100
+	// MaxRawEventsLimit is the most events GET /raw_events returns at once
+	MaxRawEventsLimit = 1000
+)
+
+// RawEventsHandler lists the raw usage events of one kind as they were
+// collected, oldest first, to help administrators debug billing issues. Use
+// the guid of the last event of a page as after_guid to fetch the next page.
+func RawEventsHandler(store eventio.RawEventReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		filter := eventio.RawEventFilter{
+			Reverse: true,
+			Limit:   DefaultRawEventsLimit,
+			Kind:    c.QueryParam("kind"),
+			State:   c.QueryParam("state"),
+		}
+		switch filter.Kind {
+		case "app", "service", "compose":
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("kind must be one of app, service or compose"))
+		}
+		if limit := c.QueryParam("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 || n > MaxRawEventsLimit {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("limit must be an integer between 1 and %d", MaxRawEventsLimit))
+			}
+			filter.Limit = n
+		}
+		var err error
+		if filter.ResourceGUID, err = optionalUUIDParam(c, "resource_guid"); err != nil {
+			return err
+		}
+		if filter.OrgGUID, err = optionalUUIDParam(c, "org_guid"); err != nil {
+			return err
+		}
+		// compose audit events are not identified by uuids
+		if filter.Kind == "compose" {
+			filter.AfterGUID = c.QueryParam("after_guid")
+		} else if filter.AfterGUID, err = optionalUUIDParam(c, "after_guid"); err != nil {
+			return err
+		}
+		if filter.Since, err = parseRawEventTime(c, "range_start"); err != nil {
+			return err
+		}
+		if filter.Before, err = parseRawEventTime(c, "range_stop"); err != nil {
+			return err
+		}
+		events, err := store.GetEvents(filter)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, events)
+	}
+}
+
+// optionalUUIDParam returns the named query parameter, which must be a uuid
+// if it is given
+func optionalUUIDParam(c echo.Context, name string) (string, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return "", nil
+	}
+	id, err := uuid.FromString(v)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("%s must be a uuid", name))
+	}
+	return id.String(), nil
+}
+
+// parseRawEventTime parses the named query parameter as a date or an RFC3339
+// time, returning the zero time if it is not given
+func parseRawEventTime(c echo.Context, name string) (time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("%s must be a date or an RFC3339 time - got %s", name, v))
+}
@@ -0,0 +1,141 @@
+package apiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RawEventsHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+	It("should only allow administrators", func() {
+		Expect(serve("/raw_events?kind=app").Code).To(Equal(401))
+		Expect(fakeStore.GetEventsCallCount()).To(Equal(0))
+
+		fakeAuthorizer.AdminReturns(true, nil)
+		Expect(serve("/raw_events?kind=app").Code).To(Equal(200))
+		Expect(fakeStore.GetEventsCallCount()).To(Equal(1))
+	})
+
+	Context("as an administrator", func() {
+		BeforeEach(func() {
+			fakeAuthorizer.AdminReturns(true, nil)
+		})
+
+		It("should reject invalid parameters", func() {
+			for _, path := range []string{
+				"/raw_events",
+				"/raw_events?kind=staging",
+				"/raw_events?kind=app&limit=0",
+				"/raw_events?kind=app&limit=1001",
+				"/raw_events?kind=app&resource_guid=not-a-guid",
+				"/raw_events?kind=app&org_guid=not-a-guid",
+				"/raw_events?kind=service&after_guid=not-a-guid",
+				"/raw_events?kind=app&range_start=yesterday",
+			} {
+				Expect(serve(path).Code).To(Equal(400), path)
+			}
+			Expect(fakeStore.GetEventsCallCount()).To(Equal(0))
+		})
+
+		It("should list the oldest events first with a default limit", func() {
+			Expect(serve("/raw_events?kind=service").Code).To(Equal(200))
+			Expect(fakeStore.GetEventsArgsForCall(0)).To(Equal(eventio.RawEventFilter{
+				Kind:    "service",
+				Reverse: true,
+				Limit:   DefaultRawEventsLimit,
+			}))
+		})
+
+		It("should filter the events", func() {
+			path := "/raw_events?kind=app&limit=10&state=STARTED" +
+				"&resource_guid=c85e98f0-6d1b-4f45-9368-ea58263165a0" +
+				"&org_guid=" + orgGUID +
+				"&after_guid=00000000-0000-0000-0000-000000000001" +
+				"&range_start=2001-01-01&range_stop=2001-01-02T12:00:00Z"
+			Expect(serve(path).Code).To(Equal(200))
+			Expect(fakeStore.GetEventsArgsForCall(0)).To(Equal(eventio.RawEventFilter{
+				Kind:         "app",
+				Reverse:      true,
+				Limit:        10,
+				State:        "STARTED",
+				ResourceGUID: "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+				OrgGUID:      orgGUID,
+				AfterGUID:    "00000000-0000-0000-0000-000000000001",
+				Since:        time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+				Before:       time.Date(2001, 1, 2, 12, 0, 0, 0, time.UTC),
+			}))
+		})
+
+		It("should accept compose event ids to continue from", func() {
+			Expect(serve("/raw_events?kind=compose&after_guid=5aba15474a64fd00141-0002").Code).To(Equal(200))
+			Expect(fakeStore.GetEventsArgsForCall(0).AfterGUID).To(Equal("5aba15474a64fd00141-0002"))
+		})
+
+		It("should return the raw events", func() {
+			fakeStore.GetEventsReturns([]eventio.RawEvent{{
+				GUID:       "00000000-0000-0000-0000-000000000001",
+				Kind:       "app",
+				RawMessage: json.RawMessage(`{"state": "STARTED"}`),
+				CreatedAt:  time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			}}, nil)
+			res := serve("/raw_events?kind=app")
+			Expect(res.Code).To(Equal(200))
+			Expect(res.Body.String()).To(MatchJSON(`[{
+				"guid": "00000000-0000-0000-0000-000000000001",
+				"kind": "app",
+				"raw_message": {"state": "STARTED"},
+				"created_at": "2001-01-01T00:00:00Z"
+			}]`))
+		})
+	})
+})
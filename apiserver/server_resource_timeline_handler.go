@@ -0,0 +1,79 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ResourceTimelineHandler returns everything that went into the charges for
+// a resource, from the raw usage events to the priced billable components, to
+// explain how its bill was worked out. Access is checked against the orgs the
+// resource has belonged to, so only administrators can see resources that
+// are not known to belong to any org. Billing managers of orgs that cost
+// allocations charged part of the cost to, and who cannot see the whole
+// timeline, only see the components charged to their orgs.
+func ResourceTimelineHandler(store eventio.ResourceTimelineReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		guid, err := uuid.FromString(c.Param("guid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("resource guid must be a uuid"))
+		}
+		// authenticate before touching the store so that unauthenticated
+		// requests cannot learn anything from how the query behaves
+		token, err := auth.GetTokenFromRequest(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		authorizer, err := uaa.NewAuthorizer(token)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		isAdmin, err := authorizer.Admin()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, fmt.Errorf("invalid credentials: %s", err))
+		}
+		timeline, err := store.GetResourceTimeline(guid.String())
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			if timeline == nil || len(timeline.OrgGUIDs)+len(timeline.AllocatedOrgGUIDs) == 0 {
+				return echo.NewHTTPError(http.StatusUnauthorized, errors.New("you need to be an administrator to use this endpoint"))
+			}
+			hasBillingAccess := false
+			if len(timeline.OrgGUIDs) > 0 {
+				hasBillingAccess, err = authorizer.HasBillingAccess(timeline.OrgGUIDs)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, fmt.Errorf("invalid credentials: %s", err))
+				}
+			}
+			if !hasBillingAccess {
+				allowedOrgGUIDs := []string{}
+				for _, orgGUID := range timeline.AllocatedOrgGUIDs {
+					ok, err := authorizer.HasBillingAccess([]string{orgGUID})
+					if err != nil {
+						return echo.NewHTTPError(http.StatusUnauthorized, fmt.Errorf("invalid credentials: %s", err))
+					}
+					if ok {
+						allowedOrgGUIDs = append(allowedOrgGUIDs, orgGUID)
+					}
+				}
+				if len(allowedOrgGUIDs) == 0 {
+					return echo.NewHTTPError(http.StatusUnauthorized, errors.New("you need to be billing_manager or an administrator to retrieve the billing data"))
+				}
+				timeline = timeline.ForAllocatedOrgs(allowedOrgGUIDs)
+			}
+		}
+		if timeline == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "resource not found")
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, timeline)
+	}
+}
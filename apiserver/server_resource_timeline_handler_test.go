@@ -0,0 +1,216 @@
+package apiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResourceTimelineHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		orgGUID           = "f5f32499-db32-4ab7-a314-20cbe3e49080"
+		recipientOrgGUID  = "8a1d3b0c-55d3-4c2b-9a8e-2c4f6e1d7b90"
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.HasBillingAccessReturns(true, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	resourceGUID := "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+	timelinePath := "/resources/" + resourceGUID + "/timeline"
+
+	timeline := func(orgGUIDs ...string) *eventio.ResourceTimeline {
+		return &eventio.ResourceTimeline{
+			ResourceGUID:      resourceGUID,
+			OrgGUIDs:          orgGUIDs,
+			AllocatedOrgGUIDs: []string{},
+			RawEvents:         []eventio.RawEvent{},
+			Events: []eventio.UsageEvent{{
+				EventGUID:    "aa30fa3c-725d-4272-9052-c7186d4968a6",
+				EventStart:   "2001-01-01T00:00:00+00:00",
+				EventStop:    "2001-01-02T00:00:00+00:00",
+				ResourceGUID: resourceGUID,
+				OrgGUID:      orgGUID,
+				MemoryInMB:   1024,
+			}},
+			Components: []eventio.BillableComponent{{
+				EventGUID:     "aa30fa3c-725d-4272-9052-c7186d4968a6",
+				OrgGUID:       orgGUID,
+				ComponentName: "compute",
+				Formula:       "ceil($time_in_seconds/3600) * 0.01",
+				ExVAT:         "0.24",
+				IncVAT:        "0.288",
+			}},
+		}
+	}
+
+	It("should reject guids that are not uuids", func() {
+		res := serve("/resources/not-a-guid/timeline")
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetResourceTimelineCallCount()).To(Equal(0))
+	})
+
+	It("should not query the store without valid credentials", func() {
+		fakeAuthenticator.NewAuthorizerReturns(nil, errors.New("invalid token"))
+		Expect(serve(timelinePath).Code).To(Equal(401))
+		Expect(fakeStore.GetResourceTimelineCallCount()).To(Equal(0))
+	})
+
+	It("should require billing access to the orgs of the resource", func() {
+		fakeStore.GetResourceTimelineReturns(timeline(orgGUID), nil)
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+		Expect(serve(timelinePath).Code).To(Equal(401))
+
+		Expect(fakeAuthorizer.HasBillingAccessCallCount()).To(Equal(1))
+		Expect(fakeAuthorizer.HasBillingAccessArgsForCall(0)).To(Equal([]string{orgGUID}))
+	})
+
+	It("should only allow administrators to see resources without an org", func() {
+		fakeStore.GetResourceTimelineReturns(timeline(), nil)
+		Expect(serve(timelinePath).Code).To(Equal(401))
+
+		fakeAuthorizer.AdminReturns(true, nil)
+		Expect(serve(timelinePath).Code).To(Equal(200))
+	})
+
+	It("should only show billing managers of allocated orgs the components charged to them", func() {
+		allocated := timeline(orgGUID)
+		allocated.AllocatedOrgGUIDs = []string{recipientOrgGUID}
+		allocated.Components = append(allocated.Components, eventio.BillableComponent{
+			EventGUID:     "5b9e0f3a-8c1d-4e7f-b2a6-3d4c5e6f7a8b",
+			OrgGUID:       recipientOrgGUID,
+			AllocatedFrom: "aa30fa3c-725d-4272-9052-c7186d4968a6",
+			ComponentName: "compute",
+			ExVAT:         "0.12",
+		})
+		fakeStore.GetResourceTimelineReturns(allocated, nil)
+		fakeAuthorizer.HasBillingAccessStub = func(orgGUIDs []string) (bool, error) {
+			return len(orgGUIDs) == 1 && orgGUIDs[0] == recipientOrgGUID, nil
+		}
+
+		res := serve(timelinePath)
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeAuthorizer.HasBillingAccessCallCount()).To(Equal(2))
+		Expect(fakeAuthorizer.HasBillingAccessArgsForCall(0)).To(Equal([]string{orgGUID}))
+		Expect(fakeAuthorizer.HasBillingAccessArgsForCall(1)).To(Equal([]string{recipientOrgGUID}))
+
+		var body eventio.ResourceTimeline
+		Expect(json.Unmarshal(res.Body.Bytes(), &body)).To(Succeed())
+		Expect(body.AllocatedOrgGUIDs).To(Equal([]string{recipientOrgGUID}))
+		Expect(body.RawEvents).To(BeEmpty())
+		Expect(body.Events).To(BeEmpty())
+		Expect(body.Components).To(Equal([]eventio.BillableComponent{allocated.Components[1]}))
+
+		fakeAuthorizer.HasBillingAccessStub = nil
+		fakeAuthorizer.HasBillingAccessReturns(false, nil)
+		Expect(serve(timelinePath).Code).To(Equal(401))
+	})
+
+	It("should return 404 if nothing is known about the resource", func() {
+		fakeStore.GetResourceTimelineReturns(nil, nil)
+		Expect(serve(timelinePath).Code).To(Equal(401))
+
+		fakeAuthorizer.AdminReturns(true, nil)
+		Expect(serve(timelinePath).Code).To(Equal(404))
+	})
+
+	It("should return the timeline of the resource", func() {
+		fakeStore.GetResourceTimelineReturns(timeline(orgGUID), nil)
+		res := serve(timelinePath)
+		Expect(res.Code).To(Equal(200))
+
+		Expect(fakeStore.GetResourceTimelineCallCount()).To(Equal(1))
+		Expect(fakeStore.GetResourceTimelineArgsForCall(0)).To(Equal(resourceGUID))
+		Expect(res.Body.String()).To(MatchJSON(`{
+			"resource_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+			"org_guids": ["f5f32499-db32-4ab7-a314-20cbe3e49080"],
+			"allocated_org_guids": [],
+			"raw_events": [],
+			"events": [{
+				"event_guid": "aa30fa3c-725d-4272-9052-c7186d4968a6",
+				"event_start": "2001-01-01T00:00:00+00:00",
+				"event_stop": "2001-01-02T00:00:00+00:00",
+				"resource_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+				"resource_name": "",
+				"resource_type": "",
+				"org_guid": "f5f32499-db32-4ab7-a314-20cbe3e49080",
+				"org_name": "",
+				"space_guid": "",
+				"space_name": "",
+				"plan_guid": "",
+				"plan_name": "",
+				"service_guid": "",
+				"service_name": "",
+				"number_of_nodes": 0,
+				"memory_in_mb": 1024,
+				"storage_in_mb": 0
+			}],
+			"components": [{
+				"event_guid": "aa30fa3c-725d-4272-9052-c7186d4968a6",
+				"resource_type": "",
+				"org_guid": "f5f32499-db32-4ab7-a314-20cbe3e49080",
+				"space_guid": "",
+				"allocated_from": "",
+				"start": "",
+				"stop": "",
+				"plan_guid": "",
+				"plan_name": "",
+				"plan_valid_from": "",
+				"component_name": "compute",
+				"formula": "ceil($time_in_seconds/3600) * 0.01",
+				"number_of_nodes": 0,
+				"memory_in_mb": 0,
+				"storage_in_mb": 0,
+				"currency_code": "",
+				"currency_rate": "",
+				"vat_code": "",
+				"vat_rate": "",
+				"inc_vat": "0.288",
+				"ex_vat": "0.24"
+			}]
+		}`))
+	})
+})
@@ -0,0 +1,66 @@
+package apiserver
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/labstack/echo"
+)
+
+// UsageAndAdoptionHandler returns the usage and adoption report as JSON, or
+// as CSV with one row per org and month if format=csv is requested
+func UsageAndAdoptionHandler(store eventio.UsageAndAdoptionReader, uaa auth.Authenticator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := authorizeAdmin(c, uaa); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err)
+		}
+		format := c.QueryParam("format")
+		if format != "" && format != "json" && format != "csv" {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("format must be json or csv - got %s", format))
+		}
+		filter := eventio.EventFilter{
+			RangeStart: c.QueryParam("range_start"),
+			RangeStop:  c.QueryParam("range_stop"),
+			OrgGUIDs:   c.Request().URL.Query()["org_guid"],
+		}
+		if err := filter.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+		report, err := store.GetUsageAndAdoption(filter)
+		if err != nil {
+			return err
+		}
+		if format == "csv" {
+			c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=UTF-8")
+			c.Response().WriteHeader(http.StatusOK)
+			return writeUsageAndAdoptionCSV(c.Response(), report)
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		return c.JSON(http.StatusOK, report)
+	}
+}
+
+func writeUsageAndAdoptionCSV(w http.ResponseWriter, report eventio.UsageAndAdoptionReport) error {
+	out := csv.NewWriter(w)
+	header := []string{"month", "org_guid", "org_name", "first_seen"}
+	header = append(header, report.ServiceCategories...)
+	header = append(header, "total_cost")
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, usage := range report.Orgs {
+		record := []string{usage.Month, usage.OrgGUID, usage.OrgName, usage.FirstSeen}
+		for _, category := range report.ServiceCategories {
+			record = append(record, usage.Costs[category])
+		}
+		record = append(record, usage.TotalCost)
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
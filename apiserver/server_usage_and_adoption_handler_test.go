@@ -0,0 +1,135 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/labstack/echo"
+
+	. "github.com/alphagov/paas-billing/apiserver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UsageAndAdoptionHandler", func() {
+
+	var (
+		ctx               context.Context
+		cancel            context.CancelFunc
+		cfg               Config
+		fakeAuthenticator *fakes.FakeAuthenticator
+		fakeAuthorizer    *fakes.FakeAuthorizer
+		fakeStore         *fakes.FakeEventStore
+		token             = "ACCESS_GRANTED_TOKEN"
+		report            = eventio.UsageAndAdoptionReport{
+			ServiceCategories: []string{"compute", "postgres", "other"},
+			Orgs: []eventio.OrgUsage{
+				{
+					Month:     "2001-01-01",
+					OrgGUID:   "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+					OrgName:   "my-org",
+					FirstSeen: "2001-01-15",
+					Costs: map[string]string{
+						"compute":  "10.5",
+						"postgres": "3",
+						"other":    "0",
+					},
+					TotalCost: "13.5",
+				},
+			},
+			ActiveOrgs: []eventio.ActiveOrgCount{
+				{Month: "2001-01-01", ActiveOrgs: 1, NewOrgs: 1},
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		fakeAuthenticator = &fakes.FakeAuthenticator{}
+		fakeAuthorizer = &fakes.FakeAuthorizer{}
+		fakeAuthenticator.NewAuthorizerReturns(fakeAuthorizer, nil)
+		fakeAuthorizer.AdminReturns(true, nil)
+		fakeStore.GetUsageAndAdoptionReturns(report, nil)
+		cfg = Config{
+			Authenticator: fakeAuthenticator,
+			Logger:        lager.NewLogger("test"),
+			Store:         fakeStore,
+			EnablePanic:   true,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		defer cancel()
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set("Authorization", "bearer "+token)
+		res := httptest.NewRecorder()
+		e := New(cfg)
+		e.ServeHTTP(res, req)
+		defer e.Shutdown(ctx)
+		return res
+	}
+
+	It("should only allow administrators to see the report", func() {
+		fakeAuthorizer.AdminReturns(false, nil)
+
+		res := serve("/usage_and_adoption?range_start=2001-01-01&range_stop=2001-02-01")
+
+		Expect(res.Code).To(Equal(401))
+		Expect(fakeStore.GetUsageAndAdoptionCallCount()).To(Equal(0))
+	})
+
+	It("should require a valid range", func() {
+		res := serve("/usage_and_adoption?range_start=2001-01-01")
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetUsageAndAdoptionCallCount()).To(Equal(0))
+	})
+
+	It("should reject unknown formats", func() {
+		res := serve("/usage_and_adoption?range_start=2001-01-01&range_stop=2001-02-01&format=xml")
+
+		Expect(res.Code).To(Equal(400))
+		Expect(fakeStore.GetUsageAndAdoptionCallCount()).To(Equal(0))
+	})
+
+	It("should return the report as JSON", func() {
+		res := serve("/usage_and_adoption?range_start=2001-01-01&range_stop=2001-02-01&org_guid=51ba75ef-edc0-47ad-a633-a8f6e8770944")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(fakeStore.GetUsageAndAdoptionArgsForCall(0)).To(Equal(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{"51ba75ef-edc0-47ad-a633-a8f6e8770944"},
+		}))
+		Expect(res.Body).To(MatchJSON(`{
+			"service_categories": ["compute", "postgres", "other"],
+			"orgs": [{
+				"month": "2001-01-01",
+				"org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944",
+				"org_name": "my-org",
+				"first_seen": "2001-01-15",
+				"costs": {"compute": "10.5", "postgres": "3", "other": "0"},
+				"total_cost": "13.5"
+			}],
+			"active_orgs": [{"month": "2001-01-01", "active_orgs": 1, "new_orgs": 1}]
+		}`))
+	})
+
+	It("should return the report as CSV", func() {
+		res := serve("/usage_and_adoption?range_start=2001-01-01&range_stop=2001-02-01&format=csv")
+
+		Expect(res.Code).To(Equal(200))
+		Expect(res.Header().Get("Content-Type")).To(HavePrefix("text/csv"))
+		Expect(res.Body.String()).To(Equal(
+			"month,org_guid,org_name,first_seen,compute,postgres,other,total_cost\n" +
+				"2001-01-01,51ba75ef-edc0-47ad-a633-a8f6e8770944,my-org,2001-01-15,10.5,3,0,13.5\n",
+		))
+	})
+})
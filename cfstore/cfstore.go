@@ -3,10 +3,12 @@ package cfstore
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
 	cfclient "github.com/cloudfoundry-community/go-cfclient"
 )
 
@@ -50,6 +52,10 @@ func (s *Store) Init() error {
 		s.logger.Error("collectServicePlans-failed", err)
 		return err
 	}
+	if err := s.collectQuotaDefinitions(tx); err != nil {
+		s.logger.Error("collectQuotaDefinitions-failed", err)
+		return err
+	}
 	if err := s.collectOrgs(tx); err != nil {
 		s.logger.Error("collectOrgs-failed", err)
 		return err
@@ -58,6 +64,18 @@ func (s *Store) Init() error {
 		s.logger.Error("collectSpaces-failed", err)
 		return err
 	}
+	if err := s.collectOrgRoles(tx); err != nil {
+		s.logger.Error("collectOrgRoles-failed", err)
+		return err
+	}
+	if err := s.collectOrgMetadata(tx); err != nil {
+		s.logger.Error("collectOrgMetadata-failed", err)
+		return err
+	}
+	if err := s.collectSpaceMetadata(tx); err != nil {
+		s.logger.Error("collectSpaceMetadata-failed", err)
+		return err
+	}
 	s.logger.Info("initialized")
 	return tx.Commit()
 }
@@ -76,71 +94,73 @@ func (s *Store) CollectServicePlans() error {
 	return tx.Commit()
 }
 
+var servicePlansTable = historicTable{
+	name: "service_plans",
+	columns: []string{
+		"name", "description",
+		"unique_id",
+		"active", "public", "free",
+		"extra",
+		"created_at", "updated_at",
+		"service_guid", "service_valid_from",
+	},
+	tracked: []string{
+		"name", "description",
+		"unique_id",
+		"active", "public", "free",
+		"extra",
+		"service_guid",
+	},
+}
+
 func (s *Store) collectServicePlans(tx *sql.Tx) error {
 	plans, err := s.client.ListServicePlans()
 	if err != nil {
 		return err
 	}
-	for _, plan := range plans {
-		validFrom := plan.UpdatedAt
-		var planCount int
-		err := tx.QueryRow(`
-			select count(*)
-			from service_plans
-			where guid = $1
-		`, plan.Guid).Scan(&planCount)
-		if err != nil {
+
+	serviceValidFroms := map[string]time.Time{}
+	rows, err := tx.Query(`
+		select distinct on (guid)
+			guid::text, valid_from
+		from services
+		order by guid, valid_from desc
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var guid string
+		var validFrom time.Time
+		if err := rows.Scan(&guid, &validFrom); err != nil {
 			return err
 		}
-		if planCount == 0 {
-			validFrom = plan.CreatedAt
-		}
+		serviceValidFroms[guid] = validFrom
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-		var serviceValidFrom *time.Time
-		err = tx.QueryRow(`
-			select valid_from
-			from services
-			where guid = $1
-			order by valid_from desc
-			limit 1
-		`, plan.ServiceGuid).Scan(&serviceValidFrom)
-		if err == sql.ErrNoRows {
+	collected := [][]interface{}{}
+	for _, plan := range plans {
+		serviceValidFrom, ok := serviceValidFroms[plan.ServiceGuid]
+		if !ok {
 			s.logger.Error("service-not-found", fmt.Errorf("failed to find service '%s' for service_plan '%s'... skipping", plan.ServiceGuid, plan.Guid))
 			continue
-		} else if err != nil {
-			return err
 		}
-
-		_, err = tx.Exec(`
-			insert into service_plans (
-				guid, valid_from,
-				name, description,
-				unique_id,
-				active, public, free,
-				extra,
-				created_at, updated_at,
-				service_guid, service_valid_from
-			) values (
-				$1, $2,
-				$3, $4,
-				$5,
-				$6, $7, $8,
-				$9,
-				$10, $11,
-				$12, $13
-			) on conflict (guid, valid_from) do nothing`,
-			plan.Guid, validFrom,
+		collected = append(collected, []interface{}{
+			plan.Guid,
 			plan.Name, plan.Description,
 			plan.UniqueId,
 			plan.Active, plan.Public, plan.Free,
 			plan.Extra,
-			plan.CreatedAt, plan.UpdatedAt,
-			plan.ServiceGuid, serviceValidFrom)
-		if err != nil {
-			return err
-		}
+			plan.CreatedAt, nullIfEmpty(plan.UpdatedAt),
+			plan.ServiceGuid, serviceValidFrom,
+		})
 	}
-	return nil
+	_, err = recordHistory(tx, s.logger, servicePlansTable, collected)
+	return err
 }
 
 func (s *Store) CollectServices() error {
@@ -157,50 +177,38 @@ func (s *Store) CollectServices() error {
 	return tx.Commit()
 }
 
+var servicesTable = historicTable{
+	name: "services",
+	columns: []string{
+		"label", "description",
+		"active", "bindable",
+		"service_broker_guid",
+		"created_at", "updated_at",
+	},
+	tracked: []string{
+		"label", "description",
+		"active", "bindable",
+		"service_broker_guid",
+	},
+}
+
 func (s *Store) collectServices(tx *sql.Tx) error {
 	services, err := s.client.ListServices()
 	if err != nil {
 		return err
 	}
-	for _, service := range services {
-		validFrom := service.UpdatedAt
-		var serviceCount int
-		err := tx.QueryRow(`
-			select count(*)
-			from services
-			where guid = $1
-		`, service.Guid).Scan(&serviceCount)
-		if err != nil {
-			return err
-		}
-		if serviceCount == 0 {
-			validFrom = service.CreatedAt
-		}
-
-		_, err = tx.Exec(`
-			insert into services (
-				guid, valid_from,
-				label, description,
-				active, bindable,
-				service_broker_guid,
-				created_at, updated_at
-			) values (
-				$1, $2,
-				$3, $4,
-				$5, $6,
-				$7,
-				$8, $9
-			) on conflict (guid, valid_from) do nothing`,
-			service.Guid, validFrom,
+	collected := make([][]interface{}, len(services))
+	for i, service := range services {
+		collected[i] = []interface{}{
+			service.Guid,
 			service.Label, service.Description,
 			service.Active, service.Bindable,
 			service.ServiceBrokerGuid,
-			service.CreatedAt, service.UpdatedAt)
-		if err != nil {
-			return err
+			service.CreatedAt, nullIfEmpty(service.UpdatedAt),
 		}
 	}
-	return nil
+	_, err = recordHistory(tx, s.logger, servicesTable, collected)
+	return err
 }
 
 func (s *Store) CollectOrgs() error {
@@ -217,50 +225,104 @@ func (s *Store) CollectOrgs() error {
 	return tx.Commit()
 }
 
+var orgsTable = historicTable{
+	name: "orgs",
+	columns: []string{
+		"name",
+		"created_at",
+		"updated_at",
+		"quota_definition_guid",
+	},
+	tracked: []string{
+		"name",
+		"quota_definition_guid",
+	},
+}
+
 func (s *Store) collectOrgs(tx *sql.Tx) error {
 	orgs, err := s.client.ListOrgs()
 	if err != nil {
 		return err
 	}
-	for _, org := range orgs {
-		validFrom := org.UpdatedAt
-		var recordCount int
-		err := tx.QueryRow(
-			`select count(*) from orgs where guid = $1`,
+	collected := make([][]interface{}, len(orgs))
+	for i, org := range orgs {
+		collected[i] = []interface{}{
 			org.Guid,
-		).Scan(&recordCount)
-		if err != nil {
-			return err
-		}
-		if recordCount == 0 {
-			validFrom = org.CreatedAt
-		}
-		_, err = tx.Exec(`
-			insert into orgs (
-				guid, valid_from,
-				name,
-				created_at,
-				updated_at,
-				quota_definition_guid
-			) values (
-				$1, $2,
-				$3,
-				$4,
-				$5,
-				$6
-			) on conflict (guid, valid_from) do nothing`,
-			org.Guid, validFrom,
 			org.Name,
 			org.CreatedAt,
 			org.UpdatedAt,
-			org.QuotaDefinitionGuid,
-		)
+			nullIfEmpty(org.QuotaDefinitionGuid),
+		}
+	}
+	_, err = recordHistory(tx, s.logger, orgsTable, collected)
+	return err
+}
 
-		if err != nil {
-			return err
+func (s *Store) CollectQuotaDefinitions() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultInitTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.collectQuotaDefinitions(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var quotaDefinitionsTable = historicTable{
+	name: "quota_definitions",
+	columns: []string{
+		"name",
+		"memory_limit",
+		"instance_memory_limit",
+		"total_services",
+		"total_routes",
+		"app_instance_limit",
+		"non_basic_services_allowed",
+		"created_at",
+		"updated_at",
+	},
+	tracked: []string{
+		"name",
+		"memory_limit",
+		"instance_memory_limit",
+		"total_services",
+		"total_routes",
+		"app_instance_limit",
+		"non_basic_services_allowed",
+	},
+}
+
+func (s *Store) collectQuotaDefinitions(tx *sql.Tx) error {
+	quotas, err := s.client.ListOrgQuotas()
+	if err != nil {
+		return err
+	}
+	collected := make([][]interface{}, len(quotas))
+	for i, quota := range quotas {
+		// quota definitions that have never been updated have no updated_at
+		updatedAt := quota.UpdatedAt
+		if updatedAt == "" {
+			updatedAt = quota.CreatedAt
+		}
+		collected[i] = []interface{}{
+			quota.Guid,
+			quota.Name,
+			quota.MemoryLimit,
+			quota.InstanceMemoryLimit,
+			quota.TotalServices,
+			quota.TotalRoutes,
+			quota.AppInstanceLimit,
+			quota.NonBasicServicesAllowed,
+			quota.CreatedAt,
+			updatedAt,
 		}
 	}
-	return nil
+	_, err = recordHistory(tx, s.logger, quotaDefinitionsTable, collected)
+	return err
 }
 
 func (s *Store) CollectSpaces() error {
@@ -277,51 +339,213 @@ func (s *Store) CollectSpaces() error {
 	return tx.Commit()
 }
 
+var spacesTable = historicTable{
+	name: "spaces",
+	columns: []string{
+		"name",
+		"created_at",
+		"updated_at",
+	},
+	tracked: []string{
+		"name",
+	},
+}
+
 func (s *Store) collectSpaces(tx *sql.Tx) error {
 	spaces, err := s.client.ListSpaces()
 	if err != nil {
 		return err
 	}
-	for _, space := range spaces {
-		validFrom := space.UpdatedAt
-		var recordCount int
-		err := tx.QueryRow(
-			`select count(*) from spaces where guid = $1`,
+	collected := make([][]interface{}, len(spaces))
+	for i, space := range spaces {
+		collected[i] = []interface{}{
 			space.Guid,
-		).Scan(&recordCount)
-		if err != nil {
+			space.Name,
+			space.CreatedAt,
+			space.UpdatedAt,
+		}
+	}
+	_, err = recordHistory(tx, s.logger, spacesTable, collected)
+	return err
+}
+
+func (s *Store) CollectOrgRoles() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultInitTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.collectOrgRoles(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var orgRolesTable = historicTable{
+	name: "org_roles",
+	keys: []string{
+		"org_guid",
+		"user_guid",
+		"role",
+	},
+	columns: []string{
+		"username",
+	},
+	tracked: []string{
+		"username",
+	},
+	observed: true,
+}
+
+// collectOrgRoles records the billing managers and org managers of every org
+// that has been collected and not deleted. The cf API has no timestamps for
+// role assignments so changes are recorded as of the time they were
+// collected.
+func (s *Store) collectOrgRoles(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		select guid from (
+			select distinct on (guid)
+				guid::text, deleted
+			from orgs
+			order by guid, valid_from desc
+		) latest
+		where not deleted
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	orgGUIDs := []string{}
+	for rows.Next() {
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
 			return err
 		}
-		if recordCount == 0 {
-			validFrom = space.CreatedAt
+		orgGUIDs = append(orgGUIDs, guid)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	roles := []struct {
+		role eventio.OrgRole
+		list func(orgGUID string) ([]cfclient.User, error)
+	}{
+		{eventio.BillingManagerRole, s.client.ListOrgBillingManagers},
+		{eventio.OrgManagerRole, s.client.ListOrgManagers},
+	}
+	collected := [][]interface{}{}
+	for _, orgGUID := range orgGUIDs {
+		for _, r := range roles {
+			users, err := r.list(orgGUID)
+			if err != nil {
+				return fmt.Errorf("failed to list %s users of org '%s': %s", r.role, orgGUID, err)
+			}
+			for _, user := range users {
+				collected = append(collected, []interface{}{
+					orgGUID,
+					user.Guid,
+					string(r.role),
+					nullIfEmpty(user.Username),
+				})
+			}
 		}
+	}
+	_, err = recordHistory(tx, s.logger, orgRolesTable, collected)
+	return err
+}
 
-		_, err = tx.Exec(`
-			insert into spaces (
-				guid,
-				valid_from,
-				name,
-				created_at,
-				updated_at
-			) values (
-				$1,
-				$2,
-				$3,
-				$4,
-				$5
-			) on conflict (guid, valid_from) do nothing`,
-			space.Guid,
-			validFrom,
-			space.Name,
-			space.CreatedAt,
-			space.UpdatedAt,
-		)
+func (s *Store) CollectOrgMetadata() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultInitTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.collectOrgMetadata(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) collectOrgMetadata(tx *sql.Tx) error {
+	metadata, err := s.client.ListOrgMetadata()
+	if err != nil {
+		return err
+	}
+	return s.recordMetadata(tx, "org_metadata", metadata)
+}
 
+func (s *Store) CollectSpaceMetadata() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultInitTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.collectSpaceMetadata(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) collectSpaceMetadata(tx *sql.Tx) error {
+	metadata, err := s.client.ListSpaceMetadata()
+	if err != nil {
+		return err
+	}
+	return s.recordMetadata(tx, "space_metadata", metadata)
+}
+
+// recordMetadata stores a new version of the labels and annotations of each
+// resource whenever they change
+func (s *Store) recordMetadata(tx *sql.Tx, tableName string, metadata []ResourceMetadata) error {
+	table := historicTable{
+		name: tableName,
+		columns: []string{
+			"labels",
+			"annotations",
+			"created_at",
+			"updated_at",
+		},
+		tracked: []string{
+			"labels",
+			"annotations",
+		},
+	}
+	collected := make([][]interface{}, len(metadata))
+	for i, m := range metadata {
+		labels, err := jsonObject(m.Metadata.Labels)
+		if err != nil {
+			return err
+		}
+		annotations, err := jsonObject(m.Metadata.Annotations)
 		if err != nil {
 			return err
 		}
+		collected[i] = []interface{}{
+			m.Guid,
+			labels,
+			annotations,
+			m.CreatedAt,
+			m.UpdatedAt,
+		}
+	}
+	_, err := recordHistory(tx, s.logger, table, collected)
+	return err
+}
+
+// jsonObject encodes a map as a JSON object, nil maps are encoded as {}
+func jsonObject(m map[string]string) (string, error) {
+	if m == nil {
+		m = map[string]string{}
 	}
-	return nil
+	b, err := json.Marshal(m)
+	return string(b), err
 }
 
 func New(cfg Config) (*Store, error) {
@@ -1,6 +1,11 @@
 package cfstore
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
 	"github.com/cloudfoundry-community/go-cfclient"
 )
 
@@ -9,6 +14,23 @@ type CFDataClient interface {
 	ListServices() ([]cfclient.Service, error)
 	ListOrgs() ([]cfclient.Org, error)
 	ListSpaces() ([]cfclient.Space, error)
+	ListOrgQuotas() ([]cfclient.OrgQuota, error)
+	ListOrgBillingManagers(orgGUID string) ([]cfclient.User, error)
+	ListOrgManagers(orgGUID string) ([]cfclient.User, error)
+	ListOrgMetadata() ([]ResourceMetadata, error)
+	ListSpaceMetadata() ([]ResourceMetadata, error)
+}
+
+// ResourceMetadata is the labels and annotations of an org or space, which
+// are only available from the v3 API
+type ResourceMetadata struct {
+	Guid      string `json:"guid"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Metadata  struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
 }
 
 var _ CFDataClient = &Client{}
@@ -32,3 +54,60 @@ func (c *Client) ListOrgs() ([]cfclient.Org, error) {
 func (c *Client) ListSpaces() ([]cfclient.Space, error) {
 	return c.Client.ListSpaces()
 }
+
+func (c *Client) ListOrgQuotas() ([]cfclient.OrgQuota, error) {
+	return c.Client.ListOrgQuotas()
+}
+
+func (c *Client) ListOrgBillingManagers(orgGUID string) ([]cfclient.User, error) {
+	return c.Client.ListOrgBillingManagers(orgGUID)
+}
+
+func (c *Client) ListOrgManagers(orgGUID string) ([]cfclient.User, error) {
+	return c.Client.ListOrgManagers(orgGUID)
+}
+
+func (c *Client) ListOrgMetadata() ([]ResourceMetadata, error) {
+	return c.listV3Metadata("/v3/organizations?per_page=5000")
+}
+
+func (c *Client) ListSpaceMetadata() ([]ResourceMetadata, error) {
+	return c.listV3Metadata("/v3/spaces?per_page=5000")
+}
+
+func (c *Client) listV3Metadata(path string) ([]ResourceMetadata, error) {
+	resources := []ResourceMetadata{}
+	for path != "" {
+		var page struct {
+			Pagination struct {
+				Next *struct {
+					Href string `json:"href"`
+				} `json:"next"`
+			} `json:"pagination"`
+			Resources []ResourceMetadata `json:"resources"`
+		}
+		resp, err := c.Client.DoRequest(c.Client.NewRequest("GET", path))
+		if err != nil {
+			return nil, fmt.Errorf("error requesting %s: %s", path, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("error requesting %s: %s", path, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s: %s", path, err)
+		}
+		resources = append(resources, page.Resources...)
+		path = ""
+		if page.Pagination.Next != nil && page.Pagination.Next.Href != "" {
+			next, err := url.Parse(page.Pagination.Next.Href)
+			if err != nil {
+				return nil, err
+			}
+			path = next.RequestURI()
+		}
+	}
+	return resources, nil
+}
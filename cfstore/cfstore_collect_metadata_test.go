@@ -0,0 +1,91 @@
+package cfstore_test
+
+import (
+	"errors"
+
+	"github.com/alphagov/paas-billing/cfstore"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+var _ = Describe("Metadata", func() {
+
+	var (
+		tempdb     *testenv.TempDB
+		fakeClient *fakes.FakeCFDataClient
+		store      *cfstore.Store
+		org        cfstore.ResourceMetadata
+		space      cfstore.ResourceMetadata
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempdb, err = testenv.Open(testenv.BasicConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		org = cfstore.ResourceMetadata{
+			Guid:      uuid.NewV4().String(),
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2001-01-01T01:01:01+00:00",
+		}
+		org.Metadata.Labels = map[string]string{"team": "billing"}
+		space = cfstore.ResourceMetadata{
+			Guid:      uuid.NewV4().String(),
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2001-01-01T01:01:01+00:00",
+		}
+		space.Metadata.Annotations = map[string]string{"cost-centre": "1234"}
+
+		fakeClient = &fakes.FakeCFDataClient{}
+		store, err = cfstore.New(cfstore.Config{
+			Client: fakeClient,
+			DB:     tempdb.Conn,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Init()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		tempdb.Close()
+	})
+
+	It("should collect the labels and annotations of orgs and spaces", func() {
+		fakeClient.ListOrgMetadataReturns([]cfstore.ResourceMetadata{org}, nil)
+		fakeClient.ListSpaceMetadataReturns([]cfstore.ResourceMetadata{space}, nil)
+
+		Expect(store.CollectOrgMetadata()).To(Succeed())
+		Expect(store.CollectSpaceMetadata()).To(Succeed())
+
+		Expect(tempdb.Query(`select guid, valid_from, labels, annotations, deleted from org_metadata`)).To(MatchJSON(testenv.Rows{
+			{"guid": org.Guid, "valid_from": "2001-01-01T01:01:01+00:00", "labels": map[string]string{"team": "billing"}, "annotations": map[string]string{}, "deleted": false},
+		}))
+		Expect(tempdb.Query(`select guid, valid_from, labels, annotations, deleted from space_metadata`)).To(MatchJSON(testenv.Rows{
+			{"guid": space.Guid, "valid_from": "2001-01-01T01:01:01+00:00", "labels": map[string]string{}, "annotations": map[string]string{"cost-centre": "1234"}, "deleted": false},
+		}))
+	})
+
+	It("should only record a new version when the metadata changes", func() {
+		fakeClient.ListOrgMetadataReturns([]cfstore.ResourceMetadata{org}, nil)
+		Expect(store.CollectOrgMetadata()).To(Succeed())
+		Expect(store.CollectOrgMetadata()).To(Succeed())
+
+		org.UpdatedAt = "2002-02-02T02:02:02+00:00"
+		org.Metadata.Labels = map[string]string{"team": "platform"}
+		fakeClient.ListOrgMetadataReturns([]cfstore.ResourceMetadata{org}, nil)
+		Expect(store.CollectOrgMetadata()).To(Succeed())
+
+		Expect(tempdb.Query(`select valid_from, labels from org_metadata order by valid_from`)).To(MatchJSON(testenv.Rows{
+			{"valid_from": "2001-01-01T01:01:01+00:00", "labels": map[string]string{"team": "billing"}},
+			{"valid_from": "2002-02-02T02:02:02+00:00", "labels": map[string]string{"team": "platform"}},
+		}))
+	})
+
+	It("should return an error if the metadata cannot be listed", func() {
+		fakeClient.ListSpaceMetadataReturns(nil, errors.New("api-error"))
+
+		Expect(store.CollectSpaceMetadata()).To(MatchError("api-error"))
+	})
+})
@@ -0,0 +1,109 @@
+package cfstore_test
+
+import (
+	"errors"
+
+	"github.com/alphagov/paas-billing/cfstore"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/alphagov/paas-billing/testenv"
+	cfclient "github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+var _ = Describe("OrgRoles", func() {
+
+	var (
+		tempdb     *testenv.TempDB
+		fakeClient *fakes.FakeCFDataClient
+		store      *cfstore.Store
+		org        cfclient.Org
+		user1      cfclient.User
+		user2      cfclient.User
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempdb, err = testenv.Open(testenv.BasicConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		org = cfclient.Org{
+			Guid:      uuid.NewV4().String(),
+			Name:      "my-org",
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2001-01-01T01:01:01+00:00",
+		}
+		user1 = cfclient.User{Guid: uuid.NewV4().String(), Username: "user1@example.com"}
+		user2 = cfclient.User{Guid: uuid.NewV4().String(), Username: "user2@example.com"}
+
+		fakeClient = &fakes.FakeCFDataClient{}
+		fakeClient.ListOrgsReturns([]cfclient.Org{org}, nil)
+
+		store, err = cfstore.New(cfstore.Config{
+			Client: fakeClient,
+			DB:     tempdb.Conn,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Init()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		tempdb.Close()
+	})
+
+	query := func() testenv.Rows {
+		return tempdb.Query(`
+			select user_guid, role, username, deleted
+			from org_roles
+			where org_guid = $1
+			order by valid_from, role, username
+		`, org.Guid)
+	}
+
+	It("should collect the billing managers and org managers of each org", func() {
+		fakeClient.ListOrgBillingManagersReturns([]cfclient.User{user1}, nil)
+		fakeClient.ListOrgManagersReturns([]cfclient.User{user1, user2}, nil)
+
+		Expect(store.CollectOrgRoles()).To(Succeed())
+
+		Expect(fakeClient.ListOrgBillingManagersArgsForCall(1)).To(Equal(org.Guid))
+		Expect(fakeClient.ListOrgManagersArgsForCall(1)).To(Equal(org.Guid))
+		Expect(query()).To(MatchJSON(testenv.Rows{
+			{"user_guid": user1.Guid, "role": "billing_manager", "username": user1.Username, "deleted": false},
+			{"user_guid": user1.Guid, "role": "org_manager", "username": user1.Username, "deleted": false},
+			{"user_guid": user2.Guid, "role": "org_manager", "username": user2.Username, "deleted": false},
+		}))
+	})
+
+	It("should only record changes to the roles", func() {
+		fakeClient.ListOrgBillingManagersReturns([]cfclient.User{user1}, nil)
+		fakeClient.ListOrgManagersReturns([]cfclient.User{user2}, nil)
+		Expect(store.CollectOrgRoles()).To(Succeed())
+		Expect(store.CollectOrgRoles()).To(Succeed())
+		Expect(query()).To(HaveLen(2))
+
+		By("recording a tombstone when a user loses a role")
+		fakeClient.ListOrgBillingManagersReturns([]cfclient.User{user2}, nil)
+		Expect(store.CollectOrgRoles()).To(Succeed())
+		Expect(tempdb.Query(`
+			select user_guid, role, deleted
+			from org_roles
+			where role = 'billing_manager'
+			order by valid_from, deleted
+		`)).To(MatchJSON(testenv.Rows{
+			{"user_guid": user1.Guid, "role": "billing_manager", "deleted": false},
+			{"user_guid": user2.Guid, "role": "billing_manager", "deleted": false},
+			{"user_guid": user1.Guid, "role": "billing_manager", "deleted": true},
+		}))
+	})
+
+	It("should fail without recording anything if the roles of an org cannot be listed", func() {
+		fakeClient.ListOrgBillingManagersReturns([]cfclient.User{user1}, nil)
+		fakeClient.ListOrgManagersReturns(nil, errors.New("api-error"))
+
+		Expect(store.CollectOrgRoles()).To(MatchError(ContainSubstring("api-error")))
+		Expect(query()).To(BeEmpty())
+	})
+})
@@ -60,60 +60,115 @@ var _ = Describe("Orgs", func() {
 			"updated_at":            org1.UpdatedAt,
 			"created_at":            org1.CreatedAt,
 			"quota_definition_guid": org1.QuotaDefinitionGuid,
+			"deleted":               false,
 		}
 		expectedResult1 := testenv.Rows{expectedFirstRow}
 		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult1))
 
-		By("storing the data using the updated_at date for the valid_from field for all subsequent operations")
-		expectedSecondRow := testenv.Row{
-			"guid":                  org1.Guid,
-			"name":                  org1.Name,
-			"valid_from":            org1.UpdatedAt, // THIS IS THE DIFFERENCE FROM 1stROW ^^
-			"updated_at":            org1.UpdatedAt,
-			"created_at":            org1.CreatedAt,
-			"quota_definition_guid": org1.QuotaDefinitionGuid,
-		}
-		expectedResult2 := testenv.Rows{expectedFirstRow, expectedSecondRow}
-
+		By("not changing any data when none of the tracked fields have changed")
 		fakeClient.ListOrgsReturnsOnCall(2, []cfclient.Org{
 			org1,
 		}, nil)
 		Expect(store.CollectOrgs()).To(Succeed())
-		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult2))
-
-		By("not changing any data when the stored valid_from date matches the updated_at field during all subsequent operations")
-		fakeClient.ListOrgsReturnsOnCall(3, []cfclient.Org{
-			org1,
-		}, nil)
-		Expect(store.CollectOrgs()).To(Succeed())
-		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult2))
+		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult1))
 
-		By("storing updates to the org")
+		By("storing a new version using the updated_at date when a tracked field changes")
 		org2 := cfclient.Org{
 			Guid:                org1.Guid,
-			Name:                "my-org",
+			Name:                "my-renamed-org",
 			CreatedAt:           "2001-01-01T01:01:01+00:00",
 			UpdatedAt:           "2003-03-03T03:03:03+00:00",
 			QuotaDefinitionGuid: org1.QuotaDefinitionGuid,
 		}
-		fakeClient.ListOrgsReturnsOnCall(4, []cfclient.Org{
+		fakeClient.ListOrgsReturnsOnCall(3, []cfclient.Org{
 			org2,
 		}, nil)
 		Expect(store.CollectOrgs()).To(Succeed())
-		expectedThirdRow := testenv.Row{
+		expectedSecondRow := testenv.Row{
 			"guid":                  org2.Guid,
 			"name":                  org2.Name,
 			"valid_from":            org2.UpdatedAt,
 			"updated_at":            org2.UpdatedAt,
 			"created_at":            org2.CreatedAt,
 			"quota_definition_guid": org2.QuotaDefinitionGuid,
+			"deleted":               false,
 		}
-		expectedResult3 := testenv.Rows{
+		expectedResult2 := testenv.Rows{
 			expectedFirstRow,
 			expectedSecondRow,
-			expectedThirdRow,
 		}
-		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult3))
+		Expect(tempdb.Query(`select * from orgs`)).To(MatchJSON(expectedResult2))
+	})
+
+	It("should store a new version valid from now when a tracked field changes without updated_at moving", func() {
+		org1 := cfclient.Org{
+			Guid:                uuid.NewV4().String(),
+			Name:                "my-org",
+			CreatedAt:           "2001-01-01T01:01:01+00:00",
+			UpdatedAt:           "2001-01-01T01:01:01+00:00",
+			QuotaDefinitionGuid: uuid.NewV4().String(),
+		}
+		org2 := org1
+		org2.QuotaDefinitionGuid = uuid.NewV4().String()
+
+		fakeClient.ListOrgsReturnsOnCall(1, []cfclient.Org{org1}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+		fakeClient.ListOrgsReturnsOnCall(2, []cfclient.Org{org2}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+
+		Expect(tempdb.Query(`
+			select
+				quota_definition_guid,
+				valid_from > now() - interval '1 minute' as recent
+			from orgs
+			order by valid_from
+		`)).To(MatchJSON(testenv.Rows{
+			{"quota_definition_guid": org1.QuotaDefinitionGuid, "recent": false},
+			{"quota_definition_guid": org2.QuotaDefinitionGuid, "recent": true},
+		}))
+	})
+
+	It("should record a tombstone for orgs that are no longer returned", func() {
+		org1 := cfclient.Org{
+			Guid:      uuid.NewV4().String(),
+			Name:      "my-org",
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2002-02-02T02:02:02+00:00",
+		}
+		org2 := cfclient.Org{
+			Guid:      uuid.NewV4().String(),
+			Name:      "my-other-org",
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2002-02-02T02:02:02+00:00",
+		}
+
+		fakeClient.ListOrgsReturnsOnCall(1, []cfclient.Org{org1, org2}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+
+		By("marking the latest version of the missing org as deleted")
+		fakeClient.ListOrgsReturnsOnCall(2, []cfclient.Org{org1}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+		Expect(tempdb.Query(`
+			select name, deleted from orgs order by name, valid_from
+		`)).To(MatchJSON(testenv.Rows{
+			{"name": "my-org", "deleted": false},
+			{"name": "my-other-org", "deleted": false},
+			{"name": "my-other-org", "deleted": true},
+		}))
+
+		By("not recording another tombstone while the org stays missing")
+		fakeClient.ListOrgsReturnsOnCall(3, []cfclient.Org{org1}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+		Expect(tempdb.Query(`select count(*) as count from orgs`)).To(MatchJSON(testenv.Rows{
+			{"count": 3},
+		}))
+
+		By("not recording tombstones when no orgs are returned at all")
+		fakeClient.ListOrgsReturnsOnCall(4, []cfclient.Org{}, nil)
+		Expect(store.CollectOrgs()).To(Succeed())
+		Expect(tempdb.Query(`select count(*) as count from orgs where deleted`)).To(MatchJSON(testenv.Rows{
+			{"count": 1},
+		}))
 	})
 
 })
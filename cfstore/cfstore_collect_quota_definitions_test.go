@@ -0,0 +1,122 @@
+package cfstore_test
+
+import (
+	"github.com/alphagov/paas-billing/cfstore"
+	"github.com/alphagov/paas-billing/fakes"
+	"github.com/alphagov/paas-billing/testenv"
+	cfclient "github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+
+	. "github.com/onsi/gomega"
+	uuid "github.com/satori/go.uuid"
+)
+
+var _ = Describe("QuotaDefinitions", func() {
+
+	var (
+		tempdb     *testenv.TempDB
+		fakeClient *fakes.FakeCFDataClient
+		store      *cfstore.Store
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempdb, err = testenv.Open(testenv.BasicConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeClient = &fakes.FakeCFDataClient{}
+		fakeClient.ListOrgQuotasReturnsOnCall(0, []cfclient.OrgQuota{}, nil)
+
+		store, err = cfstore.New(cfstore.Config{
+			Client: fakeClient,
+			DB:     tempdb.Conn,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Init()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		tempdb.Close()
+	})
+
+	It("should collect quota definition data", func() {
+		quota1 := cfclient.OrgQuota{
+			Guid:                    uuid.NewV4().String(),
+			Name:                    "small",
+			CreatedAt:               "2001-01-01T01:01:01+00:00",
+			UpdatedAt:               "2002-02-02T02:02:02+00:00",
+			MemoryLimit:             10240,
+			InstanceMemoryLimit:     -1,
+			TotalServices:           10,
+			TotalRoutes:             1000,
+			AppInstanceLimit:        -1,
+			NonBasicServicesAllowed: false,
+		}
+
+		By("storing the data using the created_at date for the valid_from field initially")
+		fakeClient.ListOrgQuotasReturnsOnCall(1, []cfclient.OrgQuota{
+			quota1,
+		}, nil)
+		Expect(store.CollectQuotaDefinitions()).To(Succeed())
+		expectedFirstRow := testenv.Row{
+			"guid":                       quota1.Guid,
+			"valid_from":                 quota1.CreatedAt,
+			"name":                       quota1.Name,
+			"memory_limit":               quota1.MemoryLimit,
+			"instance_memory_limit":      quota1.InstanceMemoryLimit,
+			"total_services":             quota1.TotalServices,
+			"total_routes":               quota1.TotalRoutes,
+			"app_instance_limit":         quota1.AppInstanceLimit,
+			"non_basic_services_allowed": quota1.NonBasicServicesAllowed,
+			"created_at":                 quota1.CreatedAt,
+			"updated_at":                 quota1.UpdatedAt,
+			"deleted":                    false,
+		}
+		Expect(tempdb.Query(`select * from quota_definitions`)).To(MatchJSON(testenv.Rows{expectedFirstRow}))
+
+		By("storing changes to the limits using the updated_at date for the valid_from field")
+		quota2 := quota1
+		quota2.UpdatedAt = "2003-03-03T03:03:03+00:00"
+		quota2.NonBasicServicesAllowed = true
+		fakeClient.ListOrgQuotasReturnsOnCall(2, []cfclient.OrgQuota{
+			quota2,
+		}, nil)
+		Expect(store.CollectQuotaDefinitions()).To(Succeed())
+		expectedSecondRow := testenv.Row{
+			"guid":                       quota2.Guid,
+			"valid_from":                 quota2.UpdatedAt,
+			"name":                       quota2.Name,
+			"memory_limit":               quota2.MemoryLimit,
+			"instance_memory_limit":      quota2.InstanceMemoryLimit,
+			"total_services":             quota2.TotalServices,
+			"total_routes":               quota2.TotalRoutes,
+			"app_instance_limit":         quota2.AppInstanceLimit,
+			"non_basic_services_allowed": quota2.NonBasicServicesAllowed,
+			"created_at":                 quota2.CreatedAt,
+			"updated_at":                 quota2.UpdatedAt,
+			"deleted":                    false,
+		}
+		Expect(tempdb.Query(`select * from quota_definitions`)).To(MatchJSON(testenv.Rows{expectedFirstRow, expectedSecondRow}))
+
+		By("not changing any data when the quota has not been updated")
+		fakeClient.ListOrgQuotasReturnsOnCall(3, []cfclient.OrgQuota{
+			quota2,
+		}, nil)
+		Expect(store.CollectQuotaDefinitions()).To(Succeed())
+		Expect(tempdb.Query(`select * from quota_definitions`)).To(MatchJSON(testenv.Rows{expectedFirstRow, expectedSecondRow}))
+	})
+
+	It("should use created_at when a quota definition has never been updated", func() {
+		quota := cfclient.OrgQuota{
+			Guid:      uuid.NewV4().String(),
+			Name:      "default",
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+		}
+		fakeClient.ListOrgQuotasReturnsOnCall(1, []cfclient.OrgQuota{quota}, nil)
+		Expect(store.CollectQuotaDefinitions()).To(Succeed())
+		Expect(tempdb.Query(`select valid_from, updated_at from quota_definitions`)).To(MatchJSON(testenv.Rows{{
+			"valid_from": quota.CreatedAt,
+			"updated_at": quota.CreatedAt,
+		}}))
+	})
+})
@@ -124,6 +124,7 @@ var _ = Describe("ServicePlans", func() {
 				"public":             false,
 				"service_guid":       testService.Guid,
 				"service_valid_from": testService.CreatedAt,
+				"deleted":            false,
 			},
 		}))
 	})
@@ -168,6 +169,7 @@ var _ = Describe("ServicePlans", func() {
 				"public":             false,
 				"service_guid":       testService.Guid,
 				"service_valid_from": testService.CreatedAt,
+				"deleted":            false,
 			},
 			{
 				"guid":               servicePlanVersion2.Guid,
@@ -183,6 +185,7 @@ var _ = Describe("ServicePlans", func() {
 				"public":             false,
 				"service_guid":       testService.Guid,
 				"service_valid_from": testService.CreatedAt,
+				"deleted":            false,
 			},
 		}))
 	})
@@ -232,6 +235,7 @@ var _ = Describe("ServicePlans", func() {
 				"public":             false,
 				"service_guid":       testService.Guid,
 				"service_valid_from": testService.CreatedAt,
+				"deleted":            false,
 			},
 			{
 				"guid":               servicePlanVersion2.Guid,
@@ -247,6 +251,7 @@ var _ = Describe("ServicePlans", func() {
 				"public":             false,
 				"service_guid":       testService.Guid,
 				"service_valid_from": testService.CreatedAt,
+				"deleted":            false,
 			},
 		}))
 	})
@@ -103,6 +103,7 @@ var _ = Describe("Services", func() {
 				"valid_from":          "2001-01-01T01:01:01+00:00",
 				"active":              false,
 				"bindable":            false,
+				"deleted":             false,
 			},
 		}))
 	})
@@ -142,6 +143,7 @@ var _ = Describe("Services", func() {
 				"valid_from":          "2001-01-01T01:01:01+00:00",
 				"active":              false,
 				"bindable":            false,
+				"deleted":             false,
 			},
 			{
 				"guid":                serviceVersion2.Guid,
@@ -153,6 +155,7 @@ var _ = Describe("Services", func() {
 				"valid_from":          "2002-02-02T02:02:02+00:00",
 				"active":              false,
 				"bindable":            false,
+				"deleted":             false,
 			},
 		}))
 	})
@@ -197,6 +200,7 @@ var _ = Describe("Services", func() {
 				"valid_from":          "2001-01-01T01:01:01+00:00",
 				"active":              false,
 				"bindable":            false,
+				"deleted":             false,
 			},
 			{
 				"guid":                serviceVersion2.Guid,
@@ -208,8 +212,79 @@ var _ = Describe("Services", func() {
 				"valid_from":          "2002-02-02T02:02:02+00:00",
 				"active":              false,
 				"bindable":            false,
+				"deleted":             false,
 			},
 		}))
 	})
 
+	It("should record a new version when a tracked field changes without updated_at moving", func() {
+		serviceVersion1 := cfclient.Service{
+			Guid:              uuid.NewV4().String(),
+			ServiceBrokerGuid: uuid.NewV4().String(),
+			Label:             "my-service",
+			Description:       "my-service-description",
+			UpdatedAt:         "2001-01-01T01:01:01+00:00",
+			CreatedAt:         "2001-01-01T01:01:01+00:00",
+		}
+		fakeClient.ListServicesReturnsOnCall(1, []cfclient.Service{
+			serviceVersion1,
+		}, nil)
+		Expect(store.CollectServices()).To(Succeed())
+
+		serviceVersion2 := serviceVersion1
+		serviceVersion2.Active = true
+		fakeClient.ListServicesReturnsOnCall(2, []cfclient.Service{
+			serviceVersion2,
+		}, nil)
+		Expect(store.CollectServices()).To(Succeed())
+
+		Expect(tempdb.Query(`
+			select
+				active,
+				valid_from > now() - interval '1 minute' as recent
+			from services
+			order by valid_from
+		`)).To(MatchJSON(testenv.Rows{
+			{"active": false, "recent": false},
+			{"active": true, "recent": true},
+		}))
+	})
+
+	It("should record a tombstone for services that are no longer returned", func() {
+		service1 := cfclient.Service{
+			Guid:              uuid.NewV4().String(),
+			ServiceBrokerGuid: uuid.NewV4().String(),
+			Label:             "my-service",
+			Description:       "my-service-description",
+			UpdatedAt:         "2001-01-01T01:01:01+00:00",
+			CreatedAt:         "2001-01-01T01:01:01+00:00",
+		}
+		service2 := cfclient.Service{
+			Guid:              uuid.NewV4().String(),
+			ServiceBrokerGuid: uuid.NewV4().String(),
+			Label:             "my-other-service",
+			Description:       "my-other-service-description",
+			UpdatedAt:         "2001-01-01T01:01:01+00:00",
+			CreatedAt:         "2001-01-01T01:01:01+00:00",
+		}
+		fakeClient.ListServicesReturnsOnCall(1, []cfclient.Service{
+			service1,
+			service2,
+		}, nil)
+		Expect(store.CollectServices()).To(Succeed())
+
+		fakeClient.ListServicesReturnsOnCall(2, []cfclient.Service{
+			service1,
+		}, nil)
+		Expect(store.CollectServices()).To(Succeed())
+
+		Expect(tempdb.Query(`
+			select label, description, deleted from services order by label, valid_from
+		`)).To(MatchJSON(testenv.Rows{
+			{"label": "my-other-service", "description": "my-other-service-description", "deleted": false},
+			{"label": "my-other-service", "description": "my-other-service-description", "deleted": true},
+			{"label": "my-service", "description": "my-service-description", "deleted": false},
+		}))
+	})
+
 })
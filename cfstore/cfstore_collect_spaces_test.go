@@ -58,57 +58,76 @@ var _ = Describe("Spaces", func() {
 			"valid_from": space1.CreatedAt,
 			"updated_at": space1.UpdatedAt,
 			"created_at": space1.CreatedAt,
+			"deleted":    false,
 		}
 		expectedResult1 := testenv.Rows{expectedFirstRow}
 		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult1))
 
-		By("storing the data using the updated_at date for the valid_from field for all subsequent operations")
-		expectedSecondRow := testenv.Row{
-			"guid":       space1.Guid,
-			"name":       space1.Name,
-			"valid_from": space1.UpdatedAt, // THIS IS THE DIFFERENCE FROM 1stROW ^^
-			"updated_at": space1.UpdatedAt,
-			"created_at": space1.CreatedAt,
-		}
-		expectedResult2 := testenv.Rows{expectedFirstRow, expectedSecondRow}
-
+		By("not changing any data when none of the tracked fields have changed")
 		fakeClient.ListSpacesReturnsOnCall(2, []cfclient.Space{
 			space1,
 		}, nil)
 		Expect(store.CollectSpaces()).To(Succeed())
-		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult2))
-
-		By("not changing any data when the stored valid_from date matches the updated_at field during all subsequent operations")
-		fakeClient.ListSpacesReturnsOnCall(3, []cfclient.Space{
-			space1,
-		}, nil)
-		Expect(store.CollectSpaces()).To(Succeed())
-		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult2))
+		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult1))
 
-		By("storing updates to the space")
+		By("storing a new version using the updated_at date when the space is renamed")
 		space2 := cfclient.Space{
 			Guid:      space1.Guid,
-			Name:      "my-space",
+			Name:      "my-renamed-space",
 			CreatedAt: "2001-01-01T01:01:01+00:00",
 			UpdatedAt: "2003-03-03T03:03:03+00:00",
 		}
-		fakeClient.ListSpacesReturnsOnCall(4, []cfclient.Space{
+		fakeClient.ListSpacesReturnsOnCall(3, []cfclient.Space{
 			space2,
 		}, nil)
 		Expect(store.CollectSpaces()).To(Succeed())
-		expectedThirdRow := testenv.Row{
+		expectedSecondRow := testenv.Row{
 			"guid":       space2.Guid,
 			"name":       space2.Name,
 			"valid_from": space2.UpdatedAt,
 			"updated_at": space2.UpdatedAt,
 			"created_at": space2.CreatedAt,
+			"deleted":    false,
 		}
-		expectedResult3 := testenv.Rows{
+		expectedResult2 := testenv.Rows{
 			expectedFirstRow,
 			expectedSecondRow,
-			expectedThirdRow,
 		}
-		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult3))
+		Expect(tempdb.Query(`select * from spaces`)).To(MatchJSON(expectedResult2))
+
+		By("recording a tombstone when the space is no longer returned")
+		otherSpace := cfclient.Space{
+			Guid:      uuid.NewV4().String(),
+			Name:      "my-other-space",
+			CreatedAt: "2001-01-01T01:01:01+00:00",
+			UpdatedAt: "2001-01-01T01:01:01+00:00",
+		}
+		fakeClient.ListSpacesReturnsOnCall(4, []cfclient.Space{
+			otherSpace,
+		}, nil)
+		Expect(store.CollectSpaces()).To(Succeed())
+		Expect(tempdb.Query(`
+			select name, deleted from spaces where guid = $1 order by valid_from
+		`, space1.Guid)).To(MatchJSON(testenv.Rows{
+			{"name": space1.Name, "deleted": false},
+			{"name": space2.Name, "deleted": false},
+			{"name": space2.Name, "deleted": true},
+		}))
+
+		By("storing a new version when a deleted space is returned again")
+		fakeClient.ListSpacesReturnsOnCall(5, []cfclient.Space{
+			otherSpace,
+			space2,
+		}, nil)
+		Expect(store.CollectSpaces()).To(Succeed())
+		Expect(tempdb.Query(`
+			select name, deleted from spaces where guid = $1 order by valid_from
+		`, space1.Guid)).To(MatchJSON(testenv.Rows{
+			{"name": space1.Name, "deleted": false},
+			{"name": space2.Name, "deleted": false},
+			{"name": space2.Name, "deleted": true},
+			{"name": space2.Name, "deleted": false},
+		}))
 	})
 
 })
@@ -0,0 +1,202 @@
+package cfstore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/lib/pq"
+)
+
+// historicTable describes a table that keeps every version of a cf entity
+// keyed on the entity's keys and valid_from
+type historicTable struct {
+	// name of the table
+	name string
+	// keys identify an entity, defaults to guid
+	keys []string
+	// columns are the collected columns other than the keys, valid_from and
+	// deleted. They must include created_at and updated_at unless the table
+	// is observed.
+	columns []string
+	// tracked are the columns that create a new version when they change
+	tracked []string
+	// observed tables have no timestamps from the API, so every version is
+	// valid from the time it was collected
+	observed bool
+}
+
+func (t historicTable) keyColumns() []string {
+	if len(t.keys) == 0 {
+		return []string{"guid"}
+	}
+	return t.keys
+}
+
+// historyChanges counts the versions written by recordHistory
+type historyChanges struct {
+	versions   int64
+	tombstones int64
+}
+
+// recordHistory compares the collected rows (the keys followed by the values
+// of table.columns) with the latest stored version of each entity. New entities
+// are inserted as valid from their created_at. Entities whose tracked columns
+// differ get a new version valid from their updated_at, or from now if
+// updated_at has not moved past the latest version. Entities that are stored
+// but were not collected get a tombstone: a copy of their latest version
+// marked as deleted and valid from now.
+//
+// The rows are copied into a temporary table in bulk so that the comparison is
+// done in a couple of statements rather than a query per entity.
+func recordHistory(tx *sql.Tx, logger lager.Logger, table historicTable, rows [][]interface{}) (historyChanges, error) {
+	changes := historyChanges{}
+	collected := "collected_" + table.name
+	keys := table.keyColumns()
+	allColumns := append(append([]string{}, keys...), table.columns...)
+
+	if _, err := tx.Exec(fmt.Sprintf(`
+		create temporary table %s on commit drop as
+		select %s from %s limit 0
+	`, collected, strings.Join(allColumns, ", "), table.name)); err != nil {
+		return changes, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(collected, allColumns...))
+	if err != nil {
+		return changes, err
+	}
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return changes, err
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return changes, err
+	}
+	if err := stmt.Close(); err != nil {
+		return changes, err
+	}
+
+	prefixed := func(prefix string, columns []string) string {
+		out := make([]string, len(columns))
+		for i, c := range columns {
+			out[i] = prefix + c
+		}
+		return strings.Join(out, ", ")
+	}
+	joined := make([]string, len(keys))
+	for i, k := range keys {
+		joined[i] = fmt.Sprintf("l.%s = c.%s", k, k)
+	}
+	sameEntity := strings.Join(joined, " and ")
+
+	latest := fmt.Sprintf(`
+		latest as (
+			select distinct on (%s) *
+			from %s
+			order by %s, valid_from desc
+		)
+	`, strings.Join(keys, ", "), table.name, strings.Join(keys, ", "))
+
+	validFrom := fmt.Sprintf(`(case
+		when l.%s is null then c.created_at
+		when c.updated_at > l.valid_from then c.updated_at
+		else now()
+	end)`, keys[0])
+	if table.observed {
+		validFrom = "now()"
+	}
+
+	res, err := tx.Exec(fmt.Sprintf(`
+		with %s
+		insert into %s (
+			%s, valid_from, deleted, %s
+		) select
+			%s,
+			%s,
+			false,
+			%s
+		from
+			%s c
+		left join
+			latest l on %s
+		where
+			l.%s is null
+			or l.deleted
+			or (%s) is distinct from (%s)
+		on conflict (%s, valid_from) do nothing
+	`,
+		latest,
+		table.name, strings.Join(keys, ", "), strings.Join(table.columns, ", "),
+		prefixed("c.", keys),
+		validFrom,
+		prefixed("c.", table.columns),
+		collected,
+		sameEntity,
+		keys[0],
+		prefixed("c.", table.tracked), prefixed("l.", table.tracked),
+		strings.Join(keys, ", "),
+	))
+	if err != nil {
+		return changes, err
+	}
+	if changes.versions, err = res.RowsAffected(); err != nil {
+		return changes, err
+	}
+
+	if len(rows) == 0 {
+		// an empty response is far more likely to be a problem with the
+		// API than every entity having been deleted at once
+		logger.Info("skipping-tombstones", lager.Data{
+			"table":  table.name,
+			"reason": "no entities were collected",
+		})
+		return changes, nil
+	}
+
+	res, err = tx.Exec(fmt.Sprintf(`
+		with %s
+		insert into %s (
+			%s, valid_from, deleted, %s
+		) select
+			%s, now(), true, %s
+		from
+			latest l
+		where
+			not l.deleted
+			and not exists (select 1 from %s c where %s)
+		on conflict (%s, valid_from) do nothing
+	`,
+		latest,
+		table.name, strings.Join(keys, ", "), strings.Join(table.columns, ", "),
+		prefixed("l.", keys), prefixed("l.", table.columns),
+		collected, sameEntity,
+		strings.Join(keys, ", "),
+	))
+	if err != nil {
+		return changes, err
+	}
+	if changes.tombstones, err = res.RowsAffected(); err != nil {
+		return changes, err
+	}
+
+	logger.Info("recorded-history", lager.Data{
+		"table":      table.name,
+		"collected":  len(rows),
+		"versions":   changes.versions,
+		"tombstones": changes.tombstones,
+	})
+	return changes, nil
+}
+
+// nullIfEmpty stores empty strings from the API as null
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,229 @@
+package eventarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+const (
+	DefaultRetentionMonths  = 13
+	DefaultRestoreBatchSize = 1000
+)
+
+// Kinds are the kinds of raw events that are archived
+var Kinds = []string{"app", "service"}
+
+// Archiver moves raw events older than the retention window out of the
+// database into gzipped newline delimited JSON blobs, one event per line, and
+// restores them again
+type Archiver struct {
+	logger          lager.Logger
+	store           eventio.RawEventArchiver
+	blobs           BlobStore
+	retentionMonths int
+	batchSize       int
+}
+
+type Config struct {
+	Logger lager.Logger
+	Store  eventio.RawEventArchiver
+	Blobs  BlobStore
+	// RetentionMonths is the number of whole months before the current one
+	// whose raw events are kept in the database
+	RetentionMonths int
+	// BatchSize is the number of events restored in each transaction
+	BatchSize int
+}
+
+func New(cfg Config) *Archiver {
+	if cfg.Logger == nil {
+		cfg.Logger = lager.NewLogger("archiver")
+	}
+	if cfg.RetentionMonths <= 0 {
+		cfg.RetentionMonths = DefaultRetentionMonths
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultRestoreBatchSize
+	}
+	return &Archiver{
+		logger:          cfg.Logger,
+		store:           cfg.Store,
+		blobs:           cfg.Blobs,
+		retentionMonths: cfg.RetentionMonths,
+		batchSize:       cfg.BatchSize,
+	}
+}
+
+// Cutoff returns the time before which raw events are archived at now, the
+// start of the (UTC) month RetentionMonths before the month of now
+func (a *Archiver) Cutoff(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -a.retentionMonths, 0)
+}
+
+// Archive archives the raw events of every kind that are older than the
+// retention window at now, and returns the archives that were created
+func (a *Archiver) Archive(ctx context.Context, now time.Time) ([]eventio.RawEventArchive, error) {
+	before := a.Cutoff(now)
+	archives := []eventio.RawEventArchive{}
+	for _, kind := range Kinds {
+		key := fmt.Sprintf("%s/%s-%d.ndjson.gz", kind, before.Format("2006-01"), now.UnixNano())
+		archive, err := a.store.ArchiveRawEvents(ctx, kind, before, a.exporter(ctx, key))
+		if err != nil {
+			a.logger.Error("archive-error", err, lager.Data{
+				"kind":   kind,
+				"before": before,
+			})
+			return archives, err
+		}
+		if archive == nil {
+			a.logger.Info("nothing-to-archive", lager.Data{
+				"kind":   kind,
+				"before": before,
+			})
+			continue
+		}
+		a.logger.Info("archived", lager.Data{
+			"archive": archive,
+		})
+		archives = append(archives, *archive)
+	}
+	return archives, nil
+}
+
+// exporter returns a RawEventExporter that writes the events to the blob
+// store under key
+func (a *Archiver) exporter(ctx context.Context, key string) eventio.RawEventExporter {
+	return func(events eventio.ArchivedRawEventRows) (string, error) {
+		r, w := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			w.CloseWithError(writeEvents(w, events))
+		}()
+		err := a.blobs.Put(ctx, key, r)
+		// make sure the writer has stopped reading the events before
+		// handing them back
+		r.CloseWithError(io.ErrClosedPipe)
+		<-done
+		if err != nil {
+			return "", err
+		}
+		return key, nil
+	}
+}
+
+func writeEvents(w io.Writer, events eventio.ArchivedRawEventRows) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	for events.Next() {
+		event, err := events.Event()
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := events.Err(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Restore puts the archived raw events of kind created at or after since and
+// before before back into the database and returns how many were added.
+// Events that are already in the database are skipped, so a range can be
+// restored more than once. Archives whose events are all within the range are
+// marked as restored, so their months are no longer treated as archived.
+func (a *Archiver) Restore(ctx context.Context, kind string, since, before time.Time) (int, error) {
+	archives, err := a.store.GetRawEventArchives(eventio.RawEventArchiveFilter{
+		Kind:   kind,
+		Since:  since,
+		Before: before,
+	})
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, archive := range archives {
+		n, err := a.restoreArchive(ctx, archive, since, before)
+		restored += n
+		if err != nil {
+			a.logger.Error("restore-error", err, lager.Data{
+				"archive":  archive,
+				"restored": restored,
+			})
+			return restored, err
+		}
+		a.logger.Info("restored", lager.Data{
+			"archive":  archive,
+			"restored": n,
+		})
+		if (since.IsZero() || !archive.FirstCreatedAt.Before(since)) &&
+			(before.IsZero() || archive.LastCreatedAt.Before(before)) {
+			if err := a.store.MarkRawEventArchiveRestored(ctx, archive.ID); err != nil {
+				return restored, err
+			}
+		}
+	}
+	return restored, nil
+}
+
+func (a *Archiver) restoreArchive(ctx context.Context, archive eventio.RawEventArchive, since, before time.Time) (int, error) {
+	blob, err := a.blobs.Get(ctx, archive.Key)
+	if err != nil {
+		return 0, err
+	}
+	defer blob.Close()
+	zr, err := gzip.NewReader(bufio.NewReader(blob))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive '%s': %s", archive.Key, err)
+	}
+	defer zr.Close()
+
+	restored := 0
+	batch := []eventio.ArchivedRawEvent{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := a.store.RestoreRawEvents(ctx, batch)
+		if err != nil {
+			return err
+		}
+		restored += n
+		batch = []eventio.ArchivedRawEvent{}
+		return nil
+	}
+	dec := json.NewDecoder(zr)
+	for {
+		var event eventio.ArchivedRawEvent
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return restored, fmt.Errorf("failed to decode event from archive '%s': %s", archive.Key, err)
+		}
+		if !since.IsZero() && event.CreatedAt.Before(since) {
+			continue
+		}
+		if !before.IsZero() && !event.CreatedAt.Before(before) {
+			continue
+		}
+		batch = append(batch, event)
+		if len(batch) >= a.batchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	return restored, flush()
+}
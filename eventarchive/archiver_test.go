@@ -0,0 +1,191 @@
+package eventarchive_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+
+	. "github.com/alphagov/paas-billing/eventarchive"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type archivedRawEventRows struct {
+	events []eventio.ArchivedRawEvent
+	pos    int
+}
+
+func (r *archivedRawEventRows) Next() bool {
+	r.pos++
+	return r.pos <= len(r.events)
+}
+
+func (r *archivedRawEventRows) Err() error {
+	return nil
+}
+
+func (r *archivedRawEventRows) Event() (*eventio.ArchivedRawEvent, error) {
+	return &r.events[r.pos-1], nil
+}
+
+func archivedEvent(seq int64, guid string, createdAt time.Time) eventio.ArchivedRawEvent {
+	return eventio.ArchivedRawEvent{
+		Sequence: seq,
+		RawEvent: eventio.RawEvent{
+			GUID:       guid,
+			Kind:       "app",
+			CreatedAt:  createdAt,
+			RawMessage: json.RawMessage(`{"state":"STARTED"}`),
+		},
+	}
+}
+
+var _ = Describe("Archiver", func() {
+
+	var (
+		dir       string
+		blobs     *FileBlobStore
+		fakeStore *fakes.FakeEventStore
+		archiver  *Archiver
+		ctx       = context.Background()
+		now       = time.Date(2019, 3, 14, 12, 0, 0, 0, time.UTC)
+		events    = []eventio.ArchivedRawEvent{
+			archivedEvent(1, "ee28a570-f485-48e1-87d0-98b7b8b66dfa", time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)),
+			archivedEvent(2, "f3d2e0c7-2bf6-4ad1-9a5b-7e0d2c1b8f11", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)),
+			archivedEvent(3, "7f8c9a1b-3e4d-4c5b-8a6f-1d2e3f4a5b6c", time.Date(2018, 1, 15, 0, 0, 0, 0, time.UTC)),
+		}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eventarchive")
+		Expect(err).ToNot(HaveOccurred())
+		blobs, err = NewFileBlobStore(dir)
+		Expect(err).ToNot(HaveOccurred())
+		fakeStore = &fakes.FakeEventStore{}
+		archiver = New(Config{
+			Logger:          lager.NewLogger("test"),
+			Store:           fakeStore,
+			Blobs:           blobs,
+			RetentionMonths: 12,
+			BatchSize:       2,
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should archive events from before the start of the retention window", func() {
+		Expect(archiver.Cutoff(now)).To(Equal(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+		fakeStore.ArchiveRawEventsStub = func(ctx context.Context, kind string, before time.Time, export eventio.RawEventExporter) (*eventio.RawEventArchive, error) {
+			if kind != "app" {
+				return nil, nil
+			}
+			key, err := export(&archivedRawEventRows{events: events})
+			if err != nil {
+				return nil, err
+			}
+			return &eventio.RawEventArchive{Kind: kind, Key: key, EventCount: int64(len(events))}, nil
+		}
+
+		archives, err := archiver.Archive(ctx, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archives).To(HaveLen(1))
+		Expect(archives[0].Key).To(HavePrefix("app/2018-03-"))
+
+		Expect(fakeStore.ArchiveRawEventsCallCount()).To(Equal(2))
+		_, kind, before, _ := fakeStore.ArchiveRawEventsArgsForCall(1)
+		Expect(kind).To(Equal("service"))
+		Expect(before).To(Equal(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+		By("restoring only the events in the requested range")
+		fakeStore.GetRawEventArchivesReturns(archives, nil)
+		fakeStore.RestoreRawEventsStub = func(ctx context.Context, batch []eventio.ArchivedRawEvent) (int, error) {
+			return len(batch), nil
+		}
+
+		restored, err := archiver.Restore(ctx, "app", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal(2))
+
+		filter := fakeStore.GetRawEventArchivesArgsForCall(0)
+		Expect(filter.Kind).To(Equal("app"))
+		Expect(fakeStore.RestoreRawEventsCallCount()).To(Equal(1))
+		_, batch := fakeStore.RestoreRawEventsArgsForCall(0)
+		Expect(batch).To(HaveLen(2))
+		Expect(batch[0].Sequence).To(Equal(int64(2)))
+		Expect(batch[0].GUID).To(Equal(events[1].GUID))
+		Expect(batch[0].CreatedAt.Equal(events[1].CreatedAt)).To(BeTrue())
+		Expect(batch[0].RawMessage).To(MatchJSON(events[1].RawMessage))
+		Expect(batch[1].Sequence).To(Equal(int64(3)))
+
+		By("leaving archives that were only partly restored unmarked")
+		Expect(fakeStore.MarkRawEventArchiveRestoredCallCount()).To(Equal(0))
+	})
+
+	It("should mark archives whose events were all restored", func() {
+		fakeStore.ArchiveRawEventsStub = func(ctx context.Context, kind string, before time.Time, export eventio.RawEventExporter) (*eventio.RawEventArchive, error) {
+			if kind != "app" {
+				return nil, nil
+			}
+			key, err := export(&archivedRawEventRows{events: events})
+			if err != nil {
+				return nil, err
+			}
+			return &eventio.RawEventArchive{
+				ID:             42,
+				Kind:           kind,
+				Key:            key,
+				EventCount:     int64(len(events)),
+				FirstCreatedAt: events[0].CreatedAt,
+				LastCreatedAt:  events[2].CreatedAt,
+			}, nil
+		}
+		archives, err := archiver.Archive(ctx, now)
+		Expect(err).ToNot(HaveOccurred())
+		fakeStore.GetRawEventArchivesReturns(archives, nil)
+		fakeStore.RestoreRawEventsStub = func(ctx context.Context, batch []eventio.ArchivedRawEvent) (int, error) {
+			return len(batch), nil
+		}
+
+		restored, err := archiver.Restore(ctx, "app", time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal(3))
+		Expect(fakeStore.MarkRawEventArchiveRestoredCallCount()).To(Equal(1))
+		_, id := fakeStore.MarkRawEventArchiveRestoredArgsForCall(0)
+		Expect(id).To(Equal(int64(42)))
+	})
+
+	It("should not archive anything if the events cannot be stored", func() {
+		fakeStore.ArchiveRawEventsStub = func(ctx context.Context, kind string, before time.Time, export eventio.RawEventExporter) (*eventio.RawEventArchive, error) {
+			_, err := export(&archivedRawEventRows{events: events})
+			return nil, err
+		}
+		os.RemoveAll(dir)
+		Expect(ioutil.WriteFile(dir, []byte("not a directory"), 0644)).To(Succeed())
+
+		_, err := archiver.Archive(ctx, now)
+		Expect(err).To(HaveOccurred())
+		Expect(fakeStore.ArchiveRawEventsCallCount()).To(Equal(1))
+	})
+
+	It("should fail to restore if an archive or its summary cannot be read", func() {
+		fakeStore.GetRawEventArchivesReturns([]eventio.RawEventArchive{{Kind: "app", Key: "app/missing.ndjson.gz"}}, nil)
+
+		_, err := archiver.Restore(ctx, "app", time.Time{}, time.Time{})
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		fakeStore.GetRawEventArchivesReturns(nil, errors.New("db error"))
+		_, err = archiver.Restore(ctx, "app", time.Time{}, time.Time{})
+		Expect(err).To(MatchError("db error"))
+	})
+})
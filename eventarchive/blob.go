@@ -0,0 +1,102 @@
+package eventarchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore stores archives. Implementations must not leave a partial blob
+// behind if Put fails.
+type BlobStore interface {
+	// Put stores everything read from r under key, replacing any blob
+	// already stored there
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns a reader for the blob stored under key, which must be
+	// closed by the caller
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// FileBlobStore is a BlobStore that keeps each blob as a file under a
+// directory, using the key as the file's path relative to it
+type FileBlobStore struct {
+	dir string
+}
+
+var _ BlobStore = &FileBlobStore{}
+
+// NewFileBlobStore returns a FileBlobStore keeping blobs under dir, which is
+// created if it does not exist
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("a directory must be given to store blobs in")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+// Put writes r to a temporary file next to the blob's path and renames it
+// into place once everything has been written
+func (fs *FileBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, &contextReader{ctx, r}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (fs *FileBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := fs.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// path returns the path of the file for key, which must stay inside the
+// store's directory
+func (fs *FileBlobStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key '%s'", key)
+	}
+	return filepath.Join(fs.dir, clean), nil
+}
+
+// contextReader stops reading from r once ctx is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,70 @@
+package eventarchive_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/alphagov/paas-billing/eventarchive"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+var _ = Describe("FileBlobStore", func() {
+
+	var (
+		dir   string
+		blobs *FileBlobStore
+		ctx   = context.Background()
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eventarchive")
+		Expect(err).ToNot(HaveOccurred())
+		blobs, err = NewFileBlobStore(filepath.Join(dir, "archives"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should read back what was put under a key", func() {
+		Expect(blobs.Put(ctx, "app/2018-01.ndjson.gz", strings.NewReader("hello"))).To(Succeed())
+
+		r, err := blobs.Get(ctx, "app/2018-01.ndjson.gz")
+		Expect(err).ToNot(HaveOccurred())
+		defer r.Close()
+		b, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).To(Equal("hello"))
+	})
+
+	It("should not leave anything behind when reading fails", func() {
+		err := blobs.Put(ctx, "app/broken", io.MultiReader(strings.NewReader("partial"), failingReader{}))
+		Expect(err).To(MatchError("read failed"))
+
+		_, err = blobs.Get(ctx, "app/broken")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		files, err := ioutil.ReadDir(filepath.Join(dir, "archives", "app"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+
+	It("should refuse keys outside of its directory", func() {
+		Expect(blobs.Put(ctx, "../escaped", strings.NewReader("x"))).To(MatchError("invalid blob key '../escaped'"))
+		_, err := blobs.Get(ctx, "/etc/passwd")
+		Expect(err).To(MatchError("invalid blob key '/etc/passwd'"))
+	})
+})
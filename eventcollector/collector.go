@@ -2,6 +2,7 @@ package eventcollector
 
 import (
 	"context"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -11,7 +12,8 @@ import (
 )
 
 const (
-	DefaultSchedule = time.Duration(15 * time.Minute)
+	DefaultSchedule       = time.Duration(15 * time.Minute)
+	DefaultInitialBackoff = time.Duration(10 * time.Second)
 )
 
 type state string
@@ -23,8 +25,20 @@ const (
 	Scheduled state = "waiting"
 	// Collecting means the collector thinks it probably has more to collect but is rate limited by MinWaitTime
 	Collecting state = "collecting"
+	// Failing means the last collection failed and the collector is backing off before retrying
+	Failing state = "failing"
 )
 
+// Status describes the health of an EventCollector
+type Status struct {
+	Kind              string    `json:"kind"`
+	State             string    `json:"state"`
+	ConsecutiveErrors int       `json:"consecutive_errors"`
+	LastError         string    `json:"last_error,omitempty"`
+	LastSuccess       time.Time `json:"last_success"`
+	EventsCollected   int       `json:"events_collected"`
+}
+
 // EventCollector periodically fetches events via the given EventFetcher and
 // stores them to the given EventStore
 type EventCollector struct {
@@ -35,8 +49,15 @@ type EventCollector struct {
 	logger          lager.Logger
 	fetcher         eventio.EventFetcher
 	store           eventio.EventStore
+	initialBackoff  time.Duration
+	maxBackoff      time.Duration
 	mu              sync.Mutex
+	statusMu        sync.RWMutex
 	eventsCollected int
+	errorCount      int
+	lastError       error
+	permanentError  bool
+	lastSuccess     time.Time
 }
 
 // Run executes collect periodically the rate is dictated by Schedule and MinWaitTime
@@ -47,22 +68,28 @@ func (c *EventCollector) Run(ctx context.Context) error {
 	defer c.mu.Unlock()
 
 	for {
+		delay := c.waitDuration()
 		c.logger.Info("status", lager.Data{
-			"state":            c.state,
-			"kind":             c.fetcher.Kind(),
-			"next_collection":  c.waitDuration().String(),
-			"events_collected": c.eventsCollected,
+			"state":              c.state,
+			"kind":               c.fetcher.Kind(),
+			"next_collection":    delay.String(),
+			"events_collected":   c.eventsCollected,
+			"consecutive_errors": c.errorCount,
 		})
 		select {
-		case <-time.After(c.waitDuration()):
+		case <-time.After(delay):
 			startTime := time.Now()
 			collectedEvents, err := c.collect(ctx)
 			if err != nil {
-				c.state = Scheduled
-				c.logger.Error("collect-error", err)
+				c.recordFailure(err)
+				c.logger.Error("collect-error", err, lager.Data{
+					"kind":               c.fetcher.Kind(),
+					"consecutive_errors": c.errorCount,
+					"permanent":          c.permanentError,
+				})
 				continue
 			}
-			c.eventsCollected += len(collectedEvents)
+			c.recordSuccess(len(collectedEvents))
 			elapsed := time.Since(startTime)
 			c.logger.Info("collected", lager.Data{
 				"count":   len(collectedEvents),
@@ -75,6 +102,49 @@ func (c *EventCollector) Run(ctx context.Context) error {
 	}
 }
 
+// Status returns the current state of the collector. It is safe to call while
+// the collector is running.
+func (c *EventCollector) Status() Status {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	status := Status{
+		Kind:              c.fetcher.Kind(),
+		State:             string(c.state),
+		ConsecutiveErrors: c.errorCount,
+		LastSuccess:       c.lastSuccess,
+		EventsCollected:   c.eventsCollected,
+	}
+	if c.lastError != nil {
+		status.LastError = c.lastError.Error()
+	}
+	return status
+}
+
+func (c *EventCollector) setState(s state) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.state = s
+}
+
+func (c *EventCollector) recordFailure(err error) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.state = Failing
+	c.errorCount++
+	c.lastError = err
+	_, c.permanentError = err.(*eventio.PermanentFetchError)
+}
+
+func (c *EventCollector) recordSuccess(count int) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.eventsCollected += count
+	c.errorCount = 0
+	c.lastError = nil
+	c.permanentError = false
+	c.lastSuccess = time.Now()
+}
+
 // collect reads a batch of RawEvents from the EventFetcher and writes them to the EventStore
 func (c *EventCollector) collect(ctx context.Context) ([]eventio.RawEvent, error) {
 	lastEvent, err := c.getLastEvent()
@@ -98,15 +168,89 @@ func (c *EventCollector) collect(ctx context.Context) ([]eventio.RawEvent, error
 		return nil, err
 	}
 	if len(events) == 0 {
-		c.state = Scheduled
+		c.setState(Scheduled)
 	} else if len(events) > 0 && lastEvent != nil && events[len(events)-1].GUID == lastEvent.GUID {
-		c.state = Scheduled
+		c.setState(Scheduled)
 	} else {
-		c.state = Collecting
+		c.setState(Collecting)
 	}
 	return events, nil
 }
 
+// Backfill re-fetches every event after the event with the given GUID (or
+// from the very first event if afterGUID is empty) and writes them to the
+// EventStore. Events that have already been stored are ignored by the store so
+// it is safe to replay a window that overlaps existing events. Backfill
+// returns the number of events fetched once it has caught up.
+func (c *EventCollector) Backfill(ctx context.Context, afterGUID string) (int, error) {
+	var lastEvent *eventio.RawEvent
+	if afterGUID != "" {
+		lastEvent = &eventio.RawEvent{
+			GUID: afterGUID,
+			Kind: c.fetcher.Kind(),
+		}
+	}
+	c.logger.Info("backfill-started", lager.Data{
+		"kind":       c.fetcher.Kind(),
+		"after_guid": afterGUID,
+	})
+	startTime := time.Now()
+	count := 0
+	for {
+		events, err := c.fetcher.FetchEvents(ctx, lastEvent)
+		if err != nil {
+			return count, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		if lastEvent != nil && events[len(events)-1].GUID == lastEvent.GUID {
+			break
+		}
+		if err := c.store.StoreEvents(events); err != nil {
+			return count, err
+		}
+		count += len(events)
+		lastEvent = &events[len(events)-1]
+		c.logger.Info("backfill-progress", lager.Data{
+			"kind":            c.fetcher.Kind(),
+			"count":           count,
+			"last_guid":       lastEvent.GUID,
+			"last_created_at": lastEvent.CreatedAt,
+		})
+		select {
+		case <-time.After(c.minWaitTime):
+		case <-ctx.Done():
+			return count, ctx.Err()
+		}
+	}
+	c.logger.Info("backfill-finished", lager.Data{
+		"kind":    c.fetcher.Kind(),
+		"count":   count,
+		"elapsed": int64(time.Since(startTime)),
+	})
+	return count, nil
+}
+
+// BackfillSince is like Backfill but starts from the last stored event that
+// was created before the given time. The usage event APIs can only be paged
+// by GUID so if no earlier event is stored then every event is re-fetched.
+func (c *EventCollector) BackfillSince(ctx context.Context, since time.Time) (int, error) {
+	events, err := c.store.GetEvents(eventio.RawEventFilter{
+		Kind:   c.fetcher.Kind(),
+		Limit:  1,
+		Before: since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	afterGUID := ""
+	if len(events) > 0 {
+		afterGUID = events[0].GUID
+	}
+	return c.Backfill(ctx, afterGUID)
+}
+
 // getLastEvent returns the latest event of the same kind as the fetcher or nil if no events
 func (c *EventCollector) getLastEvent() (*eventio.RawEvent, error) {
 	lastEvents, err := c.store.GetEvents(eventio.RawEventFilter{
@@ -122,7 +266,7 @@ func (c *EventCollector) getLastEvent() (*eventio.RawEvent, error) {
 	return &lastEvents[0], nil
 }
 
-// wait returns a channel that closes after the collection schedule time has elapsed
+// waitDuration returns how long to wait before the next collection
 func (c *EventCollector) waitDuration() time.Duration {
 	delay := c.schedule
 	if c.state == Syncing {
@@ -131,13 +275,38 @@ func (c *EventCollector) waitDuration() time.Duration {
 	if c.state == Collecting {
 		delay = c.minWaitTime
 	}
+	if c.state == Failing {
+		delay = c.backoffDuration()
+		// retrying quickly will not fix a permanent error so wait as long
+		// as possible
+		if c.permanentError {
+			delay = c.maxBackoff
+		}
+	}
 	return delay
 }
 
+// backoffDuration starts at initialBackoff and doubles the wait after each
+// consecutive error up to maxBackoff. Half of the delay is randomised so that collectors that failed
+// together do not all retry at the same moment.
+func (c *EventCollector) backoffDuration() time.Duration {
+	delay := c.initialBackoff
+	for i := 1; i < c.errorCount && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
 type Config struct {
 	Schedule        time.Duration
 	MinWaitTime     time.Duration
 	InitialWaitTime time.Duration
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
 	Logger          lager.Logger
 	Fetcher         eventio.EventFetcher
 	Store           eventio.EventStore
@@ -147,12 +316,20 @@ func New(cfg Config) *EventCollector {
 	if cfg.Logger == nil {
 		cfg.Logger = lager.NewLogger("collector")
 	}
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = cfg.Schedule
+	}
 	return &EventCollector{
-		schedule:    cfg.Schedule,
-		minWaitTime: cfg.MinWaitTime,
-		logger:      cfg.Logger,
-		fetcher:     cfg.Fetcher,
-		store:       cfg.Store,
-		state:       Syncing,
+		schedule:       cfg.Schedule,
+		minWaitTime:    cfg.MinWaitTime,
+		initialBackoff: cfg.InitialBackoff,
+		maxBackoff:     cfg.MaxBackoff,
+		logger:         cfg.Logger,
+		fetcher:        cfg.Fetcher,
+		store:          cfg.Store,
+		state:          Syncing,
 	}
 }
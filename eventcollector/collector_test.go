@@ -122,6 +122,84 @@ var _ = Describe("Collector", func() {
 		Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(3))
 	}, 5)
 
+	It("should back off exponentially while collection keeps failing", func() {
+		cfg.Schedule = 999 * time.Minute
+		cfg.InitialBackoff = 100 * time.Millisecond
+		cfg.MaxBackoff = 400 * time.Millisecond
+
+		fakeEventStore.GetEventsReturns([]eventio.RawEvent{}, nil)
+		fakeEventFetcher.FetchEventsReturns(nil, errors.New("some error"))
+
+		go New(cfg).Run(ctx)
+
+		// waits of at most 100ms, 200ms, 400ms, 400ms... after the first
+		// attempt and at least half of that
+		time.Sleep(1000 * time.Millisecond)
+
+		Expect(fakeEventFetcher.FetchEventsCallCount()).To(BeNumerically(">=", 3))
+		Expect(fakeEventFetcher.FetchEventsCallCount()).To(BeNumerically("<=", 6))
+	}, 5)
+
+	It("should wait MaxBackoff after a permanent error", func() {
+		cfg.Schedule = 999 * time.Minute
+		cfg.InitialBackoff = 10 * time.Millisecond
+		cfg.MaxBackoff = 400 * time.Millisecond
+
+		fakeEventStore.GetEventsReturns([]eventio.RawEvent{}, nil)
+		fakeEventFetcher.FetchEventsReturns(nil, &eventio.PermanentFetchError{Err: errors.New("forbidden")})
+
+		go New(cfg).Run(ctx)
+
+		// transient errors would be retried after 10ms, 20ms, 40ms...
+		time.Sleep(300 * time.Millisecond)
+
+		Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(1))
+	}, 5)
+
+	It("should report a failing state and the number of consecutive errors", func() {
+		cfg.Schedule = 999 * time.Minute
+		cfg.InitialBackoff = 10 * time.Millisecond
+		cfg.MaxBackoff = 10 * time.Millisecond
+
+		fakeEventStore.GetEventsReturns([]eventio.RawEvent{}, nil)
+		fakeEventFetcher.KindReturns("app")
+		fakeEventFetcher.FetchEventsReturns(nil, errors.New("some error"))
+
+		collector := New(cfg)
+		go collector.Run(ctx)
+
+		Eventually(func() int {
+			return collector.Status().ConsecutiveErrors
+		}, 5*time.Second).Should(BeNumerically(">=", 3))
+		status := collector.Status()
+		Expect(status.Kind).To(Equal("app"))
+		Expect(status.State).To(Equal(string(Failing)))
+		Expect(status.LastError).To(Equal("some error"))
+		Expect(status.LastSuccess.IsZero()).To(BeTrue())
+	}, 5)
+
+	It("should reset the error count once collection succeeds again", func() {
+		cfg.Schedule = 999 * time.Minute
+		cfg.InitialBackoff = 10 * time.Millisecond
+
+		fakeEventStore.GetEventsReturns([]eventio.RawEvent{}, nil)
+		fakeEventFetcher.FetchEventsReturnsOnCall(0, nil, errors.New("some error"))
+		fakeEventFetcher.FetchEventsReturnsOnCall(1, nil, errors.New("some error"))
+		fakeEventFetcher.FetchEventsReturns([]eventio.RawEvent{}, nil)
+
+		collector := New(cfg)
+		go collector.Run(ctx)
+
+		Eventually(func() string {
+			return collector.Status().State
+		}, 5*time.Second).Should(Equal(string(Scheduled)))
+		status := collector.Status()
+		Expect(status.ConsecutiveErrors).To(Equal(0))
+		Expect(status.LastError).To(BeEmpty())
+		Expect(status.LastSuccess.IsZero()).To(BeFalse())
+		Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(3))
+	}, 5)
+
 	It("should stop gracefully when context is cancelled", func() {
 		ctx, cancelFunc := context.WithCancel(context.Background())
 
@@ -136,4 +214,85 @@ var _ = Describe("Collector", func() {
 		Expect(<-c).To(BeTrue())
 	}, 5)
 
+	Describe("Backfill", func() {
+
+		BeforeEach(func() {
+			cfg.MinWaitTime = 0
+			fakeEventFetcher.KindReturns("app")
+		})
+
+		It("should page through events from the given GUID until it has caught up", func() {
+			fakeEventFetcher.FetchEventsReturnsOnCall(0, []eventio.RawEvent{{GUID: "event-1"}, {GUID: "event-2"}}, nil)
+			fakeEventFetcher.FetchEventsReturnsOnCall(1, []eventio.RawEvent{{GUID: "event-3"}}, nil)
+			fakeEventFetcher.FetchEventsReturnsOnCall(2, []eventio.RawEvent{}, nil)
+
+			count, err := New(cfg).Backfill(ctx, "start-event")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(3))
+
+			Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(3))
+			_, lastEvent := fakeEventFetcher.FetchEventsArgsForCall(0)
+			Expect(lastEvent.GUID).To(Equal("start-event"))
+			_, lastEvent = fakeEventFetcher.FetchEventsArgsForCall(1)
+			Expect(lastEvent.GUID).To(Equal("event-2"))
+			_, lastEvent = fakeEventFetcher.FetchEventsArgsForCall(2)
+			Expect(lastEvent.GUID).To(Equal("event-3"))
+
+			Expect(fakeEventStore.StoreEventsCallCount()).To(Equal(2))
+			Expect(fakeEventStore.StoreEventsArgsForCall(0)).To(Equal([]eventio.RawEvent{{GUID: "event-1"}, {GUID: "event-2"}}))
+			Expect(fakeEventStore.StoreEventsArgsForCall(1)).To(Equal([]eventio.RawEvent{{GUID: "event-3"}}))
+			Expect(fakeEventStore.GetEventsCallCount()).To(Equal(0))
+		})
+
+		It("should start from the first event when no GUID is given", func() {
+			fakeEventFetcher.FetchEventsReturns([]eventio.RawEvent{}, nil)
+
+			count, err := New(cfg).Backfill(ctx, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+
+			Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(1))
+			_, lastEvent := fakeEventFetcher.FetchEventsArgsForCall(0)
+			Expect(lastEvent).To(BeNil())
+		})
+
+		It("should stop when the fetcher returns the last seen event", func() {
+			fakeEventFetcher.FetchEventsReturnsOnCall(0, []eventio.RawEvent{{GUID: "event-1"}}, nil)
+			fakeEventFetcher.FetchEventsReturnsOnCall(1, []eventio.RawEvent{{GUID: "event-1"}}, nil)
+
+			count, err := New(cfg).Backfill(ctx, "start-event")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			Expect(fakeEventFetcher.FetchEventsCallCount()).To(Equal(2))
+			Expect(fakeEventStore.StoreEventsCallCount()).To(Equal(1))
+		})
+
+		It("should return fetch errors along with the number of events stored so far", func() {
+			fakeEventFetcher.FetchEventsReturnsOnCall(0, []eventio.RawEvent{{GUID: "event-1"}}, nil)
+			fakeEventFetcher.FetchEventsReturnsOnCall(1, nil, errors.New("fetch-error"))
+
+			count, err := New(cfg).Backfill(ctx, "start-event")
+			Expect(err).To(MatchError("fetch-error"))
+			Expect(count).To(Equal(1))
+		})
+
+		It("should start from the last stored event before the given time", func() {
+			since := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+			fakeEventStore.GetEventsReturns([]eventio.RawEvent{{GUID: "stored-event"}}, nil)
+			fakeEventFetcher.FetchEventsReturns([]eventio.RawEvent{}, nil)
+
+			_, err := New(cfg).BackfillSince(ctx, since)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeEventStore.GetEventsCallCount()).To(Equal(1))
+			Expect(fakeEventStore.GetEventsArgsForCall(0)).To(Equal(eventio.RawEventFilter{
+				Kind:   "app",
+				Limit:  1,
+				Before: since,
+			}))
+			_, lastEvent := fakeEventFetcher.FetchEventsArgsForCall(0)
+			Expect(lastEvent.GUID).To(Equal("stored-event"))
+		})
+	})
+
 })
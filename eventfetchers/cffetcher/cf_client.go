@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
 	cfclient "github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pkg/errors"
 )
@@ -84,11 +85,16 @@ func NewServiceUsageEventsAPI(client UsageEventsClient, logger lager.Logger) Usa
 }
 
 func (u *usageEventsAPI) doRequest(path string, target interface{}) error {
-	u.logger.Debug("fetching", lager.Data{
+	return doRequest(u.client, u.logger, path, target)
+}
+
+// doRequest GETs the path and decodes the JSON response body into target
+func doRequest(client UsageEventsClient, logger lager.Logger, path string, target interface{}) error {
+	logger.Debug("fetching", lager.Data{
 		"path": path,
 	})
 
-	resp, err := u.client.Get(path)
+	resp, err := client.Get(path)
 	if err != nil {
 		return errors.Wrapf(err, "error fetching %s", path)
 	}
@@ -100,7 +106,15 @@ func (u *usageEventsAPI) doRequest(path string, target interface{}) error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s request failed: %d %s", path, resp.StatusCode, resBody)
+		err := fmt.Errorf("%s request failed: %d %s", path, resp.StatusCode, resBody)
+		// client errors other than timeouts and rate limiting will keep
+		// failing until the config or the API changes
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout &&
+			resp.StatusCode != http.StatusTooManyRequests {
+			return &eventio.PermanentFetchError{Err: err}
+		}
+		return err
 	}
 
 	err = json.Unmarshal(resBody, target)
@@ -12,6 +12,7 @@ import (
 
 	"code.cloudfoundry.org/lager"
 	. "github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+	"github.com/alphagov/paas-billing/eventio"
 	"github.com/alphagov/paas-billing/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -227,6 +228,30 @@ var usageEventTests = func(eventType string, clientFactory clientFactory) func()
 			Expect(events).To(BeNil())
 		})
 
+		It("should return a permanent error for rejected requests", func() {
+			resp := &http.Response{
+				StatusCode: 403,
+				Body:       ioutil.NopCloser(strings.NewReader("forbidden")),
+			}
+			fakeUsageEventsClient.GetReturns(resp, nil)
+			_, err := usageEvents.Get(GUIDNil, 10, 0)
+			Expect(err).To(BeAssignableToTypeOf(&eventio.PermanentFetchError{}))
+			Expect(err.Error()).To(Equal(fmt.Sprintf("/v2/%s_usage_events?results-per-page=10 request failed: 403 forbidden", eventType)))
+		})
+
+		It("should not return a permanent error for server errors or rate limiting", func() {
+			for _, code := range []int{429, 500, 503} {
+				resp := &http.Response{
+					StatusCode: code,
+					Body:       ioutil.NopCloser(strings.NewReader("some error")),
+				}
+				fakeUsageEventsClient.GetReturns(resp, nil)
+				_, err := usageEvents.Get(GUIDNil, 10, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err).ToNot(BeAssignableToTypeOf(&eventio.PermanentFetchError{}))
+			}
+		})
+
 		It("should return an error when response contains invalid JSON", func() {
 			resp := &http.Response{
 				StatusCode: 200,
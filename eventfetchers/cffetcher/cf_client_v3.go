@@ -0,0 +1,251 @@
+package cffetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pkg/errors"
+)
+
+// v3UsageEventList is a page of the /v3/{app,service}_usage_events APIs
+type v3UsageEventList struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []json.RawMessage `json:"resources"`
+}
+
+// v3Resource is a reference to a related resource in a v3 usage event
+type v3Resource struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// v3Change is a value of a v3 app usage event that has a current and
+// previous value
+type v3Change struct {
+	Current  interface{} `json:"current"`
+	Previous interface{} `json:"previous"`
+}
+
+type v3AppUsageEvent struct {
+	GUID                  string     `json:"guid"`
+	CreatedAt             time.Time  `json:"created_at"`
+	State                 v3Change   `json:"state"`
+	App                   v3Resource `json:"app"`
+	Process               v3Resource `json:"process"`
+	Space                 v3Resource `json:"space"`
+	Organization          v3Resource `json:"organization"`
+	Buildpack             v3Resource `json:"buildpack"`
+	Task                  v3Resource `json:"task"`
+	MemoryInMBPerInstance v3Change   `json:"memory_in_mb_per_instance"`
+	InstanceCount         v3Change   `json:"instance_count"`
+}
+
+// v2AppUsageEntity has the fields of a v2 app usage event entity that are
+// read by create_events.sql
+type v2AppUsageEntity struct {
+	State                         interface{} `json:"state"`
+	PreviousState                 interface{} `json:"previous_state"`
+	MemoryInMBPerInstance         interface{} `json:"memory_in_mb_per_instance,omitempty"`
+	PreviousMemoryInMBPerInstance interface{} `json:"previous_memory_in_mb_per_instance,omitempty"`
+	InstanceCount                 interface{} `json:"instance_count,omitempty"`
+	PreviousInstanceCount         interface{} `json:"previous_instance_count,omitempty"`
+	AppGUID                       string      `json:"app_guid,omitempty"`
+	AppName                       string      `json:"app_name,omitempty"`
+	SpaceGUID                     string      `json:"space_guid,omitempty"`
+	SpaceName                     string      `json:"space_name,omitempty"`
+	OrgGUID                       string      `json:"org_guid,omitempty"`
+	BuildpackGUID                 string      `json:"buildpack_guid,omitempty"`
+	BuildpackName                 string      `json:"buildpack_name,omitempty"`
+	ParentAppGUID                 string      `json:"parent_app_guid,omitempty"`
+	ParentAppName                 string      `json:"parent_app_name,omitempty"`
+	ProcessType                   string      `json:"process_type,omitempty"`
+	TaskGUID                      string      `json:"task_guid,omitempty"`
+	TaskName                      string      `json:"task_name,omitempty"`
+}
+
+type v3ServiceUsageEvent struct {
+	GUID            string     `json:"guid"`
+	CreatedAt       time.Time  `json:"created_at"`
+	State           string     `json:"state"`
+	Space           v3Resource `json:"space"`
+	Organization    v3Resource `json:"organization"`
+	ServiceInstance v3Resource `json:"service_instance"`
+	ServicePlan     v3Resource `json:"service_plan"`
+	ServiceOffering v3Resource `json:"service_offering"`
+	ServiceBroker   v3Resource `json:"service_broker"`
+}
+
+// v2ServiceUsageEntity has the fields of a v2 service usage event entity
+// that are read by create_events.sql
+type v2ServiceUsageEntity struct {
+	State               string `json:"state"`
+	OrgGUID             string `json:"org_guid,omitempty"`
+	SpaceGUID           string `json:"space_guid,omitempty"`
+	SpaceName           string `json:"space_name,omitempty"`
+	ServiceInstanceGUID string `json:"service_instance_guid,omitempty"`
+	ServiceInstanceName string `json:"service_instance_name,omitempty"`
+	ServiceInstanceType string `json:"service_instance_type,omitempty"`
+	ServicePlanGUID     string `json:"service_plan_guid,omitempty"`
+	ServicePlanName     string `json:"service_plan_name,omitempty"`
+	ServiceGUID         string `json:"service_guid,omitempty"`
+	ServiceLabel        string `json:"service_label,omitempty"`
+	ServiceBrokerGUID   string `json:"service_broker_guid,omitempty"`
+	ServiceBrokerName   string `json:"service_broker_name,omitempty"`
+}
+
+// v3UsageEventsAPI is a CloudFoundry v3 API client for getting usage events.
+// The events are converted to the shape of the v2 API so that the stored
+// raw_message of an event does not depend on the API version it was
+// collected from.
+type v3UsageEventsAPI struct {
+	eventType string
+	client    UsageEventsClient
+	logger    lager.Logger
+}
+
+// NewV3AppUsageEventsAPI returns with a new v3 app usage events API client
+func NewV3AppUsageEventsAPI(client UsageEventsClient, logger lager.Logger) UsageEventsAPI {
+	return &v3UsageEventsAPI{
+		client:    client,
+		eventType: appType,
+		logger:    logger,
+	}
+}
+
+// NewV3ServiceUsageEventsAPI returns with a new v3 service usage events API client
+func NewV3ServiceUsageEventsAPI(client UsageEventsClient, logger lager.Logger) UsageEventsAPI {
+	return &v3UsageEventsAPI{
+		client:    client,
+		eventType: serviceType,
+		logger:    logger,
+	}
+}
+
+// Get returns with up to count usage events or an error on failure. Pages
+// are followed until count events are found, in case the API returns
+// smaller pages than requested.
+func (u *v3UsageEventsAPI) Get(afterGUID string, count int, minAge time.Duration) (*UsageEventList, error) {
+	if afterGUID == "" {
+		panic("afterGUID parameter should not be empty")
+	}
+
+	path := fmt.Sprintf("/v3/%s_usage_events?per_page=%d&order_by=created_at", u.eventType, count)
+	if afterGUID != GUIDNil {
+		path = path + fmt.Sprintf("&after_guid=%s", afterGUID)
+	}
+
+	t := time.Now().Add(-minAge)
+	res := &UsageEventList{Resources: []UsageEvent{}}
+	for path != "" {
+		page := &v3UsageEventList{}
+		if err := doRequest(u.client, u.logger, path, page); err != nil {
+			return nil, err
+		}
+		path = ""
+		for _, raw := range page.Resources {
+			event, err := u.convert(raw)
+			if err != nil {
+				return nil, err
+			}
+			if event.MetaData.CreatedAt.After(t) {
+				return res, nil
+			}
+			res.Resources = append(res.Resources, event)
+			if len(res.Resources) >= count {
+				return res, nil
+			}
+		}
+		if page.Pagination.Next != nil && page.Pagination.Next.Href != "" {
+			next, err := url.Parse(page.Pagination.Next.Href)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing next page link")
+			}
+			path = next.RequestURI()
+		}
+	}
+
+	return res, nil
+}
+
+// convert returns the v3 event with its fields mapped to a v2 entity
+func (u *v3UsageEventsAPI) convert(raw json.RawMessage) (UsageEvent, error) {
+	var (
+		meta   MetaData
+		entity interface{}
+	)
+	switch u.eventType {
+	case appType:
+		var event v3AppUsageEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return UsageEvent{}, errors.Wrapf(err, "error unmarshalling %s usage event", u.eventType)
+		}
+		meta = MetaData{GUID: event.GUID, CreatedAt: event.CreatedAt}
+		// v2 reports the process as the "app" and the v3 app as its parent
+		appGUID := event.Process.GUID
+		if appGUID == "" {
+			appGUID = event.App.GUID
+		}
+		entity = v2AppUsageEntity{
+			State:                         event.State.Current,
+			PreviousState:                 event.State.Previous,
+			MemoryInMBPerInstance:         event.MemoryInMBPerInstance.Current,
+			PreviousMemoryInMBPerInstance: event.MemoryInMBPerInstance.Previous,
+			InstanceCount:                 event.InstanceCount.Current,
+			PreviousInstanceCount:         event.InstanceCount.Previous,
+			AppGUID:                       appGUID,
+			AppName:                       event.App.Name,
+			SpaceGUID:                     event.Space.GUID,
+			SpaceName:                     event.Space.Name,
+			OrgGUID:                       event.Organization.GUID,
+			BuildpackGUID:                 event.Buildpack.GUID,
+			BuildpackName:                 event.Buildpack.Name,
+			ParentAppGUID:                 event.App.GUID,
+			ParentAppName:                 event.App.Name,
+			ProcessType:                   event.Process.Type,
+			TaskGUID:                      event.Task.GUID,
+			TaskName:                      event.Task.Name,
+		}
+	case serviceType:
+		var event v3ServiceUsageEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return UsageEvent{}, errors.Wrapf(err, "error unmarshalling %s usage event", u.eventType)
+		}
+		meta = MetaData{GUID: event.GUID, CreatedAt: event.CreatedAt}
+		entity = v2ServiceUsageEntity{
+			State:               event.State,
+			OrgGUID:             event.Organization.GUID,
+			SpaceGUID:           event.Space.GUID,
+			SpaceName:           event.Space.Name,
+			ServiceInstanceGUID: event.ServiceInstance.GUID,
+			ServiceInstanceName: event.ServiceInstance.Name,
+			ServiceInstanceType: event.ServiceInstance.Type,
+			ServicePlanGUID:     event.ServicePlan.GUID,
+			ServicePlanName:     event.ServicePlan.Name,
+			ServiceGUID:         event.ServiceOffering.GUID,
+			ServiceLabel:        event.ServiceOffering.Name,
+			ServiceBrokerGUID:   event.ServiceBroker.GUID,
+			ServiceBrokerName:   event.ServiceBroker.Name,
+		}
+	}
+	entityRaw, err := json.Marshal(entity)
+	if err != nil {
+		return UsageEvent{}, err
+	}
+	return UsageEvent{
+		MetaData:  meta,
+		EntityRaw: entityRaw,
+	}, nil
+}
+
+// Type returns with the client type
+func (u *v3UsageEventsAPI) Type() string {
+	return u.eventType
+}
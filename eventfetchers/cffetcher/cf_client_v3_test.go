@@ -0,0 +1,207 @@
+package cffetcher_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	. "github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+	"github.com/alphagov/paas-billing/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The v3 Usage Events APIs", func() {
+	var (
+		now                   time.Time
+		logger                = lager.NewLogger("test")
+		fakeUsageEventsClient *fakes.FakeUsageEventsClient
+	)
+
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	timestamp := func(t time.Time) string {
+		return t.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+		fakeUsageEventsClient = &fakes.FakeUsageEventsClient{}
+	})
+
+	It("should have the right types", func() {
+		Expect(NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Type()).To(Equal("app"))
+		Expect(NewV3ServiceUsageEventsAPI(fakeUsageEventsClient, logger).Type()).To(Equal("service"))
+	})
+
+	It("should use the v3 endpoint and after_guid filter", func() {
+		fakeUsageEventsClient.GetReturns(respond(`{"pagination": {"next": null}, "resources": []}`), nil)
+
+		events, err := NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Get("abcd", 3, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events.Resources).To(BeEmpty())
+		Expect(fakeUsageEventsClient.GetCallCount()).To(Equal(1))
+		Expect(fakeUsageEventsClient.GetArgsForCall(0)).To(Equal("/v3/app_usage_events?per_page=3&order_by=created_at&after_guid=abcd"))
+	})
+
+	It("should not filter by guid when afterGUID is GUIDNil", func() {
+		fakeUsageEventsClient.GetReturns(respond(`{"pagination": {"next": null}, "resources": []}`), nil)
+
+		_, err := NewV3ServiceUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 3, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeUsageEventsClient.GetArgsForCall(0)).To(Equal("/v3/service_usage_events?per_page=3&order_by=created_at"))
+	})
+
+	It("should map app usage events to the v2 entity fields", func() {
+		fakeUsageEventsClient.GetReturns(respond(`{
+			"pagination": {"next": null},
+			"resources": [{
+				"guid": "a000",
+				"created_at": "`+timestamp(now.Add(-time.Hour))+`",
+				"updated_at": "`+timestamp(now.Add(-time.Hour))+`",
+				"state": {"current": "STARTED", "previous": "STOPPED"},
+				"app": {"guid": "app-guid", "name": "my-app"},
+				"process": {"guid": "process-guid", "type": "web"},
+				"space": {"guid": "space-guid", "name": "my-space"},
+				"organization": {"guid": "org-guid"},
+				"buildpack": {"guid": "buildpack-guid", "name": "go_buildpack"},
+				"task": {"guid": null, "name": null},
+				"memory_in_mb_per_instance": {"current": 512, "previous": 256},
+				"instance_count": {"current": 2, "previous": 1}
+			}]
+		}`), nil)
+
+		events, err := NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 10, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events.Resources).To(HaveLen(1))
+		Expect(events.Resources[0].MetaData.GUID).To(Equal("a000"))
+		Expect(events.Resources[0].MetaData.CreatedAt).To(BeTemporally("~", now.Add(-time.Hour), time.Second))
+		Expect(string(events.Resources[0].EntityRaw)).To(MatchJSON(`{
+			"state": "STARTED",
+			"previous_state": "STOPPED",
+			"memory_in_mb_per_instance": 512,
+			"previous_memory_in_mb_per_instance": 256,
+			"instance_count": 2,
+			"previous_instance_count": 1,
+			"app_guid": "process-guid",
+			"app_name": "my-app",
+			"space_guid": "space-guid",
+			"space_name": "my-space",
+			"org_guid": "org-guid",
+			"buildpack_guid": "buildpack-guid",
+			"buildpack_name": "go_buildpack",
+			"parent_app_guid": "app-guid",
+			"parent_app_name": "my-app",
+			"process_type": "web"
+		}`))
+	})
+
+	It("should map service usage events to the v2 entity fields", func() {
+		fakeUsageEventsClient.GetReturns(respond(`{
+			"pagination": {"next": null},
+			"resources": [{
+				"guid": "b000",
+				"created_at": "`+timestamp(now.Add(-time.Hour))+`",
+				"state": "CREATED",
+				"space": {"guid": "space-guid", "name": "my-space"},
+				"organization": {"guid": "org-guid"},
+				"service_instance": {"guid": "instance-guid", "name": "my-db", "type": "managed_service_instance"},
+				"service_plan": {"guid": "plan-guid", "name": "small"},
+				"service_offering": {"guid": "offering-guid", "name": "postgres"},
+				"service_broker": {"guid": "broker-guid", "name": "rds-broker"}
+			}]
+		}`), nil)
+
+		events, err := NewV3ServiceUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 10, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events.Resources).To(HaveLen(1))
+		Expect(events.Resources[0].MetaData.GUID).To(Equal("b000"))
+		Expect(string(events.Resources[0].EntityRaw)).To(MatchJSON(`{
+			"state": "CREATED",
+			"org_guid": "org-guid",
+			"space_guid": "space-guid",
+			"space_name": "my-space",
+			"service_instance_guid": "instance-guid",
+			"service_instance_name": "my-db",
+			"service_instance_type": "managed_service_instance",
+			"service_plan_guid": "plan-guid",
+			"service_plan_name": "small",
+			"service_guid": "offering-guid",
+			"service_label": "postgres",
+			"service_broker_guid": "broker-guid",
+			"service_broker_name": "rds-broker"
+		}`))
+	})
+
+	It("should follow the next page link until count events are found", func() {
+		fakeUsageEventsClient.GetReturnsOnCall(0, respond(`{
+			"pagination": {"next": {"href": "https://api.example.com/v3/service_usage_events?page=2&per_page=2"}},
+			"resources": [
+				{"guid": "a000", "created_at": "`+timestamp(now.Add(-time.Hour))+`", "state": "CREATED"}
+			]
+		}`), nil)
+		fakeUsageEventsClient.GetReturnsOnCall(1, respond(`{
+			"pagination": {"next": {"href": "https://api.example.com/v3/service_usage_events?page=3&per_page=2"}},
+			"resources": [
+				{"guid": "b000", "created_at": "`+timestamp(now.Add(-time.Hour))+`", "state": "CREATED"},
+				{"guid": "c000", "created_at": "`+timestamp(now.Add(-time.Hour))+`", "state": "CREATED"}
+			]
+		}`), nil)
+
+		events, err := NewV3ServiceUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 2, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeUsageEventsClient.GetCallCount()).To(Equal(2))
+		Expect(fakeUsageEventsClient.GetArgsForCall(1)).To(Equal("/v3/service_usage_events?page=2&per_page=2"))
+		Expect(events.Resources).To(HaveLen(2))
+		Expect(events.Resources[0].MetaData.GUID).To(Equal("a000"))
+		Expect(events.Resources[1].MetaData.GUID).To(Equal("b000"))
+	})
+
+	It("should not process records after the first item newer than the minimum age", func() {
+		fakeUsageEventsClient.GetReturns(respond(`{
+			"pagination": {"next": {"href": "https://api.example.com/v3/service_usage_events?page=2"}},
+			"resources": [
+				{"guid": "a000", "created_at": "`+timestamp(now.Add(-time.Hour))+`", "state": "CREATED"},
+				{"guid": "b000", "created_at": "`+timestamp(now)+`", "state": "CREATED"},
+				{"guid": "c000", "created_at": "`+timestamp(now.Add(-time.Hour))+`", "state": "CREATED"}
+			]
+		}`), nil)
+
+		events, err := NewV3ServiceUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 10, 10*time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeUsageEventsClient.GetCallCount()).To(Equal(1))
+		Expect(events.Resources).To(HaveLen(1))
+		Expect(events.Resources[0].MetaData.GUID).To(Equal("a000"))
+	})
+
+	It("should handle client errors", func() {
+		fakeUsageEventsClient.GetReturns(nil, errors.New("some error"))
+
+		events, err := NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 10, 0)
+		Expect(err).To(MatchError("error fetching /v3/app_usage_events?per_page=10&order_by=created_at: some error"))
+		Expect(events).To(BeNil())
+	})
+
+	It("should return an error for non-200 response codes", func() {
+		fakeUsageEventsClient.GetReturns(&http.Response{
+			StatusCode: 500,
+			Body:       ioutil.NopCloser(strings.NewReader("some error")),
+		}, nil)
+
+		events, err := NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Get(GUIDNil, 10, 0)
+		Expect(err).To(MatchError("/v3/app_usage_events?per_page=10&order_by=created_at request failed: 500 some error"))
+		Expect(events).To(BeNil())
+	})
+
+	It("should panic when afterGUID is an empty string", func() {
+		Expect(func() { NewV3AppUsageEventsAPI(fakeUsageEventsClient, logger).Get("", 10, 0) }).To(Panic())
+	})
+})
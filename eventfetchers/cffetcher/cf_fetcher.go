@@ -22,6 +22,15 @@ const (
 	Service Kind = "service"
 )
 
+// APIVersion selects which version of the Cloud Foundry usage events API is
+// used to fetch events
+type APIVersion string
+
+const (
+	APIVersion2 APIVersion = "v2"
+	APIVersion3 APIVersion = "v3"
+)
+
 var _ eventio.EventFetcher = &CFEventFetcher{}
 
 // CFEventFetcher is an EventFetcher that fetches cloudfoundry App or Service usage events
@@ -106,6 +115,8 @@ type Config struct {
 	RecordMinAge time.Duration
 	// FetchLimit dictates the max number of events returned in each FetchEvents call
 	FetchLimit int
+	// APIVersion sets the version of the usage events API to use, defaults to APIVersion2
+	APIVersion APIVersion
 }
 
 // New creates a new CFEventFetcher for the given config
@@ -122,13 +133,19 @@ func New(cfg Config) (*CFEventFetcher, error) {
 			return nil, err
 		}
 		apiEngine := &client{cf}
-		switch cfg.Type {
-		case App:
+		switch {
+		case cfg.Type == App && (cfg.APIVersion == "" || cfg.APIVersion == APIVersion2):
 			cfg.Client = NewAppUsageEventsAPI(apiEngine, cfg.Logger)
-		case Service:
+		case cfg.Type == Service && (cfg.APIVersion == "" || cfg.APIVersion == APIVersion2):
 			cfg.Client = NewServiceUsageEventsAPI(apiEngine, cfg.Logger)
-		default:
+		case cfg.Type == App && cfg.APIVersion == APIVersion3:
+			cfg.Client = NewV3AppUsageEventsAPI(apiEngine, cfg.Logger)
+		case cfg.Type == Service && cfg.APIVersion == APIVersion3:
+			cfg.Client = NewV3ServiceUsageEventsAPI(apiEngine, cfg.Logger)
+		case cfg.Type != App && cfg.Type != Service:
 			return nil, fmt.Errorf("missing or unknown FetcherConfig.Type")
+		default:
+			return nil, fmt.Errorf("unknown FetcherConfig.APIVersion '%s'", cfg.APIVersion)
 		}
 	}
 	fetcher := &CFEventFetcher{
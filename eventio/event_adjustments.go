@@ -0,0 +1,121 @@
+package eventio
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type AdjustmentKind string
+
+const (
+	// CreditAdjustment takes a fixed amount off an org's bill on ValidFrom
+	CreditAdjustment AdjustmentKind = "credit"
+	// DebitAdjustment adds a fixed amount to an org's bill on ValidFrom
+	DebitAdjustment AdjustmentKind = "debit"
+	// DiscountAdjustment takes a percentage off all of an org's charges
+	// between ValidFrom and ValidTo
+	DiscountAdjustment AdjustmentKind = "discount"
+)
+
+type AdjustmentAction string
+
+const (
+	AdjustmentCreated AdjustmentAction = "created"
+	AdjustmentRevoked AdjustmentAction = "revoked"
+)
+
+type AdjustmentStore interface {
+	CreateAdjustment(adjustment Adjustment, actor string) (Adjustment, error)
+	RevokeAdjustment(id string, actor string) (*Adjustment, error)
+	GetAdjustments(filter AdjustmentFilter) ([]Adjustment, error)
+	GetAdjustmentAudit(id string) ([]AdjustmentAuditEntry, error)
+}
+
+type AdjustmentFilter struct {
+	OrgGUIDs       []string
+	IncludeRevoked bool
+}
+
+// Adjustment is a manual change to an org's bill. Adjustments are applied to
+// the billable events the next time they are refreshed. Adjustments that
+// apply to a consolidated month cannot be created or revoked.
+type Adjustment struct {
+	ID          string         `json:"id"`
+	OrgGUID     string         `json:"org_guid"`
+	Kind        AdjustmentKind `json:"kind"`
+	Description string         `json:"description"`
+	AmountExVAT string         `json:"amount_ex_vat,omitempty"`
+	Percentage  string         `json:"percentage,omitempty"`
+	VATCode     string         `json:"vat_code"`
+	ValidFrom   string         `json:"valid_from"`
+	ValidTo     string         `json:"valid_to,omitempty"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   string         `json:"created_at"`
+	RevokedBy   string         `json:"revoked_by,omitempty"`
+	RevokedAt   string         `json:"revoked_at,omitempty"`
+}
+
+// ConsolidatedAdjustmentError is returned when creating or revoking an
+// adjustment would change the bill of a month that has been consolidated, as
+// consolidated months are never recalculated
+type ConsolidatedAdjustmentError struct {
+	MonthStart string
+}
+
+func (e *ConsolidatedAdjustmentError) Error() string {
+	return fmt.Sprintf("the adjustment applies to the consolidated month starting %s, which cannot be changed unless the month is detached", e.MonthStart)
+}
+
+// AdjustmentAuditEntry records who created or revoked an adjustment and what
+// it looked like afterwards
+type AdjustmentAuditEntry struct {
+	AdjustmentID string           `json:"adjustment_id"`
+	Action       AdjustmentAction `json:"action"`
+	Actor        string           `json:"actor"`
+	OccurredAt   string           `json:"occurred_at"`
+	Adjustment   Adjustment       `json:"adjustment"`
+}
+
+// Validate checks that the adjustment has the fields its Kind requires
+func (a *Adjustment) Validate() error {
+	if a.OrgGUID == "" {
+		return errors.New("org_guid is required")
+	}
+	if a.Description == "" {
+		return errors.New("description is required")
+	}
+	validFrom, err := time.Parse("2006-01-02", a.ValidFrom)
+	if err != nil {
+		return fmt.Errorf("a valid valid_from date is required - expected format 2006-01-02 - got %s", a.ValidFrom)
+	}
+	switch a.Kind {
+	case CreditAdjustment, DebitAdjustment:
+		if amount, err := strconv.ParseFloat(a.AmountExVAT, 64); err != nil || amount <= 0 {
+			return fmt.Errorf("a %s requires a positive amount_ex_vat - got %s", a.Kind, a.AmountExVAT)
+		}
+		if a.Percentage != "" || a.ValidTo != "" {
+			return fmt.Errorf("a %s only applies on valid_from and cannot have a percentage or valid_to", a.Kind)
+		}
+	case DiscountAdjustment:
+		if percentage, err := strconv.ParseFloat(a.Percentage, 64); err != nil || percentage <= 0 || percentage > 100 {
+			return fmt.Errorf("a discount requires a percentage greater than 0 and at most 100 - got %s", a.Percentage)
+		}
+		if a.AmountExVAT != "" {
+			return errors.New("a discount cannot have an amount_ex_vat")
+		}
+		if a.ValidTo != "" {
+			validTo, err := time.Parse("2006-01-02", a.ValidTo)
+			if err != nil {
+				return fmt.Errorf("valid_to must be a date - expected format 2006-01-02 - got %s", a.ValidTo)
+			}
+			if !validTo.After(validFrom) {
+				return errors.New("valid_to must be after valid_from")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown adjustment kind: %s", a.Kind)
+	}
+	return nil
+}
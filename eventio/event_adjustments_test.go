@@ -0,0 +1,39 @@
+package eventio_test
+
+import (
+	. "github.com/alphagov/paas-billing/eventio"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adjustment", func() {
+	orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+
+	table.DescribeTable(
+		"Validate should accept adjustments with the fields their kind requires",
+		func(adjustment Adjustment) {
+			Expect(adjustment.Validate()).To(Succeed())
+		},
+		table.Entry("credit", Adjustment{OrgGUID: orgGUID, Kind: CreditAdjustment, Description: "outage", AmountExVAT: "10.50", ValidFrom: "2018-03-01"}),
+		table.Entry("debit", Adjustment{OrgGUID: orgGUID, Kind: DebitAdjustment, Description: "support", AmountExVAT: "100", ValidFrom: "2018-03-15"}),
+		table.Entry("open ended discount", Adjustment{OrgGUID: orgGUID, Kind: DiscountAdjustment, Description: "pilot", Percentage: "10", ValidFrom: "2018-03-01"}),
+		table.Entry("discount with end date", Adjustment{OrgGUID: orgGUID, Kind: DiscountAdjustment, Description: "pilot", Percentage: "100", ValidFrom: "2018-03-01", ValidTo: "2018-04-01"}),
+	)
+
+	table.DescribeTable(
+		"Validate should reject invalid adjustments",
+		func(adjustment Adjustment, expectedErr string) {
+			Expect(adjustment.Validate()).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		table.Entry("unknown kind", Adjustment{OrgGUID: orgGUID, Kind: "refund", Description: "x", ValidFrom: "2018-03-01"}, "unknown adjustment kind: refund"),
+		table.Entry("missing org", Adjustment{Kind: CreditAdjustment, Description: "x", AmountExVAT: "1", ValidFrom: "2018-03-01"}, "org_guid is required"),
+		table.Entry("missing description", Adjustment{OrgGUID: orgGUID, Kind: CreditAdjustment, AmountExVAT: "1", ValidFrom: "2018-03-01"}, "description is required"),
+		table.Entry("invalid valid_from", Adjustment{OrgGUID: orgGUID, Kind: CreditAdjustment, Description: "x", AmountExVAT: "1", ValidFrom: "March"}, "valid_from"),
+		table.Entry("negative credit", Adjustment{OrgGUID: orgGUID, Kind: CreditAdjustment, Description: "x", AmountExVAT: "-1", ValidFrom: "2018-03-01"}, "positive amount_ex_vat"),
+		table.Entry("credit with period", Adjustment{OrgGUID: orgGUID, Kind: CreditAdjustment, Description: "x", AmountExVAT: "1", ValidFrom: "2018-03-01", ValidTo: "2018-04-01"}, "cannot have a percentage or valid_to"),
+		table.Entry("discount over 100%", Adjustment{OrgGUID: orgGUID, Kind: DiscountAdjustment, Description: "x", Percentage: "150", ValidFrom: "2018-03-01"}, "at most 100"),
+		table.Entry("discount with amount", Adjustment{OrgGUID: orgGUID, Kind: DiscountAdjustment, Description: "x", Percentage: "10", AmountExVAT: "1", ValidFrom: "2018-03-01"}, "cannot have an amount_ex_vat"),
+		table.Entry("discount ending before it starts", Adjustment{OrgGUID: orgGUID, Kind: DiscountAdjustment, Description: "x", Percentage: "10", ValidFrom: "2018-03-01", ValidTo: "2018-03-01"}, "valid_to must be after valid_from"),
+	)
+})
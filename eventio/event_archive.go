@@ -0,0 +1,80 @@
+package eventio
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RawEventArchiver moves raw usage events out of the database into archives
+// kept elsewhere and puts them back again
+type RawEventArchiver interface {
+	// ArchiveRawEvents passes the raw events of kind created before the
+	// given time to export, then removes them from the database and records
+	// an archive of them under the key returned by export. The latest event
+	// of each resource is kept so that events can still be generated for
+	// resources that were running at that time. Nothing is removed if export
+	// fails, and nil is returned if there is nothing to archive.
+	ArchiveRawEvents(ctx context.Context, kind string, before time.Time, export RawEventExporter) (*RawEventArchive, error)
+	// RestoreRawEvents puts archived raw events back into the database,
+	// ignoring any that are already there, and returns how many were added
+	RestoreRawEvents(ctx context.Context, events []ArchivedRawEvent) (int, error)
+	// MarkRawEventArchiveRestored records that all of the events of an
+	// archive are back in the database, so its months are no longer treated
+	// as archived and its events are not archived again
+	MarkRawEventArchiveRestored(ctx context.Context, id int64) error
+	GetRawEventArchives(filter RawEventArchiveFilter) ([]RawEventArchive, error)
+}
+
+// ArchivedRangeError is returned by reports calculated from the live billable
+// events when they are asked for a range that starts before the first month
+// whose raw events are all still in the database
+type ArchivedRangeError struct {
+	RangeStart      string
+	UnarchivedSince string
+}
+
+func (e *ArchivedRangeError) Error() string {
+	return fmt.Sprintf("the raw events before %s have been archived so the range cannot start at %s", e.UnarchivedSince, e.RangeStart)
+}
+
+// RawEventExporter stores the events it is given somewhere and returns the
+// key they can be found under
+type RawEventExporter func(events ArchivedRawEventRows) (string, error)
+
+type ArchivedRawEventRows interface {
+	Next() bool
+	Err() error
+	Event() (*ArchivedRawEvent, error)
+}
+
+// ArchivedRawEvent is a RawEvent with the position it was stored in, which is
+// kept so that restored events are read back in the same order
+type ArchivedRawEvent struct {
+	Sequence int64 `json:"sequence"`
+	RawEvent
+}
+
+// RawEventArchive is the summary of a set of raw events that have been
+// archived. FirstCreatedAt and LastCreatedAt are the times the oldest and
+// newest of the events were created. RestoredAt is set once all of the
+// events have been restored.
+type RawEventArchive struct {
+	ID             int64      `json:"id"`
+	Kind           string     `json:"kind"`
+	Key            string     `json:"key"`
+	EventCount     int64      `json:"event_count"`
+	FirstCreatedAt time.Time  `json:"first_created_at"`
+	LastCreatedAt  time.Time  `json:"last_created_at"`
+	ArchivedAt     time.Time  `json:"archived_at"`
+	RestoredAt     *time.Time `json:"restored_at"`
+}
+
+// RawEventArchiveFilter selects the archives of a kind holding events
+// created at or after Since and before Before, either of which are ignored
+// if they are the zero time
+type RawEventArchiveFilter struct {
+	Kind   string
+	Since  time.Time
+	Before time.Time
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type BillableEventReader interface {
@@ -19,13 +20,20 @@ type ConsolidatedBillableEventReader interface {
 
 type BillableEventConsolidator interface {
 	ConsolidateAll() error
+	ConsolidateAllContext(ctx context.Context) error
 	ConsolidateFullMonths(startAt string, endAt string) error
 	Consolidate(filter EventFilter) error
+	ConsolidateContext(ctx context.Context, filter EventFilter) error
+	// DetachConsolidatedMonths removes the consolidated billable events of
+	// the months ending on or before the given time from the store's
+	// partitions and returns the names of the tables they were kept in
+	DetachConsolidatedMonths(ctx context.Context, before time.Time) ([]string, error)
 }
 
 type BillableEventForecaster interface {
 	ForecastBillableEventRows(ctx context.Context, events []UsageEvent, filter EventFilter) (BillableEventRows, error)
 	ForecastBillableEvents(events []UsageEvent, filter EventFilter) ([]BillableEvent, error)
+	ForecastBillableEventDiffs(ctx context.Context, adjustments []ResourceAdjustment, filter EventFilter) ([]ForecastDiff, error)
 }
 
 type BillableEventRows interface {
@@ -46,6 +54,22 @@ type PriceComponent struct {
 	CurrencyCode string `json:"currency_code"`
 	IncVAT       string `json:"inc_vat"`
 	ExVAT        string `json:"ex_vat"`
+	// Formula, Inputs, SubstitutedFormula, CurrencyRate and PlanValidFrom
+	// explain how the price was derived. They are only set when asked for.
+	Formula            string         `json:"formula,omitempty"`
+	Inputs             *FormulaInputs `json:"inputs,omitempty"`
+	SubstitutedFormula string         `json:"substituted_formula,omitempty"`
+	CurrencyRate       string         `json:"currency_rate,omitempty"`
+	PlanValidFrom      string         `json:"plan_valid_from,omitempty"`
+}
+
+// FormulaInputs are the values of the variables of a pricing plan
+// component's formula used to price an event
+type FormulaInputs struct {
+	MemoryInMB    int64   `json:"memory_in_mb"`
+	StorageInMB   int64   `json:"storage_in_mb"`
+	NumberOfNodes int64   `json:"number_of_nodes"`
+	TimeInSeconds float64 `json:"time_in_seconds"`
 }
 
 type Price struct {
@@ -55,23 +79,49 @@ type Price struct {
 }
 
 type BillableEvent struct {
-	EventGUID           string `json:"event_guid"`
-	EventStart          string `json:"event_start"`
-	EventStop           string `json:"event_stop"`
-	ResourceGUID        string `json:"resource_guid"`
-	ResourceName        string `json:"resource_name"`
-	ResourceType        string `json:"resource_type"`
-	OrgGUID             string `json:"org_guid"`
-	OrgName             string `json:"org_name"`
-	SpaceGUID           string `json:"space_guid"`
-	SpaceName           string `json:"space_name"`
-	PlanGUID            string `json:"plan_guid"`
-	PlanName            string `json:"plan_name"`
-	QuotaDefinitionGUID string `json:"quota_definition_guid"`
-	NumberOfNodes       int64  `json:"number_of_nodes"`
-	MemoryInMB          int64  `json:"memory_in_mb"`
-	StorageInMB         int64  `json:"storage_in_mb"`
-	Price               Price  `json:"price"`
+	EventGUID           string            `json:"event_guid"`
+	EventStart          string            `json:"event_start"`
+	EventStop           string            `json:"event_stop"`
+	ResourceGUID        string            `json:"resource_guid"`
+	ResourceName        string            `json:"resource_name"`
+	ResourceType        string            `json:"resource_type"`
+	OrgGUID             string            `json:"org_guid"`
+	OrgName             string            `json:"org_name"`
+	SpaceGUID           string            `json:"space_guid"`
+	SpaceName           string            `json:"space_name"`
+	PlanGUID            string            `json:"plan_guid"`
+	PlanName            string            `json:"plan_name"`
+	QuotaDefinitionGUID string            `json:"quota_definition_guid"`
+	NumberOfNodes       int64             `json:"number_of_nodes"`
+	MemoryInMB          int64             `json:"memory_in_mb"`
+	StorageInMB         int64             `json:"storage_in_mb"`
+	Labels              map[string]string `json:"labels"`
+	Price               Price             `json:"price"`
+	Allocations         []AllocatedCharge `json:"allocations"`
+	QuotaDefinition     *QuotaDefinition  `json:"quota_definition"`
+}
+
+// QuotaDefinition is the quota tier of a BillableEvent's org, as it was
+// defined when the event ended. It is for reporting only: events are not
+// split when an org's quota changes and prices do not depend on it.
+type QuotaDefinition struct {
+	GUID                    string `json:"guid"`
+	Name                    string `json:"name"`
+	MemoryLimit             int64  `json:"memory_limit"`
+	InstanceMemoryLimit     int64  `json:"instance_memory_limit"`
+	TotalServices           int64  `json:"total_services"`
+	TotalRoutes             int64  `json:"total_routes"`
+	AppInstanceLimit        int64  `json:"app_instance_limit"`
+	NonBasicServicesAllowed bool   `json:"non_basic_services_allowed"`
+}
+
+// AllocatedCharge is the part of a BillableEvent's price recharged to another
+// org or space by a CostAllocationRule
+type AllocatedCharge struct {
+	OrgGUID   string `json:"org_guid"`
+	SpaceGUID string `json:"space_guid,omitempty"`
+	IncVAT    string `json:"inc_vat"`
+	ExVAT     string `json:"ex_vat"`
 }
 
 func (e *BillableEvent) Scan(src interface{}) error {
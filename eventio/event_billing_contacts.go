@@ -0,0 +1,43 @@
+package eventio
+
+import (
+	"fmt"
+	"time"
+)
+
+type OrgRole string
+
+const (
+	BillingManagerRole OrgRole = "billing_manager"
+	OrgManagerRole     OrgRole = "org_manager"
+)
+
+type BillingContactReader interface {
+	GetBillingContacts(filter BillingContactFilter) ([]OrgBillingContacts, error)
+}
+
+// BillingContactFilter selects the billing contacts of the given orgs (or
+// all orgs if OrgGUIDs is empty) as they were at the end of Date
+type BillingContactFilter struct {
+	Date     string
+	OrgGUIDs []string
+}
+
+func (filter *BillingContactFilter) Validate() error {
+	if _, err := time.Parse("2006-01-02", filter.Date); err != nil {
+		return fmt.Errorf("a valid date filter value is required - expected format 2006-01-02 - got %s", filter.Date)
+	}
+	return nil
+}
+
+type BillingContact struct {
+	UserGUID string  `json:"user_guid"`
+	Username string  `json:"username"`
+	Role     OrgRole `json:"role"`
+}
+
+type OrgBillingContacts struct {
+	OrgGUID  string           `json:"org_guid"`
+	OrgName  string           `json:"org_name"`
+	Contacts []BillingContact `json:"contacts"`
+}
@@ -28,3 +28,28 @@ type CurrencyRate struct {
 	ValidFrom string  `json:"valid_from"`
 	Rate      float64 `json:"rate"`
 }
+
+// CostAllocationRule recharges the cost of a resource to other orgs or
+// spaces from ValidFrom until the next rule for the same resource. A rule
+// without any allocations stops allocating the resource's cost.
+type CostAllocationRule struct {
+	ResourceGUID string           `json:"resource_guid"`
+	ValidFrom    string           `json:"valid_from"`
+	Allocations  []CostAllocation `json:"allocations"`
+}
+
+// CostAllocation is the share of a resource's cost charged to an org, or to
+// a space within it. The shares of a rule must add up to 1.
+type CostAllocation struct {
+	OrgGUID   string  `json:"org_guid"`
+	SpaceGUID string  `json:"space_guid,omitempty"`
+	Share     float64 `json:"share"`
+}
+
+// ServiceCategory groups pricing plans in the usage and adoption report. A
+// plan belongs to the first category whose PlanNamePattern (a regular
+// expression) matches its name.
+type ServiceCategory struct {
+	Name            string `json:"name"`
+	PlanNamePattern string `json:"plan_name_pattern"`
+}
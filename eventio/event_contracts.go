@@ -0,0 +1,36 @@
+package eventio
+
+// Contract is an org's agreement to pay at least MinimumMonthlyFee each
+// month and/or to prepay CommittedAmount, which is drawn down by the org's
+// charges until it is used up. A contract applies from ValidFrom until the
+// next contract for the same org. All amounts exclude VAT.
+type Contract struct {
+	OrgGUID           string  `json:"org_guid"`
+	ValidFrom         string  `json:"valid_from"`
+	MinimumMonthlyFee float64 `json:"minimum_monthly_fee"`
+	CommittedAmount   float64 `json:"committed_amount"`
+}
+
+type ContractStatementReader interface {
+	GetContractStatements(filter EventFilter) ([]ContractStatement, error)
+}
+
+// ContractStatement compares an org's actual charges for a month with its
+// contract. ShortfallExVAT is the amount needed to reach the minimum fee,
+// DrawdownExVAT is the part of the charges paid from the commitment and
+// OverageExVAT is the part that was not. AmountDueExVAT is what is left to
+// invoice for the month.
+type ContractStatement struct {
+	OrgGUID                  string `json:"org_guid"`
+	OrgName                  string `json:"org_name"`
+	Month                    string `json:"month"`
+	ContractValidFrom        string `json:"contract_valid_from"`
+	ActualExVAT              string `json:"actual_ex_vat"`
+	MinimumFeeExVAT          string `json:"minimum_fee_ex_vat"`
+	ShortfallExVAT           string `json:"shortfall_ex_vat"`
+	CommittedExVAT           string `json:"committed_ex_vat"`
+	DrawdownExVAT            string `json:"drawdown_ex_vat"`
+	RemainingCommitmentExVAT string `json:"remaining_commitment_ex_vat"`
+	OverageExVAT             string `json:"overage_ex_vat"`
+	AmountDueExVAT           string `json:"amount_due_ex_vat"`
+}
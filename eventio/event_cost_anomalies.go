@@ -0,0 +1,54 @@
+package eventio
+
+import "fmt"
+
+// CostAnomalyScope is what a cost anomaly was detected for
+type CostAnomalyScope string
+
+const (
+	// OrgAnomalyScope is the total daily cost of an org
+	OrgAnomalyScope CostAnomalyScope = "org"
+	// ServiceAnomalyScope is the daily cost of a plan within an org
+	ServiceAnomalyScope CostAnomalyScope = "service"
+	// ResourceAnomalyScope is the daily cost of a single resource
+	ResourceAnomalyScope CostAnomalyScope = "resource"
+)
+
+type CostAnomalyReader interface {
+	GetCostAnomalies(filter CostAnomalyFilter) ([]CostAnomaly, error)
+}
+
+// CostAnomalyFilter selects the anomalies found on the days of the range,
+// optionally only those of a single Scope
+type CostAnomalyFilter struct {
+	EventFilter
+	Scope CostAnomalyScope
+}
+
+func (filter *CostAnomalyFilter) Validate() error {
+	if err := filter.EventFilter.Validate(); err != nil {
+		return err
+	}
+	switch filter.Scope {
+	case "", OrgAnomalyScope, ServiceAnomalyScope, ResourceAnomalyScope:
+		return nil
+	default:
+		return fmt.Errorf("unknown anomaly scope %s - expected org, service or resource", filter.Scope)
+	}
+}
+
+// CostAnomaly is a day on which the cost of an org, service or resource was
+// well above its trailing baseline. SubjectGUID and SubjectName are those of
+// the org, plan or resource depending on the Scope.
+type CostAnomaly struct {
+	Day                 string           `json:"day"`
+	Scope               CostAnomalyScope `json:"scope"`
+	OrgGUID             string           `json:"org_guid"`
+	OrgName             string           `json:"org_name"`
+	SubjectGUID         string           `json:"subject_guid"`
+	SubjectName         string           `json:"subject_name"`
+	CostExVAT           string           `json:"cost_ex_vat"`
+	BaselineMeanExVAT   string           `json:"baseline_mean_ex_vat"`
+	BaselineStddevExVAT string           `json:"baseline_stddev_ex_vat"`
+	DetectedAt          string           `json:"detected_at"`
+}
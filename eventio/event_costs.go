@@ -1,10 +1,18 @@
 package eventio
 
 type TotalCostReader interface {
-	GetTotalCost() ([]TotalCost, error)
+	GetTotalCost(filter TotalCostFilter) ([]TotalCost, error)
+}
+
+// TotalCostFilter restricts the total costs to events with the given label
+// values and optionally splits each plan's total by the value of a label
+type TotalCostFilter struct {
+	Labels       []LabelFilter
+	GroupByLabel string
 }
 
 type TotalCost struct {
-	PlanGUID string  `json:"plan_guid"`
-	Cost     float32 `json:"cost"`
+	PlanGUID   string  `json:"plan_guid"`
+	LabelValue string  `json:"label_value,omitempty"`
+	Cost       float32 `json:"cost"`
 }
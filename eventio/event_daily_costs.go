@@ -0,0 +1,70 @@
+package eventio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DailyCostGrouping is a dimension that daily costs can be split by
+type DailyCostGrouping string
+
+const (
+	GroupByOrg       DailyCostGrouping = "org"
+	GroupBySpace     DailyCostGrouping = "space"
+	GroupByPlan      DailyCostGrouping = "plan"
+	GroupByComponent DailyCostGrouping = "component"
+)
+
+type DailyCostReader interface {
+	GetDailyCosts(filter DailyCostFilter) ([]DailyCost, error)
+}
+
+// DailyCostFilter selects the days and orgs of the daily costs. Without any
+// GroupBy there is a single total for each day.
+type DailyCostFilter struct {
+	EventFilter
+	GroupBy []DailyCostGrouping
+}
+
+// ParseDailyCostGroupings parses a comma separated list of groupings
+func ParseDailyCostGroupings(s string) ([]DailyCostGrouping, error) {
+	groupings := []DailyCostGrouping{}
+	if s == "" {
+		return groupings, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		grouping := DailyCostGrouping(strings.TrimSpace(name))
+		switch grouping {
+		case GroupByOrg, GroupBySpace, GroupByPlan, GroupByComponent:
+			groupings = append(groupings, grouping)
+		default:
+			return nil, fmt.Errorf("cannot group by %s - expected org, space, plan or component", name)
+		}
+	}
+	return groupings, nil
+}
+
+// HasGrouping returns true if the costs should be split by g
+func (filter *DailyCostFilter) HasGrouping(g DailyCostGrouping) bool {
+	for _, grouping := range filter.GroupBy {
+		if grouping == g {
+			return true
+		}
+	}
+	return false
+}
+
+// DailyCost is the cost of a UTC day. Only the fields of the requested
+// groupings are set.
+type DailyCost struct {
+	Day           string `json:"day"`
+	OrgGUID       string `json:"org_guid,omitempty"`
+	OrgName       string `json:"org_name,omitempty"`
+	SpaceGUID     string `json:"space_guid,omitempty"`
+	SpaceName     string `json:"space_name,omitempty"`
+	PlanGUID      string `json:"plan_guid,omitempty"`
+	PlanName      string `json:"plan_name,omitempty"`
+	ComponentName string `json:"component_name,omitempty"`
+	CostExVAT     string `json:"cost_ex_vat"`
+	CostIncVAT    string `json:"cost_inc_vat"`
+}
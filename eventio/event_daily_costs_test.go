@@ -0,0 +1,33 @@
+package eventio_test
+
+import (
+	. "github.com/alphagov/paas-billing/eventio"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DailyCostFilter", func() {
+	table.DescribeTable(
+		"ParseDailyCostGroupings should parse comma separated groupings",
+		func(s string, expected []DailyCostGrouping) {
+			groupings, err := ParseDailyCostGroupings(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groupings).To(Equal(expected))
+		},
+		table.Entry("nothing", "", []DailyCostGrouping{}),
+		table.Entry("a single grouping", "org", []DailyCostGrouping{GroupByOrg}),
+		table.Entry("several groupings", "space, plan,component", []DailyCostGrouping{GroupBySpace, GroupByPlan, GroupByComponent}),
+	)
+
+	It("should reject unknown groupings", func() {
+		_, err := ParseDailyCostGroupings("org,resource")
+		Expect(err).To(MatchError(ContainSubstring("cannot group by resource")))
+	})
+
+	It("should know which groupings were requested", func() {
+		filter := DailyCostFilter{GroupBy: []DailyCostGrouping{GroupByPlan}}
+		Expect(filter.HasGrouping(GroupByPlan)).To(BeTrue())
+		Expect(filter.HasGrouping(GroupByOrg)).To(BeFalse())
+	})
+})
@@ -6,3 +6,13 @@ type EventFetcher interface {
 	FetchEvents(ctx context.Context, lastKnownEvent *RawEvent) ([]RawEvent, error)
 	Kind() string
 }
+
+// PermanentFetchError is returned by an EventFetcher when retrying soon will
+// not help, for example because the request was rejected
+type PermanentFetchError struct {
+	Err error
+}
+
+func (e *PermanentFetchError) Error() string {
+	return e.Err.Error()
+}
@@ -2,6 +2,7 @@ package eventio
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -9,6 +10,30 @@ type EventFilter struct {
 	RangeStart string
 	RangeStop  string
 	OrgGUIDs   []string
+	// Labels restricts the events to those with all of the given label
+	// values. Only the configured label keys are carried by events.
+	Labels []LabelFilter
+	// GroupByLabel splits aggregated results by the value of the given
+	// label key. It is ignored when listing individual events.
+	GroupByLabel string
+	// Explain adds how each price was derived to the details of listed
+	// billable events. It is ignored by everything else.
+	Explain bool
+}
+
+// LabelFilter matches events where the label Key has the value Value
+type LabelFilter struct {
+	Key   string
+	Value string
+}
+
+// ParseLabelFilter parses a label filter in the form key:value
+func ParseLabelFilter(s string) (LabelFilter, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return LabelFilter{}, fmt.Errorf("label filter must be in the form key:value - got %s", s)
+	}
+	return LabelFilter{Key: parts[0], Value: parts[1]}, nil
 }
 
 func (filter *EventFilter) SplitByMonth() ([]EventFilter, error) {
@@ -36,9 +61,12 @@ func (filter *EventFilter) recursiveSplitByMonth(t1, t2 time.Time) []EventFilter
 		return append(
 			[]EventFilter{
 				{
-					RangeStart: t1.Format(dateFormat),
-					RangeStop:  minDate(t2, next).Format(dateFormat),
-					OrgGUIDs:   filter.OrgGUIDs,
+					RangeStart:   t1.Format(dateFormat),
+					RangeStop:    minDate(t2, next).Format(dateFormat),
+					OrgGUIDs:     filter.OrgGUIDs,
+					Labels:       filter.Labels,
+					GroupByLabel: filter.GroupByLabel,
+					Explain:      filter.Explain,
 				},
 			},
 			filter.recursiveSplitByMonth(next, t2)...,
@@ -69,9 +97,11 @@ func (filter *EventFilter) TruncateMonth() (EventFilter, error) {
 	}
 
 	return EventFilter{
-		RangeStart: truncateMonth(start).Format("2006-01-02"),
-		RangeStop:  truncateMonth(stop).Format("2006-01-02"),
-		OrgGUIDs:   filter.OrgGUIDs,
+		RangeStart:   truncateMonth(start).Format("2006-01-02"),
+		RangeStop:    truncateMonth(stop).Format("2006-01-02"),
+		OrgGUIDs:     filter.OrgGUIDs,
+		Labels:       filter.Labels,
+		GroupByLabel: filter.GroupByLabel,
 	}, nil
 }
 
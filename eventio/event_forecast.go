@@ -0,0 +1,50 @@
+package eventio
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResourceAdjustment describes a hypothetical change to an existing resource
+// (for example scaling an app or switching a service to another plan) that
+// takes effect from the given point in time
+type ResourceAdjustment struct {
+	ResourceGUID  string `json:"resource_guid"`
+	From          string `json:"from"`
+	PlanGUID      string `json:"plan_guid,omitempty"`
+	NumberOfNodes *int64 `json:"number_of_nodes,omitempty"`
+	MemoryInMB    *int64 `json:"memory_in_mb,omitempty"`
+	StorageInMB   *int64 `json:"storage_in_mb,omitempty"`
+}
+
+func (a *ResourceAdjustment) Validate() error {
+	if a.ResourceGUID == "" {
+		return fmt.Errorf("adjustments must have a resource_guid")
+	}
+	if _, err := time.Parse(time.RFC3339, a.From); err != nil {
+		if err := validateDateString("from", a.From); err != nil {
+			return fmt.Errorf("adjustments must have a valid from date - expected format 2006-01-02 or RFC3339 - got %s", a.From)
+		}
+	}
+	if a.PlanGUID == "" && a.NumberOfNodes == nil && a.MemoryInMB == nil && a.StorageInMB == nil {
+		return fmt.Errorf("adjustment for resource '%s' does not change anything", a.ResourceGUID)
+	}
+	return nil
+}
+
+// ForecastDiff compares the actual cost of a resource with the forecasted
+// cost after applying a set of ResourceAdjustments
+type ForecastDiff struct {
+	ResourceGUID     string `json:"resource_guid"`
+	ResourceName     string `json:"resource_name"`
+	ResourceType     string `json:"resource_type"`
+	OrgGUID          string `json:"org_guid"`
+	SpaceGUID        string `json:"space_guid"`
+	SpaceName        string `json:"space_name"`
+	ActualExVAT      string `json:"actual_ex_vat"`
+	ActualIncVAT     string `json:"actual_inc_vat"`
+	ForecastExVAT    string `json:"forecast_ex_vat"`
+	ForecastIncVAT   string `json:"forecast_inc_vat"`
+	DifferenceExVAT  string `json:"difference_ex_vat"`
+	DifferenceIncVAT string `json:"difference_inc_vat"`
+}
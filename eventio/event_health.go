@@ -0,0 +1,8 @@
+package eventio
+
+import "time"
+
+type StoreHealthChecker interface {
+	Ping() error
+	GetLastRefresh() (time.Time, error)
+}
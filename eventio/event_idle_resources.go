@@ -0,0 +1,46 @@
+package eventio
+
+// ResourceWarning is a sign that a resource may be costing money without
+// being used
+type ResourceWarning string
+
+const (
+	// StagingOnlyWarning is given to apps that were only charged for staging
+	// this month, so they were staged but never ran
+	StagingOnlyWarning ResourceWarning = "staging_only"
+	// StoppedOrgWarning is given to running services in orgs that have no
+	// running apps
+	StoppedOrgWarning ResourceWarning = "org_has_no_running_apps"
+)
+
+type IdleResourceReader interface {
+	GetIdleResources(filter IdleResourceFilter) ([]IdleResource, error)
+}
+
+// IdleResourceFilter selects the resources of OrgGUIDs (or every org) that
+// were first seen at least MinAgeDays ago
+type IdleResourceFilter struct {
+	OrgGUIDs   []string
+	MinAgeDays int
+}
+
+// IdleResource is a resource that has cost money this month, with what it
+// has cost so far and how long it has been around. Running is true if the
+// resource was still running at the last refresh of the billable events.
+type IdleResource struct {
+	ResourceGUID     string            `json:"resource_guid"`
+	ResourceName     string            `json:"resource_name"`
+	ResourceType     string            `json:"resource_type"`
+	OrgGUID          string            `json:"org_guid"`
+	OrgName          string            `json:"org_name"`
+	SpaceGUID        string            `json:"space_guid"`
+	SpaceName        string            `json:"space_name"`
+	PlanGUID         string            `json:"plan_guid"`
+	PlanName         string            `json:"plan_name"`
+	Running          bool              `json:"running"`
+	FirstSeen        string            `json:"first_seen"`
+	AgeDays          int               `json:"age_days"`
+	LastStateChange  string            `json:"last_state_change"`
+	MonthToDateExVAT string            `json:"month_to_date_ex_vat"`
+	Warnings         []ResourceWarning `json:"warnings"`
+}
@@ -0,0 +1,116 @@
+package eventio
+
+import (
+	"fmt"
+	"time"
+)
+
+type JobKind string
+
+const (
+	// RefreshJob regenerates the billable events and optionally consolidates
+	// any months that are due afterwards
+	RefreshJob JobKind = "refresh"
+	// ConsolidateJob consolidates a single month or every month that is due
+	ConsolidateJob JobKind = "consolidate"
+	// BackfillJob re-fetches usage events of a given kind
+	BackfillJob JobKind = "backfill"
+	// ArchiveJob archives the raw events older than the retention window
+	ArchiveJob JobKind = "archive"
+)
+
+// ConsolidationDelayDays is how many days after the end of a month it can be
+// consolidated, which leaves time for late usage events to be collected
+const ConsolidationDelayDays = 5
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+type JobQueue interface {
+	EnqueueJob(job Job) (Job, error)
+	ClaimNextJob() (*Job, error)
+	FinishJob(id int64, state JobState, errorMessage string) error
+	FailRunningJobs(errorMessage string) error
+	CancelJob(id int64) (*Job, error)
+	GetJob(id int64) (*Job, error)
+	GetJobs(filter JobFilter) ([]Job, error)
+}
+
+type JobFilter struct {
+	Kind  JobKind
+	State JobState
+	Limit int
+}
+
+// JobParams are the arguments of a Job, which are used depends on the Kind
+type JobParams struct {
+	// Consolidate makes a RefreshJob consolidate every month that is due
+	// after the refresh has succeeded
+	Consolidate bool `json:"consolidate,omitempty"`
+	// Month is the first day of the month for a ConsolidateJob to
+	// consolidate, all months that are due are consolidated if empty
+	Month string `json:"month,omitempty"`
+	// EventKind is the kind of usage events for a BackfillJob to fetch
+	EventKind string `json:"event_kind,omitempty"`
+	// AfterGUID is the event GUID for a BackfillJob to start after
+	AfterGUID string `json:"after_guid,omitempty"`
+	// From is the date for a BackfillJob to start from if AfterGUID is empty
+	From string `json:"from,omitempty"`
+}
+
+type Job struct {
+	ID              int64      `json:"id"`
+	Kind            JobKind    `json:"kind"`
+	Params          JobParams  `json:"params"`
+	State           JobState   `json:"state"`
+	Error           string     `json:"error,omitempty"`
+	RequestedBy     string     `json:"requested_by"`
+	CancelRequested bool       `json:"cancel_requested"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+// Validate checks that the job has the params its Kind requires
+func (job *Job) Validate() error {
+	switch job.Kind {
+	case RefreshJob, ArchiveJob:
+		return nil
+	case ConsolidateJob:
+		if job.Params.Month == "" {
+			return nil
+		}
+		month, err := time.Parse("2006-01-02", job.Params.Month)
+		if err != nil {
+			return fmt.Errorf("month must be a date: %s", err)
+		}
+		if month.Day() != 1 {
+			return fmt.Errorf("month must be the first day of a month")
+		}
+		if month.AddDate(0, 1, ConsolidationDelayDays).After(time.Now()) {
+			return fmt.Errorf("month cannot be consolidated until %d days after it has ended", ConsolidationDelayDays)
+		}
+		return nil
+	case BackfillJob:
+		if job.Params.EventKind != "app" && job.Params.EventKind != "service" {
+			return fmt.Errorf("event_kind must be one of [app | service]")
+		}
+		if (job.Params.AfterGUID == "") == (job.Params.From == "") {
+			return fmt.Errorf("exactly one of after_guid or from must be given")
+		}
+		if job.Params.From != "" {
+			if _, err := time.Parse("2006-01-02", job.Params.From); err != nil {
+				return fmt.Errorf("from must be a date: %s", err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown job kind '%s'", job.Kind)
+}
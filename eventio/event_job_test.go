@@ -0,0 +1,40 @@
+package eventio_test
+
+import (
+	"time"
+
+	. "github.com/alphagov/paas-billing/eventio"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Job", func() {
+	table.DescribeTable(
+		"Validate should accept jobs with the params their kind requires",
+		func(job Job) {
+			Expect(job.Validate()).To(Succeed())
+		},
+		table.Entry("refresh", Job{Kind: RefreshJob}),
+		table.Entry("refresh and consolidate", Job{Kind: RefreshJob, Params: JobParams{Consolidate: true}}),
+		table.Entry("consolidate all", Job{Kind: ConsolidateJob}),
+		table.Entry("consolidate month", Job{Kind: ConsolidateJob, Params: JobParams{Month: "2018-03-01"}}),
+		table.Entry("backfill after guid", Job{Kind: BackfillJob, Params: JobParams{EventKind: "app", AfterGUID: "c85e98f0-6d1b-4f45-9368-ea58263165a0"}}),
+		table.Entry("backfill from date", Job{Kind: BackfillJob, Params: JobParams{EventKind: "service", From: "2018-03-01"}}),
+	)
+
+	table.DescribeTable(
+		"Validate should reject invalid jobs",
+		func(job Job, expectedErr string) {
+			Expect(job.Validate()).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		table.Entry("unknown kind", Job{Kind: "explode"}, "unknown job kind 'explode'"),
+		table.Entry("consolidate mid month", Job{Kind: ConsolidateJob, Params: JobParams{Month: "2018-03-02"}}, "first day of a month"),
+		table.Entry("consolidate current month", Job{Kind: ConsolidateJob, Params: JobParams{Month: time.Now().Format("2006-01") + "-01"}}, "cannot be consolidated until 5 days after it has ended"),
+		table.Entry("consolidate future month", Job{Kind: ConsolidateJob, Params: JobParams{Month: "9001-01-01"}}, "cannot be consolidated until 5 days after it has ended"),
+		table.Entry("consolidate invalid month", Job{Kind: ConsolidateJob, Params: JobParams{Month: "March"}}, "month must be a date"),
+		table.Entry("backfill unknown event kind", Job{Kind: BackfillJob, Params: JobParams{EventKind: "compose", From: "2018-03-01"}}, "event_kind must be one of"),
+		table.Entry("backfill without start", Job{Kind: BackfillJob, Params: JobParams{EventKind: "app"}}, "exactly one of after_guid or from"),
+		table.Entry("backfill invalid date", Job{Kind: BackfillJob, Params: JobParams{EventKind: "app", From: "March"}}, "from must be a date"),
+	)
+})
@@ -0,0 +1,25 @@
+package eventio
+
+type CostProjectionReader interface {
+	GetCostProjections(filter EventFilter) ([]CostProjection, error)
+}
+
+// CostProjection is the actual cost of a plan within a space so far this
+// month plus the projected cost of any resources still running at the end of
+// the month at current pricing
+type CostProjection struct {
+	OrgGUID                  string `json:"org_guid"`
+	OrgName                  string `json:"org_name"`
+	SpaceGUID                string `json:"space_guid"`
+	SpaceName                string `json:"space_name"`
+	LabelValue               string `json:"label_value,omitempty"`
+	PlanGUID                 string `json:"plan_guid"`
+	PlanName                 string `json:"plan_name"`
+	ProjectedUntil           string `json:"projected_until"`
+	ActualExVAT              string `json:"actual_ex_vat"`
+	ActualIncVAT             string `json:"actual_inc_vat"`
+	ProjectedRemainderExVAT  string `json:"projected_remainder_ex_vat"`
+	ProjectedRemainderIncVAT string `json:"projected_remainder_inc_vat"`
+	ProjectedTotalExVAT      string `json:"projected_total_ex_vat"`
+	ProjectedTotalIncVAT     string `json:"projected_total_inc_vat"`
+}
@@ -10,6 +10,35 @@ type RawEventFilter struct {
 	Reverse bool
 	Limit   int
 	Kind    string
+	// Before restricts the results to events created before the given time
+	// when it is not the zero time
+	Before time.Time
+	// Since restricts the results to events created at or after the given
+	// time when it is not the zero time
+	Since time.Time
+	// ResourceGUID restricts the results to the events of an app (including
+	// its staging and tasks), service instance or Compose deployment
+	ResourceGUID string
+	// OrgGUID restricts the results to the events of resources in an org
+	OrgGUID string
+	// State restricts the results to events with the given state, or the
+	// given event type for Compose audit events
+	State string
+	// AfterGUID continues a listing from the event with the given GUID,
+	// returning the events stored after it in the order of the listing. An
+	// UnknownAfterGUIDError is returned if there is no such event.
+	AfterGUID string
+}
+
+// UnknownAfterGUIDError is returned when a listing of raw events is asked to
+// continue after an event that is not in the database
+type UnknownAfterGUIDError struct {
+	Kind string
+	GUID string
+}
+
+func (e *UnknownAfterGUIDError) Error() string {
+	return fmt.Sprintf("there is no %s event with guid %s to continue after", e.Kind, e.GUID)
 }
 
 type RawEvent struct {
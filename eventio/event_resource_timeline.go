@@ -0,0 +1,72 @@
+package eventio
+
+type ResourceTimelineReader interface {
+	GetResourceTimeline(resourceGUID string) (*ResourceTimeline, error)
+}
+
+// ResourceTimeline is everything that went into the charges for a resource
+// over its lifetime: the raw usage events collected from Cloud Foundry (and
+// Compose), the UsageEvents normalised from them and the priced components of
+// those events. OrgGUIDs are the orgs the resource has belonged to and
+// AllocatedOrgGUIDs are the other orgs that cost allocations have charged
+// part of its cost to.
+type ResourceTimeline struct {
+	ResourceGUID      string              `json:"resource_guid"`
+	OrgGUIDs          []string            `json:"org_guids"`
+	AllocatedOrgGUIDs []string            `json:"allocated_org_guids"`
+	RawEvents         []RawEvent          `json:"raw_events"`
+	Events            []UsageEvent        `json:"events"`
+	Components        []BillableComponent `json:"components"`
+}
+
+// ForAllocatedOrgs returns a copy of the timeline with only the components
+// charged to the given orgs by cost allocations. The raw events and
+// UsageEvents belong to the orgs of the resource so they are left out.
+func (t *ResourceTimeline) ForAllocatedOrgs(orgGUIDs []string) *ResourceTimeline {
+	allowed := map[string]bool{}
+	for _, orgGUID := range orgGUIDs {
+		allowed[orgGUID] = true
+	}
+	restricted := &ResourceTimeline{
+		ResourceGUID:      t.ResourceGUID,
+		OrgGUIDs:          t.OrgGUIDs,
+		AllocatedOrgGUIDs: orgGUIDs,
+		RawEvents:         []RawEvent{},
+		Events:            []UsageEvent{},
+		Components:        []BillableComponent{},
+	}
+	for _, component := range t.Components {
+		if component.AllocatedFrom != "" && allowed[component.OrgGUID] {
+			restricted.Components = append(restricted.Components, component)
+		}
+	}
+	return restricted
+}
+
+// BillableComponent is the price of one pricing plan component for (part of)
+// a UsageEvent, with the inputs of its formula. Components charged to another
+// org or space by a cost allocation have the guid of the event they were
+// allocated from in AllocatedFrom.
+type BillableComponent struct {
+	EventGUID     string `json:"event_guid"`
+	ResourceType  string `json:"resource_type"`
+	OrgGUID       string `json:"org_guid"`
+	SpaceGUID     string `json:"space_guid"`
+	AllocatedFrom string `json:"allocated_from"`
+	Start         string `json:"start"`
+	Stop          string `json:"stop"`
+	PlanGUID      string `json:"plan_guid"`
+	PlanName      string `json:"plan_name"`
+	PlanValidFrom string `json:"plan_valid_from"`
+	ComponentName string `json:"component_name"`
+	Formula       string `json:"formula"`
+	NumberOfNodes int64  `json:"number_of_nodes"`
+	MemoryInMB    int64  `json:"memory_in_mb"`
+	StorageInMB   int64  `json:"storage_in_mb"`
+	CurrencyCode  string `json:"currency_code"`
+	CurrencyRate  string `json:"currency_rate"`
+	VATCode       string `json:"vat_code"`
+	VATRate       string `json:"vat_rate"`
+	IncVAT        string `json:"inc_vat"`
+	ExVAT         string `json:"ex_vat"`
+}
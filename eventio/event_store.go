@@ -1,5 +1,7 @@
 package eventio
 
+import "context"
+
 type RawEventWriter interface {
 	StoreEvents(events []RawEvent) error
 }
@@ -23,6 +25,7 @@ type VATRateReader interface {
 type EventStore interface {
 	Init() error
 	Refresh() error
+	RefreshContext(ctx context.Context) error
 	PricingPlanReader
 	CurrencyRateReader
 	VATRateReader
@@ -30,8 +33,20 @@ type EventStore interface {
 	RawEventReader
 	UsageEventReader
 	TotalCostReader
+	CostProjectionReader
 	BillableEventReader
 	BillableEventForecaster
 	ConsolidatedBillableEventReader
 	BillableEventConsolidator
+	StoreHealthChecker
+	JobQueue
+	BillingContactReader
+	AdjustmentStore
+	ContractStatementReader
+	UsageAndAdoptionReader
+	DailyCostReader
+	CostAnomalyReader
+	IdleResourceReader
+	ResourceTimelineReader
+	RawEventArchiver
 }
@@ -0,0 +1,38 @@
+package eventio
+
+// OtherServiceCategory is the category of plans that do not match any of the
+// configured service categories
+const OtherServiceCategory = "other"
+
+type UsageAndAdoptionReader interface {
+	GetUsageAndAdoption(filter EventFilter) (UsageAndAdoptionReport, error)
+}
+
+// UsageAndAdoptionReport shows how much each org spent on each service
+// category per month and how many orgs were using the platform.
+// ServiceCategories lists the keys of OrgUsage.Costs in their configured
+// order, followed by OtherServiceCategory.
+type UsageAndAdoptionReport struct {
+	ServiceCategories []string         `json:"service_categories"`
+	Orgs              []OrgUsage       `json:"orgs"`
+	ActiveOrgs        []ActiveOrgCount `json:"active_orgs"`
+}
+
+// OrgUsage is the cost of an org's usage in a month by service category.
+// FirstSeen is the date of the org's first usage event.
+type OrgUsage struct {
+	Month     string            `json:"month"`
+	OrgGUID   string            `json:"org_guid"`
+	OrgName   string            `json:"org_name"`
+	FirstSeen string            `json:"first_seen"`
+	Costs     map[string]string `json:"costs"`
+	TotalCost string            `json:"total_cost"`
+}
+
+// ActiveOrgCount is the number of orgs with usage in a month, NewOrgs of
+// which were seen for the first time that month
+type ActiveOrgCount struct {
+	Month      string `json:"month"`
+	ActiveOrgs int    `json:"active_orgs"`
+	NewOrgs    int    `json:"new_orgs"`
+}
@@ -0,0 +1,40 @@
+-- manual credits, debits and discounts applied to an org's bill
+CREATE TABLE IF NOT EXISTS adjustments (
+	id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+	org_guid uuid NOT NULL,
+	kind text NOT NULL,
+	description text NOT NULL,
+	amount_ex_vat numeric,
+	percentage numeric,
+	vat_code vat_code NOT NULL DEFAULT 'Standard',
+	valid_from timestamptz NOT NULL,
+	valid_to timestamptz,
+	created_by text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	revoked_by text,
+	revoked_at timestamptz,
+
+	CONSTRAINT valid_kind CHECK (kind IN ('credit', 'debit', 'discount')),
+	CONSTRAINT description_must_not_be_blank CHECK (length(trim(description)) > 0),
+	CONSTRAINT amount_required_for_credits_and_debits CHECK (
+		(kind = 'discount') OR (amount_ex_vat > 0 AND percentage IS NULL AND valid_to IS NULL)
+	),
+	CONSTRAINT percentage_required_for_discounts CHECK (
+		(kind <> 'discount') OR (percentage > 0 AND percentage <= 100 AND amount_ex_vat IS NULL)
+	),
+	CONSTRAINT valid_to_after_valid_from CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+CREATE INDEX IF NOT EXISTS adjustments_org_idx ON adjustments (org_guid);
+
+-- every change to an adjustment with the state of the adjustment afterwards
+CREATE TABLE IF NOT EXISTS adjustment_audit (
+	id bigserial PRIMARY KEY,
+	adjustment_id uuid NOT NULL REFERENCES adjustments (id),
+	action text NOT NULL,
+	actor text NOT NULL,
+	occurred_at timestamptz NOT NULL DEFAULT now(),
+	adjustment jsonb NOT NULL,
+
+	CONSTRAINT valid_action CHECK (action IN ('created', 'revoked'))
+);
+CREATE INDEX IF NOT EXISTS adjustment_audit_adjustment_idx ON adjustment_audit (adjustment_id, id);
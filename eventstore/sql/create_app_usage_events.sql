@@ -14,3 +14,7 @@ DO $$ BEGIN
 EXCEPTION
 	WHEN duplicate_object THEN RAISE NOTICE 'constraint already exists';
 END; $$;
+
+CREATE INDEX IF NOT EXISTS app_usage_app_guid_idx ON app_usage_events ( (raw_message->>'app_guid') );
+CREATE INDEX IF NOT EXISTS app_usage_parent_app_guid_idx ON app_usage_events ( (raw_message->>'parent_app_guid') );
+CREATE INDEX IF NOT EXISTS app_usage_task_guid_idx ON app_usage_events ( (raw_message->>'task_guid') );
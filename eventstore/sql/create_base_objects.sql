@@ -2,6 +2,12 @@ DROP TABLE IF EXISTS pricing_plan_components;
 DROP TABLE IF EXISTS pricing_plans;
 DROP TABLE IF EXISTS vat_rates;
 DROP TABLE IF EXISTS currency_rates;
+DROP TABLE IF EXISTS billing_label_keys;
+DROP TABLE IF EXISTS cost_allocations;
+DROP TABLE IF EXISTS cost_allocation_rules;
+DROP TABLE IF EXISTS contracts;
+DROP TABLE IF EXISTS service_categories;
+DROP TABLE IF EXISTS excluded_report_orgs;
 
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
@@ -65,6 +71,25 @@ BEGIN
 	return out;
 END; $$ LANGUAGE plpgsql;
 
+-- substitute the values of an event into a formula to show how it was priced
+CREATE OR REPLACE FUNCTION explain_formula(
+	memory_in_mb numeric,
+	storage_in_mb numeric,
+	number_of_nodes integer,
+	duration tstzrange,
+	formula text
+) RETURNS text AS $$
+DECLARE
+	out text;
+BEGIN
+	out := coalesce(lower(formula), '0');
+	out := regexp_replace(out, '\$memory_in_mb', coalesce(memory_in_mb, 0)::text, 'g');
+	out := regexp_replace(out, '\$storage_in_mb', coalesce(storage_in_mb, 0)::text, 'g');
+	out := regexp_replace(out, '\$number_of_nodes', coalesce(number_of_nodes, 0)::text, 'g');
+	out := regexp_replace(out, '\$time_in_seconds', coalesce(to_seconds(duration), 0)::text, 'g');
+	return out;
+END; $$ LANGUAGE plpgsql IMMUTABLE;
+
 -- validate formula whitelist's selected terms that can be evaluated in
 -- "formulas" this should not be considered "safe" for untrusted input it is
 -- intended as a technique to restrict formulas from becoming complex SQL
@@ -200,3 +225,72 @@ CREATE TABLE pricing_plan_components (
 	CONSTRAINT formula_must_not_be_blank CHECK (length(trim(formula)) > 0)
 );
 CREATE TRIGGER tgr_ppc_validate_formula BEFORE INSERT OR UPDATE ON pricing_plan_components FOR EACH ROW EXECUTE PROCEDURE validate_formula();
+
+-- the label (or annotation) keys of orgs and spaces that are carried into
+-- the billable events
+CREATE TABLE billing_label_keys (
+	key text PRIMARY KEY NOT NULL,
+
+	CONSTRAINT key_must_not_be_blank CHECK (length(trim(key)) > 0)
+);
+
+-- rules that recharge the cost of a shared resource to other orgs or spaces,
+-- each rule applies from valid_from until the next rule for the resource
+CREATE TABLE cost_allocation_rules (
+	resource_guid uuid NOT NULL,
+	valid_from timestamptz NOT NULL,
+
+	PRIMARY KEY (resource_guid, valid_from)
+);
+
+CREATE TABLE cost_allocations (
+	resource_guid uuid NOT NULL,
+	valid_from timestamptz NOT NULL,
+	org_guid uuid NOT NULL,
+	space_guid uuid,
+	share numeric NOT NULL,
+
+	UNIQUE (resource_guid, valid_from, org_guid, space_guid),
+	FOREIGN KEY (resource_guid, valid_from) REFERENCES cost_allocation_rules (resource_guid, valid_from),
+	CONSTRAINT share_must_be_a_fraction CHECK (share > 0 AND share <= 1)
+);
+
+-- minimum monthly fees and prepaid commitments of orgs, each contract applies
+-- from valid_from until the next contract for the org
+CREATE TABLE contracts (
+	org_guid uuid NOT NULL,
+	valid_from timestamptz NOT NULL,
+	minimum_monthly_fee numeric NOT NULL DEFAULT 0,
+	committed_amount numeric NOT NULL DEFAULT 0,
+
+	PRIMARY KEY (org_guid, valid_from),
+	CONSTRAINT minimum_monthly_fee_must_not_be_negative CHECK (minimum_monthly_fee >= 0),
+	CONSTRAINT committed_amount_must_not_be_negative CHECK (committed_amount >= 0),
+	CONSTRAINT valid_from_start_of_month CHECK (
+	  (extract (day from valid_from)) = 1 AND
+	  (extract (hour from valid_from)) = 0 AND
+	  (extract (minute from valid_from)) = 0 AND
+	  (extract (second from valid_from)) = 0
+	)
+);
+
+-- groups of plans in the usage and adoption report, a plan belongs to the
+-- category with the lowest position whose pattern matches its name
+CREATE TABLE service_categories (
+	name text PRIMARY KEY NOT NULL,
+	plan_name_pattern text NOT NULL,
+	position integer NOT NULL UNIQUE,
+
+	CONSTRAINT name_must_not_be_blank CHECK (length(trim(name)) > 0),
+	CONSTRAINT name_must_not_be_other CHECK (name <> 'other'),
+	-- raises an error if the pattern is not a valid regular expression
+	CONSTRAINT plan_name_pattern_must_be_valid CHECK ('' ~ plan_name_pattern IS NOT NULL)
+);
+
+-- patterns of org names (such as test orgs) that are left
+-- out of the usage and adoption report
+CREATE TABLE excluded_report_orgs (
+	org_name_pattern text PRIMARY KEY NOT NULL,
+
+	CONSTRAINT org_name_pattern_must_be_valid CHECK ('' ~ org_name_pattern IS NOT NULL)
+);
@@ -1,4 +1,6 @@
 DROP FUNCTION IF EXISTS generate_billable_event_components();
+DROP FUNCTION IF EXISTS generate_allocated_billable_event_components();
+DROP FUNCTION IF EXISTS generate_adjustment_billable_event_components();
 
 CREATE TABLE billable_event_components_temp (
 	event_guid uuid NOT NULL,
@@ -7,6 +9,7 @@ CREATE TABLE billable_event_components_temp (
 	resource_type text NOT NULL,
 	org_guid uuid NOT NULL,
 	org_name text NOT NULL,
+	quota_definition_guid uuid,
 	space_guid uuid NOT NULL,
 	space_name text NOT NULL,
 	duration tstzrange NOT NULL,
@@ -16,6 +19,7 @@ CREATE TABLE billable_event_components_temp (
 	number_of_nodes integer NOT NULL,
 	memory_in_mb numeric NOT NULL,
 	storage_in_mb numeric NOT NULL,
+	labels jsonb NOT NULL,
 	component_name text NOT NULL,
 	component_formula text NOT NULL,
 	currency_code currency_code NOT NULL,
@@ -23,6 +27,7 @@ CREATE TABLE billable_event_components_temp (
 	vat_code vat_code NOT NULL,
 	vat_rate numeric NOT NULL,
 	cost_for_duration numeric NOT NULL,
+	allocated_from uuid,
 
 	PRIMARY KEY (event_guid, plan_guid, duration, component_name),
 	CONSTRAINT no_empty_duration CHECK (not isempty(duration))
@@ -64,6 +69,7 @@ CREATE OR REPLACE FUNCTION generate_billable_event_components() RETURNS SETOF bi
 		ev.resource_type,
 		ev.org_guid,
 		ev.org_name,
+		ev.quota_definition_guid,
 		ev.space_guid,
 		ev.space_name,
 		ev.duration * vpp.valid_for * vcr.valid_for * vvr.valid_for as duration,
@@ -73,6 +79,11 @@ CREATE OR REPLACE FUNCTION generate_billable_event_components() RETURNS SETOF bi
 		coalesce(ev.number_of_nodes, vpp.number_of_nodes)::integer as number_of_nodes,
 		coalesce(ev.memory_in_mb, vpp.memory_in_mb)::numeric as memory_in_mb,
 		coalesce(ev.storage_in_mb, vpp.storage_in_mb)::numeric as storage_in_mb,
+		(
+			select coalesce(jsonb_object_agg(l.key, l.value), '{}')
+			from jsonb_each(ev.labels) l
+			where l.key in (select key from billing_label_keys)
+		) as labels,
 		ppc.name AS component_name,
 		ppc.formula as component_formula,
 		vcr.code as currency_code,
@@ -85,7 +96,8 @@ CREATE OR REPLACE FUNCTION generate_billable_event_components() RETURNS SETOF bi
 			coalesce(ev.number_of_nodes, vpp.number_of_nodes)::integer,
 			ev.duration * vpp.valid_for * vcr.valid_for * vvr.valid_for,
 			ppc.formula
-		) * vcr.rate) as cost_for_duration
+		) * vcr.rate) as cost_for_duration,
+		null::uuid as allocated_from
 	from
 		events ev
 	left join
@@ -104,6 +116,265 @@ CREATE OR REPLACE FUNCTION generate_billable_event_components() RETURNS SETOF bi
 
 INSERT INTO billable_event_components_temp (select * from generate_billable_event_components());
 
+-- cost allocation rules recharge part of each component of a shared resource
+-- to other orgs or spaces. Each component is split at the boundaries of the
+-- rules and every part is priced with the rule valid for it. The recipient
+-- gets an allocated copy of the part, recorded against the org's guid as the
+-- space when the rule does not name a space, and the owner gets a matching
+-- reduction so the total charged for the resource is unchanged. Allocated
+-- copies record the event they were allocated from. The components are read
+-- from billable_event_components_temp so that forecasts can run this against
+-- a temporary table of the same name.
+CREATE OR REPLACE FUNCTION generate_allocated_billable_event_components() RETURNS SETOF billable_event_components_temp AS $$
+	with
+	valid_cost_allocation_rules as (
+		select
+			*,
+			tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+				partition by resource_guid order by valid_from rows between current row and 1 following
+			)) as valid_for
+		from
+			cost_allocation_rules
+	),
+	allocated_components as (
+		select
+			c.*,
+			c.duration * vcar.valid_for as allocated_duration,
+			ca.org_guid as allocated_org_guid,
+			coalesce(ca.space_guid, ca.org_guid) as allocated_space_guid,
+			ca.space_guid is null as allocated_to_org,
+			ca.share
+		from
+			billable_event_components_temp c
+		inner join
+			valid_cost_allocation_rules vcar on vcar.resource_guid = c.resource_guid
+			and vcar.valid_for && c.duration
+		inner join
+			cost_allocations ca on ca.resource_guid = vcar.resource_guid
+			and ca.valid_from = vcar.valid_from
+		where
+			not (ca.org_guid = c.org_guid and coalesce(ca.space_guid, c.space_guid) = c.space_guid)
+	)
+	select
+		uuid_generate_v5(a.event_guid, 'allocation:' || a.allocated_org_guid::text || ':' || a.allocated_space_guid::text) as event_guid,
+		a.resource_guid,
+		a.resource_name,
+		a.resource_type,
+		a.allocated_org_guid as org_guid,
+		coalesce(o.name, a.allocated_org_guid::text) as org_name,
+		o.quota_definition_guid,
+		a.allocated_space_guid as space_guid,
+		(case when a.allocated_to_org then '' else coalesce(s.name, a.allocated_space_guid::text) end) as space_name,
+		a.allocated_duration as duration,
+		a.plan_guid,
+		a.plan_valid_from,
+		a.plan_name,
+		a.number_of_nodes,
+		a.memory_in_mb,
+		a.storage_in_mb,
+		a.labels,
+		a.component_name,
+		'(' || a.share::text || ') * (' || a.component_formula || ')' as component_formula,
+		a.currency_code,
+		a.currency_rate,
+		a.vat_code,
+		a.vat_rate,
+		(eval_formula(
+			a.memory_in_mb,
+			a.storage_in_mb,
+			a.number_of_nodes,
+			a.allocated_duration,
+			a.component_formula
+		) * a.currency_rate * a.share) as cost_for_duration,
+		a.event_guid as allocated_from
+	from
+		allocated_components a
+	left join lateral
+		(
+			select name, quota_definition_guid from orgs
+			where guid = a.allocated_org_guid and valid_from <= upper(a.allocated_duration)
+			order by valid_from desc
+			limit 1
+		) o on true
+	left join lateral
+		(
+			select name from spaces
+			where guid = a.allocated_space_guid and valid_from <= upper(a.allocated_duration)
+			order by valid_from desc
+			limit 1
+		) s on true
+	union all
+	select
+		uuid_generate_v5(a.event_guid, 'allocation') as event_guid,
+		a.resource_guid,
+		a.resource_name,
+		a.resource_type,
+		a.org_guid,
+		a.org_name,
+		a.quota_definition_guid,
+		a.space_guid,
+		a.space_name,
+		a.allocated_duration as duration,
+		a.plan_guid,
+		a.plan_valid_from,
+		a.plan_name,
+		a.number_of_nodes,
+		a.memory_in_mb,
+		a.storage_in_mb,
+		a.labels,
+		a.component_name,
+		'-(' || sum(a.share)::text || ') * (' || a.component_formula || ')' as component_formula,
+		a.currency_code,
+		a.currency_rate,
+		a.vat_code,
+		a.vat_rate,
+		-(eval_formula(
+			a.memory_in_mb,
+			a.storage_in_mb,
+			a.number_of_nodes,
+			a.allocated_duration,
+			a.component_formula
+		) * a.currency_rate * sum(a.share)) as cost_for_duration,
+		null::uuid as allocated_from
+	from
+		allocated_components a
+	group by
+		a.event_guid, a.resource_guid, a.resource_name, a.resource_type,
+		a.org_guid, a.org_name, a.quota_definition_guid, a.space_guid, a.space_name,
+		a.allocated_duration, a.plan_guid, a.plan_valid_from, a.plan_name,
+		a.number_of_nodes, a.memory_in_mb, a.storage_in_mb, a.labels,
+		a.component_name, a.component_formula, a.currency_code, a.currency_rate,
+		a.vat_code, a.vat_rate
+; $$ LANGUAGE SQL;
+
+INSERT INTO billable_event_components_temp (select * from generate_allocated_billable_event_components());
+
+-- adjustments are applied after the cost allocations so that discounts also
+-- apply to the costs recharged to an org. The components are read from
+-- billable_event_components_temp so that forecasts can run this against a
+-- temporary table of the same name.
+--
+-- discounts take a percentage off every component of the org's events while
+-- the discount is valid, each discounted event becomes a discount event
+--
+-- credits and debits are a fixed charge to the org on the day they are valid
+-- from. They are priced with the adjustment plan (AdjustmentPlanGUID) that was
+-- valid on that day, whose "credit" and "debit" components set the currency
+-- the amount is in. Adjustments belong to the whole org rather than a space so
+-- they are recorded against a space with the org's guid and no name.
+CREATE OR REPLACE FUNCTION generate_adjustment_billable_event_components() RETURNS SETOF billable_event_components_temp AS $$
+	with
+	valid_adjustment_plans as (
+		select
+			*,
+			tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+				partition by plan_guid order by valid_from rows between current row and 1 following
+			)) as valid_for
+		from
+			pricing_plans
+		where
+			plan_guid = 'a7e8f2d1-7c3b-4e4a-9f5d-2b6c8e1d0a94'::uuid -- plan guid for all adjustments
+	)
+	select
+		uuid_generate_v5(adj.id, c.event_guid::text) as event_guid,
+		adj.id as resource_guid,
+		adj.description as resource_name,
+		adj.kind as resource_type,
+		c.org_guid,
+		c.org_name,
+		c.quota_definition_guid,
+		c.space_guid,
+		c.space_name,
+		c.duration * tstzrange(adj.valid_from, coalesce(adj.valid_to, 'infinity')) as duration,
+		c.plan_guid,
+		c.plan_valid_from,
+		c.plan_name,
+		c.number_of_nodes,
+		c.memory_in_mb,
+		c.storage_in_mb,
+		c.labels,
+		c.component_name,
+		'-(' || (adj.percentage / 100)::text || ') * (' || c.component_formula || ')' as component_formula,
+		c.currency_code,
+		c.currency_rate,
+		c.vat_code,
+		c.vat_rate,
+		(eval_formula(
+			c.memory_in_mb,
+			c.storage_in_mb,
+			c.number_of_nodes,
+			c.duration * tstzrange(adj.valid_from, coalesce(adj.valid_to, 'infinity')),
+			c.component_formula
+		) * c.currency_rate * -(adj.percentage / 100)) as cost_for_duration,
+		null::uuid as allocated_from
+	from
+		billable_event_components_temp c
+	inner join
+		adjustments adj on adj.org_guid = c.org_guid
+		and adj.kind = 'discount'
+		and adj.revoked_at is null
+		and c.duration && tstzrange(adj.valid_from, coalesce(adj.valid_to, 'infinity'))
+	union all
+	select
+		adj.id as event_guid,
+		adj.id as resource_guid,
+		adj.description as resource_name,
+		adj.kind as resource_type,
+		adj.org_guid,
+		coalesce(o.name, adj.org_guid::text) as org_name,
+		o.quota_definition_guid,
+		adj.org_guid as space_guid,
+		'' as space_name,
+		tstzrange(adj.valid_from, adj.valid_from + interval '1 second') as duration,
+		vpp.plan_guid,
+		vpp.valid_from as plan_valid_from,
+		vpp.name as plan_name,
+		0 as number_of_nodes,
+		0 as memory_in_mb,
+		0 as storage_in_mb,
+		'{}'::jsonb as labels,
+		ppc.name as component_name,
+		(case when adj.kind = 'credit' then '-' else '' end) || adj.amount_ex_vat::text as component_formula,
+		vcr.code as currency_code,
+		vcr.rate as currency_rate,
+		vvr.code as vat_code,
+		vvr.rate as vat_rate,
+		(case when adj.kind = 'credit' then -adj.amount_ex_vat else adj.amount_ex_vat end) * vcr.rate as cost_for_duration,
+		null::uuid as allocated_from
+	from
+		adjustments adj
+	inner join
+		valid_adjustment_plans vpp on vpp.valid_for @> adj.valid_from
+	inner join
+		pricing_plan_components ppc on ppc.plan_guid = vpp.plan_guid
+		and ppc.valid_from = vpp.valid_from
+		and ppc.name = adj.kind
+	inner join
+		currency_rates vcr on vcr.code = ppc.currency_code
+		and vcr.valid_from = (
+			select max(valid_from) from currency_rates
+			where code = ppc.currency_code and valid_from <= adj.valid_from
+		)
+	inner join
+		vat_rates vvr on vvr.code = adj.vat_code
+		and vvr.valid_from = (
+			select max(valid_from) from vat_rates
+			where code = adj.vat_code and valid_from <= adj.valid_from
+		)
+	left join lateral
+		(
+			select name, quota_definition_guid from orgs
+			where guid = adj.org_guid and valid_from <= adj.valid_from
+			order by valid_from desc
+			limit 1
+		) o on true
+	where
+		adj.kind in ('credit', 'debit')
+		and adj.revoked_at is null
+; $$ LANGUAGE SQL;
+
+INSERT INTO billable_event_components_temp (select * from generate_adjustment_billable_event_components());
+
 CREATE INDEX billable_event_components_temp_org_idx on billable_event_components_temp (org_guid);
 CREATE INDEX billable_event_components_temp_space_idx on billable_event_components_temp (space_guid);
 CREATE INDEX billable_event_components_temp_duration_idx on billable_event_components_temp using gist (duration);
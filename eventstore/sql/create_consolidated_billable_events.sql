@@ -24,6 +24,32 @@ CREATE TABLE IF NOT EXISTS consolidation_history (
   )
 );
 
+-- months whose partition has been detached by the retention command are still
+-- consolidated, but their events are no longer read from
+-- consolidated_billable_events
+ALTER TABLE consolidation_history ADD COLUMN IF NOT EXISTS detached_at timestamptz;
+
+-- consolidated_billable_events used to be a single table, move it out of the
+-- way so that it can be copied into the partitioned table below
+DO $$
+  BEGIN
+    IF EXISTS (
+      SELECT 1 FROM pg_class
+      WHERE oid = to_regclass('consolidated_billable_events')
+      AND relkind = 'r'
+    ) THEN
+      ALTER TABLE consolidated_billable_events RENAME TO consolidated_billable_events_unpartitioned;
+      ALTER TABLE consolidated_billable_events_unpartitioned RENAME CONSTRAINT consolidated_billable_events_pkey TO consolidated_billable_events_unpartitioned_pkey;
+      ALTER TABLE consolidated_billable_events_unpartitioned ADD COLUMN IF NOT EXISTS quota_definition_guid uuid;
+      ALTER TABLE consolidated_billable_events_unpartitioned ADD COLUMN IF NOT EXISTS labels jsonb;
+      ALTER TABLE consolidated_billable_events_unpartitioned ADD COLUMN IF NOT EXISTS allocations jsonb;
+      ALTER TABLE consolidated_billable_events_unpartitioned ADD COLUMN IF NOT EXISTS quota_definition jsonb;
+    END IF;
+  END;
+$$;
+
+-- consolidated_billable_events has a partition for each consolidated month,
+-- which is created by consolidate
 CREATE TABLE IF NOT EXISTS consolidated_billable_events (
   consolidated_range tstzrange REFERENCES consolidation_history(consolidated_range) NOT NULL,
 
@@ -46,18 +72,81 @@ CREATE TABLE IF NOT EXISTS consolidated_billable_events (
   number_of_nodes integer,
   memory_in_mb integer,
   storage_in_mb integer,
+  labels jsonb,
 
   price jsonb NOT NULL,
+  allocations jsonb,
+  quota_definition jsonb,
 
   PRIMARY KEY (consolidated_range, event_guid, plan_guid)
-);
+) PARTITION BY LIST (consolidated_range);
+
+ALTER TABLE consolidated_billable_events ADD COLUMN IF NOT EXISTS quota_definition jsonb;
+
+-- create the partition of consolidated_billable_events for a month if it does
+-- not exist yet and return its name
+CREATE OR REPLACE FUNCTION create_consolidated_billable_events_partition(month_range tstzrange) RETURNS text AS $$
+DECLARE
+	partition_name text := 'consolidated_billable_events_' || to_char(lower(month_range), 'YYYY_MM');
+BEGIN
+	EXECUTE format(
+		'CREATE TABLE IF NOT EXISTS %I PARTITION OF consolidated_billable_events FOR VALUES IN (%L)',
+		partition_name,
+		month_range
+	);
+	RETURN partition_name;
+END; $$ LANGUAGE plpgsql;
 
 DO $$
   BEGIN
-    BEGIN
-      ALTER TABLE consolidated_billable_events ADD COLUMN quota_definition_guid uuid;
-    EXCEPTION
-      WHEN duplicate_column THEN RAISE NOTICE 'column quota_definition_guid already exists in consolidated_billable_events.';
-    END;
+    IF to_regclass('consolidated_billable_events_unpartitioned') IS NOT NULL THEN
+      PERFORM create_consolidated_billable_events_partition(consolidated_range)
+      FROM consolidation_history
+      WHERE detached_at IS NULL;
+
+      INSERT INTO consolidated_billable_events (
+        consolidated_range,
+        event_guid,
+        duration,
+        resource_guid,
+        resource_name,
+        resource_type,
+        org_guid,
+        org_name,
+        space_guid,
+        space_name,
+        plan_guid,
+        quota_definition_guid,
+        number_of_nodes,
+        memory_in_mb,
+        storage_in_mb,
+        labels,
+        price,
+        allocations,
+        quota_definition
+      ) SELECT
+        consolidated_range,
+        event_guid,
+        duration,
+        resource_guid,
+        resource_name,
+        resource_type,
+        org_guid,
+        org_name,
+        space_guid,
+        space_name,
+        plan_guid,
+        quota_definition_guid,
+        number_of_nodes,
+        memory_in_mb,
+        storage_in_mb,
+        labels,
+        price,
+        allocations,
+        quota_definition
+      FROM consolidated_billable_events_unpartitioned;
+
+      DROP TABLE consolidated_billable_events_unpartitioned;
+    END IF;
   END;
 $$;
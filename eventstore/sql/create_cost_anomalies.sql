@@ -0,0 +1,18 @@
+-- days on which the cost of an org, service or resource was well above its
+-- trailing baseline, found by the anomaly detection that runs on refresh
+CREATE TABLE IF NOT EXISTS cost_anomalies (
+	day date NOT NULL,
+	scope text NOT NULL,
+	org_guid uuid NOT NULL,
+	org_name text NOT NULL,
+	subject_guid uuid NOT NULL,
+	subject_name text NOT NULL,
+	cost_ex_vat numeric NOT NULL,
+	baseline_mean_ex_vat numeric NOT NULL,
+	baseline_stddev_ex_vat numeric NOT NULL,
+	detected_at timestamptz NOT NULL DEFAULT now(),
+
+	PRIMARY KEY (day, scope, org_guid, subject_guid),
+	CONSTRAINT valid_scope CHECK (scope IN ('org', 'service', 'resource'))
+);
+CREATE INDEX IF NOT EXISTS cost_anomalies_org_idx ON cost_anomalies (org_guid, day);
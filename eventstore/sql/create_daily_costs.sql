@@ -0,0 +1,66 @@
+-- the cost of the billable event components split into UTC days, each
+-- component's cost is shared between days in proportion to its duration so
+-- the days always add up to the cost of the component
+CREATE TABLE daily_costs_temp (
+	day date NOT NULL,
+	org_guid uuid NOT NULL,
+	org_name text NOT NULL,
+	space_guid uuid NOT NULL,
+	space_name text NOT NULL,
+	plan_guid uuid NOT NULL,
+	plan_name text NOT NULL,
+	component_name text NOT NULL,
+	cost_ex_vat numeric NOT NULL,
+	cost_inc_vat numeric NOT NULL,
+
+	PRIMARY KEY (day, org_guid, space_guid, plan_guid, component_name)
+);
+
+INSERT INTO daily_costs_temp (
+	with
+	component_days as (
+		select
+			d.day::date as day,
+			c.org_guid,
+			c.org_name,
+			c.space_guid,
+			c.space_name,
+			c.plan_guid,
+			c.plan_name,
+			c.component_name,
+			lower(c.duration) as event_start,
+			c.cost_for_duration * to_seconds(
+				c.duration * tstzrange(d.day at time zone 'UTC', (d.day + interval '1 day') at time zone 'UTC')
+			) / to_seconds(c.duration) as cost,
+			c.vat_rate
+		from
+			billable_event_components c,
+			generate_series(
+				date_trunc('day', lower(c.duration) at time zone 'UTC'),
+				(upper(c.duration) at time zone 'UTC') - interval '1 microsecond',
+				interval '1 day'
+			) d(day)
+	)
+	select
+		day,
+		org_guid,
+		(array_agg(org_name order by event_start desc))[1] as org_name,
+		space_guid,
+		(array_agg(space_name order by event_start desc))[1] as space_name,
+		plan_guid,
+		(array_agg(plan_name order by event_start desc))[1] as plan_name,
+		component_name,
+		sum(cost) as cost_ex_vat,
+		sum(cost * (1 + vat_rate)) as cost_inc_vat
+	from
+		component_days
+	group by
+		day, org_guid, space_guid, plan_guid, component_name
+);
+
+CREATE INDEX daily_costs_temp_org_idx on daily_costs_temp (org_guid, day);
+
+DROP TABLE IF EXISTS daily_costs;
+ALTER TABLE daily_costs_temp RENAME TO daily_costs;
+ALTER INDEX daily_costs_temp_pkey RENAME TO daily_costs_pkey;
+ALTER INDEX daily_costs_temp_org_idx RENAME TO daily_costs_org_idx;
@@ -5,6 +5,7 @@ CREATE TABLE events_temp (
 	resource_type text NOT NULL,
 	org_guid uuid NOT NULL,
 	org_name text NOT NULL,
+	quota_definition_guid uuid,
 	space_guid uuid NOT NULL,
 	space_name text NOT NULL,
 	duration tstzrange NOT NULL,
@@ -15,6 +16,7 @@ CREATE TABLE events_temp (
 	number_of_nodes integer,
 	memory_in_mb integer,
 	storage_in_mb integer,
+	labels jsonb NOT NULL DEFAULT '{}',
 
 	CONSTRAINT duration_must_not_be_empty CHECK (not isempty(duration))
 );
@@ -266,6 +268,24 @@ INSERT INTO events_temp with
 			)) as valid_for
 		from
 			spaces
+	),
+	valid_org_metadata as (
+		select
+			*,
+			tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+				partition by guid order by valid_from rows between current row and 1 following
+			)) as valid_for
+		from
+			org_metadata
+	),
+	valid_space_metadata as (
+		select
+			*,
+			tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+				partition by guid order by valid_from rows between current row and 1 following
+			)) as valid_for
+		from
+			space_metadata
 	)
 
 	select
@@ -275,6 +295,9 @@ INSERT INTO events_temp with
 		resource_type,
 		org_guid,
 		coalesce(vo.name, org_guid::text) as org_name,
+		-- like the names, the quota is the one the org had when the event
+		-- ended, events are not split when it changes
+		vo.quota_definition_guid,
 		space_guid,
 		coalesce(vspace.name, space_guid::text) as space_name,
 		duration,
@@ -288,7 +311,14 @@ INSERT INTO events_temp with
 		coalesce(vs.label, ev.service_name) as service_name,
 		number_of_nodes,
 		memory_in_mb,
-		storage_in_mb
+		storage_in_mb,
+		-- labels take precedence over annotations and space metadata over org metadata
+		(
+			coalesce(vom.annotations, '{}') ||
+			coalesce(vom.labels, '{}') ||
+			coalesce(vsm.annotations, '{}') ||
+			coalesce(vsm.labels, '{}')
+		) as labels
 	from
 		event_ranges ev
 	left join
@@ -303,6 +333,12 @@ INSERT INTO events_temp with
 	left join
 		valid_spaces vspace on ev.space_guid = vspace.guid
 		and upper(ev.duration) <@ vspace.valid_for
+	left join
+		valid_org_metadata vom on ev.org_guid = vom.guid
+		and upper(ev.duration) <@ vom.valid_for
+	left join
+		valid_space_metadata vsm on ev.space_guid = vsm.guid
+		and upper(ev.duration) <@ vsm.valid_for
 	where
 		state = 'STARTED'
 		and not isempty(duration)
@@ -324,3 +360,12 @@ ALTER INDEX events_space_temp_idx RENAME TO events_space_idx;
 ALTER INDEX events_resource_temp_idx RENAME TO events_resource_idx;
 ALTER INDEX events_duration_temp_idx RENAME TO events_duration_idx;
 ALTER INDEX events_plan_temp_idx RENAME TO events_plan_idx;
+
+-- record when the events were last regenerated, any event that was still
+-- running at this point has a duration ending exactly at refreshed_at. Only
+-- the latest refresh is read, so older rows are removed.
+CREATE TABLE IF NOT EXISTS refresh_history (
+	refreshed_at timestamptz PRIMARY KEY NOT NULL
+);
+INSERT INTO refresh_history (refreshed_at) VALUES (now());
+DELETE FROM refresh_history WHERE refreshed_at < now();
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS jobs (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	params JSONB NOT NULL DEFAULT '{}',
+	state TEXT NOT NULL DEFAULT 'queued',
+	error TEXT,
+	requested_by TEXT NOT NULL,
+	cancel_requested BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	started_at TIMESTAMPTZ,
+	finished_at TIMESTAMPTZ,
+
+	CONSTRAINT valid_kind CHECK (kind IN ('refresh', 'consolidate', 'backfill', 'archive')),
+	CONSTRAINT valid_state CHECK (state IN ('queued', 'running', 'succeeded', 'failed', 'cancelled'))
+);
+CREATE INDEX IF NOT EXISTS jobs_state_idx ON jobs (state, id);
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS valid_kind;
+ALTER TABLE jobs ADD CONSTRAINT valid_kind CHECK (kind IN ('refresh', 'consolidate', 'backfill', 'archive'));
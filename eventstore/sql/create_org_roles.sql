@@ -0,0 +1,10 @@
+create table if not exists org_roles (
+	org_guid uuid not null,
+	user_guid uuid not null,
+	role text not null check (role in ('billing_manager', 'org_manager')),
+	valid_from timestamptz not null,
+	username text,
+	deleted boolean not null default false,
+
+	primary key (org_guid, user_guid, role, valid_from)
+);
@@ -8,3 +8,13 @@ create table if not exists orgs (
 
 	primary key (guid, valid_from)
 );
+
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE orgs ADD COLUMN deleted boolean not null default false;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'column deleted already exists in orgs.';
+    END;
+  END;
+$$;
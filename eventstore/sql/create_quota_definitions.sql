@@ -0,0 +1,25 @@
+create table if not exists quota_definitions (
+	guid uuid not null,
+	valid_from timestamptz not null,
+	name text not null check (length(name)>0),
+	memory_limit integer not null,
+	instance_memory_limit integer not null,
+	total_services integer not null,
+	total_routes integer not null,
+	app_instance_limit integer not null,
+	non_basic_services_allowed boolean not null,
+	created_at timestamptz not null,
+	updated_at timestamptz not null,
+
+	primary key (guid, valid_from)
+);
+
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE quota_definitions ADD COLUMN deleted boolean not null default false;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'column deleted already exists in quota_definitions.';
+    END;
+  END;
+$$;
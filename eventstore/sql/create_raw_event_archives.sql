@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS raw_event_archives (
+	id BIGSERIAL PRIMARY KEY,
+	kind TEXT NOT NULL,
+	key TEXT NOT NULL UNIQUE,
+	event_count BIGINT NOT NULL,
+	first_created_at TIMESTAMPTZ NOT NULL,
+	last_created_at TIMESTAMPTZ NOT NULL,
+	archived_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+	CONSTRAINT valid_kind CHECK (kind IN ('app', 'service')),
+	CONSTRAINT valid_created_range CHECK (first_created_at <= last_created_at)
+);
+ALTER TABLE raw_event_archives ADD COLUMN IF NOT EXISTS restored_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS raw_event_archives_kind_idx ON raw_event_archives (kind, first_created_at);
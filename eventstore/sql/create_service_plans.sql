@@ -18,3 +18,13 @@ create table if not exists service_plans (
 );
 
 alter table service_plans alter column unique_id type text using unique_id::text;
+
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE service_plans ADD COLUMN deleted boolean not null default false;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'column deleted already exists in service_plans.';
+    END;
+  END;
+$$;
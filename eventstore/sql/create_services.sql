@@ -11,3 +11,13 @@ create table if not exists services (
 
 	primary key (guid, valid_from)
 );
+
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE services ADD COLUMN deleted boolean not null default false;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'column deleted already exists in services.';
+    END;
+  END;
+$$;
@@ -0,0 +1,11 @@
+create table if not exists space_metadata (
+	guid uuid not null,
+	valid_from timestamptz not null,
+	labels jsonb not null default '{}',
+	annotations jsonb not null default '{}',
+	created_at timestamptz not null,
+	updated_at timestamptz not null,
+	deleted boolean not null default false,
+
+	primary key (guid, valid_from)
+);
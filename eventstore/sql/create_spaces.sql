@@ -7,3 +7,13 @@ create table if not exists spaces (
 
 	primary key (guid, valid_from)
 );
+
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE spaces ADD COLUMN deleted boolean not null default false;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'column deleted already exists in spaces.';
+    END;
+  END;
+$$;
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,10 +25,17 @@ const (
 	ComputeServiceGUID    = "4f6f0a18-cdd4-4e51-8b6b-dc39b696e61b"
 	TaskPlanGUID          = "ebfa9453-ef66-450c-8c37-d53dfd931038"
 	StagingPlanGUID       = "9d071c77-7a68-4346-9981-e8dafac95b6f"
+	// AdjustmentPlanGUID is the plan that credits and debits are priced with.
+	// Unless it is configured in the pricing plans it is created at the epoch
+	// with "credit" and "debit" components in GBP.
+	AdjustmentPlanGUID    = "a7e8f2d1-7c3b-4e4a-9f5d-2b6c8e1d0a94"
 	DefaultInitTimeout    = 25 * time.Minute
 	DefaultRefreshTimeout = 90 * time.Minute
 	DefaultStoreTimeout   = 45 * time.Second
 	DefaultQueryTimeout   = 45 * time.Second
+	// MinServerVersion is the oldest Postgres version (as server_version_num)
+	// that supports partitioning consolidated_billable_events by month
+	MinServerVersion = 110000
 )
 
 var _ eventio.EventStore = &EventStore{}
@@ -62,6 +70,11 @@ func (s *EventStore) Init() error {
 	ctx, cancel := context.WithTimeout(s.ctx, DefaultInitTimeout)
 	defer cancel()
 
+	if err := s.checkServerVersion(ctx); err != nil {
+		s.logger.Error("init", err)
+		return err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -75,6 +88,10 @@ func (s *EventStore) Init() error {
 		"create_base_objects.sql",
 		"create_orgs.sql",
 		"create_spaces.sql",
+		"create_quota_definitions.sql",
+		"create_org_roles.sql",
+		"create_org_metadata.sql",
+		"create_space_metadata.sql",
 	}
 	for _, sqlFile := range sqlFiles {
 		err := s.runSQLFile(tx, sqlFile)
@@ -92,6 +109,21 @@ func (s *EventStore) Init() error {
 	if err := s.initPlans(tx); err != nil {
 		return fmt.Errorf("failed to init plans: %s", err)
 	}
+	if err := s.initLabelKeys(tx); err != nil {
+		return fmt.Errorf("failed to init label keys: %s", err)
+	}
+	if err := s.initCostAllocationRules(tx); err != nil {
+		return fmt.Errorf("failed to init cost allocation rules: %s", err)
+	}
+	if err := s.initContracts(tx); err != nil {
+		return fmt.Errorf("failed to init contracts: %s", err)
+	}
+	if err := s.initServiceCategories(tx); err != nil {
+		return fmt.Errorf("failed to init service categories: %s", err)
+	}
+	if err := s.initExcludedReportOrgs(tx); err != nil {
+		return fmt.Errorf("failed to init excluded report orgs: %s", err)
+	}
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -102,11 +134,15 @@ func (s *EventStore) Init() error {
 		"create_service_usage_events.sql",
 		"create_compose_audit_events.sql",
 		"create_consolidated_billable_events.sql",
+		"create_jobs.sql",
+		"create_adjustments.sql",
+		"create_cost_anomalies.sql",
+		"create_raw_event_archives.sql",
 	); err != nil {
 		return err
 	}
 
-	if err := s.regenerateEvents(); err != nil {
+	if err := s.regenerateEvents(s.ctx); err != nil {
 		return err
 	}
 	s.logger.Info("initialized")
@@ -116,11 +152,16 @@ func (s *EventStore) Init() error {
 // Refresh triggers regeneration of the cached normalized view of the event dat and rebuilds the
 // billable components. Ideally you should do this once a day
 func (s *EventStore) Refresh() error {
-	return s.regenerateEvents()
+	return s.regenerateEvents(s.ctx)
 }
 
-func (s *EventStore) regenerateEvents() error {
-	ctx, cancel := context.WithTimeout(s.ctx, DefaultRefreshTimeout)
+// RefreshContext is like Refresh but is abandoned if ctx is cancelled
+func (s *EventStore) RefreshContext(ctx context.Context) error {
+	return s.regenerateEvents(ctx)
+}
+
+func (s *EventStore) regenerateEvents(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultRefreshTimeout)
 	defer cancel()
 
 	if err := s.runSQLFilesInTransaction(
@@ -153,10 +194,15 @@ func (s *EventStore) regenerateEvents() error {
 	if err := s.runSQLFilesInTransaction(
 		ctx,
 		"create_billable_event_components.sql",
+		"create_daily_costs.sql",
 	); err != nil {
 		return err
 	}
 
+	if err := s.detectCostAnomalies(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -181,6 +227,23 @@ func (s *EventStore) initVATRates(tx *sql.Tx) error {
 	return nil
 }
 
+// checkServerVersion returns an error if the database is older than
+// MinServerVersion
+func (s *EventStore) checkServerVersion(ctx context.Context) error {
+	var version int
+	var name string
+	err := s.db.QueryRowContext(ctx, `
+		select current_setting('server_version_num')::integer, current_setting('server_version')
+	`).Scan(&version, &name)
+	if err != nil {
+		return wrapPqError(err, "check-server-version")
+	}
+	if version < MinServerVersion {
+		return fmt.Errorf("postgres 11 or later is required but the database is running %s", name)
+	}
+	return nil
+}
+
 func (s *EventStore) initCurrencyRates(tx *sql.Tx) error {
 	for _, cr := range s.cfg.CurrencyRates {
 		s.logger.Info("configuring-currency-rate", lager.Data{
@@ -202,6 +265,137 @@ func (s *EventStore) initCurrencyRates(tx *sql.Tx) error {
 	return nil
 }
 
+func (s *EventStore) initCostAllocationRules(tx *sql.Tx) error {
+	for _, rule := range s.cfg.CostAllocationRules {
+		s.logger.Info("configuring-cost-allocation-rule", lager.Data{
+			"resource_guid": rule.ResourceGUID,
+			"valid_from":    rule.ValidFrom,
+			"allocations":   rule.Allocations,
+		})
+		_, err := tx.Exec(`
+			insert into cost_allocation_rules (
+				resource_guid, valid_from
+			) values (
+				$1, $2
+			)
+		`, rule.ResourceGUID, rule.ValidFrom)
+		if err != nil {
+			return wrapPqError(err, "invalid cost allocation rule")
+		}
+		total := 0.0
+		for _, allocation := range rule.Allocations {
+			_, err := tx.Exec(`
+				insert into cost_allocations (
+					resource_guid, valid_from, org_guid, space_guid, share
+				) values (
+					$1, $2, $3, nullif($4, '')::uuid, $5
+				)
+			`, rule.ResourceGUID, rule.ValidFrom, allocation.OrgGUID, allocation.SpaceGUID, allocation.Share)
+			if err != nil {
+				return wrapPqError(err, "invalid cost allocation")
+			}
+			total += allocation.Share
+		}
+		if len(rule.Allocations) > 0 && math.Abs(total-1) > 1e-9 {
+			return fmt.Errorf("cost allocation shares for resource %s from %s must add up to 1 - got %v", rule.ResourceGUID, rule.ValidFrom, total)
+		}
+	}
+	return nil
+}
+
+func (s *EventStore) initContracts(tx *sql.Tx) error {
+	for _, c := range s.cfg.Contracts {
+		s.logger.Info("configuring-contract", lager.Data{
+			"org_guid":            c.OrgGUID,
+			"valid_from":          c.ValidFrom,
+			"minimum_monthly_fee": c.MinimumMonthlyFee,
+			"committed_amount":    c.CommittedAmount,
+		})
+		_, err := tx.Exec(`
+			insert into contracts (
+				org_guid, valid_from, minimum_monthly_fee, committed_amount
+			) values (
+				$1, $2, $3, $4
+			)
+		`, c.OrgGUID, c.ValidFrom, c.MinimumMonthlyFee, c.CommittedAmount)
+		if err != nil {
+			return wrapPqError(err, "invalid contract")
+		}
+	}
+	return nil
+}
+
+// initServiceCategories stores the configured service categories, or the
+// defaults if none are configured. An empty list disables the defaults.
+func (s *EventStore) initServiceCategories(tx *sql.Tx) error {
+	categories := s.cfg.ServiceCategories
+	if categories == nil {
+		categories = DefaultServiceCategories
+	}
+	for i, category := range categories {
+		s.logger.Info("configuring-service-category", lager.Data{
+			"name":              category.Name,
+			"plan_name_pattern": category.PlanNamePattern,
+		})
+		_, err := tx.Exec(`
+			insert into service_categories (
+				name, plan_name_pattern, position
+			) values (
+				$1, $2, $3
+			)
+		`, category.Name, category.PlanNamePattern, i)
+		if err != nil {
+			return wrapPqError(err, "invalid service category")
+		}
+	}
+	return nil
+}
+
+// initExcludedReportOrgs stores the configured excluded org name patterns,
+// or the defaults if none are configured. An empty list disables the
+// defaults.
+func (s *EventStore) initExcludedReportOrgs(tx *sql.Tx) error {
+	patterns := s.cfg.ExcludedReportOrgs
+	if patterns == nil {
+		patterns = DefaultExcludedReportOrgs
+	}
+	for _, pattern := range patterns {
+		s.logger.Info("configuring-excluded-report-org", lager.Data{
+			"org_name_pattern": pattern,
+		})
+		_, err := tx.Exec(`
+			insert into excluded_report_orgs (
+				org_name_pattern
+			) values (
+				$1
+			)
+		`, pattern)
+		if err != nil {
+			return wrapPqError(err, "invalid excluded report org")
+		}
+	}
+	return nil
+}
+
+func (s *EventStore) initLabelKeys(tx *sql.Tx) error {
+	for _, key := range s.cfg.LabelKeys {
+		s.logger.Info("configuring-label-key", lager.Data{
+			"key": key,
+		})
+		_, err := tx.Exec(`
+			insert into billing_label_keys (
+				key
+			) values (
+				$1
+			)
+		`, key)
+		if err != nil {
+			return wrapPqError(err, "invalid label key")
+		}
+	}
+	return nil
+}
+
 // InitPlans destroys all existing plans and replaces them with those specified
 // by pricingPlans if the new set of plans does not satisfy the existing data
 // (for example if you are missing plans for services found in the events then
@@ -244,6 +438,10 @@ func (s *EventStore) initPlans(tx *sql.Tx) (err error) {
 		}
 	}
 
+	if err := initAdjustmentPlan(tx); err != nil {
+		return err
+	}
+
 	if err := checkPricingComponents(tx); err != nil {
 		return err
 	}
@@ -338,74 +536,84 @@ func (s *EventStore) GetEvents(filter eventio.RawEventFilter) ([]eventio.RawEven
 }
 
 func (s *EventStore) getComposeEvents(filter eventio.RawEventFilter) ([]eventio.RawEvent, error) {
-	events := []eventio.RawEvent{}
-	sortDirection := "desc"
-	if filter.Reverse {
-		sortDirection = "asc"
-	}
-	limit := ""
-	if filter.Limit > 0 {
-		limit = fmt.Sprintf(`limit %d`, filter.Limit)
-	}
 	if filter.Kind != "compose" {
 		return nil, fmt.Errorf("getComposeEvents can not filter events of kind: %s", filter.Kind)
 	}
-	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
-	defer cancel()
-	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-	rows, err := tx.Query(`
-		select
-			event_id,
-			created_at,
-			raw_message
-		from
-			compose_audit_events
-		order by
-			id ` + sortDirection + `
-		` + limit + `
-	`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var event = eventio.RawEvent{Kind: filter.Kind}
-		err := rows.Scan(
-			&event.GUID,
-			&event.CreatedAt,
-			&event.RawMessage,
-		)
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, event)
-	}
-	return events, nil
-
+	return s.getRawEvents(filter, "compose_audit_events", "event_id")
 }
 
 func (s *EventStore) getUsageEvents(filter eventio.RawEventFilter) ([]eventio.RawEvent, error) {
+	tableName := ""
+	switch filter.Kind {
+	case "service":
+		tableName = "service_usage_events"
+	case "app":
+		tableName = "app_usage_events"
+	default:
+		return nil, fmt.Errorf("getUsageEvents unknown kind: %s", filter.Kind)
+	}
+	return s.getRawEvents(filter, tableName, "guid")
+}
+
+// getRawEvents returns the events of filter.Kind stored in tableName, which
+// are identified by guidColumn, in the order they were stored
+func (s *EventStore) getRawEvents(filter eventio.RawEventFilter, tableName string, guidColumn string) ([]eventio.RawEvent, error) {
 	events := []eventio.RawEvent{}
 	sortDirection := "desc"
+	afterOperator := "<"
 	if filter.Reverse {
 		sortDirection = "asc"
+		afterOperator = ">"
 	}
 	limit := ""
 	if filter.Limit > 0 {
 		limit = fmt.Sprintf(`limit %d`, filter.Limit)
 	}
-	tableName := ""
-	switch filter.Kind {
-	case "service":
-		tableName = "service_usage_events"
-	case "app":
-		tableName = "app_usage_events"
-	default:
-		return nil, fmt.Errorf("getUsageEvents unknown kind: %s", filter.Kind)
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	conditions := []string{"true"}
+	if !filter.Before.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.Before)+"::timestamptz")
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.Since)+"::timestamptz")
+	}
+	if filter.ResourceGUID != "" {
+		p := arg(filter.ResourceGUID) + "::text"
+		switch filter.Kind {
+		case "app":
+			conditions = append(conditions, fmt.Sprintf(
+				"(raw_message->>'app_guid' = %[1]s or raw_message->>'parent_app_guid' = %[1]s or raw_message->>'task_guid' = %[1]s)", p,
+			))
+		case "service":
+			conditions = append(conditions, "raw_message->>'service_instance_guid' = "+p)
+		case "compose":
+			conditions = append(conditions, "raw_message->'data'->>'deployment' like '%' || "+p)
+		}
+	}
+	if filter.OrgGUID != "" {
+		p := arg(filter.OrgGUID) + "::text"
+		if filter.Kind == "compose" {
+			conditions = append(conditions, `exists (
+				select 1 from service_usage_events s
+				where s.raw_message->>'service_instance_guid' = substring(
+					raw_message->'data'->>'deployment'
+					from '[a-zA-Z0-9]{8}-[a-zA-Z0-9]{4}-[a-zA-Z0-9]{4}-[a-zA-Z0-9]{4}-[a-zA-Z0-9]{12}$'
+				) and s.raw_message->>'org_guid' = `+p+`
+			)`)
+		} else {
+			conditions = append(conditions, "raw_message->>'org_guid' = "+p)
+		}
+	}
+	if filter.State != "" {
+		if filter.Kind == "compose" {
+			conditions = append(conditions, "raw_message->>'event' = "+arg(filter.State)+"::text")
+		} else {
+			conditions = append(conditions, "raw_message->>'state' = "+arg(filter.State)+"::text")
+		}
 	}
 	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
 	defer cancel()
@@ -414,17 +622,34 @@ func (s *EventStore) getUsageEvents(filter eventio.RawEventFilter) ([]eventio.Ra
 		return nil, err
 	}
 	defer tx.Rollback()
+	if filter.AfterGUID != "" {
+		// look the event up first, as comparing with the id of an event
+		// that does not exist would quietly return nothing
+		var afterID int64
+		err := tx.QueryRow(
+			`select id from `+tableName+` where `+guidColumn+` = $1`,
+			filter.AfterGUID,
+		).Scan(&afterID)
+		if err == sql.ErrNoRows {
+			return nil, &eventio.UnknownAfterGUIDError{Kind: filter.Kind, GUID: filter.AfterGUID}
+		} else if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("id %s %s", afterOperator, arg(afterID)))
+	}
 	rows, err := tx.Query(`
 		select
-			guid,
+			`+guidColumn+`,
 			created_at,
 			raw_message
 		from
-			` + tableName + `
+			`+tableName+`
+		where
+			`+strings.Join(conditions, "\n\t\t\tand ")+`
 		order by
-			id ` + sortDirection + `
-		` + limit + `
-	`)
+			id `+sortDirection+`
+		`+limit+`
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -441,7 +666,7 @@ func (s *EventStore) getUsageEvents(filter eventio.RawEventFilter) ([]eventio.Ra
 		}
 		events = append(events, event)
 	}
-	return events, nil
+	return events, rows.Err()
 }
 
 func checkVATRates(tx *sql.Tx) error {
@@ -508,6 +733,38 @@ func checkCurrencyRates(tx *sql.Tx) error {
 	return rows.Err()
 }
 
+// initAdjustmentPlan creates the plan credits and debits are priced with
+// unless it has been configured. The amount of each adjustment is taken as is
+// so the formulas of the components are only placeholders.
+func initAdjustmentPlan(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		insert into pricing_plans (
+			plan_guid, valid_from, name
+		) (
+			select $1::uuid, 'epoch'::timestamptz, 'adjustment'
+			where not exists (
+				select 1 from pricing_plans where plan_guid = $1::uuid
+			)
+		)
+	`, AdjustmentPlanGUID); err != nil {
+		return wrapPqError(err, "generate-adjustment-plan")
+	}
+	if _, err := tx.Exec(`
+		insert into pricing_plan_components (
+			plan_guid, valid_from, name, formula, vat_code, currency_code
+		) (
+			select $1::uuid, 'epoch'::timestamptz, kind, '0', 'Standard'::vat_code, 'GBP'::currency_code
+			from unnest(array['credit', 'debit']) kind
+			where not exists (
+				select 1 from pricing_plan_components where plan_guid = $1::uuid
+			)
+		)
+	`, AdjustmentPlanGUID); err != nil {
+		return wrapPqError(err, "generate-adjustment-plan-component")
+	}
+	return nil
+}
+
 // generateMissingPlans creates dummy plans with 0 cost at the epoch time
 // for every single plan in events, unless there is already one.
 // Useful for getting the system up with an existing dataset without
@@ -0,0 +1,259 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.AdjustmentStore = &EventStore{}
+
+// adjustmentColumns selects an adjustment in the shape of eventio.Adjustment
+const adjustmentColumns = `
+	id,
+	org_guid,
+	kind,
+	description,
+	amount_ex_vat::text,
+	percentage::text,
+	vat_code,
+	valid_from,
+	valid_to,
+	created_by,
+	created_at,
+	revoked_by,
+	revoked_at
+`
+
+// CreateAdjustment stores a new adjustment and records who created it in the
+// audit trail
+func (s *EventStore) CreateAdjustment(adjustment eventio.Adjustment, actor string) (eventio.Adjustment, error) {
+	if err := adjustment.Validate(); err != nil {
+		return adjustment, err
+	}
+	if adjustment.VATCode == "" {
+		adjustment.VATCode = "Standard"
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return adjustment, err
+	}
+	defer tx.Rollback()
+	adjustments, err := s.queryAdjustmentsTx(tx, `
+		insert into adjustments (
+			org_guid, kind, description, amount_ex_vat, percentage,
+			vat_code, valid_from, valid_to, created_by
+		) values (
+			$1, $2, $3, nullif($4, '')::numeric, nullif($5, '')::numeric,
+			$6, $7, nullif($8, '')::timestamptz, $9
+		) returning `+adjustmentColumns,
+		adjustment.OrgGUID,
+		adjustment.Kind,
+		adjustment.Description,
+		adjustment.AmountExVAT,
+		adjustment.Percentage,
+		adjustment.VATCode,
+		adjustment.ValidFrom,
+		adjustment.ValidTo,
+		actor,
+	)
+	if err != nil {
+		return adjustment, wrapPqError(err, "create-adjustment")
+	}
+	if err := checkAdjustmentNotConsolidated(tx, adjustments[0].ID); err != nil {
+		return adjustment, err
+	}
+	if err := recordAdjustmentAudit(tx, adjustments[0], eventio.AdjustmentCreated, actor); err != nil {
+		return adjustment, err
+	}
+	return adjustments[0], tx.Commit()
+}
+
+// RevokeAdjustment stops an adjustment from being applied and records who
+// revoked it in the audit trail. Revoking an adjustment that has already been
+// revoked returns it unchanged. It returns nil if the adjustment does not
+// exist.
+func (s *EventStore) RevokeAdjustment(id string, actor string) (*eventio.Adjustment, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	adjustments, err := s.queryAdjustmentsTx(tx, `
+		update adjustments set
+			revoked_by = $2,
+			revoked_at = now()
+		where id = $1
+		and revoked_at is null
+		returning `+adjustmentColumns,
+		id, actor,
+	)
+	if err != nil {
+		return nil, wrapPqError(err, "revoke-adjustment")
+	}
+	if len(adjustments) == 0 {
+		adjustments, err = s.queryAdjustmentsTx(tx, `
+			select `+adjustmentColumns+` from adjustments where id = $1
+		`, id)
+		if err != nil {
+			return nil, wrapPqError(err, "revoke-adjustment")
+		}
+		if len(adjustments) == 0 {
+			return nil, nil
+		}
+		return &adjustments[0], nil
+	}
+	if err := checkAdjustmentNotConsolidated(tx, adjustments[0].ID); err != nil {
+		return nil, err
+	}
+	if err := recordAdjustmentAudit(tx, adjustments[0], eventio.AdjustmentRevoked, actor); err != nil {
+		return nil, err
+	}
+	return &adjustments[0], tx.Commit()
+}
+
+// GetAdjustments returns the adjustments of the requested orgs (or all orgs)
+// in the order they were created
+func (s *EventStore) GetAdjustments(filter eventio.AdjustmentFilter) ([]eventio.Adjustment, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	adjustments, err := s.queryAdjustmentsTx(tx, `
+		select `+adjustmentColumns+`
+		from adjustments
+		where (cardinality($1::uuid[]) = 0 or org_guid = any($1::uuid[]))
+		and ($2 or revoked_at is null)
+		order by created_at, id
+	`, pq.Array(filter.OrgGUIDs), filter.IncludeRevoked)
+	if err != nil {
+		return nil, wrapPqError(err, "get-adjustments")
+	}
+	return adjustments, nil
+}
+
+// GetAdjustmentAudit returns the audit trail of an adjustment, oldest first
+func (s *EventStore) GetAdjustmentAudit(id string) ([]eventio.AdjustmentAuditEntry, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := queryJSON(tx, `
+		select
+			adjustment_id,
+			action,
+			actor,
+			occurred_at,
+			adjustment
+		from
+			adjustment_audit
+		where
+			adjustment_id = $1
+		order by
+			id
+	`, id)
+	if err != nil {
+		return nil, wrapPqError(err, "get-adjustment-audit")
+	}
+	defer rows.Close()
+	entries := []eventio.AdjustmentAuditEntry{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var entry eventio.AdjustmentAuditEntry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode adjustment audit entry: %s", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// checkAdjustmentNotConsolidated returns a ConsolidatedAdjustmentError if the
+// adjustment applies to any month whose consolidated billable events are
+// still in use. Credits and debits apply on their valid_from, discounts to
+// every day they are valid for.
+func checkAdjustmentNotConsolidated(tx *sql.Tx, id string) error {
+	var monthStart string
+	err := tx.QueryRow(`
+		select
+			to_char(lower(ch.consolidated_range) at time zone 'UTC', 'YYYY-MM-DD')
+		from
+			consolidation_history ch
+		inner join
+			adjustments adj on ch.consolidated_range && (case
+				when adj.kind = 'discount' then tstzrange(adj.valid_from, coalesce(adj.valid_to, 'infinity'))
+				else tstzrange(adj.valid_from, adj.valid_from + interval '1 second')
+			end)
+		where
+			adj.id = $1
+			and ch.detached_at is null
+		order by
+			lower(ch.consolidated_range)
+		limit 1
+	`, id).Scan(&monthStart)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return wrapPqError(err, "check-adjustment-not-consolidated")
+	}
+	return &eventio.ConsolidatedAdjustmentError{MonthStart: monthStart}
+}
+
+func recordAdjustmentAudit(tx *sql.Tx, adjustment eventio.Adjustment, action eventio.AdjustmentAction, actor string) error {
+	snapshot, err := json.Marshal(adjustment)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		insert into adjustment_audit (
+			adjustment_id, action, actor, adjustment
+		) values (
+			$1, $2, $3, $4
+		)
+	`, adjustment.ID, action, actor, string(snapshot))
+	if err != nil {
+		return wrapPqError(err, "record-adjustment-audit")
+	}
+	return nil
+}
+
+func (s *EventStore) queryAdjustmentsTx(tx *sql.Tx, q string, args ...interface{}) ([]eventio.Adjustment, error) {
+	rows, err := queryJSON(tx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	adjustments := []eventio.Adjustment{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var adjustment eventio.Adjustment
+		if err := json.Unmarshal(b, &adjustment); err != nil {
+			return nil, fmt.Errorf("failed to decode adjustment: %s", err)
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,209 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adjustments", func() {
+
+	var (
+		db      *testenv.TempDB
+		orgGUID = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+	)
+
+	BeforeEach(func() {
+		cfg := testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 1",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		var err error
+		db, err = testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-01-01T10:00Z", "STOPPED"),
+		)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should record who created and revoked an adjustment", func() {
+		created, err := db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.CreditAdjustment,
+			Description: "outage",
+			AmountExVAT: "5",
+			ValidFrom:   "2001-01-15",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.ID).ToNot(BeEmpty())
+		Expect(created.VATCode).To(Equal("Standard"))
+		Expect(created.CreatedBy).To(Equal("alice"))
+
+		revoked, err := db.Schema.RevokeAdjustment(created.ID, "bob")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked.RevokedBy).To(Equal("bob"))
+
+		adjustments, err := db.Schema.GetAdjustments(eventio.AdjustmentFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(adjustments).To(BeEmpty())
+
+		adjustments, err = db.Schema.GetAdjustments(eventio.AdjustmentFilter{
+			OrgGUIDs:       []string{orgGUID},
+			IncludeRevoked: true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(adjustments).To(Equal([]eventio.Adjustment{*revoked}))
+
+		audit, err := db.Schema.GetAdjustmentAudit(created.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(audit).To(HaveLen(2))
+		Expect(audit[0].Action).To(Equal(eventio.AdjustmentCreated))
+		Expect(audit[0].Actor).To(Equal("alice"))
+		Expect(audit[0].Adjustment).To(Equal(created))
+		Expect(audit[1].Action).To(Equal(eventio.AdjustmentRevoked))
+		Expect(audit[1].Actor).To(Equal("bob"))
+		Expect(audit[1].Adjustment).To(Equal(*revoked))
+	})
+
+	It("should return nil when revoking an adjustment that does not exist", func() {
+		revoked, err := db.Schema.RevokeAdjustment("00000000-0000-0000-0000-000000000001", "bob")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(revoked).To(BeNil())
+	})
+
+	It("should apply credits, debits and discounts to the billable events and totals", func() {
+		credit, err := db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.CreditAdjustment,
+			Description: "outage",
+			AmountExVAT: "3",
+			ValidFrom:   "2001-01-15",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.DiscountAdjustment,
+			Description: "pilot",
+			Percentage:  "10",
+			ValidFrom:   "2001-01-01",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+		revoked, err := db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.DebitAdjustment,
+			Description: "mistake",
+			AmountExVAT: "100",
+			ValidFrom:   "2001-01-15",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.RevokeAdjustment(revoked.ID, "bob")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{orgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		prices := map[string]string{}
+		for _, ev := range events {
+			prices[ev.ResourceType] = ev.Price.ExVAT
+		}
+		Expect(prices).To(Equal(map[string]string{
+			"app":      "10",
+			"discount": "-1.0",
+			"credit":   "-3",
+		}))
+		for _, ev := range events {
+			if ev.ResourceType == "credit" {
+				Expect(ev.ResourceGUID).To(Equal(credit.ID))
+				Expect(ev.PlanGUID).To(Equal(eventstore.AdjustmentPlanGUID))
+				Expect(ev.PlanName).To(Equal("adjustment"))
+				Expect(ev.SpaceGUID).To(Equal(orgGUID))
+				Expect(ev.Price.IncVAT).To(Equal("-3.6"))
+			}
+		}
+
+		totals, err := db.Schema.GetTotalCost(eventio.TotalCostFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(totals).To(Equal([]eventio.TotalCost{
+			{PlanGUID: eventstore.AdjustmentPlanGUID, Cost: -3},
+			{PlanGUID: eventstore.ComputePlanGUID, Cost: 9},
+		}))
+	})
+
+	It("should refuse to create or revoke adjustments of consolidated months", func() {
+		credit, err := db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.CreditAdjustment,
+			Description: "outage",
+			AmountExVAT: "3",
+			ValidFrom:   "2001-01-15",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Conn.Exec(`
+			insert into consolidation_history (consolidated_range, created_at)
+			values (tstzrange('2001-01-01', '2001-02-01'), now())
+		`)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.DiscountAdjustment,
+			Description: "pilot",
+			Percentage:  "10",
+			ValidFrom:   "2000-12-01",
+			ValidTo:     "2001-01-02",
+		}, "alice")
+		Expect(err).To(Equal(&eventio.ConsolidatedAdjustmentError{MonthStart: "2001-01-01"}))
+
+		_, err = db.Schema.RevokeAdjustment(credit.ID, "bob")
+		Expect(err).To(Equal(&eventio.ConsolidatedAdjustmentError{MonthStart: "2001-01-01"}))
+
+		adjustments, err := db.Schema.GetAdjustments(eventio.AdjustmentFilter{IncludeRevoked: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(adjustments).To(HaveLen(1))
+		Expect(adjustments[0].RevokedAt).To(BeEmpty())
+		audit, err := db.Schema.GetAdjustmentAudit(credit.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(audit).To(HaveLen(1))
+
+		_, err = db.Schema.CreateAdjustment(eventio.Adjustment{
+			OrgGUID:     orgGUID,
+			Kind:        eventio.DebitAdjustment,
+			Description: "support",
+			AmountExVAT: "5",
+			ValidFrom:   "2001-02-01",
+		}, "alice")
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -135,13 +135,36 @@ func (ber *BillableEventRows) Event() (*eventio.BillableEvent, error) {
 	return &event, nil
 }
 
+// appendLabelConditions adds a condition and its args for each label filter
+func appendLabelConditions(conditions []string, args []interface{}, labels []eventio.LabelFilter) ([]string, []interface{}) {
+	for _, label := range labels {
+		args = append(args, label.Key, label.Value)
+		conditions = append(conditions, fmt.Sprintf("labels->>$%d = $%d", len(args)-1, len(args)))
+	}
+	return conditions, args
+}
+
+// labelValueExpression selects the value of the label key from the labels
+// column, or null when no key is given
+func labelValueExpression(key string) string {
+	if key == "" {
+		return "null::text"
+	}
+	return "labels->>'" + strings.Replace(key, "'", "''", -1) + "'"
+}
+
 // WithBillableEvents wraps a given query with a subquery called
 // billable_events, containing the result of applying the given pricing
-// formula to the events for the given filter.
+// formula to the events for the given filter. The details of each price also
+// show how it was derived if the filter asks for an explanation.
 //
 // Other included tables are:
 //  - components_with_price: Components and formulas selected for this filter
-//  - filtered range: time range of the filter
+//  - filtered_range: time range of the filter
+//  - event_allocations: the charges of each event recharged by the cost
+//    allocation rules
+//  - valid_quota_definitions: the quota definitions with the range each
+//    version was valid for
 func WithBillableEvents(query string, filter eventio.EventFilter, args ...interface{}) (string, []interface{}, error) {
 	if err := filter.Validate(); err != nil {
 		return query, args, err
@@ -158,11 +181,33 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 	if len(orgPlaceholders) > 0 {
 		filterConditions = append(filterConditions, fmt.Sprintf("org_guid = any (values %s)", strings.Join(orgPlaceholders, ",")))
 	}
+	filterConditions, args = appendLabelConditions(filterConditions, args, filter.Labels)
 	filterQuery := ""
 	if len(filterConditions) > 0 {
 		filterQuery = " and " + strings.Join(filterConditions, " and ")
 	}
 
+	explainFields := ""
+	if filter.Explain {
+		explainFields = `,
+						'formula', component_formula,
+						'inputs', json_build_object(
+							'memory_in_mb', coalesce(memory_in_mb, 0)::bigint,
+							'storage_in_mb', coalesce(storage_in_mb, 0)::bigint,
+							'number_of_nodes', coalesce(number_of_nodes, 0)::bigint,
+							'time_in_seconds', to_seconds(duration)
+						),
+						'substituted_formula', explain_formula(
+							memory_in_mb,
+							storage_in_mb,
+							number_of_nodes,
+							duration,
+							component_formula
+						),
+						'currency_rate', (currency_rate)::text,
+						'plan_valid_from', plan_valid_from`
+	}
+
 	wrappedQuery := fmt.Sprintf(`
 		with
 		filtered_range as (
@@ -176,18 +221,22 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 				b.resource_type,
 				b.org_guid,
 				b.org_name,
+				b.quota_definition_guid,
 				b.space_guid,
 				b.space_name,
 				b.plan_guid,
 				b.plan_name,
+				b.plan_valid_from,
 				b.duration * filtered_range as duration,
 				b.number_of_nodes,
 				b.memory_in_mb,
 				b.storage_in_mb,
+				b.labels,
 				b.component_name,
 				b.component_formula,
 				b.vat_code,
 				b.vat_rate,
+				b.currency_rate,
 				'GBP' as currency_code,
 				(eval_formula(
 					b.memory_in_mb,
@@ -205,6 +254,56 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 			order by
 				lower(duration) asc
 		),
+		valid_quota_definitions as (
+			select
+				*,
+				tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+					partition by guid order by valid_from rows between current row and 1 following
+				)) as valid_for
+			from
+				quota_definitions
+		),
+		event_allocations as (
+			select
+				allocated_from as event_guid,
+				json_agg(json_build_object(
+					'org_guid', org_guid,
+					'space_guid', space_guid,
+					'ex_vat', (ex_vat)::text,
+					'inc_vat', (inc_vat)::text
+				) order by org_guid, space_guid) as allocations
+			from
+				(
+					select
+						a.allocated_from,
+						a.org_guid,
+						nullif(a.space_guid, a.org_guid) as space_guid,
+						sum(eval_formula(
+							a.memory_in_mb,
+							a.storage_in_mb,
+							a.number_of_nodes,
+							a.duration * filtered_range,
+							a.component_formula
+						) * a.currency_rate) as ex_vat,
+						sum(eval_formula(
+							a.memory_in_mb,
+							a.storage_in_mb,
+							a.number_of_nodes,
+							a.duration * filtered_range,
+							a.component_formula
+						) * a.currency_rate * (1 + a.vat_rate)) as inc_vat
+					from
+						filtered_range,
+						billable_event_components a
+					where
+						a.allocated_from in (select event_guid from components_with_price)
+						and a.duration && filtered_range
+					group by
+						a.allocated_from, a.org_guid, a.space_guid
+				) allocated_components
+			group by
+				allocated_from
+		),
 		billable_events as (
 			select
 				event_guid,
@@ -215,13 +314,14 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 				resource_type,
 				org_guid,
 				org_name,
-				null::uuid as quota_definition_guid,
+				quota_definition_guid,
 				space_guid,
 				space_name,
 				plan_guid,
 				number_of_nodes,
 				memory_in_mb,
 				storage_in_mb,
+				nullif(labels, '{}') as labels,
 				json_build_object(
 					'ex_vat', (sum(price_ex_vat))::text,
 					'inc_vat', (sum(price_ex_vat * (1 + vat_rate)))::text,
@@ -234,9 +334,29 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 						'inc_vat', (price_ex_vat * (1 + vat_rate))::text,
 						'vat_rate', (vat_rate)::text,
 						'vat_code', vat_code,
-						'currency_code', currency_code
+						'currency_code', currency_code%s
 					))
-				) as price
+				) as price,
+				(
+					select ea.allocations
+					from event_allocations ea
+					where ea.event_guid = components_with_price.event_guid
+				) as allocations,
+				(
+					select json_build_object(
+						'guid', vqd.guid,
+						'name', vqd.name,
+						'memory_limit', vqd.memory_limit,
+						'instance_memory_limit', vqd.instance_memory_limit,
+						'total_services', vqd.total_services,
+						'total_routes', vqd.total_routes,
+						'app_instance_limit', vqd.app_instance_limit,
+						'non_basic_services_allowed', vqd.non_basic_services_allowed
+					)
+					from valid_quota_definitions vqd
+					where vqd.guid = components_with_price.quota_definition_guid
+					and max(upper(components_with_price.duration)) <@ vqd.valid_for
+				) as quota_definition
 			from
 				components_with_price
 			group by
@@ -252,7 +372,8 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 				plan_guid,
 				number_of_nodes,
 				memory_in_mb,
-				storage_in_mb
+				storage_in_mb,
+				labels
 			order by
 				event_guid
 	  )
@@ -260,6 +381,7 @@ func WithBillableEvents(query string, filter eventio.EventFilter, args ...interf
 	  `,
 		durationArgPosition,
 		filterQuery,
+		explainFields,
 		query,
 	)
 
@@ -209,6 +209,71 @@ var _ = Describe("GetBillableEvents", func() {
 		Expect(rows.Next()).To(BeFalse(), "did not expect any more rows")
 	})
 
+	It("should explain how each price was derived when asked to", func() {
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * $number_of_nodes * 0.01",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Insert("app_usage_events",
+			testenv.Row{
+				"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+				"created_at":  "2001-01-01T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 2, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "8d9036c5-8367-497d-bb56-94bfcac6621a",
+				"created_at":  "2001-01-01T01:00Z",
+				"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 2, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+		)).To(Succeed())
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			Explain:    true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Price.Details).To(HaveLen(1))
+
+		component := events[0].Price.Details[0]
+		Expect(component.ExVAT).To(Equal("0.02"))
+		Expect(component.Formula).To(Equal("ceil($time_in_seconds/3600) * $number_of_nodes * 0.01"))
+		Expect(component.Inputs).To(Equal(&eventio.FormulaInputs{
+			MemoryInMB:    1024,
+			StorageInMB:   0,
+			NumberOfNodes: 2,
+			TimeInSeconds: 3600,
+		}))
+		Expect(component.SubstitutedFormula).To(MatchRegexp(`^ceil\(3600(\.0+)?/3600\) \* 2 \* 0\.01$`))
+		Expect(component.CurrencyRate).To(Equal("1"))
+		Expect(component.PlanValidFrom).To(Equal("2001-01-01T00:00:00+00:00"))
+
+		events, err = db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events[0].Price.Details[0].Formula).To(BeEmpty())
+		Expect(events[0].Price.Details[0].Inputs).To(BeNil())
+	})
+
 	/*-----------------------------------------------------------------------------------*
 	.                                                                                    .
 	       00:00       01:00                                                             .
@@ -1261,3 +1326,251 @@ var _ = Describe("GetBillableEvents", func() {
 		}))
 	})
 })
+
+var _ = Describe("GetBillableEvents quota definitions", func() {
+
+	var (
+		cfg eventstore.Config
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 0.01",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+	})
+
+	It("should include the quota definition of the org at the time of each event", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		trialQuotaGUID := "3dc3a8b8-8b48-4e7e-9e5b-4b0a3c7d0b0a"
+		paidQuotaGUID := "9f1c5b3e-0f4c-4c0e-9d1c-2c6e5c0a7e1b"
+		Expect(db.Insert("orgs",
+			testenv.Row{
+				"guid":                  orgGUID,
+				"valid_from":            "2001-01-01T00:00Z",
+				"name":                  "ORG1",
+				"created_at":            "2001-01-01T00:00Z",
+				"updated_at":            "2001-01-01T00:00Z",
+				"quota_definition_guid": trialQuotaGUID,
+			},
+			testenv.Row{
+				"guid":                  orgGUID,
+				"valid_from":            "2001-01-01T02:00Z",
+				"name":                  "ORG1",
+				"created_at":            "2001-01-01T00:00Z",
+				"updated_at":            "2001-01-01T02:00Z",
+				"quota_definition_guid": paidQuotaGUID,
+			},
+		)).To(Succeed())
+
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-01-01T01:00Z", "STOPPED"),
+			appEvent("2a6b4f5c-3f0e-4a59-8e0a-5c4c3e8b1d2f", "2001-01-01T03:00Z", "STARTED"),
+			appEvent("6b0e7c1d-8a3f-4b5e-9c2d-1e4f3a2b5c6d", "2001-01-01T04:00Z", "STOPPED"),
+		)).To(Succeed())
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].EventGUID).To(Equal("2a6b4f5c-3f0e-4a59-8e0a-5c4c3e8b1d2f"))
+		Expect(events[0].QuotaDefinitionGUID).To(Equal(paidQuotaGUID))
+		Expect(events[1].EventGUID).To(Equal("ee28a570-f485-48e1-87d0-98b7b8b66dfa"))
+		Expect(events[1].QuotaDefinitionGUID).To(Equal(trialQuotaGUID))
+	})
+
+	It("should include the quota tier valid when each event ended", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		quotaGUID := "3dc3a8b8-8b48-4e7e-9e5b-4b0a3c7d0b0a"
+		quota := func(validFrom, name string, memoryLimit int) testenv.Row {
+			return testenv.Row{
+				"guid":                       quotaGUID,
+				"valid_from":                 validFrom,
+				"name":                       name,
+				"memory_limit":               memoryLimit,
+				"instance_memory_limit":      -1,
+				"total_services":             10,
+				"total_routes":               1000,
+				"app_instance_limit":         -1,
+				"non_basic_services_allowed": true,
+				"created_at":                 "2001-01-01T00:00Z",
+				"updated_at":                 validFrom,
+			}
+		}
+		Expect(db.Insert("quota_definitions",
+			quota("2001-01-01T00:00Z", "small", 2048),
+			quota("2001-01-01T02:00Z", "large", 10240),
+		)).To(Succeed())
+		Expect(db.Insert("orgs", testenv.Row{
+			"guid":                  orgGUID,
+			"valid_from":            "2001-01-01T00:00Z",
+			"name":                  "ORG1",
+			"created_at":            "2001-01-01T00:00Z",
+			"updated_at":            "2001-01-01T00:00Z",
+			"quota_definition_guid": quotaGUID,
+		})).To(Succeed())
+
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-01-01T01:00Z", "STOPPED"),
+			appEvent("2a6b4f5c-3f0e-4a59-8e0a-5c4c3e8b1d2f", "2001-01-01T03:00Z", "STARTED"),
+			appEvent("6b0e7c1d-8a3f-4b5e-9c2d-1e4f3a2b5c6d", "2001-01-01T04:00Z", "STOPPED"),
+		)).To(Succeed())
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].EventGUID).To(Equal("2a6b4f5c-3f0e-4a59-8e0a-5c4c3e8b1d2f"))
+		Expect(events[0].QuotaDefinition).To(Equal(&eventio.QuotaDefinition{
+			GUID:                    quotaGUID,
+			Name:                    "large",
+			MemoryLimit:             10240,
+			InstanceMemoryLimit:     -1,
+			TotalServices:           10,
+			TotalRoutes:             1000,
+			AppInstanceLimit:        -1,
+			NonBasicServicesAllowed: true,
+		}))
+		Expect(events[1].EventGUID).To(Equal("ee28a570-f485-48e1-87d0-98b7b8b66dfa"))
+		Expect(events[1].QuotaDefinition).ToNot(BeNil())
+		Expect(events[1].QuotaDefinition.Name).To(Equal("small"))
+	})
+})
+
+var _ = Describe("GetBillableEvents labels", func() {
+
+	var (
+		cfg       eventstore.Config
+		orgGUID   = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		spaceGUID = "276f4886-ac40-492d-a8cd-b2646637ba76"
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.LabelKeys = []string{"team", "cost-centre"}
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 0.01",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+	})
+
+	It("should carry the configured labels of the org and space and filter by them", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Insert("org_metadata",
+			testenv.Row{
+				"guid":        orgGUID,
+				"valid_from":  "2001-01-01T00:00Z",
+				"labels":      json.RawMessage(`{"team": "billing", "unconfigured": "ignored"}`),
+				"annotations": json.RawMessage(`{"cost-centre": "1234"}`),
+				"created_at":  "2001-01-01T00:00Z",
+				"updated_at":  "2001-01-01T00:00Z",
+			},
+		)).To(Succeed())
+		Expect(db.Insert("space_metadata",
+			testenv.Row{
+				"guid":        spaceGUID,
+				"valid_from":  "2001-01-01T00:00Z",
+				"labels":      json.RawMessage(`{"cost-centre": "5678"}`),
+				"annotations": json.RawMessage(`{}`),
+				"created_at":  "2001-01-01T00:00Z",
+				"updated_at":  "2001-01-01T00:00Z",
+			},
+		)).To(Succeed())
+
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "` + spaceGUID + `", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-01-01T01:00Z", "STOPPED"),
+		)).To(Succeed())
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			Labels:     []eventio.LabelFilter{{Key: "team", Value: "billing"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Labels).To(Equal(map[string]string{
+			"team":        "billing",
+			"cost-centre": "5678",
+		}))
+
+		events, err = db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			Labels:     []eventio.LabelFilter{{Key: "team", Value: "platform"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(BeEmpty())
+
+		totals, err := db.Schema.GetTotalCost(eventio.TotalCostFilter{
+			GroupByLabel: "cost-centre",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(totals).To(Equal([]eventio.TotalCost{
+			{PlanGUID: eventstore.ComputePlanGUID, LabelValue: "5678", Cost: 0.01},
+		}))
+	})
+})
@@ -0,0 +1,82 @@
+package eventstore
+
+import (
+	"context"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.BillingContactReader = &EventStore{}
+
+// GetBillingContacts returns the billing managers and org managers of each org
+// as they were collected at the end of filter.Date. Orgs without any contacts
+// are omitted.
+func (s *EventStore) GetBillingContacts(filter eventio.BillingContactFilter) ([]eventio.OrgBillingContacts, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `
+		with
+		roles_at_date as (
+			select distinct on (org_guid, user_guid, role)
+				*
+			from
+				org_roles
+			where
+				valid_from < $1::date + interval '1 day'
+			order by
+				org_guid, user_guid, role, valid_from desc
+		),
+		orgs_at_date as (
+			select distinct on (guid)
+				guid, name
+			from
+				orgs
+			where
+				valid_from < $1::date + interval '1 day'
+			order by
+				guid, valid_from desc
+		)
+		select
+			r.org_guid,
+			coalesce(o.name, ''),
+			r.user_guid,
+			coalesce(r.username, ''),
+			r.role
+		from
+			roles_at_date r
+		left join
+			orgs_at_date o on o.guid = r.org_guid
+		where
+			not r.deleted
+			and (cardinality($2::uuid[]) = 0 or r.org_guid = any($2::uuid[]))
+		order by
+			o.name, r.org_guid, r.role, r.username, r.user_guid
+	`, filter.Date, pq.Array(filter.OrgGUIDs))
+	if err != nil {
+		return nil, wrapPqError(err, "get-billing-contacts")
+	}
+	defer rows.Close()
+
+	orgs := []eventio.OrgBillingContacts{}
+	for rows.Next() {
+		var orgGUID, orgName string
+		var contact eventio.BillingContact
+		if err := rows.Scan(&orgGUID, &orgName, &contact.UserGUID, &contact.Username, &contact.Role); err != nil {
+			return nil, err
+		}
+		if len(orgs) == 0 || orgs[len(orgs)-1].OrgGUID != orgGUID {
+			orgs = append(orgs, eventio.OrgBillingContacts{
+				OrgGUID:  orgGUID,
+				OrgName:  orgName,
+				Contacts: []eventio.BillingContact{},
+			})
+		}
+		last := &orgs[len(orgs)-1]
+		last.Contacts = append(last.Contacts, contact)
+	}
+	return orgs, rows.Err()
+}
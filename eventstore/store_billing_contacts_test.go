@@ -0,0 +1,101 @@
+package eventstore_test
+
+import (
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetBillingContacts", func() {
+
+	var (
+		db       *testenv.TempDB
+		org1GUID = "00000001-0000-0000-0000-000000000001"
+		org2GUID = "00000001-0000-0000-0000-000000000002"
+		user1    = "00000002-0000-0000-0000-000000000001"
+		user2    = "00000002-0000-0000-0000-000000000002"
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = testenv.Open(eventstore.Config{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Insert("orgs",
+			testenv.Row{"guid": org1GUID, "valid_from": "2001-01-01T00:00Z", "name": "org-1", "created_at": "2001-01-01T00:00Z", "updated_at": "2001-01-01T00:00Z"},
+			testenv.Row{"guid": org1GUID, "valid_from": "2001-03-01T00:00Z", "name": "org-1-renamed", "created_at": "2001-01-01T00:00Z", "updated_at": "2001-03-01T00:00Z"},
+			testenv.Row{"guid": org2GUID, "valid_from": "2001-01-01T00:00Z", "name": "org-2", "created_at": "2001-01-01T00:00Z", "updated_at": "2001-01-01T00:00Z"},
+		)).To(Succeed())
+
+		Expect(db.Insert("org_roles",
+			testenv.Row{"org_guid": org1GUID, "user_guid": user1, "role": "billing_manager", "valid_from": "2001-01-01T00:00Z", "username": "user1@example.com", "deleted": false},
+			testenv.Row{"org_guid": org1GUID, "user_guid": user1, "role": "billing_manager", "valid_from": "2001-02-01T00:00Z", "username": "user1@example.com", "deleted": true},
+			testenv.Row{"org_guid": org1GUID, "user_guid": user2, "role": "billing_manager", "valid_from": "2001-02-01T00:00Z", "username": "user2@example.com", "deleted": false},
+			testenv.Row{"org_guid": org1GUID, "user_guid": user1, "role": "org_manager", "valid_from": "2001-01-01T00:00Z", "username": "user1@example.com", "deleted": false},
+			testenv.Row{"org_guid": org2GUID, "user_guid": user2, "role": "org_manager", "valid_from": "2001-01-01T00:00Z", "username": "user2@example.com", "deleted": false},
+		)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should return the contacts of each org on the given date", func() {
+		contacts, err := db.Schema.GetBillingContacts(eventio.BillingContactFilter{
+			Date: "2001-01-15",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contacts).To(Equal([]eventio.OrgBillingContacts{
+			{
+				OrgGUID: org1GUID,
+				OrgName: "org-1",
+				Contacts: []eventio.BillingContact{
+					{UserGUID: user1, Username: "user1@example.com", Role: eventio.BillingManagerRole},
+					{UserGUID: user1, Username: "user1@example.com", Role: eventio.OrgManagerRole},
+				},
+			},
+			{
+				OrgGUID: org2GUID,
+				OrgName: "org-2",
+				Contacts: []eventio.BillingContact{
+					{UserGUID: user2, Username: "user2@example.com", Role: eventio.OrgManagerRole},
+				},
+			},
+		}))
+	})
+
+	It("should not return contacts whose role was removed before the given date", func() {
+		contacts, err := db.Schema.GetBillingContacts(eventio.BillingContactFilter{
+			Date:     "2001-03-15",
+			OrgGUIDs: []string{org1GUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contacts).To(Equal([]eventio.OrgBillingContacts{
+			{
+				OrgGUID: org1GUID,
+				OrgName: "org-1-renamed",
+				Contacts: []eventio.BillingContact{
+					{UserGUID: user2, Username: "user2@example.com", Role: eventio.BillingManagerRole},
+					{UserGUID: user1, Username: "user1@example.com", Role: eventio.OrgManagerRole},
+				},
+			},
+		}))
+	})
+
+	It("should return no contacts before any were collected", func() {
+		contacts, err := db.Schema.GetBillingContacts(eventio.BillingContactFilter{
+			Date: "2000-01-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contacts).To(BeEmpty())
+	})
+
+	It("should require a valid date", func() {
+		_, err := db.Schema.GetBillingContacts(eventio.BillingContactFilter{
+			Date: "bad-date",
+		})
+		Expect(err).To(MatchError(ContainSubstring("a valid date filter value is required")))
+	})
+})
@@ -7,10 +7,25 @@ import (
 )
 
 type Config struct {
-	VATRates           []eventio.VATRate      `json:"vat_rates"`            // vat rate
-	CurrencyRates      []eventio.CurrencyRate `json:"currency_rates"`       // exchange rates
-	PricingPlans       []eventio.PricingPlan  `json:"pricing_plans"`        // dataset to generate prices from
-	IgnoreMissingPlans bool                   `json:"ignore_missing_plans"` // if true, will generate missing plans that emit "£0", useful for testing
+	VATRates            []eventio.VATRate            `json:"vat_rates"`             // vat rate
+	CurrencyRates       []eventio.CurrencyRate       `json:"currency_rates"`        // exchange rates
+	PricingPlans        []eventio.PricingPlan        `json:"pricing_plans"`         // dataset to generate prices from
+	IgnoreMissingPlans  bool                         `json:"ignore_missing_plans"`  // if true, will generate missing plans that emit "£0", useful for testing
+	LabelKeys           []string                     `json:"label_keys"`            // org and space label keys to include in billable events
+	CostAllocationRules []eventio.CostAllocationRule `json:"cost_allocation_rules"` // recharges of shared resources to other orgs or spaces
+	Contracts           []eventio.Contract           `json:"contracts"`             // minimum fees and committed spend of orgs
+	ServiceCategories   []eventio.ServiceCategory    `json:"service_categories"`    // groups of plans in the usage and adoption report
+	ExcludedReportOrgs  []string                     `json:"excluded_report_orgs"`  // org name patterns left out of the usage and adoption report
+	AnomalyDetection    AnomalyDetectionConfig       `json:"anomaly_detection"`     // sensitivity of the cost anomaly detection
+}
+
+// AnomalyDetectionConfig controls which daily costs are reported as
+// anomalies. Zero values are replaced with the defaults.
+type AnomalyDetectionConfig struct {
+	Sensitivity      float64 `json:"sensitivity"`        // how many standard deviations above the baseline mean a cost must be
+	BaselineDays     int     `json:"baseline_days"`      // how many days before each day make up its baseline
+	DetectionDays    int     `json:"detection_days"`     // how many of the latest complete days are checked on each refresh
+	MinimumDailyCost float64 `json:"minimum_daily_cost"` // daily costs below this are never anomalies
 }
 
 func (cfg *Config) AddPlan(p eventio.PricingPlan) {
@@ -25,8 +40,22 @@ func (cfg *Config) AddCurrencyRate(c eventio.CurrencyRate) {
 	cfg.CurrencyRates = append(cfg.CurrencyRates, c)
 }
 
+func (cfg *Config) AddContract(c eventio.Contract) {
+	cfg.Contracts = append(cfg.Contracts, c)
+}
+
+func (cfg *Config) AddServiceCategory(c eventio.ServiceCategory) {
+	cfg.ServiceCategories = append(cfg.ServiceCategories, c)
+}
+
+func (cfg *Config) AddCostAllocationRule(r eventio.CostAllocationRule) {
+	cfg.CostAllocationRules = append(cfg.CostAllocationRules, r)
+}
+
 var _ eventio.PricingPlanReader = &EventStore{}
 
+// GetPricingPlans returns the pricing plans valid during the filter's range,
+// except the adjustment plan which only prices credits and debits
 func (s *EventStore) GetPricingPlans(filter eventio.TimeRangeFilter) ([]eventio.PricingPlan, error) {
 	if err := filter.Validate(); err != nil {
 		return nil, err
@@ -68,6 +97,7 @@ func (s *EventStore) GetPricingPlans(filter eventio.TimeRangeFilter) ([]eventio.
 			and ppc.valid_from = vpp.valid_from
 		where
 			vpp.valid_for && tstzrange($1, $2)
+			and vpp.plan_guid <> $3::uuid
 		group by
 			vpp.plan_guid,
 			vpp.valid_from,
@@ -77,7 +107,7 @@ func (s *EventStore) GetPricingPlans(filter eventio.TimeRangeFilter) ([]eventio.
 			vpp.storage_in_mb
 		order by
 			valid_from
-	`, filter.RangeStart, filter.RangeStop)
+	`, filter.RangeStart, filter.RangeStop, AdjustmentPlanGUID)
 	if err != nil {
 		return nil, err
 	}
@@ -10,6 +10,7 @@ import (
 
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
 )
 
 const (
@@ -41,8 +42,18 @@ func (e *EventStore) getConsolidatedBillableEventRows(tx *sql.Tx, filter eventio
 	if err != nil {
 		return nil, err
 	}
+	// each month is a separate partition, listing the months rather than
+	// checking for overlaps lets postgres skip the partitions of other months
+	months, err := filter.SplitByMonth()
+	if err != nil {
+		return nil, err
+	}
+	monthRanges := []string{}
+	for _, month := range months {
+		monthRanges = append(monthRanges, fmt.Sprintf("[%s, %s)", month.RangeStart, month.RangeStop))
+	}
 	args := []interface{}{
-		fmt.Sprintf("[%s, %s)", filter.RangeStart, filter.RangeStop), // $1
+		pq.Array(monthRanges), // $1
 	}
 	filterConditions := []string{}
 	orgPlaceholders := []string{}
@@ -53,6 +64,7 @@ func (e *EventStore) getConsolidatedBillableEventRows(tx *sql.Tx, filter eventio
 	if len(orgPlaceholders) > 0 {
 		filterConditions = append(filterConditions, fmt.Sprintf("org_guid = any (values %s)", strings.Join(orgPlaceholders, ",")))
 	}
+	filterConditions, args = appendLabelConditions(filterConditions, args, filter.Labels)
 	filterQuery := ""
 	if len(filterConditions) > 0 {
 		filterQuery = " and " + strings.Join(filterConditions, " and ")
@@ -76,11 +88,14 @@ func (e *EventStore) getConsolidatedBillableEventRows(tx *sql.Tx, filter eventio
 			number_of_nodes,
 			memory_in_mb,
 			storage_in_mb,
-			price
+			labels,
+			price,
+			allocations,
+			quota_definition
 		from
 			consolidated_billable_events
  		where
-			consolidated_range && $1::tstzrange
+			consolidated_range = any($1::tstzrange[])
 			%s
 		order by event_guid
 	`, filterQuery), args...)
@@ -136,13 +151,26 @@ func (e *EventStore) IsRangeConsolidated(filter eventio.EventFilter) (bool, erro
 	return result, tx.Commit()
 }
 
+// isRangeConsolidated returns true if the consolidated billable events of
+// the range can be read, which is not the case once the month's partition has
+// been detached
 func (e *EventStore) isRangeConsolidated(tx *sql.Tx, filter eventio.EventFilter) (bool, error) {
+	return e.queryConsolidationHistory(tx, filter, "SELECT 1 FROM consolidation_history where consolidated_range=$1::tstzrange and detached_at is null")
+}
+
+// hasRangeBeenConsolidated returns true if the range has ever been
+// consolidated, including months whose partition has since been detached
+func (e *EventStore) hasRangeBeenConsolidated(tx *sql.Tx, filter eventio.EventFilter) (bool, error) {
+	return e.queryConsolidationHistory(tx, filter, "SELECT 1 FROM consolidation_history where consolidated_range=$1::tstzrange")
+}
+
+func (e *EventStore) queryConsolidationHistory(tx *sql.Tx, filter eventio.EventFilter, query string) (bool, error) {
 	if err := filter.Validate(); err != nil {
 		return false, err
 	}
 	startTime := time.Now()
 	rows, err := tx.Query(
-		"SELECT 1 FROM consolidation_history where consolidated_range=$1::tstzrange",
+		query,
 		fmt.Sprintf("[%s, %s)", filter.RangeStart, filter.RangeStop),
 	)
 	elapsed := time.Since(startTime)
@@ -162,7 +190,13 @@ func (e *EventStore) isRangeConsolidated(tx *sql.Tx, filter eventio.EventFilter)
 }
 
 func (e *EventStore) ConsolidateAll() error {
-	tx, err := e.db.Begin()
+	return e.ConsolidateAllContext(context.Background())
+}
+
+// ConsolidateAllContext is like ConsolidateAll but is abandoned if ctx is
+// cancelled
+func (e *EventStore) ConsolidateAllContext(ctx context.Context) error {
+	tx, err := e.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -181,7 +215,7 @@ func (e *EventStore) consolidateAll(tx *sql.Tx) error {
 	}
 	endAt := os.Getenv("CONSOLIDATION_END_DATE")
 	if endAt == "" {
-		endAt = time.Now().AddDate(0, 0, -5).Format("2006-01-02")
+		endAt = time.Now().AddDate(0, 0, -eventio.ConsolidationDelayDays).Format("2006-01-02")
 	}
 	return e.consolidateFullMonths(tx, startAt, endAt)
 }
@@ -219,7 +253,7 @@ func (e *EventStore) consolidateFullMonths(tx *sql.Tx, startAt string, endAt str
 		return err
 	}
 	for _, filter := range monthFilters {
-		isConsolidated, err := e.isRangeConsolidated(tx, filter)
+		isConsolidated, err := e.hasRangeBeenConsolidated(tx, filter)
 		if err != nil {
 			return err
 		}
@@ -244,7 +278,12 @@ func (e *EventStore) consolidateFullMonths(tx *sql.Tx, startAt string, endAt str
 }
 
 func (e *EventStore) Consolidate(filter eventio.EventFilter) error {
-	tx, err := e.db.Begin()
+	return e.ConsolidateContext(context.Background(), filter)
+}
+
+// ConsolidateContext is like Consolidate but is abandoned if ctx is cancelled
+func (e *EventStore) ConsolidateContext(ctx context.Context, filter eventio.EventFilter) error {
+	tx, err := e.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -285,6 +324,18 @@ func (e *EventStore) consolidate(tx *sql.Tx, filter eventio.EventFilter) error {
 		"elapsed": int64(elapsed),
 	})
 
+	var partition string
+	err = tx.QueryRow(
+		"select create_consolidated_billable_events_partition($1::tstzrange)",
+		fmt.Sprintf("[%s, %s)", filter.RangeStart, filter.RangeStop),
+	).Scan(&partition)
+	if err != nil {
+		e.logger.Error("consolidation-partition-query", err, lager.Data{
+			"filter": filter,
+		})
+		return err
+	}
+
 	query, args, err := WithBillableEvents(`
 			insert into consolidated_billable_events (
 				consolidated_range,
@@ -303,7 +354,10 @@ func (e *EventStore) consolidate(tx *sql.Tx, filter eventio.EventFilter) error {
 				number_of_nodes,
 				memory_in_mb,
 				storage_in_mb,
-				price
+				labels,
+				price,
+				allocations,
+				quota_definition
 			)
 			select
 				filtered_range,
@@ -322,7 +376,10 @@ func (e *EventStore) consolidate(tx *sql.Tx, filter eventio.EventFilter) error {
 				billable_events.number_of_nodes,
 				billable_events.memory_in_mb,
 				billable_events.storage_in_mb,
-				billable_events.price
+				billable_events.labels,
+				billable_events.price,
+				billable_events.allocations,
+				billable_events.quota_definition
 			from
 				billable_events,
 				filtered_range
@@ -344,13 +401,115 @@ func (e *EventStore) consolidate(tx *sql.Tx, filter eventio.EventFilter) error {
 		return err
 	}
 	e.logger.Info("consolidation-insert-query", lager.Data{
-		"filter":  filter,
-		"elapsed": int64(elapsed),
+		"filter":    filter,
+		"partition": partition,
+		"elapsed":   int64(elapsed),
 	})
 
 	return nil
 }
 
+// DetachConsolidatedMonths detaches the partitions of consolidated billable
+// events for the months that ended on or before the given time and returns
+// the names of the tables they were left in. The months stay consolidated so
+// they are not consolidated again, but their billable events are calculated
+// from the raw events until the table is attached again, so months whose raw
+// events have been archived, and not restored, cannot be detached.
+func (e *EventStore) DetachConsolidatedMonths(ctx context.Context, before time.Time) ([]string, error) {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var archived string
+	err = tx.QueryRow(`
+		select
+			to_char(lower(ch.consolidated_range), 'YYYY-MM-DD')
+		from
+			consolidation_history ch
+		where
+			upper(ch.consolidated_range) <= $1
+			and ch.detached_at is null
+			and exists (
+				select 1 from raw_event_archives a
+				where a.first_created_at < upper(ch.consolidated_range)
+				and a.restored_at is null
+			)
+		order by
+			ch.consolidated_range
+		limit 1
+	`, before).Scan(&archived)
+	if err == nil {
+		return nil, fmt.Errorf("cannot detach the month starting %s because its raw events have been archived", archived)
+	} else if err != sql.ErrNoRows {
+		return nil, wrapPqError(err, "detach-consolidated-months")
+	}
+
+	rows, err := tx.Query(`
+		select
+			c.relname,
+			ch.consolidated_range::text
+		from
+			consolidation_history ch
+		inner join
+			pg_class c on c.relname = 'consolidated_billable_events_' || to_char(lower(ch.consolidated_range), 'YYYY_MM')
+		inner join
+			pg_inherits i on i.inhrelid = c.oid
+			and i.inhparent = 'consolidated_billable_events'::regclass
+		where
+			upper(ch.consolidated_range) <= $1
+			and ch.detached_at is null
+		order by
+			ch.consolidated_range
+	`, before)
+	if err != nil {
+		return nil, wrapPqError(err, "detach-consolidated-months")
+	}
+	defer rows.Close()
+	partitions := map[string]string{}
+	names := []string{}
+	for rows.Next() {
+		var name, consolidatedRange string
+		if err := rows.Scan(&name, &consolidatedRange); err != nil {
+			return nil, err
+		}
+		partitions[name] = consolidatedRange
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, name := range names {
+		startTime := time.Now()
+		_, err := tx.Exec(fmt.Sprintf(
+			"alter table consolidated_billable_events detach partition %s",
+			pq.QuoteIdentifier(name),
+		))
+		if err == nil {
+			_, err = tx.Exec(`
+				update consolidation_history set detached_at = now() where consolidated_range = $1::tstzrange
+			`, partitions[name])
+		}
+		if err != nil {
+			e.logger.Error("detach-consolidated-month", err, lager.Data{
+				"partition": name,
+			})
+			return nil, wrapPqError(err, "detach-consolidated-months")
+		}
+		e.logger.Info("detach-consolidated-month", lager.Data{
+			"partition": name,
+			"elapsed":   int64(time.Since(startTime)),
+		})
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 func checkMonthBoundary(value string) error {
 	rangeStart, err := time.Parse("2006-01-02", value)
 	if err != nil {
@@ -1,6 +1,7 @@
 package eventstore_test
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -355,3 +356,66 @@ var _ = Describe("ConsolidateFullMonths", func() {
 		Expect(consolidatedEventsAfterTwoConsolidations).NotTo(Equal(billableEvents))
 	})
 })
+
+var _ = Describe("Consolidated billable event partitions", func() {
+	var (
+		cfg      eventstore.Config
+		scenario *testenv.TestScenario
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		scenario = testenv.NewTestScenario("2018-01-01T00:00")
+		scenario.AddComputePlan()
+		scenario.AppLifeCycle("org1", "space1", "app1",
+			testenv.EventInfo{Delta: "+0h", State: "STARTED"},
+			testenv.EventInfo{Delta: "+1000h", State: "STOPPED"},
+		)
+	})
+
+	It("should keep each consolidated month in its own partition and detach old ones", func() {
+		db, err := scenario.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+		Expect(db.Schema.ConsolidateFullMonths("2018-01-01", "2018-03-01")).To(Succeed())
+
+		var count int
+		err = db.Conn.QueryRow(`select count(*) from consolidated_billable_events_2018_02`).Scan(&count)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		bothMonths := eventio.EventFilter{RangeStart: "2018-01-01", RangeStop: "2018-03-01"}
+		events, err := db.Schema.GetConsolidatedBillableEvents(bothMonths)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+
+		detached, err := db.Schema.DetachConsolidatedMonths(context.Background(), time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detached).To(Equal([]string{"consolidated_billable_events_2018_01"}))
+
+		january := eventio.EventFilter{RangeStart: "2018-01-01", RangeStop: "2018-02-01"}
+		isConsolidated, err := db.Schema.IsRangeConsolidated(january)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isConsolidated).To(BeFalse())
+
+		events, err = db.Schema.GetConsolidatedBillableEvents(bothMonths)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+
+		err = db.Conn.QueryRow(`select count(*) from consolidated_billable_events_2018_01`).Scan(&count)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		By("not consolidating the detached month again")
+		Expect(db.Schema.ConsolidateFullMonths("2018-01-01", "2018-03-01")).To(Succeed())
+		events, err = db.Schema.GetConsolidatedBillableEvents(january)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(BeEmpty())
+
+		detached, err = db.Schema.DetachConsolidatedMonths(context.Background(), time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detached).To(BeEmpty())
+	})
+})
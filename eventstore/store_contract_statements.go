@@ -0,0 +1,162 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.ContractStatementReader = &EventStore{}
+
+// GetContractStatements returns a statement for each month of the filter
+// range for every requested org that has a contract. The actual charges of a
+// month are taken from the consolidated billable events once the month has
+// been consolidated. Commitments are drawn down from the start of each
+// contract, so months before the filter range are included in the
+// calculation but not returned. A contract with neither a minimum fee nor a
+// commitment ends the previous contract without producing statements.
+func (s *EventStore) GetContractStatements(filter eventio.EventFilter) ([]eventio.ContractStatement, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	startTime := time.Now()
+	rows, err := queryJSON(tx, `
+		with
+		valid_contracts as (
+			select
+				*,
+				tstzrange(valid_from, lead(valid_from, 1, 'infinity') over (
+					partition by org_guid order by valid_from rows between current row and 1 following
+				)) as valid_for
+			from
+				contracts
+			where
+				cardinality($3::uuid[]) = 0 or org_guid = any($3::uuid[])
+		),
+		contract_months as (
+			select
+				vc.org_guid,
+				vc.valid_from,
+				vc.minimum_monthly_fee,
+				vc.committed_amount,
+				tstzrange(m.month, m.month + interval '1 month') as month
+			from
+				valid_contracts vc,
+				generate_series(
+					lower(vc.valid_for),
+					least(upper(vc.valid_for), $2::timestamptz) - interval '1 second',
+					interval '1 month'
+				) m(month)
+		),
+		monthly_actuals as (
+			select
+				cm.*,
+				(case
+					when exists (
+						select 1 from consolidation_history ch
+						where ch.consolidated_range = cm.month
+						and ch.detached_at is null
+					)
+					then (
+						select coalesce(sum((cbe.price->>'ex_vat')::numeric), 0)
+						from consolidated_billable_events cbe
+						where cbe.consolidated_range = cm.month
+						and cbe.org_guid = cm.org_guid
+					)
+					else (
+						select coalesce(sum(eval_formula(
+							b.memory_in_mb,
+							b.storage_in_mb,
+							b.number_of_nodes,
+							b.duration * cm.month,
+							b.component_formula
+						) * b.currency_rate), 0)
+						from billable_event_components b
+						where b.org_guid = cm.org_guid
+						and b.duration && cm.month
+					)
+				end) as actual
+			from
+				contract_months cm
+		),
+		drawdowns as (
+			select
+				*,
+				greatest(0, least(actual, committed_amount - coalesce(sum(greatest(0, actual)) over (
+					partition by org_guid, valid_from order by month rows between unbounded preceding and 1 preceding
+				), 0))) as drawdown,
+				greatest(0, committed_amount - sum(greatest(0, actual)) over (
+					partition by org_guid, valid_from order by month rows between unbounded preceding and current row
+				)) as remaining_commitment,
+				greatest(0, minimum_monthly_fee - actual) as shortfall
+			from
+				monthly_actuals
+		)
+		select
+			d.org_guid,
+			coalesce((
+				select o.name from orgs o
+				where o.guid = d.org_guid
+				order by o.valid_from desc
+				limit 1
+			), d.org_guid::text) as org_name,
+			to_char(lower(d.month), 'YYYY-MM-DD') as month,
+			to_char(d.valid_from, 'YYYY-MM-DD') as contract_valid_from,
+			d.actual::text as actual_ex_vat,
+			d.minimum_monthly_fee::text as minimum_fee_ex_vat,
+			d.shortfall::text as shortfall_ex_vat,
+			d.committed_amount::text as committed_ex_vat,
+			d.drawdown::text as drawdown_ex_vat,
+			d.remaining_commitment::text as remaining_commitment_ex_vat,
+			(d.actual - d.drawdown)::text as overage_ex_vat,
+			(d.actual - d.drawdown + d.shortfall)::text as amount_due_ex_vat
+		from
+			drawdowns d
+		where
+			upper(d.month) > $1::timestamptz
+			and (d.minimum_monthly_fee > 0 or d.committed_amount > 0)
+		order by
+			d.org_guid, d.month
+	`, filter.RangeStart, filter.RangeStop, pq.Array(filter.OrgGUIDs))
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("get-contract-statements-query", err, lager.Data{
+			"filter":  filter,
+			"elapsed": int64(elapsed),
+		})
+		return nil, wrapPqError(err, "get-contract-statements")
+	}
+	s.logger.Info("get-contract-statements-query", lager.Data{
+		"filter":  filter,
+		"elapsed": int64(elapsed),
+	})
+	defer rows.Close()
+
+	statements := []eventio.ContractStatement{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var statement eventio.ContractStatement
+		if err := json.Unmarshal(b, &statement); err != nil {
+			return nil, fmt.Errorf("failed to decode contract statement: %s", err)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, rows.Err()
+}
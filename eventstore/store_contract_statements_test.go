@@ -0,0 +1,131 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetContractStatements", func() {
+
+	var (
+		cfg     eventstore.Config
+		orgGUID = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 1",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+	})
+
+	// open runs an app for 40 hours in each of January, February and March
+	open := func() *testenv.TempDB {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("00000000-0000-0000-0001-000000000001", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("00000000-0000-0000-0001-000000000002", "2001-01-02T16:00Z", "STOPPED"),
+			appEvent("00000000-0000-0000-0001-000000000003", "2001-02-01T00:00Z", "STARTED"),
+			appEvent("00000000-0000-0000-0001-000000000004", "2001-02-02T16:00Z", "STOPPED"),
+			appEvent("00000000-0000-0000-0001-000000000005", "2001-03-01T00:00Z", "STARTED"),
+			appEvent("00000000-0000-0000-0001-000000000006", "2001-03-02T16:00Z", "STOPPED"),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+		return db
+	}
+
+	It("should charge the shortfall against the minimum monthly fee", func() {
+		cfg.AddContract(eventio.Contract{
+			OrgGUID:           orgGUID,
+			ValidFrom:         "2001-01-01",
+			MinimumMonthlyFee: 50,
+		})
+		db := open()
+		defer db.Close()
+
+		statements, err := db.Schema.GetContractStatements(eventio.EventFilter{
+			RangeStart: "2001-02-01",
+			RangeStop:  "2001-03-01",
+			OrgGUIDs:   []string{orgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statements).To(HaveLen(1))
+		Expect(statements[0].Month).To(Equal("2001-02-01"))
+		Expect(statements[0].ActualExVAT).To(Equal("40"))
+		Expect(statements[0].ShortfallExVAT).To(Equal("10"))
+		Expect(statements[0].AmountDueExVAT).To(Equal("50"))
+	})
+
+	It("should draw down the commitment from the start of the contract", func() {
+		cfg.AddContract(eventio.Contract{
+			OrgGUID:         orgGUID,
+			ValidFrom:       "2001-01-01",
+			CommittedAmount: 100,
+		})
+		db := open()
+		defer db.Close()
+
+		statements, err := db.Schema.GetContractStatements(eventio.EventFilter{
+			RangeStart: "2001-02-01",
+			RangeStop:  "2001-04-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statements).To(HaveLen(2))
+
+		Expect(statements[0].Month).To(Equal("2001-02-01"))
+		Expect(statements[0].DrawdownExVAT).To(Equal("40"))
+		Expect(statements[0].RemainingCommitmentExVAT).To(Equal("20"))
+		Expect(statements[0].OverageExVAT).To(Equal("0"))
+
+		Expect(statements[1].Month).To(Equal("2001-03-01"))
+		Expect(statements[1].DrawdownExVAT).To(Equal("20"))
+		Expect(statements[1].RemainingCommitmentExVAT).To(Equal("0"))
+		Expect(statements[1].OverageExVAT).To(Equal("20"))
+		Expect(statements[1].AmountDueExVAT).To(Equal("20"))
+	})
+
+	It("should only return statements for orgs with a contract", func() {
+		db := open()
+		defer db.Close()
+
+		statements, err := db.Schema.GetContractStatements(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-04-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statements).To(BeEmpty())
+	})
+
+	It("should require contracts to start at the beginning of a month", func() {
+		cfg.AddContract(eventio.Contract{
+			OrgGUID:           orgGUID,
+			ValidFrom:         "2001-01-15",
+			MinimumMonthlyFee: 50,
+		})
+		_, err := testenv.Open(cfg)
+		Expect(err).To(MatchError(ContainSubstring("invalid contract")))
+	})
+})
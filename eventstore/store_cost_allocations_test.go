@@ -0,0 +1,208 @@
+package eventstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cost allocation", func() {
+
+	var (
+		cfg          eventstore.Config
+		orgGUID      = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		orgAGUID     = "00000001-0000-0000-0000-00000000000a"
+		orgBGUID     = "00000001-0000-0000-0000-00000000000b"
+		spaceBGUID   = "00000002-0000-0000-0000-00000000000b"
+		resourceGUID = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 1",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddCostAllocationRule(eventio.CostAllocationRule{
+			ResourceGUID: resourceGUID,
+			ValidFrom:    "2001-01-01",
+			Allocations: []eventio.CostAllocation{
+				{OrgGUID: orgAGUID, Share: 0.4},
+				{OrgGUID: orgBGUID, SpaceGUID: spaceBGUID, Share: 0.6},
+			},
+		})
+	})
+
+	appEvent := func(guid, createdAt, state string) testenv.Row {
+		return testenv.Row{
+			"guid":        guid,
+			"created_at":  createdAt,
+			"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "` + resourceGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+		}
+	}
+
+	insertEvents := func(db *testenv.TempDB) {
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-01T00:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-01-01T10:00Z", "STOPPED"),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+	}
+
+	costsByOrg := func(db *testenv.TempDB, filter eventio.EventFilter) map[string]float64 {
+		events, err := db.Schema.GetBillableEvents(filter)
+		Expect(err).ToNot(HaveOccurred())
+		costs := map[string]float64{}
+		for _, ev := range events {
+			price, err := strconv.ParseFloat(ev.Price.ExVAT, 64)
+			Expect(err).ToNot(HaveOccurred())
+			costs[ev.OrgGUID] += price
+		}
+		return costs
+	}
+
+	It("should show the original price and the allocated charges", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+		insertEvents(db)
+
+		events, err := db.Schema.GetBillableEvents(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{orgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		original := events[0]
+		if original.Price.ExVAT != "10" {
+			original = events[1]
+		}
+		Expect(original.Price.ExVAT).To(Equal("10"))
+		Expect(original.Allocations).To(HaveLen(2))
+		Expect(original.Allocations[0].OrgGUID).To(Equal(orgAGUID))
+		Expect(original.Allocations[0].SpaceGUID).To(BeEmpty())
+		Expect(original.Allocations[1].OrgGUID).To(Equal(orgBGUID))
+		Expect(original.Allocations[1].SpaceGUID).To(Equal(spaceBGUID))
+		for i, expected := range []float64{4, 6} {
+			exVAT, err := strconv.ParseFloat(original.Allocations[i].ExVAT, 64)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exVAT).To(BeNumerically("~", expected, 0.001))
+		}
+	})
+
+	It("should charge the allocated costs to the recipients and take them off the owner", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+		insertEvents(db)
+
+		january := eventio.EventFilter{RangeStart: "2001-01-01", RangeStop: "2001-02-01"}
+		costs := costsByOrg(db, january)
+		Expect(costs[orgGUID]).To(BeNumerically("~", 0, 0.001))
+		Expect(costs[orgAGUID]).To(BeNumerically("~", 4, 0.001))
+		Expect(costs[orgBGUID]).To(BeNumerically("~", 6, 0.001))
+
+		january.OrgGUIDs = []string{orgBGUID}
+		events, err := db.Schema.GetBillableEvents(january)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].ResourceGUID).To(Equal(resourceGUID))
+		Expect(events[0].SpaceGUID).To(Equal(spaceBGUID))
+
+		january.OrgGUIDs = []string{orgAGUID}
+		events, err = db.Schema.GetBillableEvents(january)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].SpaceGUID).To(Equal(orgAGUID))
+	})
+
+	It("should split components at the boundaries of the rules", func() {
+		cfg.AddCostAllocationRule(eventio.CostAllocationRule{
+			ResourceGUID: resourceGUID,
+			ValidFrom:    "2001-02-01",
+			Allocations: []eventio.CostAllocation{
+				{OrgGUID: orgAGUID, Share: 1},
+			},
+		})
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+		Expect(db.Insert("app_usage_events",
+			appEvent("ee28a570-f485-48e1-87d0-98b7b8b66dfa", "2001-01-31T20:00Z", "STARTED"),
+			appEvent("8d9036c5-8367-497d-bb56-94bfcac6621a", "2001-02-01T04:00Z", "STOPPED"),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		costs := costsByOrg(db, eventio.EventFilter{RangeStart: "2001-01-01", RangeStop: "2001-03-01"})
+		Expect(costs[orgGUID]).To(BeNumerically("~", 0, 0.001))
+		Expect(costs[orgAGUID]).To(BeNumerically("~", 0.4*4+4, 0.001))
+		Expect(costs[orgBGUID]).To(BeNumerically("~", 0.6*4, 0.001))
+	})
+
+	It("should freeze the allocations when the month is consolidated", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+		insertEvents(db)
+
+		filter := eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+		}
+		Expect(db.Schema.Consolidate(filter)).To(Succeed())
+
+		By("changing the allocation rule")
+		cfg.CostAllocationRules = []eventio.CostAllocationRule{{
+			ResourceGUID: resourceGUID,
+			ValidFrom:    "2001-01-01",
+			Allocations: []eventio.CostAllocation{
+				{OrgGUID: orgAGUID, Share: 1},
+			},
+		}}
+		store := eventstore.New(context.Background(), db.Conn, lager.NewLogger("test"), cfg)
+		Expect(store.Init()).To(Succeed())
+
+		filter.OrgGUIDs = []string{orgGUID}
+		events, err := store.GetBillableEvents(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(allocationCounts(events)).To(ConsistOf(0, 1))
+
+		consolidatedEvents, err := store.GetConsolidatedBillableEvents(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consolidatedEvents).To(HaveLen(2))
+		Expect(allocationCounts(consolidatedEvents)).To(ConsistOf(0, 2))
+	})
+
+	It("should require the shares of a rule to add up to 1", func() {
+		cfg.CostAllocationRules[0].Allocations[1].Share = 0.5
+
+		_, err := testenv.Open(cfg)
+		Expect(err).To(MatchError(ContainSubstring("must add up to 1")))
+	})
+})
+
+func allocationCounts(events []eventio.BillableEvent) []int {
+	counts := []int{}
+	for _, ev := range events {
+		counts = append(counts, len(ev.Allocations))
+	}
+	return counts
+}
@@ -0,0 +1,321 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+const (
+	DefaultAnomalySensitivity      = 3
+	DefaultAnomalyBaselineDays     = 14
+	DefaultAnomalyDetectionDays    = 3
+	DefaultAnomalyMinimumDailyCost = 1
+)
+
+var _ eventio.CostAnomalyReader = &EventStore{}
+
+func (cfg AnomalyDetectionConfig) withDefaults() AnomalyDetectionConfig {
+	if cfg.Sensitivity <= 0 {
+		cfg.Sensitivity = DefaultAnomalySensitivity
+	}
+	if cfg.BaselineDays <= 0 {
+		cfg.BaselineDays = DefaultAnomalyBaselineDays
+	}
+	if cfg.DetectionDays <= 0 {
+		cfg.DetectionDays = DefaultAnomalyDetectionDays
+	}
+	if cfg.MinimumDailyCost <= 0 {
+		cfg.MinimumDailyCost = DefaultAnomalyMinimumDailyCost
+	}
+	return cfg
+}
+
+// detectCostAnomalies checks the latest complete days of the billable event
+// components for orgs, services (plans within an org) and resources whose
+// daily cost is more than Sensitivity standard deviations (and at least 10%)
+// above the mean of the preceding BaselineDays. Days without any costs count
+// as zero, so new resources are compared against a baseline of nothing.
+// Findings for the checked days that no longer apply are removed, the others
+// keep the time they were first detected. Adjustments are not usage so they
+// are ignored, and days before the raw events were archived are left out of
+// both windows as their costs are incomplete.
+func (s *EventStore) detectCostAnomalies(ctx context.Context) error {
+	cfg := s.cfg.AnomalyDetection.withDefaults()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	startTime := time.Now()
+	_, err = tx.Exec(`
+		with
+		window_bounds as (
+			select
+				greatest(stop - $1::integer, unarchived_since) as eval_start,
+				stop as eval_stop,
+				greatest(stop - $1::integer - $2::integer, unarchived_since) as baseline_start
+			from (
+				select
+					(date_trunc('day', max(upper(duration)) at time zone 'UTC'))::date as stop
+				from
+					billable_event_components
+				where
+					resource_type not in ('credit', 'debit', 'discount')
+			) s,
+			(
+				select
+					coalesce(
+						(date_trunc('month', max(last_created_at) at time zone 'UTC') + interval '1 month')::date,
+						'-infinity'::date
+					) as unarchived_since
+				from
+					raw_event_archives
+				where
+					restored_at is null
+			) a
+		),
+		component_days as (
+			select
+				d.day::date as day,
+				c.org_guid,
+				c.org_name,
+				c.plan_guid,
+				c.plan_name,
+				c.resource_guid,
+				c.resource_name,
+				lower(c.duration) as event_start,
+				c.cost_for_duration * to_seconds(
+					c.duration * tstzrange(d.day at time zone 'UTC', (d.day + interval '1 day') at time zone 'UTC')
+				) / to_seconds(c.duration) as cost
+			from
+				window_bounds w
+			cross join
+				billable_event_components c
+			cross join
+				generate_series(
+					greatest(date_trunc('day', lower(c.duration) at time zone 'UTC'), w.baseline_start::timestamp),
+					least((upper(c.duration) at time zone 'UTC'), w.eval_stop::timestamp) - interval '1 microsecond',
+					interval '1 day'
+				) d(day)
+			where
+				c.resource_type not in ('credit', 'debit', 'discount')
+				and c.duration && tstzrange(
+					w.baseline_start::timestamp at time zone 'UTC',
+					w.eval_stop::timestamp at time zone 'UTC'
+				)
+		),
+		subject_days as (
+			select
+				day,
+				'org' as scope,
+				org_guid,
+				(array_agg(org_name order by event_start desc))[1] as org_name,
+				org_guid as subject_guid,
+				(array_agg(org_name order by event_start desc))[1] as subject_name,
+				sum(cost) as cost
+			from
+				component_days
+			group by
+				day, org_guid
+			union all
+			select
+				day,
+				'service' as scope,
+				org_guid,
+				(array_agg(org_name order by event_start desc))[1] as org_name,
+				plan_guid as subject_guid,
+				(array_agg(plan_name order by event_start desc))[1] as subject_name,
+				sum(cost) as cost
+			from
+				component_days
+			group by
+				day, org_guid, plan_guid
+			union all
+			select
+				day,
+				'resource' as scope,
+				org_guid,
+				(array_agg(org_name order by event_start desc))[1] as org_name,
+				resource_guid as subject_guid,
+				(array_agg(resource_name order by event_start desc))[1] as subject_name,
+				sum(cost) as cost
+			from
+				component_days
+			group by
+				day, org_guid, resource_guid
+		),
+		subjects as (
+			select distinct on (scope, org_guid, subject_guid)
+				scope,
+				org_guid,
+				org_name,
+				subject_guid,
+				subject_name
+			from
+				subject_days
+			order by
+				scope, org_guid, subject_guid, day desc
+		),
+		dense_subject_days as (
+			select
+				d.day::date as day,
+				s.*,
+				coalesce(sd.cost, 0) as cost
+			from
+				window_bounds w
+			cross join
+				subjects s
+			cross join
+				generate_series(w.baseline_start::timestamp, (w.eval_stop - 1)::timestamp, interval '1 day') d(day)
+			left join
+				subject_days sd on sd.scope = s.scope
+				and sd.org_guid = s.org_guid
+				and sd.subject_guid = s.subject_guid
+				and sd.day = d.day::date
+		),
+		baselines as (
+			select
+				*,
+				avg(cost) over baseline as baseline_mean,
+				coalesce(stddev_pop(cost) over baseline, 0) as baseline_stddev
+			from
+				dense_subject_days
+			window
+				baseline as (
+					partition by scope, org_guid, subject_guid order by day
+					rows between $2 preceding and 1 preceding
+				)
+		),
+		anomalies as (
+			select
+				b.*
+			from
+				baselines b,
+				window_bounds w
+			where
+				b.day >= w.eval_start
+				and b.cost >= $4
+				and b.cost > b.baseline_mean + $3 * greatest(b.baseline_stddev, b.baseline_mean * 0.1)
+		),
+		resolved as (
+			delete from
+				cost_anomalies ca
+			using
+				window_bounds w
+			where
+				ca.day >= w.eval_start
+				and ca.day < w.eval_stop
+				and not exists (
+					select 1 from anomalies a
+					where a.day = ca.day
+					and a.scope = ca.scope
+					and a.org_guid = ca.org_guid
+					and a.subject_guid = ca.subject_guid
+				)
+		)
+		insert into cost_anomalies (
+			day,
+			scope,
+			org_guid,
+			org_name,
+			subject_guid,
+			subject_name,
+			cost_ex_vat,
+			baseline_mean_ex_vat,
+			baseline_stddev_ex_vat
+		)
+		select
+			day,
+			scope,
+			org_guid,
+			org_name,
+			subject_guid,
+			subject_name,
+			round(cost, 2),
+			round(baseline_mean, 2),
+			round(baseline_stddev, 2)
+		from
+			anomalies
+		on conflict (day, scope, org_guid, subject_guid) do update set
+			org_name = excluded.org_name,
+			subject_name = excluded.subject_name,
+			cost_ex_vat = excluded.cost_ex_vat,
+			baseline_mean_ex_vat = excluded.baseline_mean_ex_vat,
+			baseline_stddev_ex_vat = excluded.baseline_stddev_ex_vat
+	`, cfg.DetectionDays, cfg.BaselineDays, cfg.Sensitivity, cfg.MinimumDailyCost)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("detect-cost-anomalies", err, lager.Data{
+			"config":  cfg,
+			"elapsed": int64(elapsed),
+		})
+		return wrapPqError(err, "detect-cost-anomalies")
+	}
+	s.logger.Info("detect-cost-anomalies", lager.Data{
+		"config":  cfg,
+		"elapsed": int64(elapsed),
+	})
+	return tx.Commit()
+}
+
+// GetCostAnomalies returns the anomalies found on the days of the filter
+// range, most expensive first within each day
+func (s *EventStore) GetCostAnomalies(filter eventio.CostAnomalyFilter) ([]eventio.CostAnomaly, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := queryJSON(tx, `
+		select
+			to_char(day, 'YYYY-MM-DD') as day,
+			scope,
+			org_guid,
+			org_name,
+			subject_guid,
+			subject_name,
+			cost_ex_vat::text,
+			baseline_mean_ex_vat::text,
+			baseline_stddev_ex_vat::text,
+			iso8601(detected_at) as detected_at
+		from
+			cost_anomalies
+		where
+			day >= $1::date
+			and day < $2::date
+			and (cardinality($3::uuid[]) = 0 or org_guid = any($3::uuid[]))
+			and ($4 = '' or scope = $4)
+		order by
+			day, cost_ex_vat desc, scope, org_guid, subject_guid
+	`, filter.RangeStart, filter.RangeStop, pq.Array(filter.OrgGUIDs), string(filter.Scope))
+	if err != nil {
+		return nil, wrapPqError(err, "get-cost-anomalies")
+	}
+	defer rows.Close()
+	anomalies := []eventio.CostAnomaly{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var anomaly eventio.CostAnomaly
+		if err := json.Unmarshal(b, &anomaly); err != nil {
+			return nil, fmt.Errorf("failed to decode cost anomaly: %s", err)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies, rows.Err()
+}
@@ -0,0 +1,152 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetCostAnomalies", func() {
+
+	var (
+		cfg      eventstore.Config
+		orgGUID  = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		app1GUID = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		app2GUID = "d7f0b3a2-5f3e-4b8a-9b1c-6a2e4f8d0c11"
+		filter   = eventio.CostAnomalyFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "app",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "$number_of_nodes * ($time_in_seconds / 3600)",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+	})
+
+	// open runs app1 with one instance for the first 19 days of January and
+	// app2 with four instances on the 18th
+	open := func() *testenv.TempDB {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		appEvent := func(guid, appGUID, createdAt, state string, instances int) testenv.Row {
+			raw, err := json.Marshal(map[string]interface{}{
+				"state":                     state,
+				"app_guid":                  appGUID,
+				"app_name":                  "APP-" + appGUID[:4],
+				"org_guid":                  orgGUID,
+				"space_guid":                "276f4886-ac40-492d-a8cd-b2646637ba76",
+				"space_name":                "ORG1-SPACE1",
+				"process_type":              "web",
+				"instance_count":            instances,
+				"previous_state":            "STARTED",
+				"memory_in_mb_per_instance": 1024,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(raw),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("00000000-0000-0000-0001-000000000001", app1GUID, "2001-01-01T00:00Z", "STARTED", 1),
+			appEvent("00000000-0000-0000-0001-000000000002", app2GUID, "2001-01-18T00:00Z", "STARTED", 4),
+			appEvent("00000000-0000-0000-0001-000000000003", app2GUID, "2001-01-19T00:00Z", "STOPPED", 4),
+			appEvent("00000000-0000-0000-0001-000000000004", app1GUID, "2001-01-20T00:00Z", "STOPPED", 1),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+		return db
+	}
+
+	It("should find the org, service and resource whose daily cost jumped", func() {
+		db := open()
+		defer db.Close()
+
+		anomalies, err := db.Schema.GetCostAnomalies(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(anomalies).To(HaveLen(3))
+
+		Expect(anomalies[0].Day).To(Equal("2001-01-18"))
+		Expect(anomalies[0].Scope).To(Equal(eventio.OrgAnomalyScope))
+		Expect(anomalies[0].SubjectGUID).To(Equal(orgGUID))
+		Expect(anomalies[0].CostExVAT).To(Equal("120.00"))
+		Expect(anomalies[0].BaselineMeanExVAT).To(Equal("24.00"))
+		Expect(anomalies[0].BaselineStddevExVAT).To(Equal("0.00"))
+
+		Expect(anomalies[1].Day).To(Equal("2001-01-18"))
+		Expect(anomalies[1].Scope).To(Equal(eventio.ServiceAnomalyScope))
+		Expect(anomalies[1].SubjectGUID).To(Equal(eventstore.ComputePlanGUID))
+		Expect(anomalies[1].SubjectName).To(Equal("app"))
+		Expect(anomalies[1].CostExVAT).To(Equal("120.00"))
+
+		Expect(anomalies[2].Day).To(Equal("2001-01-18"))
+		Expect(anomalies[2].Scope).To(Equal(eventio.ResourceAnomalyScope))
+		Expect(anomalies[2].SubjectGUID).To(Equal(app2GUID))
+		Expect(anomalies[2].CostExVAT).To(Equal("96.00"))
+		Expect(anomalies[2].BaselineMeanExVAT).To(Equal("0.00"))
+	})
+
+	It("should keep the findings when refreshing again", func() {
+		db := open()
+		defer db.Close()
+
+		before, err := db.Schema.GetCostAnomalies(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Schema.Refresh()).To(Succeed())
+		after, err := db.Schema.GetCostAnomalies(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(after).To(Equal(before))
+	})
+
+	It("should filter the findings by scope", func() {
+		db := open()
+		defer db.Close()
+
+		f := filter
+		f.Scope = eventio.ResourceAnomalyScope
+		anomalies, err := db.Schema.GetCostAnomalies(f)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(anomalies).To(HaveLen(1))
+		Expect(anomalies[0].SubjectGUID).To(Equal(app2GUID))
+	})
+
+	It("should only flag costs that deviate by more than the configured sensitivity", func() {
+		cfg.AnomalyDetection.Sensitivity = 100
+		db := open()
+		defer db.Close()
+
+		anomalies, err := db.Schema.GetCostAnomalies(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(anomalies).To(HaveLen(1))
+		Expect(anomalies[0].Scope).To(Equal(eventio.ResourceAnomalyScope))
+	})
+
+	It("should ignore costs below the configured minimum", func() {
+		cfg.AnomalyDetection.MinimumDailyCost = 200
+		db := open()
+		defer db.Close()
+
+		anomalies, err := db.Schema.GetCostAnomalies(filter)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(anomalies).To(BeEmpty())
+	})
+})
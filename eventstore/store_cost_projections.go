@@ -0,0 +1,114 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+var _ eventio.CostProjectionReader = &EventStore{}
+
+// GetCostProjections returns the actual cost so far and the projected cost at
+// the end of the filter range for each space and plan. Resources that were
+// still running at the last Refresh are assumed to keep running until the end
+// of the range with their current size and pricing.
+func (s *EventStore) GetCostProjections(filter eventio.EventFilter) ([]eventio.CostProjection, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return s.getCostProjections(tx, filter)
+}
+
+func (s *EventStore) getCostProjections(tx *sql.Tx, filter eventio.EventFilter) ([]eventio.CostProjection, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query, args, err := WithBillableEvents(fmt.Sprintf(`
+		select
+			org_guid,
+			org_name,
+			space_guid,
+			space_name,
+			%s as label_value,
+			plan_guid,
+			plan_name,
+			to_json(upper(filtered_range)) as projected_until,
+			sum(price_ex_vat)::text as actual_ex_vat,
+			sum(price_ex_vat * (1 + vat_rate))::text as actual_inc_vat,
+			sum(remainder_ex_vat)::text as projected_remainder_ex_vat,
+			sum(remainder_ex_vat * (1 + vat_rate))::text as projected_remainder_inc_vat,
+			sum(price_ex_vat + remainder_ex_vat)::text as projected_total_ex_vat,
+			sum((price_ex_vat + remainder_ex_vat) * (1 + vat_rate))::text as projected_total_inc_vat
+		from
+			(
+				select
+					c.*,
+					(case
+						when upper(c.duration) = last_refresh.refreshed_at
+						and upper(c.duration) < upper(filtered_range)
+						then eval_formula(
+							c.memory_in_mb,
+							c.storage_in_mb,
+							c.number_of_nodes,
+							tstzrange(upper(c.duration), upper(filtered_range)),
+							c.component_formula
+						) * c.currency_rate
+						else 0
+					end) as remainder_ex_vat
+				from
+					components_with_price c,
+					filtered_range,
+					(select max(refreshed_at) as refreshed_at from refresh_history) last_refresh
+			) projected_components,
+			filtered_range
+		group by
+			org_guid, org_name, space_guid, space_name, label_value, plan_guid, plan_name, filtered_range
+		order by
+			org_guid, space_name, label_value, plan_name
+	`, labelValueExpression(filter.GroupByLabel)), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	rows, err := queryJSON(tx, query, args...)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("get-cost-projections-query", err, lager.Data{
+			"filter":  filter,
+			"elapsed": int64(elapsed),
+		})
+		return nil, err
+	}
+	s.logger.Info("get-cost-projections-query", lager.Data{
+		"filter":  filter,
+		"elapsed": int64(elapsed),
+	})
+	defer rows.Close()
+
+	projections := []eventio.CostProjection{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var projection eventio.CostProjection
+		if err := json.Unmarshal(b, &projection); err != nil {
+			return nil, fmt.Errorf("failed to decode cost projection: %s", err)
+		}
+		projections = append(projections, projection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return projections, nil
+}
@@ -0,0 +1,109 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetCostProjections", func() {
+
+	var (
+		cfg eventstore.Config
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "APP_PLAN_1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "($time_in_seconds / 3600) * $number_of_nodes",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+	})
+
+	parsePrice := func(s string) float64 {
+		f, err := strconv.ParseFloat(s, 64)
+		Expect(err).ToNot(HaveOccurred())
+		return f
+	}
+
+	It("should project resources that are still running until the end of the month", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		monthStop := monthStart.AddDate(0, 1, 0)
+
+		app1Start := testenv.Row{
+			"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+			"created_at":  monthStart.Format(time.RFC3339),
+			"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 2, "memory_in_mb_per_instance": 1024}`),
+		}
+		Expect(db.Insert("app_usage_events", app1Start)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		projections, err := db.Schema.GetCostProjections(eventio.EventFilter{
+			RangeStart: monthStart.Format("2006-01-02"),
+			RangeStop:  monthStop.Format("2006-01-02"),
+			OrgGUIDs:   []string{"51ba75ef-edc0-47ad-a633-a8f6e8770944"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(projections).To(HaveLen(1))
+		Expect(projections[0].PlanGUID).To(Equal(eventstore.ComputePlanGUID))
+		Expect(projections[0].SpaceGUID).To(Equal("276f4886-ac40-492d-a8cd-b2646637ba76"))
+
+		hoursInMonth := monthStop.Sub(monthStart).Hours()
+		actual := parsePrice(projections[0].ActualExVAT)
+		remainder := parsePrice(projections[0].ProjectedRemainderExVAT)
+		Expect(actual).To(BeNumerically(">", 0))
+		Expect(remainder).To(BeNumerically(">", 0))
+		Expect(actual + remainder).To(BeNumerically("~", 2*hoursInMonth, 0.001))
+		Expect(parsePrice(projections[0].ProjectedTotalExVAT)).To(BeNumerically("~", 2*hoursInMonth, 0.001))
+		Expect(parsePrice(projections[0].ProjectedTotalIncVAT)).To(BeNumerically("~", 2*hoursInMonth*1.2, 0.001))
+	})
+
+	It("should not project resources that have been stopped", func() {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		app1Start := testenv.Row{
+			"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+			"created_at":  "2001-01-01T00:00Z",
+			"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+		}
+		app1Stop := testenv.Row{
+			"guid":        "8d9036c5-8367-497d-bb56-94bfcac6621a",
+			"created_at":  "2001-01-01T10:00Z",
+			"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "51ba75ef-edc0-47ad-a633-a8f6e8770944", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+		}
+		Expect(db.Insert("app_usage_events", app1Start, app1Stop)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		projections, err := db.Schema.GetCostProjections(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(projections).To(HaveLen(1))
+		Expect(parsePrice(projections[0].ActualExVAT)).To(BeNumerically("~", 10, 0.001))
+		Expect(parsePrice(projections[0].ProjectedRemainderExVAT)).To(BeNumerically("==", 0))
+		Expect(parsePrice(projections[0].ProjectedTotalExVAT)).To(BeNumerically("~", 10, 0.001))
+	})
+})
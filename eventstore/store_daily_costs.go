@@ -0,0 +1,108 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.DailyCostReader = &EventStore{}
+
+// dailyCostGroupings maps each grouping to the columns of the daily_costs
+// rollup it groups by and the name it reports
+var dailyCostGroupings = []struct {
+	grouping eventio.DailyCostGrouping
+	guid     string
+	name     string
+}{
+	{eventio.GroupByOrg, "org_guid", "org_name"},
+	{eventio.GroupBySpace, "space_guid", "space_name"},
+	{eventio.GroupByPlan, "plan_guid", "plan_name"},
+	{eventio.GroupByComponent, "component_name", ""},
+}
+
+// GetDailyCosts returns the cost of each day of the filter range from the
+// daily_costs rollup, which is rebuilt every time the billable events are
+// refreshed. Costs are split by the groupings of the filter and days without
+// any costs are left out.
+func (s *EventStore) GetDailyCosts(filter eventio.DailyCostFilter) ([]eventio.DailyCost, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkRangeNotArchived(tx, filter.RangeStart); err != nil {
+		return nil, err
+	}
+
+	columns := []string{"to_char(day, 'YYYY-MM-DD') as day"}
+	groupBy := []string{"day"}
+	for _, g := range dailyCostGroupings {
+		if !filter.HasGrouping(g.grouping) {
+			continue
+		}
+		columns = append(columns, g.guid)
+		groupBy = append(groupBy, g.guid)
+		if g.name != "" {
+			columns = append(columns, fmt.Sprintf("(array_agg(%s order by day desc))[1] as %s", g.name, g.name))
+		}
+	}
+
+	startTime := time.Now()
+	rows, err := queryJSON(tx, `
+		select
+			`+strings.Join(columns, ",\n\t\t\t")+`,
+			sum(cost_ex_vat)::text as cost_ex_vat,
+			sum(cost_inc_vat)::text as cost_inc_vat
+		from
+			daily_costs
+		where
+			day >= $1::date
+			and day < $2::date
+			and (cardinality($3::uuid[]) = 0 or org_guid = any($3::uuid[]))
+		group by
+			`+strings.Join(groupBy, ", ")+`
+		order by
+			`+strings.Join(groupBy, ", ")+`
+	`, filter.RangeStart, filter.RangeStop, pq.Array(filter.OrgGUIDs))
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("get-daily-costs-query", err, lager.Data{
+			"filter":  filter,
+			"elapsed": int64(elapsed),
+		})
+		return nil, wrapPqError(err, "get-daily-costs")
+	}
+	s.logger.Info("get-daily-costs-query", lager.Data{
+		"filter":  filter,
+		"elapsed": int64(elapsed),
+	})
+	defer rows.Close()
+
+	costs := []eventio.DailyCost{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var cost eventio.DailyCost
+		if err := json.Unmarshal(b, &cost); err != nil {
+			return nil, fmt.Errorf("failed to decode daily cost: %s", err)
+		}
+		costs = append(costs, cost)
+	}
+	return costs, rows.Err()
+}
@@ -0,0 +1,135 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetDailyCosts", func() {
+
+	var (
+		db      *testenv.TempDB
+		orgGUID = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+	)
+
+	BeforeEach(func() {
+		cfg := testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "app",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "($time_in_seconds / 3600) * 1",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+				{
+					Name:         "platform",
+					Formula:      "($time_in_seconds / 3600) * 0.5",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		var err error
+		db, err = testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		appEvent := func(guid, createdAt, state string) testenv.Row {
+			return testenv.Row{
+				"guid":        guid,
+				"created_at":  createdAt,
+				"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			}
+		}
+		Expect(db.Insert("app_usage_events",
+			appEvent("00000000-0000-0000-0001-000000000001", "2001-01-01T12:00Z", "STARTED"),
+			appEvent("00000000-0000-0000-0001-000000000002", "2001-01-02T18:00Z", "STOPPED"),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should split the cost of events across the days they ran", func() {
+		costs, err := db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+				OrgGUIDs:   []string{orgGUID},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(costs).To(HaveLen(2))
+		Expect(costs[0].Day).To(Equal("2001-01-01"))
+		Expect(costs[0].OrgGUID).To(BeEmpty())
+		Expect(strconv.ParseFloat(costs[0].CostExVAT, 64)).To(BeNumerically("~", 18, 0.000001))
+		Expect(costs[1].Day).To(Equal("2001-01-02"))
+		Expect(strconv.ParseFloat(costs[1].CostExVAT, 64)).To(BeNumerically("~", 27, 0.000001))
+	})
+
+	It("should split the costs by the requested groupings", func() {
+		costs, err := db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-02",
+				RangeStop:  "2001-01-03",
+			},
+			GroupBy: []eventio.DailyCostGrouping{eventio.GroupByPlan, eventio.GroupByComponent},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(costs).To(HaveLen(2))
+		Expect(costs[0].PlanGUID).To(Equal(eventstore.ComputePlanGUID))
+		Expect(costs[0].PlanName).To(Equal("app"))
+		Expect(costs[0].ComponentName).To(Equal("compute"))
+		Expect(strconv.ParseFloat(costs[0].CostExVAT, 64)).To(BeNumerically("~", 18, 0.000001))
+		Expect(costs[1].ComponentName).To(Equal("platform"))
+		Expect(strconv.ParseFloat(costs[1].CostExVAT, 64)).To(BeNumerically("~", 9, 0.000001))
+	})
+
+	It("should not return costs of other orgs", func() {
+		costs, err := db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+				OrgGUIDs:   []string{"2884b2bc-f74b-4aaa-956d-f679ca498dce"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(costs).To(BeEmpty())
+	})
+
+	It("should refuse ranges that start before the raw events were archived", func() {
+		_, err := db.Conn.Exec(`
+			insert into raw_event_archives (kind, key, event_count, first_created_at, last_created_at)
+			values ('app', 'app/2001-01.ndjson.gz', 1, '2001-01-01T12:00Z', '2001-01-01T12:00Z')
+		`)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-03-01",
+			},
+		})
+		Expect(err).To(Equal(&eventio.ArchivedRangeError{RangeStart: "2001-01-01", UnarchivedSince: "2001-02-01"}))
+
+		costs, err := db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-02-01",
+				RangeStop:  "2001-03-01",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(costs).To(BeEmpty())
+	})
+})
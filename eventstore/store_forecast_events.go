@@ -3,6 +3,8 @@ package eventstore
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 
 	"github.com/alphagov/paas-billing/eventio"
 	"github.com/lib/pq"
@@ -17,6 +19,11 @@ const (
 	DummySpaceName = "my-space"
 )
 
+// ForecastBillableEventRows prices the given UsageEvents as if they were
+// real events. The simulated events are only ever written to temporary tables
+// that shadow the real ones for the lifetime of the transaction, so they are
+// never visible to other sessions. The transaction is released when ctx is
+// cancelled.
 func (s *EventStore) ForecastBillableEventRows(ctx context.Context, events []eventio.UsageEvent, filter eventio.EventFilter) (eventio.BillableEventRows, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -31,7 +38,14 @@ func (s *EventStore) ForecastBillableEventRows(ctx context.Context, events []eve
 }
 
 func (s *EventStore) forecastBillableEventRows(tx *sql.Tx, events []eventio.UsageEvent, filter eventio.EventFilter) (eventio.BillableEventRows, error) {
-	eventGUIDs := []string{}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`
+		create temporary table events (like events including defaults) on commit drop;
+	`); err != nil {
+		return nil, wrapPqError(err, "forecast-events-table")
+	}
 	for _, ev := range events {
 		_, err := tx.Exec(`
 			insert into events (
@@ -60,15 +74,8 @@ func (s *EventStore) forecastBillableEventRows(tx *sql.Tx, events []eventio.Usag
 		if err != nil {
 			return nil, err
 		}
-		eventGUIDs = append(eventGUIDs, ev.EventGUID)
 	}
-	_, err := tx.Exec(`
-		insert into billable_event_components (
-			select * from generate_billable_event_components()
-			where event_guid = any($1)
-		)
-	`, pq.Array(eventGUIDs))
-	if err != nil {
+	if err := createForecastBillableEventComponents(tx); err != nil {
 		return nil, err
 	}
 
@@ -97,3 +104,232 @@ func (s *EventStore) ForecastBillableEvents(input []eventio.UsageEvent, filter e
 	}
 	return events, nil
 }
+
+// ForecastBillableEventDiffs answers "what if" questions about the existing
+// resources of the filtered orgs. The real events are copied into temporary
+// tables, the adjustments are applied to the copies from their given point in
+// time and the result is priced and compared with the actual cost of each
+// resource over the filter range.
+func (s *EventStore) ForecastBillableEventDiffs(ctx context.Context, adjustments []eventio.ResourceAdjustment, filter eventio.EventFilter) ([]eventio.ForecastDiff, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return s.forecastBillableEventDiffs(tx, adjustments, filter)
+}
+
+func (s *EventStore) forecastBillableEventDiffs(tx *sql.Tx, adjustments []eventio.ResourceAdjustment, filter eventio.EventFilter) ([]eventio.ForecastDiff, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if len(filter.OrgGUIDs) == 0 {
+		return nil, fmt.Errorf("forecasting adjustments requires at least one org_guid")
+	}
+	for _, adjustment := range adjustments {
+		if err := adjustment.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	costsByResource := `
+		select
+			resource_guid,
+			resource_type,
+			org_guid,
+			space_guid,
+			max(resource_name) as resource_name,
+			max(space_name) as space_name,
+			sum((price->>'ex_vat')::numeric) as ex_vat,
+			sum((price->>'inc_vat')::numeric) as inc_vat
+		from
+			billable_events
+		group by
+			resource_guid, resource_type, org_guid, space_guid
+	`
+
+	actualQuery, args, err := WithBillableEvents(costsByResource, filter)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`create temporary table forecast_actual_costs on commit drop as `+actualQuery, args...); err != nil {
+		return nil, wrapPqError(err, "forecast-actual-costs")
+	}
+
+	// the events of resources allocated to the orgs are needed to price
+	// the costs recharged to them
+	if _, err := tx.Exec(`
+		create temporary table events on commit drop as
+		select * from events
+		where duration && $1::tstzrange
+		and (
+			org_guid = any($2::uuid[])
+			or resource_guid in (
+				select resource_guid from cost_allocations
+				where org_guid = any($2::uuid[])
+			)
+		)
+	`,
+		fmt.Sprintf("[%s, %s)", filter.RangeStart, filter.RangeStop),
+		pq.Array(filter.OrgGUIDs),
+	); err != nil {
+		return nil, wrapPqError(err, "forecast-events-table")
+	}
+	for _, adjustment := range adjustments {
+		if err := applyResourceAdjustment(tx, adjustment); err != nil {
+			return nil, err
+		}
+	}
+	if err := createForecastBillableEventComponents(tx); err != nil {
+		return nil, err
+	}
+
+	forecastQuery, args, err := WithBillableEvents(`
+		select
+			coalesce(a.resource_guid, f.resource_guid) as resource_guid,
+			coalesce(a.resource_name, f.resource_name) as resource_name,
+			coalesce(a.resource_type, f.resource_type) as resource_type,
+			coalesce(a.org_guid, f.org_guid) as org_guid,
+			coalesce(a.space_guid, f.space_guid) as space_guid,
+			coalesce(a.space_name, f.space_name) as space_name,
+			coalesce(a.ex_vat, 0)::text as actual_ex_vat,
+			coalesce(a.inc_vat, 0)::text as actual_inc_vat,
+			coalesce(f.ex_vat, 0)::text as forecast_ex_vat,
+			coalesce(f.inc_vat, 0)::text as forecast_inc_vat,
+			(coalesce(f.ex_vat, 0) - coalesce(a.ex_vat, 0))::text as difference_ex_vat,
+			(coalesce(f.inc_vat, 0) - coalesce(a.inc_vat, 0))::text as difference_inc_vat
+		from
+			forecast_actual_costs a
+		full outer join
+			(`+costsByResource+`) f
+		on
+			a.resource_guid = f.resource_guid
+			and a.space_guid = f.space_guid
+		order by
+			resource_guid
+	`, filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryJSON(tx, forecastQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	diffs := []eventio.ForecastDiff{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var diff eventio.ForecastDiff
+		if err := json.Unmarshal(b, &diff); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// applyResourceAdjustment rewrites the (temporary) events of a resource so
+// that every event after adjustment.From uses the adjusted values. Events that
+// span adjustment.From are split in two.
+func applyResourceAdjustment(tx *sql.Tx, adjustment eventio.ResourceAdjustment) error {
+	if _, err := tx.Exec(`
+		insert into events (
+			event_guid,
+			resource_guid, resource_name, resource_type,
+			org_guid, org_name, quota_definition_guid, space_guid, space_name,
+			duration,
+			plan_guid, plan_name,
+			service_guid, service_name,
+			number_of_nodes, memory_in_mb, storage_in_mb,
+			labels
+		) select
+			uuid_generate_v4(),
+			resource_guid, resource_name, resource_type,
+			org_guid, org_name, quota_definition_guid, space_guid, space_name,
+			tstzrange($2::timestamptz, upper(duration)),
+			plan_guid, plan_name,
+			service_guid, service_name,
+			number_of_nodes, memory_in_mb, storage_in_mb,
+			labels
+		from
+			events
+		where
+			resource_guid = $1::uuid
+			and lower(duration) < $2::timestamptz
+			and upper(duration) > $2::timestamptz
+	`, adjustment.ResourceGUID, adjustment.From); err != nil {
+		return wrapPqError(err, "forecast-split-events")
+	}
+	if _, err := tx.Exec(`
+		update events set
+			duration = tstzrange(lower(duration), $2::timestamptz)
+		where
+			resource_guid = $1::uuid
+			and lower(duration) < $2::timestamptz
+			and upper(duration) > $2::timestamptz
+	`, adjustment.ResourceGUID, adjustment.From); err != nil {
+		return wrapPqError(err, "forecast-split-events")
+	}
+	var planGUID *string
+	if adjustment.PlanGUID != "" {
+		planGUID = &adjustment.PlanGUID
+	}
+	if _, err := tx.Exec(`
+		update events set
+			plan_guid = coalesce($3::uuid, plan_guid),
+			plan_name = (case when $3::uuid is null then plan_name else 'simulated' end),
+			number_of_nodes = coalesce($4::integer, number_of_nodes),
+			memory_in_mb = coalesce($5::integer, memory_in_mb),
+			storage_in_mb = coalesce($6::integer, storage_in_mb)
+		where
+			resource_guid = $1::uuid
+			and lower(duration) >= $2::timestamptz
+	`,
+		adjustment.ResourceGUID, adjustment.From,
+		planGUID,
+		adjustment.NumberOfNodes, adjustment.MemoryInMB, adjustment.StorageInMB,
+	); err != nil {
+		return wrapPqError(err, "forecast-adjust-events")
+	}
+	return nil
+}
+
+// createForecastBillableEventComponents prices the temporary events table into
+// a temporary billable_event_components table, applying the cost allocation
+// rules and the adjustments the same way a refresh does. Temporary tables take
+// precedence over the real tables of the same name for the rest of the
+// transaction.
+func createForecastBillableEventComponents(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		create temporary table billable_event_components_temp on commit drop as
+		select * from generate_billable_event_components();
+	`); err != nil {
+		return wrapPqError(err, "forecast-billable-event-components")
+	}
+	if _, err := tx.Exec(`
+		insert into billable_event_components_temp
+		select * from generate_allocated_billable_event_components();
+	`); err != nil {
+		return wrapPqError(err, "forecast-allocated-billable-event-components")
+	}
+	if _, err := tx.Exec(`
+		insert into billable_event_components_temp
+		select * from generate_adjustment_billable_event_components();
+	`); err != nil {
+		return wrapPqError(err, "forecast-adjustment-billable-event-components")
+	}
+	if _, err := tx.Exec(`
+		alter table billable_event_components_temp rename to billable_event_components;
+	`); err != nil {
+		return wrapPqError(err, "forecast-billable-event-components")
+	}
+	return nil
+}
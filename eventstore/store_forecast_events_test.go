@@ -1,6 +1,10 @@
 package eventstore_test
 
 import (
+	"context"
+	"encoding/json"
+	"strconv"
+
 	"github.com/alphagov/paas-billing/eventio"
 	"github.com/alphagov/paas-billing/eventstore"
 	"github.com/alphagov/paas-billing/testenv"
@@ -235,4 +239,241 @@ var _ = Describe("ForecastBillingEvents", func() {
 		Expect(len(realEvents)).To(Equal(0), "did not expect simulated events to persist as real events")
 	})
 
+	It("should not modify the real events tables when forecasting", func() {
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "APP-PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "node-cost",
+					Formula:      "($time_in_seconds / 3600) * $number_of_nodes",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		_, err = db.Schema.ForecastBillableEvents([]eventio.UsageEvent{
+			{
+				EventGUID:     "adf4df0c-5eee-4c38-a2da-486aedebf4fd",
+				EventStart:    "2001-01-01T00:00:00+00:00",
+				EventStop:     "2001-01-01T01:00:00+00:00",
+				ResourceGUID:  "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+				ResourceName:  "APP1",
+				ResourceType:  "app",
+				OrgGUID:       eventstore.DummyOrgGUID,
+				SpaceGUID:     eventstore.DummySpaceGUID,
+				PlanGUID:      eventstore.ComputePlanGUID,
+				NumberOfNodes: 2,
+				MemoryInMB:    64,
+			},
+		}, eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{eventstore.DummyOrgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Get(`select count(*) from events`)).To(BeNumerically("==", 0))
+		Expect(db.Get(`select count(*) from billable_event_components`)).To(BeNumerically("==", 0))
+	})
+
+	/*-----------------------------------------------------------------------------------*
+	     2001-01-01                      2001-01-01                      2001-01-02      .
+	       00:00                           12:00                           00:00         .
+	         |                               |                               |           .
+	 .   .   [==============APP1 (1 node)====================================]   .   .   .
+	 .   .   .   .   .   .   .   .   .   .   [=========APP1 (3 nodes)========]   .   .   .
+	 .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .   .
+	*-----------------------------------------------------------------------------------*/
+	It("should return the difference between actual and adjusted costs for existing resources", func() {
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "APP-PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "node-cost",
+					Formula:      "($time_in_seconds / 3600) * $number_of_nodes",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		appGUID := "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		app1Start := testenv.Row{
+			"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+			"created_at":  "2001-01-01T00:00Z",
+			"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+		}
+		app1Stop := testenv.Row{
+			"guid":        "8d9036c5-8367-497d-bb56-94bfcac6621a",
+			"created_at":  "2001-01-02T00:00Z",
+			"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+		}
+		Expect(db.Insert("app_usage_events", app1Start, app1Stop)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		nodes := int64(3)
+		diffs, err := db.Schema.ForecastBillableEventDiffs(context.Background(), []eventio.ResourceAdjustment{
+			{
+				ResourceGUID:  appGUID,
+				From:          "2001-01-01T12:00:00Z",
+				NumberOfNodes: &nodes,
+			},
+		}, eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{orgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveLen(1))
+		Expect(diffs[0].ResourceGUID).To(Equal(appGUID))
+		Expect(diffs[0].ResourceName).To(Equal("APP1"))
+
+		expectPrice := func(actual string, expected float64) {
+			f, err := strconv.ParseFloat(actual, 64)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f).To(BeNumerically("~", expected, 0.0001))
+		}
+		expectPrice(diffs[0].ActualExVAT, 24)
+		expectPrice(diffs[0].ForecastExVAT, 12+36)
+		expectPrice(diffs[0].DifferenceExVAT, 24)
+		expectPrice(diffs[0].DifferenceIncVAT, 24*1.2)
+
+		By("leaving the real events untouched")
+		Expect(db.Get(`select count(*) from events`)).To(BeNumerically("==", 1))
+		Expect(db.Get(`select max(number_of_nodes) from events`)).To(BeNumerically("==", 1))
+	})
+
+	It("should not report differences for allocated costs when nothing is adjusted", func() {
+		orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		recipientOrgGUID := "00000001-0000-0000-0000-00000000000a"
+		appGUID := "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "APP-PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "node-cost",
+					Formula:      "($time_in_seconds / 3600) * $number_of_nodes",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddCostAllocationRule(eventio.CostAllocationRule{
+			ResourceGUID: appGUID,
+			ValidFrom:    "2001-01-01",
+			Allocations: []eventio.CostAllocation{
+				{OrgGUID: recipientOrgGUID, Share: 0.4},
+				{OrgGUID: orgGUID, Share: 0.6},
+			},
+		})
+
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Insert("app_usage_events",
+			testenv.Row{
+				"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+				"created_at":  "2001-01-01T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "8d9036c5-8367-497d-bb56-94bfcac6621a",
+				"created_at":  "2001-01-02T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+			},
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		for _, orgGUIDs := range [][]string{{orgGUID, recipientOrgGUID}, {recipientOrgGUID}} {
+			diffs, err := db.Schema.ForecastBillableEventDiffs(context.Background(), []eventio.ResourceAdjustment{}, eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-02-01",
+				OrgGUIDs:   orgGUIDs,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diffs).To(HaveLen(len(orgGUIDs)))
+			for _, diff := range diffs {
+				Expect(diff.ActualExVAT).ToNot(Equal("0"))
+				difference, err := strconv.ParseFloat(diff.DifferenceExVAT, 64)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(difference).To(BeNumerically("~", 0, 0.0001))
+			}
+		}
+	})
+
+	It("should not report differences for credits, debits and discounts when nothing is adjusted", func() {
+		orgGUID := "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		appGUID := "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "APP-PLAN1",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "node-cost",
+					Formula:      "($time_in_seconds / 3600) * $number_of_nodes",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Insert("app_usage_events",
+			testenv.Row{
+				"guid":        "ee28a570-f485-48e1-87d0-98b7b8b66dfa",
+				"created_at":  "2001-01-01T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "8d9036c5-8367-497d-bb56-94bfcac6621a",
+				"created_at":  "2001-01-02T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "ORG1-SPACE1", "instance_count": 1, "memory_in_mb_per_instance": 1024}`),
+			},
+		)).To(Succeed())
+		for _, adjustment := range []eventio.Adjustment{
+			{OrgGUID: orgGUID, Kind: eventio.CreditAdjustment, Description: "outage", AmountExVAT: "3", ValidFrom: "2001-01-15"},
+			{OrgGUID: orgGUID, Kind: eventio.DebitAdjustment, Description: "support", AmountExVAT: "5", ValidFrom: "2001-01-15"},
+			{OrgGUID: orgGUID, Kind: eventio.DiscountAdjustment, Description: "pilot", Percentage: "10", ValidFrom: "2001-01-01"},
+		} {
+			_, err := db.Schema.CreateAdjustment(adjustment, "alice")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		diffs, err := db.Schema.ForecastBillableEventDiffs(context.Background(), []eventio.ResourceAdjustment{}, eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-02-01",
+			OrgGUIDs:   []string{orgGUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diffs).To(HaveLen(4))
+		for _, diff := range diffs {
+			Expect(diff.ActualExVAT).ToNot(Equal("0"))
+			difference, err := strconv.ParseFloat(diff.DifferenceExVAT, 64)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(difference).To(BeNumerically("~", 0, 0.0001))
+		}
+	})
+
 })
@@ -0,0 +1,33 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+var _ eventio.StoreHealthChecker = &EventStore{}
+
+// Ping checks that the database is reachable
+func (s *EventStore) Ping() error {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	return s.db.PingContext(ctx)
+}
+
+// GetLastRefresh returns the time of the last successful Refresh or the zero
+// time if the events have never been refreshed
+func (s *EventStore) GetLastRefresh() (time.Time, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	var refreshedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		select max(refreshed_at) from refresh_history
+	`).Scan(&refreshedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return refreshedAt.Time, nil
+}
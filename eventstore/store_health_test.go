@@ -0,0 +1,41 @@
+package eventstore_test
+
+import (
+	"time"
+
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+
+	It("should be able to ping the database", func() {
+		db, err := testenv.Open(eventstore.Config{})
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		Expect(db.Schema.Ping()).To(Succeed())
+	})
+
+	It("should return the time of the last refresh", func() {
+		db, err := testenv.Open(eventstore.Config{})
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Close()
+
+		initialRefresh, err := db.Schema.GetLastRefresh()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(initialRefresh).To(BeTemporally("~", time.Now(), time.Minute))
+
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		lastRefresh, err := db.Schema.GetLastRefresh()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastRefresh).To(BeTemporally(">", initialRefresh))
+
+		var refreshes int
+		Expect(db.Conn.QueryRow(`select count(*) from refresh_history`).Scan(&refreshes)).To(Succeed())
+		Expect(refreshes).To(Equal(1))
+	})
+})
@@ -0,0 +1,160 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.IdleResourceReader = &EventStore{}
+
+// GetIdleResources returns the resources that have cost money since the
+// start of the (UTC) month of the last refresh, ranked by what they have cost
+// so far. Events that were still running when the events were last refreshed
+// all end at the time of that refresh, which is how running resources are
+// recognised. Staging shares the resource of its app so it is told apart by
+// its plan.
+func (s *EventStore) GetIdleResources(filter eventio.IdleResourceFilter) ([]eventio.IdleResource, error) {
+	if filter.MinAgeDays < 0 {
+		return nil, fmt.Errorf("minimum age must not be negative - got %d", filter.MinAgeDays)
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	startTime := time.Now()
+	rows, err := queryJSON(tx, `
+		with
+		as_of as (
+			select
+				max(upper(duration)) as refreshed_at,
+				date_trunc('month', max(upper(duration)) at time zone 'UTC') at time zone 'UTC' as month_start
+			from
+				events
+		),
+		resources as (
+			select
+				ev.resource_guid,
+				(array_agg(ev.resource_name order by ev.plan_guid = $3, upper(ev.duration) desc))[1] as resource_name,
+				(array_agg(ev.resource_type order by ev.plan_guid = $3, upper(ev.duration) desc))[1] as resource_type,
+				(array_agg(ev.org_guid order by upper(ev.duration) desc))[1] as org_guid,
+				(array_agg(ev.org_name order by upper(ev.duration) desc))[1] as org_name,
+				(array_agg(ev.space_guid order by upper(ev.duration) desc))[1] as space_guid,
+				(array_agg(ev.space_name order by upper(ev.duration) desc))[1] as space_name,
+				(array_agg(ev.plan_guid order by ev.plan_guid = $3, upper(ev.duration) desc))[1] as plan_guid,
+				(array_agg(ev.plan_name order by ev.plan_guid = $3, upper(ev.duration) desc))[1] as plan_name,
+				bool_or(upper(ev.duration) = a.refreshed_at) as running,
+				min(lower(ev.duration)) as first_seen,
+				max(lower(ev.duration)) as last_state_change,
+				bool_and(ev.plan_guid = $3) filter (
+					where ev.duration && tstzrange(a.month_start, a.refreshed_at)
+				) as staging_only
+			from
+				events ev,
+				as_of a
+			group by
+				ev.resource_guid
+		),
+		running_app_orgs as (
+			select distinct
+				ev.org_guid
+			from
+				events ev,
+				as_of a
+			where
+				ev.plan_guid = $4
+				and upper(ev.duration) = a.refreshed_at
+		),
+		month_to_date_costs as (
+			select
+				b.resource_guid,
+				sum(eval_formula(
+					b.memory_in_mb,
+					b.storage_in_mb,
+					b.number_of_nodes,
+					b.duration * tstzrange(a.month_start, a.refreshed_at),
+					b.component_formula
+				) * b.currency_rate) as cost
+			from
+				billable_event_components b,
+				as_of a
+			where
+				b.duration && tstzrange(a.month_start, a.refreshed_at)
+				and b.resource_type not in ('credit', 'debit', 'discount')
+			group by
+				b.resource_guid
+		)
+		select
+			r.resource_guid,
+			r.resource_name,
+			r.resource_type,
+			r.org_guid,
+			r.org_name,
+			r.space_guid,
+			r.space_name,
+			r.plan_guid,
+			r.plan_name,
+			r.running,
+			iso8601(r.first_seen) as first_seen,
+			extract(day from a.refreshed_at - r.first_seen)::integer as age_days,
+			iso8601(r.last_state_change) as last_state_change,
+			mc.cost::text as month_to_date_ex_vat,
+			array_remove(array[
+				case when r.staging_only then 'staging_only' end,
+				case when r.running
+					and r.resource_type not in ('app', 'task')
+					and rao.org_guid is null
+					then 'org_has_no_running_apps' end
+			]::text[], null) as warnings
+		from
+			resources r
+		inner join
+			month_to_date_costs mc on mc.resource_guid = r.resource_guid
+		cross join
+			as_of a
+		left join
+			running_app_orgs rao on rao.org_guid = r.org_guid
+		where
+			r.first_seen <= a.refreshed_at - make_interval(days => $2)
+			and (cardinality($1::uuid[]) = 0 or r.org_guid = any($1::uuid[]))
+		order by
+			mc.cost desc, r.resource_guid
+	`, pq.Array(filter.OrgGUIDs), filter.MinAgeDays, StagingPlanGUID, ComputePlanGUID)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("get-idle-resources-query", err, lager.Data{
+			"filter":  filter,
+			"elapsed": int64(elapsed),
+		})
+		return nil, wrapPqError(err, "get-idle-resources")
+	}
+	s.logger.Info("get-idle-resources-query", lager.Data{
+		"filter":  filter,
+		"elapsed": int64(elapsed),
+	})
+	defer rows.Close()
+
+	resources := []eventio.IdleResource{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var resource eventio.IdleResource
+		if err := json.Unmarshal(b, &resource); err != nil {
+			return nil, fmt.Errorf("failed to decode idle resource: %s", err)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, rows.Err()
+}
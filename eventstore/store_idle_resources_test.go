@@ -0,0 +1,177 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetIdleResources", func() {
+
+	var (
+		db          *testenv.TempDB
+		org1GUID    = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		org2GUID    = "2884b2bc-f74b-4aaa-956d-f679ca498dce"
+		app1GUID    = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		app2GUID    = "d7f0b3a2-5f3e-4b8a-9b1c-6a2e4f8d0c11"
+		serviceGUID = "aaaaaaaa-0000-0000-0000-000000000001"
+	)
+
+	BeforeEach(func() {
+		cfg := testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "app",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "$number_of_nodes * ($time_in_seconds / 3600)",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.StagingPlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "staging",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "staging",
+					Formula:      "($time_in_seconds / 3600) * 0.01",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+			ValidFrom: "2001-01-01",
+			Name:      "postgres-medium",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "instance",
+					Formula:      "($time_in_seconds / 3600) * 2",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		var err error
+		db, err = testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Insert("services",
+			testenv.Row{
+				"label":               "postgres",
+				"guid":                "efadb775-58c4-4e17-8087-6d0f4febc489",
+				"valid_from":          "2000-01-01T00:00Z",
+				"created_at":          "2000-01-01T00:00Z",
+				"updated_at":          "2000-01-01T00:00Z",
+				"description":         "",
+				"service_broker_guid": "efadb775-58c4-4e17-8087-6d0f4febc481",
+				"active":              true,
+				"bindable":            true,
+			})).To(Succeed())
+		Expect(db.Insert("service_plans",
+			testenv.Row{
+				"unique_id":          "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+				"name":               "medium",
+				"guid":               "efb5f1ce-0a8a-435d-a8b2-6b2b61c6dbe5",
+				"valid_from":         "2000-01-01T00:00Z",
+				"created_at":         "2000-01-01T00:00Z",
+				"updated_at":         "2000-01-01T00:00Z",
+				"description":        "",
+				"service_guid":       "efadb775-58c4-4e17-8087-6d0f4febc489",
+				"service_valid_from": "2000-01-01T00:00Z",
+				"active":             true,
+				"public":             true,
+				"free":               false,
+				"extra":              "",
+			})).To(Succeed())
+
+		// a database that is still running in org1, which has no apps
+		Expect(db.Insert("service_usage_events", testenv.Row{
+			"guid":        "00000000-0000-0000-0000-000000000001",
+			"created_at":  "2001-01-01T00:00Z",
+			"raw_message": json.RawMessage(`{"state": "CREATED", "org_guid": "` + org1GUID + `", "space_guid": "276f4886-ac40-492d-a8cd-b2646637ba76", "space_name": "sandbox", "service_guid": "efadb775-58c4-4e17-8087-6d0f4febc489", "service_label": "postgres", "service_plan_guid": "efb5f1ce-0a8a-435d-a8b2-6b2b61c6dbe5", "service_plan_name": "medium", "service_instance_guid": "` + serviceGUID + `", "service_instance_name": "db1", "service_instance_type": "managed_service_instance"}`),
+		})).To(Succeed())
+
+		// an app that is still running in org2 and an app that has only
+		// been staged this month
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		Expect(db.Insert("app_usage_events",
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000001",
+				"created_at":  "2001-01-01T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + app1GUID + `", "app_name": "APP1", "org_guid": "` + org2GUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "ORG2-SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STOPPED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000002",
+				"created_at":  monthStart.Format(time.RFC3339),
+				"raw_message": json.RawMessage(`{"state": "STAGING_STARTED", "parent_app_guid": "` + app2GUID + `", "parent_app_name": "APP2", "org_guid": "` + org2GUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "ORG2-SPACE1", "process_type": null, "instance_count": 1, "previous_state": "", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000003",
+				"created_at":  monthStart.Add(time.Second).Format(time.RFC3339),
+				"raw_message": json.RawMessage(`{"state": "STAGING_STOPPED", "parent_app_guid": "` + app2GUID + `", "parent_app_name": "APP2", "org_guid": "` + org2GUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "ORG2-SPACE1", "process_type": null, "instance_count": 1, "previous_state": "STAGING_STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should rank the resources by what they have cost this month", func() {
+		resources, err := db.Schema.GetIdleResources(eventio.IdleResourceFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(3))
+
+		Expect(resources[0].ResourceGUID).To(Equal(serviceGUID))
+		Expect(resources[0].ResourceName).To(Equal("db1"))
+		Expect(resources[0].OrgGUID).To(Equal(org1GUID))
+		Expect(resources[0].Running).To(BeTrue())
+		Expect(resources[0].FirstSeen).To(Equal("2001-01-01T00:00:00Z"))
+		Expect(resources[0].LastStateChange).To(Equal("2001-01-01T00:00:00Z"))
+		Expect(resources[0].AgeDays).To(BeNumerically(">", 365))
+		Expect(resources[0].Warnings).To(Equal([]eventio.ResourceWarning{eventio.StoppedOrgWarning}))
+
+		Expect(resources[1].ResourceGUID).To(Equal(app1GUID))
+		Expect(resources[1].ResourceType).To(Equal("app"))
+		Expect(resources[1].Running).To(BeTrue())
+		Expect(resources[1].Warnings).To(BeEmpty())
+
+		Expect(resources[2].ResourceGUID).To(Equal(app2GUID))
+		Expect(resources[2].Running).To(BeFalse())
+		Expect(resources[2].AgeDays).To(BeNumerically("<", 31))
+		Expect(resources[2].Warnings).To(Equal([]eventio.ResourceWarning{eventio.StagingOnlyWarning}))
+	})
+
+	It("should leave out resources younger than the minimum age", func() {
+		resources, err := db.Schema.GetIdleResources(eventio.IdleResourceFilter{
+			MinAgeDays: 365,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(2))
+		Expect(resources[0].ResourceGUID).To(Equal(serviceGUID))
+		Expect(resources[1].ResourceGUID).To(Equal(app1GUID))
+	})
+
+	It("should only return the resources of the requested orgs", func() {
+		resources, err := db.Schema.GetIdleResources(eventio.IdleResourceFilter{
+			OrgGUIDs: []string{org1GUID},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].ResourceGUID).To(Equal(serviceGUID))
+	})
+})
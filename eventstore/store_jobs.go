@@ -0,0 +1,185 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+var _ eventio.JobQueue = &EventStore{}
+
+// EnqueueJob adds a job to the end of the queue and returns it with its ID
+func (s *EventStore) EnqueueJob(job eventio.Job) (eventio.Job, error) {
+	if err := job.Validate(); err != nil {
+		return job, err
+	}
+	params, err := json.Marshal(job.Params)
+	if err != nil {
+		return job, err
+	}
+	jobs, err := s.queryJobs(`
+		insert into jobs (
+			kind, params, requested_by
+		) values (
+			$1, $2, $3
+		) returning *
+	`, job.Kind, params, job.RequestedBy)
+	if err != nil {
+		return job, wrapPqError(err, "enqueue-job")
+	}
+	return jobs[0], nil
+}
+
+// ClaimNextJob marks the oldest queued job as running and returns it, or nil
+// if the queue is empty
+func (s *EventStore) ClaimNextJob() (*eventio.Job, error) {
+	jobs, err := s.queryJobs(`
+		update jobs set
+			state = 'running',
+			started_at = now()
+		where id = (
+			select id from jobs
+			where state = 'queued'
+			order by id
+			limit 1
+			for update skip locked
+		)
+		returning *
+	`)
+	if err != nil {
+		return nil, wrapPqError(err, "claim-next-job")
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// FinishJob records the outcome of a running job
+func (s *EventStore) FinishJob(id int64, state eventio.JobState, errorMessage string) error {
+	var errorValue *string
+	if errorMessage != "" {
+		errorValue = &errorMessage
+	}
+	_, err := s.queryJobs(`
+		update jobs set
+			state = $2,
+			error = $3,
+			finished_at = now()
+		where id = $1
+		returning *
+	`, id, state, errorValue)
+	if err != nil {
+		return wrapPqError(err, "finish-job")
+	}
+	return nil
+}
+
+// FailRunningJobs fails every job that is still marked as running. It should
+// be called by a worker before it starts claiming jobs, since only one worker
+// may run at a time any running job must have been abandoned.
+func (s *EventStore) FailRunningJobs(errorMessage string) error {
+	_, err := s.queryJobs(`
+		update jobs set
+			state = 'failed',
+			error = $1,
+			finished_at = now()
+		where state = 'running'
+		returning *
+	`, errorMessage)
+	if err != nil {
+		return wrapPqError(err, "fail-running-jobs")
+	}
+	return nil
+}
+
+// CancelJob cancels a queued job immediately or asks the worker to cancel a
+// running job. It returns nil if the job does not exist.
+func (s *EventStore) CancelJob(id int64) (*eventio.Job, error) {
+	jobs, err := s.queryJobs(`
+		update jobs set
+			cancel_requested = (state in ('queued', 'running')) or cancel_requested,
+			state = (case when state = 'queued' then 'cancelled' else state end),
+			finished_at = (case when state = 'queued' then now() else finished_at end)
+		where id = $1
+		returning *
+	`, id)
+	if err != nil {
+		return nil, wrapPqError(err, "cancel-job")
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// GetJob returns the job with the given id or nil if it does not exist
+func (s *EventStore) GetJob(id int64) (*eventio.Job, error) {
+	jobs, err := s.queryJobs(`
+		select * from jobs where id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// GetJobs returns the most recent jobs first
+func (s *EventStore) GetJobs(filter eventio.JobFilter) ([]eventio.Job, error) {
+	limit := ""
+	if filter.Limit > 0 {
+		limit = fmt.Sprintf(`limit %d`, filter.Limit)
+	}
+	return s.queryJobs(`
+		select * from jobs
+		where ($1 = '' or kind = $1)
+		and ($2 = '' or state = $2)
+		order by id desc
+		`+limit+`
+	`, string(filter.Kind), string(filter.State))
+}
+
+func (s *EventStore) queryJobs(q string, args ...interface{}) ([]eventio.Job, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	jobs, err := s.queryJobsTx(tx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, tx.Commit()
+}
+
+func (s *EventStore) queryJobsTx(tx *sql.Tx, q string, args ...interface{}) ([]eventio.Job, error) {
+	rows, err := queryJSON(tx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	jobs := []eventio.Job{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var job eventio.Job
+		if err := json.Unmarshal(b, &job); err != nil {
+			return nil, fmt.Errorf("failed to decode job: %s", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,170 @@
+package eventstore_test
+
+import (
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jobs", func() {
+
+	var (
+		db *testenv.TempDB
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = testenv.Open(eventstore.Config{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should enqueue a job", func() {
+		job, err := db.Schema.EnqueueJob(eventio.Job{
+			Kind:        eventio.ConsolidateJob,
+			Params:      eventio.JobParams{Month: "2001-01-01"},
+			RequestedBy: "admin",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job.ID).ToNot(BeZero())
+		Expect(job.State).To(Equal(eventio.JobQueued))
+		Expect(job.Params.Month).To(Equal("2001-01-01"))
+		Expect(job.RequestedBy).To(Equal("admin"))
+		Expect(job.CreatedAt).To(BeTemporally("~", time.Now(), time.Minute))
+		Expect(job.StartedAt).To(BeNil())
+		Expect(job.FinishedAt).To(BeNil())
+	})
+
+	It("should refuse to enqueue an invalid job", func() {
+		_, err := db.Schema.EnqueueJob(eventio.Job{
+			Kind:   eventio.ConsolidateJob,
+			Params: eventio.JobParams{Month: "2001-01-15"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should claim jobs in the order they were queued", func() {
+		first, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		second, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.ConsolidateJob})
+		Expect(err).ToNot(HaveOccurred())
+
+		claimed, err := db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claimed).ToNot(BeNil())
+		Expect(claimed.ID).To(Equal(first.ID))
+		Expect(claimed.State).To(Equal(eventio.JobRunning))
+		Expect(claimed.StartedAt).ToNot(BeNil())
+
+		claimed, err = db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claimed).ToNot(BeNil())
+		Expect(claimed.ID).To(Equal(second.ID))
+
+		claimed, err = db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claimed).To(BeNil())
+	})
+
+	It("should record the outcome of a job", func() {
+		job, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Schema.FinishJob(job.ID, eventio.JobFailed, "boom")).To(Succeed())
+
+		finished, err := db.Schema.GetJob(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(finished.State).To(Equal(eventio.JobFailed))
+		Expect(finished.Error).To(Equal("boom"))
+		Expect(finished.FinishedAt).ToNot(BeNil())
+	})
+
+	It("should fail jobs abandoned by a previous worker", func() {
+		job, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Schema.FailRunningJobs("worker restarted")).To(Succeed())
+
+		failed, err := db.Schema.GetJob(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(failed.State).To(Equal(eventio.JobFailed))
+		Expect(failed.Error).To(Equal("worker restarted"))
+	})
+
+	It("should cancel a queued job immediately", func() {
+		job, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+
+		cancelled, err := db.Schema.CancelJob(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cancelled.State).To(Equal(eventio.JobCancelled))
+		Expect(cancelled.CancelRequested).To(BeTrue())
+		Expect(cancelled.FinishedAt).ToNot(BeNil())
+
+		claimed, err := db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claimed).To(BeNil())
+	})
+
+	It("should request cancellation of a running job", func() {
+		job, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.ClaimNextJob()
+		Expect(err).ToNot(HaveOccurred())
+
+		cancelled, err := db.Schema.CancelJob(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cancelled.State).To(Equal(eventio.JobRunning))
+		Expect(cancelled.CancelRequested).To(BeTrue())
+	})
+
+	It("should return nil when cancelling or getting a job that does not exist", func() {
+		job, err := db.Schema.CancelJob(999)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job).To(BeNil())
+
+		job, err = db.Schema.GetJob(999)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job).To(BeNil())
+	})
+
+	It("should list the most recent jobs first with filters", func() {
+		refresh, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		consolidate, err := db.Schema.EnqueueJob(eventio.Job{Kind: eventio.ConsolidateJob})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.CancelJob(consolidate.ID)
+		Expect(err).ToNot(HaveOccurred())
+
+		jobs, err := db.Schema.GetJobs(eventio.JobFilter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(jobs).To(HaveLen(2))
+		Expect(jobs[0].ID).To(Equal(consolidate.ID))
+		Expect(jobs[1].ID).To(Equal(refresh.ID))
+
+		jobs, err = db.Schema.GetJobs(eventio.JobFilter{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].ID).To(Equal(refresh.ID))
+
+		jobs, err = db.Schema.GetJobs(eventio.JobFilter{State: eventio.JobCancelled})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].ID).To(Equal(consolidate.ID))
+
+		jobs, err = db.Schema.GetJobs(eventio.JobFilter{Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(jobs).To(HaveLen(1))
+	})
+})
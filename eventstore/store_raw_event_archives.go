@@ -0,0 +1,378 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+var _ eventio.RawEventArchiver = &EventStore{}
+
+// rawEventArchiveKinds maps each kind of raw event that can be archived to
+// its table and an expression naming the stream of events it belongs to when
+// the events are generated. The latest event of each stream is never
+// archived, nor are the events that create service instances, which are
+// needed to find the orgs of Compose deployments.
+var rawEventArchiveKinds = map[string]struct {
+	table  string
+	stream string
+	keep   string
+}{
+	"app": {
+		table: "app_usage_events",
+		stream: `case
+			when raw_message->>'state' in ('STARTED', 'STOPPED') then 'app:' || (raw_message->>'app_guid')
+			when raw_message->>'state' in ('TASK_STARTED', 'TASK_STOPPED') then 'task:' || (raw_message->>'task_guid')
+			when raw_message->>'state' in ('STAGING_STARTED', 'STAGING_STOPPED') then 'staging:' || (raw_message->>'parent_app_guid')
+		end`,
+		keep: "false",
+	},
+	"service": {
+		table:  "service_usage_events",
+		stream: `'service:' || (raw_message->>'service_instance_guid')`,
+		keep:   "raw_message->>'state' = 'CREATED'",
+	},
+}
+
+// ArchiveRawEvents removes the raw events of kind created before the given
+// time from the database once export has stored them. Only events in months
+// that have been consolidated, and not detached, can be archived, as the
+// events generated for other months will be incomplete without them. Events
+// created during an archive that has been restored are left in place, as
+// they are already in that archive.
+func (s *EventStore) ArchiveRawEvents(ctx context.Context, kind string, before time.Time, export eventio.RawEventExporter) (*eventio.RawEventArchive, error) {
+	k, ok := rawEventArchiveKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("cannot archive events of kind '%s'", kind)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var unconsolidated string
+	err = tx.QueryRow(`
+		select
+			to_char(m, 'YYYY-MM-DD')
+		from
+			generate_series(
+				date_trunc('month', (select min(created_at) from `+k.table+`)),
+				$1::timestamptz - interval '1 microsecond',
+				interval '1 month'
+			) m
+		where
+			not exists (
+				select 1 from consolidation_history
+				where consolidated_range = tstzrange(m, m + interval '1 month')
+			)
+		order by
+			m
+		limit 1
+	`, before).Scan(&unconsolidated)
+	if err == nil {
+		return nil, fmt.Errorf("cannot archive %s events created before %s until the month starting %s has been consolidated", kind, before.Format(time.RFC3339), unconsolidated)
+	} else if err != sql.ErrNoRows {
+		return nil, wrapPqError(err, "archive-raw-events")
+	}
+
+	// the billable events of detached months are generated from the raw
+	// events again so they must stay in the database
+	var detached string
+	err = tx.QueryRow(`
+		select
+			to_char(lower(consolidated_range), 'YYYY-MM-DD')
+		from
+			consolidation_history
+		where
+			detached_at is not null
+			and lower(consolidated_range) < $1
+		order by
+			consolidated_range
+		limit 1
+	`, before).Scan(&detached)
+	if err == nil {
+		return nil, fmt.Errorf("cannot archive %s events created before %s because the month starting %s has been detached", kind, before.Format(time.RFC3339), detached)
+	} else if err != sql.ErrNoRows {
+		return nil, wrapPqError(err, "archive-raw-events")
+	}
+
+	_, err = tx.Exec(`
+		create temporary table archiving_raw_events on commit drop as
+		with
+		kept as (
+			select distinct on (stream)
+				id
+			from (
+				select id, created_at, `+k.stream+` as stream
+				from `+k.table+`
+				where created_at < $1
+			) e
+			where
+				stream is not null
+			order by
+				stream, created_at desc, id desc
+		)
+		select
+			t.id,
+			t.guid,
+			t.created_at,
+			t.raw_message
+		from
+			`+k.table+` t
+		where
+			t.created_at < $1
+			and not (`+k.keep+`)
+			and not exists (select 1 from kept where kept.id = t.id)
+			and not exists (
+				select 1 from raw_event_archives r
+				where r.kind = $2
+				and r.restored_at is not null
+				and t.created_at between r.first_created_at and r.last_created_at
+			)
+	`, before, kind)
+	if err != nil {
+		return nil, wrapPqError(err, "archive-raw-events")
+	}
+
+	archive := eventio.RawEventArchive{Kind: kind}
+	var first, last *time.Time
+	err = tx.QueryRow(`
+		select count(*), min(created_at), max(created_at) from archiving_raw_events
+	`).Scan(&archive.EventCount, &first, &last)
+	if err != nil {
+		return nil, err
+	}
+	if archive.EventCount == 0 {
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	rows, err := tx.Query(`
+		select id, guid, created_at, raw_message from archiving_raw_events order by id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	archive.Key, err = export(&ArchivedRawEventRows{rows: rows, kind: kind})
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		delete from ` + k.table + ` t using archiving_raw_events a where t.guid = a.guid
+	`)
+	if err != nil {
+		return nil, wrapPqError(err, "archive-raw-events")
+	}
+	err = tx.QueryRow(`
+		insert into raw_event_archives (
+			kind, key, event_count, first_created_at, last_created_at
+		) values (
+			$1, $2, $3, $4, $5
+		) returning id, archived_at
+	`, kind, archive.Key, archive.EventCount, first, last).Scan(&archive.ID, &archive.ArchivedAt)
+	if err != nil {
+		return nil, wrapPqError(err, "archive-raw-events")
+	}
+	archive.FirstCreatedAt = *first
+	archive.LastCreatedAt = *last
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.logger.Info("archived-raw-events", lager.Data{
+		"archive": archive,
+		"elapsed": int64(time.Since(startTime)),
+	})
+	return &archive, nil
+}
+
+// rawEventsUnarchivedSince returns the start of the first month from which
+// all raw events are still in the database, or an empty string if no raw
+// events have been archived or every archive has been restored. Billable
+// events before that month are missing the archived events.
+func rawEventsUnarchivedSince(tx *sql.Tx) (string, error) {
+	var since sql.NullString
+	err := tx.QueryRow(`
+		select
+			to_char(date_trunc('month', max(last_created_at) at time zone 'UTC') + interval '1 month', 'YYYY-MM-DD')
+		from
+			raw_event_archives
+		where
+			restored_at is null
+	`).Scan(&since)
+	if err != nil {
+		return "", wrapPqError(err, "get-raw-events-unarchived-since")
+	}
+	return since.String, nil
+}
+
+// checkRangeNotArchived returns an ArchivedRangeError if a report starting
+// at rangeStart would need raw events that have been archived
+func checkRangeNotArchived(tx *sql.Tx, rangeStart string) error {
+	since, err := rawEventsUnarchivedSince(tx)
+	if err != nil {
+		return err
+	}
+	if since != "" && rangeStart < since {
+		return &eventio.ArchivedRangeError{RangeStart: rangeStart, UnarchivedSince: since}
+	}
+	return nil
+}
+
+// RestoreRawEvents puts archived events back into the tables they were
+// archived from with their original sequence, so they are read in the same
+// order as before and the collectors still continue from the latest event.
+func (s *EventStore) RestoreRawEvents(ctx context.Context, events []eventio.ArchivedRawEvent) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	restored := 0
+	for _, event := range events {
+		if err := event.Validate(); err != nil {
+			return 0, err
+		}
+		k, ok := rawEventArchiveKinds[event.Kind]
+		if !ok {
+			return 0, fmt.Errorf("cannot restore events of kind '%s'", event.Kind)
+		}
+		res, err := tx.Exec(`
+			insert into `+k.table+` (
+				id, guid, created_at, raw_message
+			) values (
+				$1, $2, $3, $4
+			) on conflict do nothing
+		`, event.Sequence, event.GUID, event.CreatedAt, event.RawMessage)
+		if err != nil {
+			return 0, wrapPqError(err, "restore-raw-events")
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		restored += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return restored, nil
+}
+
+// MarkRawEventArchiveRestored records that every event of the archive has
+// been restored. Marking an archive that is already restored does nothing.
+func (s *EventStore) MarkRawEventArchiveRestored(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		update raw_event_archives set
+			restored_at = now()
+		where
+			id = $1
+			and restored_at is null
+	`, id)
+	if err != nil {
+		return wrapPqError(err, "mark-raw-event-archive-restored")
+	}
+	return nil
+}
+
+// GetRawEventArchives returns the archives that may hold events matching the
+// filter, oldest first
+func (s *EventStore) GetRawEventArchives(filter eventio.RawEventArchiveFilter) ([]eventio.RawEventArchive, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(`
+		select
+			id,
+			kind,
+			key,
+			event_count,
+			first_created_at,
+			last_created_at,
+			archived_at,
+			restored_at
+		from
+			raw_event_archives
+		where
+			($1 = '' or kind = $1)
+			and ($2::timestamptz is null or last_created_at >= $2::timestamptz)
+			and ($3::timestamptz is null or first_created_at < $3::timestamptz)
+		order by
+			first_created_at, id
+	`, filter.Kind, nullTime(filter.Since), nullTime(filter.Before))
+	if err != nil {
+		return nil, wrapPqError(err, "get-raw-event-archives")
+	}
+	defer rows.Close()
+	archives := []eventio.RawEventArchive{}
+	for rows.Next() {
+		var archive eventio.RawEventArchive
+		err := rows.Scan(
+			&archive.ID,
+			&archive.Kind,
+			&archive.Key,
+			&archive.EventCount,
+			&archive.FirstCreatedAt,
+			&archive.LastCreatedAt,
+			&archive.ArchivedAt,
+			&archive.RestoredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, archive)
+	}
+	return archives, rows.Err()
+}
+
+// nullTime returns t as a query parameter, or nil if it is the zero time
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+type ArchivedRawEventRows struct {
+	rows *sql.Rows
+	kind string
+}
+
+// Next moves the row cursor to the next iteration. Returns false if no more
+// rows.
+func (r *ArchivedRawEventRows) Next() bool {
+	return r.rows.Next()
+}
+
+// Err returns any errors that occurred behind the scenes during processing.
+// Call this at the end of your iteration.
+func (r *ArchivedRawEventRows) Err() error {
+	return r.rows.Err()
+}
+
+// Event returns the current row's ArchivedRawEvent. Call Next() to get the
+// next row. You must call Next _before_ calling this method
+func (r *ArchivedRawEventRows) Event() (*eventio.ArchivedRawEvent, error) {
+	event := eventio.ArchivedRawEvent{}
+	event.Kind = r.kind
+	err := r.rows.Scan(
+		&event.Sequence,
+		&event.GUID,
+		&event.CreatedAt,
+		&event.RawMessage,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
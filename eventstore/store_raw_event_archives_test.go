@@ -0,0 +1,194 @@
+package eventstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RawEventArchiver", func() {
+
+	var (
+		db      *testenv.TempDB
+		ctx     = context.Background()
+		appGUID = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		before  = time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)
+		started = eventio.RawEvent{
+			GUID:       "94147a2f-2626-4445-8b4e-22ebe8071a29",
+			CreatedAt:  time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC),
+			Kind:       "app",
+			RawMessage: json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `"}`),
+		}
+		stopped = eventio.RawEvent{
+			GUID:       "395b7d4c-c859-4a28-9a53-6b15fab447c7",
+			CreatedAt:  time.Date(2001, 2, 2, 2, 2, 2, 0, time.UTC),
+			Kind:       "app",
+			RawMessage: json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `"}`),
+		}
+		restarted = eventio.RawEvent{
+			GUID:       "7311ecc5-33f7-42f5-92b6-7f0789bf92a5",
+			CreatedAt:  time.Date(2001, 3, 3, 3, 3, 3, 0, time.UTC),
+			Kind:       "app",
+			RawMessage: json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `"}`),
+		}
+		recent = eventio.RawEvent{
+			GUID:       "c1a3ad8f-4e4a-4c5e-b9f1-2f1b3b0b8f4e",
+			CreatedAt:  time.Date(2002, 2, 2, 2, 2, 2, 0, time.UTC),
+			Kind:       "app",
+			RawMessage: json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `"}`),
+		}
+	)
+
+	readAll := func(events eventio.ArchivedRawEventRows) ([]eventio.ArchivedRawEvent, error) {
+		archived := []eventio.ArchivedRawEvent{}
+		for events.Next() {
+			event, err := events.Event()
+			if err != nil {
+				return nil, err
+			}
+			archived = append(archived, *event)
+		}
+		return archived, events.Err()
+	}
+
+	BeforeEach(func() {
+		var err error
+		db, err = testenv.Open(eventstore.Config{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Schema.StoreEvents([]eventio.RawEvent{started, stopped, restarted, recent})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should refuse to archive months that have not been consolidated", func() {
+		_, err := db.Schema.ArchiveRawEvents(ctx, "app", before, func(events eventio.ArchivedRawEventRows) (string, error) {
+			Fail("events should not be exported")
+			return "", nil
+		})
+		Expect(err).To(MatchError(ContainSubstring("until the month starting 2001-01-01 has been consolidated")))
+	})
+
+	It("should refuse to archive months that have been detached", func() {
+		_, err := db.Conn.Exec(`
+			insert into consolidation_history (consolidated_range, created_at, detached_at)
+			select tstzrange(m, m + interval '1 month'), now(), case when m = '2001-06-01' then now() end
+			from generate_series('2001-01-01'::timestamptz, '2001-12-01'::timestamptz, interval '1 month') m
+		`)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Schema.ArchiveRawEvents(ctx, "app", before, func(events eventio.ArchivedRawEventRows) (string, error) {
+			Fail("events should not be exported")
+			return "", nil
+		})
+		Expect(err).To(MatchError(ContainSubstring("the month starting 2001-06-01 has been detached")))
+	})
+
+	It("should refuse to detach months whose raw events have been archived", func() {
+		_, err := db.Conn.Exec(`
+			insert into consolidation_history (consolidated_range, created_at)
+			select tstzrange(m, m + interval '1 month'), now()
+			from generate_series('2001-01-01'::timestamptz, '2001-12-01'::timestamptz, interval '1 month') m
+		`)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Schema.ArchiveRawEvents(ctx, "app", before, func(events eventio.ArchivedRawEventRows) (string, error) {
+			_, err := readAll(events)
+			return "app/2002-01.ndjson.gz", err
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Schema.DetachConsolidatedMonths(ctx, before)
+		Expect(err).To(MatchError(ContainSubstring("cannot detach the month starting 2001-01-01 because its raw events have been archived")))
+	})
+
+	It("should archive all but the latest event of each resource and restore them", func() {
+		_, err := db.Conn.Exec(`
+			insert into consolidation_history (consolidated_range, created_at)
+			select tstzrange(m, m + interval '1 month'), now()
+			from generate_series('2001-01-01'::timestamptz, '2001-12-01'::timestamptz, interval '1 month') m
+		`)
+		Expect(err).ToNot(HaveOccurred())
+
+		var exported []eventio.ArchivedRawEvent
+		archive, err := db.Schema.ArchiveRawEvents(ctx, "app", before, func(events eventio.ArchivedRawEventRows) (string, error) {
+			var err error
+			exported, err = readAll(events)
+			return "app/2002-01.ndjson.gz", err
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archive).ToNot(BeNil())
+		Expect(archive.Key).To(Equal("app/2002-01.ndjson.gz"))
+		Expect(archive.EventCount).To(Equal(int64(2)))
+		Expect(archive.FirstCreatedAt.Equal(started.CreatedAt)).To(BeTrue())
+		Expect(archive.LastCreatedAt.Equal(stopped.CreatedAt)).To(BeTrue())
+		Expect(exported).To(HaveLen(2))
+		Expect(exported[0].RawEvent).To(Equal(started))
+		Expect(exported[1].RawEvent).To(Equal(stopped))
+
+		remaining, err := db.Schema.GetEvents(eventio.RawEventFilter{Kind: "app", Reverse: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(Equal([]eventio.RawEvent{restarted, recent}))
+
+		archives, err := db.Schema.GetRawEventArchives(eventio.RawEventArchiveFilter{
+			Kind:  "app",
+			Since: time.Date(2001, 2, 1, 0, 0, 0, 0, time.UTC),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archives).To(HaveLen(1))
+		Expect(archives[0].ID).To(Equal(archive.ID))
+
+		By("restoring the events in their original order")
+		restored, err := db.Schema.RestoreRawEvents(ctx, exported)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal(2))
+		restored, err = db.Schema.RestoreRawEvents(ctx, exported)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal(0))
+
+		all, err := db.Schema.GetEvents(eventio.RawEventFilter{Kind: "app", Reverse: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(all).To(Equal([]eventio.RawEvent{started, stopped, restarted, recent}))
+
+		By("no longer treating the months of a restored archive as archived")
+		_, err = db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-03-01",
+			},
+		})
+		Expect(err).To(Equal(&eventio.ArchivedRangeError{RangeStart: "2001-01-01", UnarchivedSince: "2001-03-01"}))
+
+		Expect(db.Schema.MarkRawEventArchiveRestored(ctx, archive.ID)).To(Succeed())
+		archives, err = db.Schema.GetRawEventArchives(eventio.RawEventArchiveFilter{Kind: "app"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archives).To(HaveLen(1))
+		Expect(archives[0].RestoredAt).ToNot(BeNil())
+
+		_, err = db.Schema.GetDailyCosts(eventio.DailyCostFilter{
+			EventFilter: eventio.EventFilter{
+				RangeStart: "2001-01-01",
+				RangeStop:  "2001-03-01",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		By("not archiving the restored events again")
+		archive, err = db.Schema.ArchiveRawEvents(ctx, "app", before, func(events eventio.ArchivedRawEventRows) (string, error) {
+			_, err := readAll(events)
+			return "app/2002-01-again.ndjson.gz", err
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(archive).To(BeNil())
+
+		By("allowing the months to be detached")
+		_, err = db.Schema.DetachConsolidatedMonths(ctx, before)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
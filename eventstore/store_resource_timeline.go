@@ -0,0 +1,264 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+)
+
+var _ eventio.ResourceTimelineReader = &EventStore{}
+
+// GetResourceTimeline returns the raw usage events, the normalised events and
+// the priced billable event components of a resource over its whole
+// lifetime. Staging and tasks are found by their parent app and task guids
+// and Compose audit events by the deployment they name. It returns nil if
+// nothing is known about the resource.
+func (s *EventStore) GetResourceTimeline(resourceGUID string) (*eventio.ResourceTimeline, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	timeline := &eventio.ResourceTimeline{
+		ResourceGUID:      resourceGUID,
+		OrgGUIDs:          []string{},
+		AllocatedOrgGUIDs: []string{},
+		RawEvents:         []eventio.RawEvent{},
+		Events:            []eventio.UsageEvent{},
+		Components:        []eventio.BillableComponent{},
+	}
+
+	startTime := time.Now()
+	err = s.getResourceTimelineRows(tx, `
+		select
+			guid,
+			kind,
+			raw_message,
+			created_at
+		from (
+			select
+				id,
+				guid::text,
+				'app' as kind,
+				raw_message,
+				created_at
+			from
+				app_usage_events
+			where
+				raw_message->>'app_guid' = $1
+				or raw_message->>'parent_app_guid' = $1
+				or raw_message->>'task_guid' = $1
+			union all
+			select
+				id,
+				guid::text,
+				'service' as kind,
+				raw_message,
+				created_at
+			from
+				service_usage_events
+			where
+				raw_message->>'service_instance_guid' = $1
+			union all
+			select
+				id,
+				event_id as guid,
+				'compose' as kind,
+				raw_message,
+				created_at
+			from
+				compose_audit_events
+			where
+				raw_message->'data'->>'deployment' like '%' || $1
+		) raw_events
+		order by
+			created_at, kind, id
+	`, resourceGUID, func(b []byte) error {
+		var event eventio.RawEvent
+		if err := json.Unmarshal(b, &event); err != nil {
+			return fmt.Errorf("failed to decode raw event: %s", err)
+		}
+		timeline.RawEvents = append(timeline.RawEvents, event)
+		return nil
+	})
+	if err != nil {
+		return nil, s.resourceTimelineError(err, resourceGUID, startTime)
+	}
+
+	err = s.getResourceTimelineRows(tx, `
+		select
+			event_guid,
+			to_json(lower(duration)) as event_start,
+			to_json(upper(duration)) as event_stop,
+			resource_guid,
+			resource_name,
+			resource_type,
+			org_guid,
+			org_name,
+			space_guid,
+			space_name,
+			plan_guid,
+			plan_name,
+			service_guid,
+			service_name,
+			number_of_nodes,
+			memory_in_mb,
+			storage_in_mb
+		from
+			events
+		where
+			resource_guid = $1::uuid
+		order by
+			lower(duration), event_guid
+	`, resourceGUID, func(b []byte) error {
+		var event eventio.UsageEvent
+		if err := json.Unmarshal(b, &event); err != nil {
+			return fmt.Errorf("failed to decode usage event: %s", err)
+		}
+		timeline.Events = append(timeline.Events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, s.resourceTimelineError(err, resourceGUID, startTime)
+	}
+
+	err = s.getResourceTimelineRows(tx, `
+		select
+			event_guid,
+			resource_type,
+			org_guid,
+			space_guid,
+			allocated_from,
+			to_json(lower(duration)) as start,
+			to_json(upper(duration)) as stop,
+			plan_guid,
+			plan_name,
+			to_char(plan_valid_from, 'YYYY-MM-DD') as plan_valid_from,
+			component_name,
+			component_formula as formula,
+			coalesce(number_of_nodes, 0)::bigint as number_of_nodes,
+			coalesce(memory_in_mb, 0)::bigint as memory_in_mb,
+			coalesce(storage_in_mb, 0)::bigint as storage_in_mb,
+			currency_code,
+			currency_rate::text,
+			vat_code,
+			vat_rate::text,
+			(cost_for_duration * (1 + vat_rate))::text as inc_vat,
+			cost_for_duration::text as ex_vat
+		from
+			billable_event_components
+		where
+			resource_guid = $1::uuid
+		order by
+			lower(duration), event_guid, component_name
+	`, resourceGUID, func(b []byte) error {
+		var component eventio.BillableComponent
+		if err := json.Unmarshal(b, &component); err != nil {
+			return fmt.Errorf("failed to decode billable component: %s", err)
+		}
+		timeline.Components = append(timeline.Components, component)
+		return nil
+	})
+	if err != nil {
+		return nil, s.resourceTimelineError(err, resourceGUID, startTime)
+	}
+
+	rows, err := tx.Query(`
+		select distinct
+			org_guid
+		from (
+			select org_guid::text from events where resource_guid = $1::text::uuid
+			union all
+			select raw_message->>'org_guid' from app_usage_events
+			where
+				raw_message->>'app_guid' = $1::text
+				or raw_message->>'parent_app_guid' = $1::text
+				or raw_message->>'task_guid' = $1::text
+			union all
+			select raw_message->>'org_guid' from service_usage_events
+			where raw_message->>'service_instance_guid' = $1::text
+		) orgs
+		where
+			org_guid is not null
+		order by
+			org_guid
+	`, resourceGUID)
+	if err != nil {
+		return nil, s.resourceTimelineError(err, resourceGUID, startTime)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orgGUID string
+		if err := rows.Scan(&orgGUID); err != nil {
+			return nil, err
+		}
+		timeline.OrgGUIDs = append(timeline.OrgGUIDs, orgGUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	timeline.AllocatedOrgGUIDs = allocatedOrgGUIDs(timeline)
+	s.logger.Info("get-resource-timeline-query", lager.Data{
+		"resource_guid": resourceGUID,
+		"elapsed":       int64(time.Since(startTime)),
+	})
+
+	if len(timeline.RawEvents) == 0 && len(timeline.Events) == 0 && len(timeline.Components) == 0 {
+		return nil, nil
+	}
+	return timeline, nil
+}
+
+// allocatedOrgGUIDs returns the orgs, other than those the resource belonged
+// to, that cost allocations charged any of the timeline's components to
+func allocatedOrgGUIDs(timeline *eventio.ResourceTimeline) []string {
+	seen := map[string]bool{}
+	for _, orgGUID := range timeline.OrgGUIDs {
+		seen[orgGUID] = true
+	}
+	orgGUIDs := []string{}
+	for _, component := range timeline.Components {
+		if component.AllocatedFrom == "" || seen[component.OrgGUID] {
+			continue
+		}
+		seen[component.OrgGUID] = true
+		orgGUIDs = append(orgGUIDs, component.OrgGUID)
+	}
+	sort.Strings(orgGUIDs)
+	return orgGUIDs
+}
+
+func (s *EventStore) getResourceTimelineRows(tx *sql.Tx, q string, resourceGUID string, fn func([]byte) error) error {
+	rows, err := queryJSON(tx, q, resourceGUID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *EventStore) resourceTimelineError(err error, resourceGUID string, startTime time.Time) error {
+	s.logger.Error("get-resource-timeline-query", err, lager.Data{
+		"resource_guid": resourceGUID,
+		"elapsed":       int64(time.Since(startTime)),
+	})
+	return wrapPqError(err, "get-resource-timeline")
+}
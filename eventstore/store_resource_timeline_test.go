@@ -0,0 +1,165 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetResourceTimeline", func() {
+
+	var (
+		db       *testenv.TempDB
+		orgGUID  = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		appGUID  = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+		app2GUID = "d7f0b3a2-5f3e-4b8a-9b1c-6a2e4f8d0c11"
+	)
+
+	BeforeEach(func() {
+		cfg := testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "app",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "$number_of_nodes * ($time_in_seconds / 3600) * ($memory_in_mb / 1024)",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.StagingPlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "staging",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "staging",
+					Formula:      "($time_in_seconds / 3600) * 0.01",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		var err error
+		db, err = testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(db.Insert("app_usage_events",
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000001",
+				"created_at":  "2001-01-01T00:00Z",
+				"raw_message": json.RawMessage(`{"state": "STAGING_STARTED", "app_guid": "6f0f2b7c-2f0c-4f1a-a2a6-0d6c2f4c1b01", "parent_app_guid": "` + appGUID + `", "parent_app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": null, "instance_count": 1, "previous_state": "", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000002",
+				"created_at":  "2001-01-01T00:10Z",
+				"raw_message": json.RawMessage(`{"state": "STAGING_STOPPED", "app_guid": "6f0f2b7c-2f0c-4f1a-a2a6-0d6c2f4c1b01", "parent_app_guid": "` + appGUID + `", "parent_app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": null, "instance_count": 1, "previous_state": "STAGING_STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000003",
+				"created_at":  "2001-01-01T01:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STOPPED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000004",
+				"created_at":  "2001-01-01T02:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": "web", "instance_count": 2, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000005",
+				"created_at":  "2001-01-01T03:00Z",
+				"raw_message": json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": "web", "instance_count": 2, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+			},
+			testenv.Row{
+				"guid":        "00000000-0000-0000-0001-000000000006",
+				"created_at":  "2001-01-01T01:00Z",
+				"raw_message": json.RawMessage(`{"state": "STARTED", "app_guid": "` + app2GUID + `", "app_name": "APP2", "org_guid": "` + orgGUID + `", "space_guid": "bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d", "space_name": "SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STOPPED", "memory_in_mb_per_instance": 1024}`),
+			},
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		db.Close()
+	})
+
+	It("should return everything that went into the charges for the resource", func() {
+		timeline, err := db.Schema.GetResourceTimeline(appGUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeline).ToNot(BeNil())
+
+		Expect(timeline.ResourceGUID).To(Equal(appGUID))
+		Expect(timeline.OrgGUIDs).To(Equal([]string{orgGUID}))
+
+		Expect(timeline.RawEvents).To(HaveLen(5))
+		for i, event := range timeline.RawEvents {
+			Expect(event.Kind).To(Equal("app"))
+			Expect(event.GUID).To(Equal(fmt.Sprintf("00000000-0000-0000-0001-%012d", i+1)))
+		}
+
+		Expect(timeline.Events).To(HaveLen(3))
+		Expect(timeline.Events[0].PlanGUID).To(Equal(eventstore.StagingPlanGUID))
+		Expect(timeline.Events[0].EventStart).To(Equal("2001-01-01T00:00:00+00:00"))
+		Expect(timeline.Events[0].EventStop).To(Equal("2001-01-01T00:10:00+00:00"))
+		Expect(timeline.Events[1].PlanGUID).To(Equal(eventstore.ComputePlanGUID))
+		Expect(timeline.Events[1].NumberOfNodes).To(Equal(int64(1)))
+		Expect(timeline.Events[2].NumberOfNodes).To(Equal(int64(2)))
+		Expect(timeline.Events[2].EventStop).To(Equal("2001-01-01T03:00:00+00:00"))
+
+		Expect(timeline.Components).To(HaveLen(3))
+		Expect(timeline.Components[0].ComponentName).To(Equal("staging"))
+		Expect(timeline.Components[1].ComponentName).To(Equal("compute"))
+		Expect(timeline.Components[1].EventGUID).To(Equal(timeline.Events[1].EventGUID))
+		Expect(timeline.Components[1].MemoryInMB).To(Equal(int64(1024)))
+		Expect(strconv.ParseFloat(timeline.Components[1].ExVAT, 64)).To(BeNumerically("~", 1))
+		Expect(strconv.ParseFloat(timeline.Components[1].IncVAT, 64)).To(BeNumerically("~", 1.2))
+		Expect(strconv.ParseFloat(timeline.Components[2].ExVAT, 64)).To(BeNumerically("~", 2))
+	})
+
+	It("should include the components charged to other orgs by cost allocations", func() {
+		recipientOrgGUID := "2884b2bc-f74b-4aaa-956d-f679ca498dce"
+		_, err := db.Conn.Exec(`
+			insert into cost_allocation_rules (resource_guid, valid_from) values ($1, '2001-01-01')
+		`, appGUID)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Conn.Exec(`
+			insert into cost_allocations (resource_guid, valid_from, org_guid, share) values ($1, '2001-01-01', $2, 0.5)
+		`, appGUID, recipientOrgGUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		timeline, err := db.Schema.GetResourceTimeline(appGUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeline.OrgGUIDs).To(Equal([]string{orgGUID}))
+		Expect(timeline.AllocatedOrgGUIDs).To(Equal([]string{recipientOrgGUID}))
+
+		allocated := []eventio.BillableComponent{}
+		for _, component := range timeline.Components {
+			if component.AllocatedFrom != "" {
+				allocated = append(allocated, component)
+				continue
+			}
+			Expect(component.OrgGUID).To(Equal(orgGUID))
+			Expect(component.SpaceGUID).To(Equal("bd405d91-0b7c-4b8c-96ef-8b4c1e26e75d"))
+		}
+		Expect(allocated).ToNot(BeEmpty())
+		for _, component := range allocated {
+			Expect(component.OrgGUID).To(Equal(recipientOrgGUID))
+		}
+	})
+
+	It("should return nil if nothing is known about the resource", func() {
+		timeline, err := db.Schema.GetResourceTimeline("7aa2bb02-2dc8-4a3e-95f2-3d2c0cfed5b1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timeline).To(BeNil())
+	})
+})
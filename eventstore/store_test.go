@@ -955,6 +955,157 @@ var _ = Describe("Store", func() {
 		Entry("compose event", "compose"),
 	)
 
+	DescribeTable("should be able to fetch the last event created before a given time",
+		func(kind string) {
+			db, err := testenv.Open(eventstore.Config{})
+			Expect(err).ToNot(HaveOccurred())
+			defer db.Close()
+			event1 := eventio.RawEvent{
+				GUID:       "94147a2f-2626-4445-8b4e-22ebe8071a29",
+				CreatedAt:  time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC),
+				Kind:       kind,
+				RawMessage: json.RawMessage(`{"name": "app-1"}`),
+			}
+			event2 := eventio.RawEvent{
+				GUID:       "7311ecc5-33f7-42f5-92b6-7f0789bf92a5",
+				CreatedAt:  time.Date(2003, 3, 3, 3, 3, 3, 0, time.UTC),
+				Kind:       kind,
+				RawMessage: json.RawMessage(`{"name": "app-2"}`),
+			}
+			event3 := eventio.RawEvent{
+				GUID:       "395b7d4c-c859-4a28-9a53-6b15fab447c7",
+				CreatedAt:  time.Date(2002, 2, 2, 2, 2, 2, 0, time.UTC),
+				Kind:       kind,
+				RawMessage: json.RawMessage(`{"name": "app-3"}`),
+			}
+			By("inserting a batch of events", func() {
+				err := db.Schema.StoreEvents([]eventio.RawEvent{
+					event1,
+					event2,
+					event3,
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+			By("fetching back a single event", func() {
+				storedEvents, err := db.Schema.GetEvents(eventio.RawEventFilter{
+					Kind:   kind,
+					Limit:  1,
+					Before: time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(storedEvents).To(Equal([]eventio.RawEvent{
+					event1,
+				}))
+			})
+		},
+		Entry("app event", "app"),
+		Entry("service event", "service"),
+		Entry("compose event", "compose"),
+	)
+
+	Describe("filtering raw events", func() {
+		var (
+			db      *testenv.TempDB
+			appGUID = "c85e98f0-6d1b-4f45-9368-ea58263165a0"
+			orgGUID = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+			started = eventio.RawEvent{
+				GUID:       "94147a2f-2626-4445-8b4e-22ebe8071a29",
+				CreatedAt:  time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC),
+				Kind:       "app",
+				RawMessage: json.RawMessage(`{"state": "STARTED", "app_guid": "` + appGUID + `", "org_guid": "` + orgGUID + `"}`),
+			}
+			staging = eventio.RawEvent{
+				GUID:       "7311ecc5-33f7-42f5-92b6-7f0789bf92a5",
+				CreatedAt:  time.Date(2002, 2, 2, 2, 2, 2, 0, time.UTC),
+				Kind:       "app",
+				RawMessage: json.RawMessage(`{"state": "STAGING_STARTED", "parent_app_guid": "` + appGUID + `", "org_guid": "` + orgGUID + `"}`),
+			}
+			stopped = eventio.RawEvent{
+				GUID:       "395b7d4c-c859-4a28-9a53-6b15fab447c7",
+				CreatedAt:  time.Date(2003, 3, 3, 3, 3, 3, 0, time.UTC),
+				Kind:       "app",
+				RawMessage: json.RawMessage(`{"state": "STOPPED", "app_guid": "` + appGUID + `", "org_guid": "` + orgGUID + `"}`),
+			}
+			otherOrg = eventio.RawEvent{
+				GUID:       "c1a3ad8f-4e4a-4c5e-b9f1-2f1b3b0b8f4e",
+				CreatedAt:  time.Date(2003, 3, 3, 3, 3, 4, 0, time.UTC),
+				Kind:       "app",
+				RawMessage: json.RawMessage(`{"state": "STARTED", "app_guid": "d7f0b3a2-5f3e-4b8a-9b1c-6a2e4f8d0c11", "org_guid": "2884b2bc-f74b-4aaa-956d-f679ca498dce"}`),
+			}
+		)
+
+		BeforeEach(func() {
+			var err error
+			db, err = testenv.Open(eventstore.Config{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Schema.StoreEvents([]eventio.RawEvent{started, staging, stopped, otherOrg})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			db.Close()
+		})
+
+		It("should filter by resource, including the staging of an app", func() {
+			events, err := db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:         "app",
+				Reverse:      true,
+				ResourceGUID: appGUID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]eventio.RawEvent{started, staging, stopped}))
+		})
+
+		It("should filter by org, state and time", func() {
+			events, err := db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:    "app",
+				Reverse: true,
+				OrgGUID: orgGUID,
+				State:   "STARTED",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]eventio.RawEvent{started}))
+
+			events, err = db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:    "app",
+				Reverse: true,
+				Since:   time.Date(2002, 2, 2, 2, 2, 2, 0, time.UTC),
+				Before:  time.Date(2003, 3, 3, 3, 3, 4, 0, time.UTC),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]eventio.RawEvent{staging, stopped}))
+		})
+
+		It("should page through the events after a given event", func() {
+			events, err := db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:      "app",
+				Reverse:   true,
+				Limit:     2,
+				AfterGUID: started.GUID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]eventio.RawEvent{staging, stopped}))
+
+			events, err = db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:      "app",
+				Limit:     2,
+				AfterGUID: stopped.GUID,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]eventio.RawEvent{staging, started}))
+		})
+
+		It("should refuse to page after an event that does not exist", func() {
+			_, err := db.Schema.GetEvents(eventio.RawEventFilter{
+				Kind:      "app",
+				AfterGUID: "00000000-0000-0000-0000-000000000000",
+			})
+			Expect(err).To(Equal(&eventio.UnknownAfterGUIDError{
+				Kind: "app",
+				GUID: "00000000-0000-0000-0000-000000000000",
+			}))
+		})
+	})
+
 	Describe("pg_size_bytes", func() {
 		var db *testenv.TempDB
 
@@ -1,6 +1,8 @@
 package eventstore
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/lager"
@@ -9,12 +11,30 @@ import (
 
 var _ eventio.TotalCostReader = &EventStore{}
 
-func (s *EventStore) GetTotalCost() ([]eventio.TotalCost, error) {
+func (s *EventStore) GetTotalCost(filter eventio.TotalCostFilter) ([]eventio.TotalCost, error) {
 	startTime := time.Now()
-	rows, err := s.db.Query(`select plan_guid, round(sum(cost_for_duration),2) as cost from billable_event_components group by plan_guid order by plan_guid`)
+	conditions, args := appendLabelConditions(nil, nil, filter.Labels)
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "where " + strings.Join(conditions, " and ")
+	}
+	rows, err := s.db.Query(fmt.Sprintf(`
+		select
+			plan_guid,
+			coalesce(%s, '') as label_value,
+			round(sum(cost_for_duration),2) as cost
+		from
+			billable_event_components
+		%s
+		group by
+			plan_guid, label_value
+		order by
+			plan_guid, label_value
+	`, labelValueExpression(filter.GroupByLabel), whereClause), args...)
 
 	if err != nil {
 		s.logger.Error("get-total-cost", err, lager.Data{
+			"filter":  filter,
 			"elapsed": int64(time.Since(startTime)),
 		})
 		return nil, err
@@ -25,7 +45,7 @@ func (s *EventStore) GetTotalCost() ([]eventio.TotalCost, error) {
 	planGUIDSByCost := []eventio.TotalCost{}
 	for rows.Next() {
 		var planGUIDByCost eventio.TotalCost
-		if err := rows.Scan(&planGUIDByCost.PlanGUID, &planGUIDByCost.Cost); err != nil {
+		if err := rows.Scan(&planGUIDByCost.PlanGUID, &planGUIDByCost.LabelValue, &planGUIDByCost.Cost); err != nil {
 			return nil, err
 		}
 		planGUIDSByCost = append(planGUIDSByCost, planGUIDByCost)
@@ -33,6 +53,7 @@ func (s *EventStore) GetTotalCost() ([]eventio.TotalCost, error) {
 	}
 
 	s.logger.Info("get-total-cost", lager.Data{
+		"filter":  filter,
 		"elapsed": int64(time.Since(startTime)),
 	})
 	return planGUIDSByCost, nil
@@ -101,7 +101,7 @@ var _ = Describe("TotalCostEvents", func() {
 		Expect(db.Insert("service_usage_events", service1EventStart, service1EventStop)).To(Succeed())
 		Expect(db.Schema.Refresh()).To(Succeed())
 		store := db.Schema
-		outputEvents, err := store.GetTotalCost()
+		outputEvents, err := store.GetTotalCost(eventio.TotalCostFilter{})
 		Expect(err).ToNot(HaveOccurred())
 		Expect(len(outputEvents)).To(Equal(2))
 		Expect(outputEvents[0]).To(Equal(eventio.TotalCost{
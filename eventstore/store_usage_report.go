@@ -0,0 +1,223 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/lib/pq"
+)
+
+var _ eventio.UsageAndAdoptionReader = &EventStore{}
+
+// DefaultServiceCategories are used when service_categories is missing from
+// the config. They group the plans of each of the services offered.
+var DefaultServiceCategories = []eventio.ServiceCategory{
+	{Name: "compute", PlanNamePattern: "^(app|staging|task)$"},
+	{Name: "postgres", PlanNamePattern: "^postgres"},
+	{Name: "mysql", PlanNamePattern: "^mysql"},
+	{Name: "elasticsearch", PlanNamePattern: "^elasticsearch"},
+	{Name: "influxdb", PlanNamePattern: "^influx"},
+	{Name: "redis", PlanNamePattern: "^redis"},
+	{Name: "s3", PlanNamePattern: "^aws-s3"},
+}
+
+// DefaultExcludedReportOrgs are used when excluded_report_orgs is missing
+// from the config. They match the orgs created by acceptance and smoke tests,
+// whose names start with an upper case prefix or contain a GUID in either
+// case.
+var DefaultExcludedReportOrgs = []string{
+	"^(AIVENBACC|BACC|ACC|SMOKE)",
+	"(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}",
+}
+
+// GetUsageAndAdoption returns the monthly cost of each org's usage by service
+// category and the number of active and new orgs in each month of the filter
+// range. Months are calendar months cropped to the range. Adjustments are not
+// usage so they are left out of the report, as are orgs whose names match
+// one of the configured excluded report orgs. The range cannot start before
+// the raw events were archived.
+func (s *EventStore) GetUsageAndAdoption(filter eventio.EventFilter) (eventio.UsageAndAdoptionReport, error) {
+	report := eventio.UsageAndAdoptionReport{
+		ServiceCategories: []string{},
+		Orgs:              []eventio.OrgUsage{},
+		ActiveOrgs:        []eventio.ActiveOrgCount{},
+	}
+	if err := filter.Validate(); err != nil {
+		return report, err
+	}
+	periods, err := filter.SplitByMonth()
+	if err != nil {
+		return report, err
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, DefaultQueryTimeout)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	if err := checkRangeNotArchived(tx, filter.RangeStart); err != nil {
+		return report, err
+	}
+
+	rows, err := tx.Query(`select name from service_categories order by position`)
+	if err != nil {
+		return report, wrapPqError(err, "get-service-categories")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return report, err
+		}
+		report.ServiceCategories = append(report.ServiceCategories, name)
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+	report.ServiceCategories = append(report.ServiceCategories, eventio.OtherServiceCategory)
+
+	startTime := time.Now()
+	usageRows, err := queryJSON(tx, `
+		with
+		usage_components as (
+			select
+				*
+			from
+				billable_event_components
+			where
+				resource_type not in ('credit', 'debit', 'discount')
+		),
+		usage_orgs as (
+			select
+				org_guid,
+				(array_agg(org_name order by lower(duration) desc))[1] as org_name,
+				min(lower(duration)) as first_seen
+			from
+				usage_components
+			group by
+				org_guid
+		),
+		report_orgs as (
+			select
+				*
+			from
+				usage_orgs o
+			where
+				(cardinality($3::uuid[]) = 0 or o.org_guid = any($3::uuid[]))
+				and not exists (
+					select 1 from excluded_report_orgs e
+					where o.org_name ~ e.org_name_pattern
+				)
+		),
+		months as (
+			select
+				m.month,
+				tstzrange(m.month, m.month + interval '1 month') * tstzrange($1, $2) as period
+			from
+				generate_series(
+					date_trunc('month', $1::timestamptz),
+					$2::timestamptz - interval '1 second',
+					interval '1 month'
+				) m(month)
+		),
+		monthly_costs as (
+			select
+				m.month,
+				b.org_guid,
+				coalesce((
+					select sc.name from service_categories sc
+					where b.plan_name ~ sc.plan_name_pattern
+					order by sc.position
+					limit 1
+				), 'other') as service_category,
+				sum(eval_formula(
+					b.memory_in_mb,
+					b.storage_in_mb,
+					b.number_of_nodes,
+					b.duration * m.period,
+					b.component_formula
+				) * b.currency_rate) as cost
+			from
+				months m
+			inner join
+				usage_components b on b.duration && m.period
+			inner join
+				report_orgs o on o.org_guid = b.org_guid
+			group by
+				m.month, b.org_guid, service_category
+		)
+		select
+			to_char(mc.month, 'YYYY-MM-DD') as month,
+			mc.org_guid,
+			o.org_name,
+			to_char(o.first_seen, 'YYYY-MM-DD') as first_seen,
+			json_object_agg(mc.service_category, mc.cost::text) as costs,
+			sum(mc.cost)::text as total_cost
+		from
+			monthly_costs mc
+		inner join
+			report_orgs o on o.org_guid = mc.org_guid
+		group by
+			mc.month, mc.org_guid, o.org_name, o.first_seen
+		order by
+			mc.month, o.org_name, mc.org_guid
+	`, filter.RangeStart, filter.RangeStop, pq.Array(filter.OrgGUIDs))
+	elapsed := time.Since(startTime)
+	if err != nil {
+		s.logger.Error("get-usage-and-adoption-query", err, lager.Data{
+			"filter":  filter,
+			"elapsed": int64(elapsed),
+		})
+		return report, wrapPqError(err, "get-usage-and-adoption")
+	}
+	s.logger.Info("get-usage-and-adoption-query", lager.Data{
+		"filter":  filter,
+		"elapsed": int64(elapsed),
+	})
+	defer usageRows.Close()
+
+	counts := map[string]int{}
+	for i, period := range periods {
+		start, err := time.Parse("2006-01-02", period.RangeStart)
+		if err != nil {
+			return report, err
+		}
+		month := truncateMonth(start).Format("2006-01-02")
+		report.ActiveOrgs = append(report.ActiveOrgs, eventio.ActiveOrgCount{Month: month})
+		counts[month] = i
+	}
+	for usageRows.Next() {
+		var b []byte
+		if err := usageRows.Scan(&b); err != nil {
+			return report, err
+		}
+		var usage eventio.OrgUsage
+		if err := json.Unmarshal(b, &usage); err != nil {
+			return report, fmt.Errorf("failed to decode org usage: %s", err)
+		}
+		for _, category := range report.ServiceCategories {
+			if _, ok := usage.Costs[category]; !ok {
+				usage.Costs[category] = "0"
+			}
+		}
+		report.Orgs = append(report.Orgs, usage)
+		if i, ok := counts[usage.Month]; ok {
+			report.ActiveOrgs[i].ActiveOrgs++
+			if usage.FirstSeen[:7] == usage.Month[:7] {
+				report.ActiveOrgs[i].NewOrgs++
+			}
+		}
+	}
+	return report, usageRows.Err()
+}
+
+func truncateMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,173 @@
+package eventstore_test
+
+import (
+	"encoding/json"
+
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetUsageAndAdoption", func() {
+
+	var (
+		cfg       eventstore.Config
+		org1GUID  = "51ba75ef-edc0-47ad-a633-a8f6e8770944"
+		org2GUID  = "2884b2bc-f74b-4aaa-956d-f679ca498dce"
+		spaceGUID = "276f4886-ac40-492d-a8cd-b2646637ba76"
+	)
+
+	BeforeEach(func() {
+		cfg = testenv.BasicConfig
+		cfg.AddPlan(eventio.PricingPlan{
+			PlanGUID:  eventstore.ComputePlanGUID,
+			ValidFrom: "2001-01-01",
+			Name:      "app",
+			Components: []eventio.PricingPlanComponent{
+				{
+					Name:         "compute",
+					Formula:      "ceil($time_in_seconds/3600) * 1",
+					CurrencyCode: "GBP",
+					VATCode:      "Standard",
+				},
+			},
+		})
+		cfg.AddServiceCategory(eventio.ServiceCategory{
+			Name:            "compute",
+			PlanNamePattern: "^(app|task|staging)$",
+		})
+		cfg.AddServiceCategory(eventio.ServiceCategory{
+			Name:            "postgres",
+			PlanNamePattern: "^postgres",
+		})
+		cfg.ExcludedReportOrgs = []string{}
+	})
+
+	appEvent := func(guid, createdAt, state, orgGUID string) testenv.Row {
+		return testenv.Row{
+			"guid":        guid,
+			"created_at":  createdAt,
+			"raw_message": json.RawMessage(`{"state": "` + state + `", "app_guid": "c85e98f0-6d1b-4f45-9368-ea58263165a0", "app_name": "APP1", "org_guid": "` + orgGUID + `", "space_guid": "` + spaceGUID + `", "space_name": "SPACE1", "process_type": "web", "instance_count": 1, "previous_state": "STARTED", "memory_in_mb_per_instance": 1024}`),
+		}
+	}
+
+	open := func() *testenv.TempDB {
+		db, err := testenv.Open(cfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Insert("orgs",
+			testenv.Row{"guid": org1GUID, "valid_from": "2001-01-01T00:00Z", "name": "ORG1", "created_at": "2001-01-01T00:00Z", "updated_at": "2001-01-01T00:00Z"},
+			testenv.Row{"guid": org2GUID, "valid_from": "2001-01-01T00:00Z", "name": "SMOKE-ORG", "created_at": "2001-01-01T00:00Z", "updated_at": "2001-01-01T00:00Z"},
+		)).To(Succeed())
+		Expect(db.Insert("app_usage_events",
+			appEvent("00000000-0000-0000-0001-000000000001", "2001-01-01T00:00Z", "STARTED", org1GUID),
+			appEvent("00000000-0000-0000-0001-000000000002", "2001-01-02T16:00Z", "STOPPED", org1GUID),
+			appEvent("00000000-0000-0000-0001-000000000003", "2001-02-01T00:00Z", "STARTED", org1GUID),
+			appEvent("00000000-0000-0000-0001-000000000004", "2001-02-01T10:00Z", "STOPPED", org1GUID),
+			appEvent("00000000-0000-0000-0001-000000000005", "2001-02-01T00:00Z", "STARTED", org2GUID),
+			appEvent("00000000-0000-0000-0001-000000000006", "2001-02-01T05:00Z", "STOPPED", org2GUID),
+		)).To(Succeed())
+		Expect(db.Schema.Refresh()).To(Succeed())
+		return db
+	}
+
+	It("should report the monthly cost of each org by service category", func() {
+		db := open()
+		defer db.Close()
+
+		report, err := db.Schema.GetUsageAndAdoption(eventio.EventFilter{
+			RangeStart: "2001-01-01",
+			RangeStop:  "2001-03-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.ServiceCategories).To(Equal([]string{"compute", "postgres", "other"}))
+		Expect(report.Orgs).To(HaveLen(3))
+
+		Expect(report.Orgs[0].Month).To(Equal("2001-01-01"))
+		Expect(report.Orgs[0].OrgGUID).To(Equal(org1GUID))
+		Expect(report.Orgs[0].FirstSeen).To(Equal("2001-01-01"))
+		Expect(report.Orgs[0].Costs).To(Equal(map[string]string{
+			"compute":  "40",
+			"postgres": "0",
+			"other":    "0",
+		}))
+		Expect(report.Orgs[0].TotalCost).To(Equal("40"))
+
+		Expect(report.Orgs[1].Month).To(Equal("2001-02-01"))
+		Expect(report.Orgs[1].OrgGUID).To(Equal(org1GUID))
+		Expect(report.Orgs[1].Costs["compute"]).To(Equal("10"))
+
+		Expect(report.Orgs[2].Month).To(Equal("2001-02-01"))
+		Expect(report.Orgs[2].OrgGUID).To(Equal(org2GUID))
+		Expect(report.Orgs[2].FirstSeen).To(Equal("2001-02-01"))
+		Expect(report.Orgs[2].Costs["compute"]).To(Equal("5"))
+
+		Expect(report.ActiveOrgs).To(Equal([]eventio.ActiveOrgCount{
+			{Month: "2001-01-01", ActiveOrgs: 1, NewOrgs: 1},
+			{Month: "2001-02-01", ActiveOrgs: 2, NewOrgs: 1},
+		}))
+	})
+
+	It("should leave out orgs matching the excluded report orgs", func() {
+		cfg.ExcludedReportOrgs = []string{"^SMOKE"}
+		db := open()
+		defer db.Close()
+
+		report, err := db.Schema.GetUsageAndAdoption(eventio.EventFilter{
+			RangeStart: "2001-02-01",
+			RangeStop:  "2001-03-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Orgs).To(HaveLen(1))
+		Expect(report.Orgs[0].OrgGUID).To(Equal(org1GUID))
+		Expect(report.ActiveOrgs).To(Equal([]eventio.ActiveOrgCount{
+			{Month: "2001-02-01", ActiveOrgs: 1, NewOrgs: 0},
+		}))
+	})
+
+	It("should use the default service categories and excluded orgs if none are configured", func() {
+		cfg.ServiceCategories = nil
+		cfg.ExcludedReportOrgs = nil
+		db := open()
+		defer db.Close()
+
+		report, err := db.Schema.GetUsageAndAdoption(eventio.EventFilter{
+			RangeStart: "2001-02-01",
+			RangeStop:  "2001-03-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.ServiceCategories).To(Equal([]string{
+			"compute", "postgres", "mysql", "elasticsearch", "influxdb", "redis", "s3", "other",
+		}))
+		Expect(report.Orgs).To(HaveLen(1))
+		Expect(report.Orgs[0].OrgGUID).To(Equal(org1GUID))
+		Expect(report.Orgs[0].Costs["compute"]).To(Equal("10"))
+	})
+
+	It("should match the excluded org name prefixes case sensitively", func() {
+		cfg.ExcludedReportOrgs = nil
+		db := open()
+		defer db.Close()
+		_, err := db.Conn.Exec(`update orgs set name = 'accessibility' where guid = $1`, org1GUID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.Schema.Refresh()).To(Succeed())
+
+		report, err := db.Schema.GetUsageAndAdoption(eventio.EventFilter{
+			RangeStart: "2001-02-01",
+			RangeStop:  "2001-03-01",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Orgs).To(HaveLen(1))
+		Expect(report.Orgs[0].OrgGUID).To(Equal(org1GUID))
+	})
+
+	It("should reject service categories with invalid patterns", func() {
+		cfg.AddServiceCategory(eventio.ServiceCategory{
+			Name:            "broken",
+			PlanNamePattern: "(",
+		})
+		_, err := testenv.Open(cfg)
+		Expect(err).To(MatchError(ContainSubstring("invalid service category")))
+	})
+})
@@ -10,8 +10,9 @@ import (
 type FakeAuthorizer struct {
 	AdminStub        func() (bool, error)
 	adminMutex       sync.RWMutex
-	adminArgsForCall []struct{}
-	adminReturns     struct {
+	adminArgsForCall []struct {
+	}
+	adminReturns struct {
 		result1 bool
 		result2 error
 	}
@@ -19,6 +20,18 @@ type FakeAuthorizer struct {
 		result1 bool
 		result2 error
 	}
+	AdminWriteStub        func() (bool, error)
+	adminWriteMutex       sync.RWMutex
+	adminWriteArgsForCall []struct {
+	}
+	adminWriteReturns struct {
+		result1 bool
+		result2 error
+	}
+	adminWriteReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	HasBillingAccessStub        func([]string) (bool, error)
 	hasBillingAccessMutex       sync.RWMutex
 	hasBillingAccessArgsForCall []struct {
@@ -32,6 +45,18 @@ type FakeAuthorizer struct {
 		result1 bool
 		result2 error
 	}
+	UsernameStub        func() (string, error)
+	usernameMutex       sync.RWMutex
+	usernameArgsForCall []struct {
+	}
+	usernameReturns struct {
+		result1 string
+		result2 error
+	}
+	usernameReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -39,7 +64,8 @@ type FakeAuthorizer struct {
 func (fake *FakeAuthorizer) Admin() (bool, error) {
 	fake.adminMutex.Lock()
 	ret, specificReturn := fake.adminReturnsOnCall[len(fake.adminArgsForCall)]
-	fake.adminArgsForCall = append(fake.adminArgsForCall, struct{}{})
+	fake.adminArgsForCall = append(fake.adminArgsForCall, struct {
+	}{})
 	fake.recordInvocation("Admin", []interface{}{})
 	fake.adminMutex.Unlock()
 	if fake.AdminStub != nil {
@@ -48,7 +74,8 @@ func (fake *FakeAuthorizer) Admin() (bool, error) {
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.adminReturns.result1, fake.adminReturns.result2
+	fakeReturns := fake.adminReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeAuthorizer) AdminCallCount() int {
@@ -57,7 +84,15 @@ func (fake *FakeAuthorizer) AdminCallCount() int {
 	return len(fake.adminArgsForCall)
 }
 
+func (fake *FakeAuthorizer) AdminCalls(stub func() (bool, error)) {
+	fake.adminMutex.Lock()
+	defer fake.adminMutex.Unlock()
+	fake.AdminStub = stub
+}
+
 func (fake *FakeAuthorizer) AdminReturns(result1 bool, result2 error) {
+	fake.adminMutex.Lock()
+	defer fake.adminMutex.Unlock()
 	fake.AdminStub = nil
 	fake.adminReturns = struct {
 		result1 bool
@@ -66,6 +101,8 @@ func (fake *FakeAuthorizer) AdminReturns(result1 bool, result2 error) {
 }
 
 func (fake *FakeAuthorizer) AdminReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.adminMutex.Lock()
+	defer fake.adminMutex.Unlock()
 	fake.AdminStub = nil
 	if fake.adminReturnsOnCall == nil {
 		fake.adminReturnsOnCall = make(map[int]struct {
@@ -79,6 +116,61 @@ func (fake *FakeAuthorizer) AdminReturnsOnCall(i int, result1 bool, result2 erro
 	}{result1, result2}
 }
 
+func (fake *FakeAuthorizer) AdminWrite() (bool, error) {
+	fake.adminWriteMutex.Lock()
+	ret, specificReturn := fake.adminWriteReturnsOnCall[len(fake.adminWriteArgsForCall)]
+	fake.adminWriteArgsForCall = append(fake.adminWriteArgsForCall, struct {
+	}{})
+	fake.recordInvocation("AdminWrite", []interface{}{})
+	fake.adminWriteMutex.Unlock()
+	if fake.AdminWriteStub != nil {
+		return fake.AdminWriteStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.adminWriteReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAuthorizer) AdminWriteCallCount() int {
+	fake.adminWriteMutex.RLock()
+	defer fake.adminWriteMutex.RUnlock()
+	return len(fake.adminWriteArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AdminWriteCalls(stub func() (bool, error)) {
+	fake.adminWriteMutex.Lock()
+	defer fake.adminWriteMutex.Unlock()
+	fake.AdminWriteStub = stub
+}
+
+func (fake *FakeAuthorizer) AdminWriteReturns(result1 bool, result2 error) {
+	fake.adminWriteMutex.Lock()
+	defer fake.adminWriteMutex.Unlock()
+	fake.AdminWriteStub = nil
+	fake.adminWriteReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuthorizer) AdminWriteReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.adminWriteMutex.Lock()
+	defer fake.adminWriteMutex.Unlock()
+	fake.AdminWriteStub = nil
+	if fake.adminWriteReturnsOnCall == nil {
+		fake.adminWriteReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.adminWriteReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAuthorizer) HasBillingAccess(arg1 []string) (bool, error) {
 	var arg1Copy []string
 	if arg1 != nil {
@@ -98,7 +190,8 @@ func (fake *FakeAuthorizer) HasBillingAccess(arg1 []string) (bool, error) {
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.hasBillingAccessReturns.result1, fake.hasBillingAccessReturns.result2
+	fakeReturns := fake.hasBillingAccessReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeAuthorizer) HasBillingAccessCallCount() int {
@@ -107,13 +200,22 @@ func (fake *FakeAuthorizer) HasBillingAccessCallCount() int {
 	return len(fake.hasBillingAccessArgsForCall)
 }
 
+func (fake *FakeAuthorizer) HasBillingAccessCalls(stub func([]string) (bool, error)) {
+	fake.hasBillingAccessMutex.Lock()
+	defer fake.hasBillingAccessMutex.Unlock()
+	fake.HasBillingAccessStub = stub
+}
+
 func (fake *FakeAuthorizer) HasBillingAccessArgsForCall(i int) []string {
 	fake.hasBillingAccessMutex.RLock()
 	defer fake.hasBillingAccessMutex.RUnlock()
-	return fake.hasBillingAccessArgsForCall[i].arg1
+	argsForCall := fake.hasBillingAccessArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *FakeAuthorizer) HasBillingAccessReturns(result1 bool, result2 error) {
+	fake.hasBillingAccessMutex.Lock()
+	defer fake.hasBillingAccessMutex.Unlock()
 	fake.HasBillingAccessStub = nil
 	fake.hasBillingAccessReturns = struct {
 		result1 bool
@@ -122,6 +224,8 @@ func (fake *FakeAuthorizer) HasBillingAccessReturns(result1 bool, result2 error)
 }
 
 func (fake *FakeAuthorizer) HasBillingAccessReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.hasBillingAccessMutex.Lock()
+	defer fake.hasBillingAccessMutex.Unlock()
 	fake.HasBillingAccessStub = nil
 	if fake.hasBillingAccessReturnsOnCall == nil {
 		fake.hasBillingAccessReturnsOnCall = make(map[int]struct {
@@ -135,13 +239,72 @@ func (fake *FakeAuthorizer) HasBillingAccessReturnsOnCall(i int, result1 bool, r
 	}{result1, result2}
 }
 
+func (fake *FakeAuthorizer) Username() (string, error) {
+	fake.usernameMutex.Lock()
+	ret, specificReturn := fake.usernameReturnsOnCall[len(fake.usernameArgsForCall)]
+	fake.usernameArgsForCall = append(fake.usernameArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Username", []interface{}{})
+	fake.usernameMutex.Unlock()
+	if fake.UsernameStub != nil {
+		return fake.UsernameStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.usernameReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAuthorizer) UsernameCallCount() int {
+	fake.usernameMutex.RLock()
+	defer fake.usernameMutex.RUnlock()
+	return len(fake.usernameArgsForCall)
+}
+
+func (fake *FakeAuthorizer) UsernameCalls(stub func() (string, error)) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = stub
+}
+
+func (fake *FakeAuthorizer) UsernameReturns(result1 string, result2 error) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = nil
+	fake.usernameReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAuthorizer) UsernameReturnsOnCall(i int, result1 string, result2 error) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = nil
+	if fake.usernameReturnsOnCall == nil {
+		fake.usernameReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.usernameReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAuthorizer) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.adminMutex.RLock()
 	defer fake.adminMutex.RUnlock()
+	fake.adminWriteMutex.RLock()
+	defer fake.adminWriteMutex.RUnlock()
 	fake.hasBillingAccessMutex.RLock()
 	defer fake.hasBillingAccessMutex.RUnlock()
+	fake.usernameMutex.RLock()
+	defer fake.usernameMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -9,10 +9,73 @@ import (
 )
 
 type FakeCFDataClient struct {
+	ListOrgBillingManagersStub        func(string) ([]cfclient.User, error)
+	listOrgBillingManagersMutex       sync.RWMutex
+	listOrgBillingManagersArgsForCall []struct {
+		arg1 string
+	}
+	listOrgBillingManagersReturns struct {
+		result1 []cfclient.User
+		result2 error
+	}
+	listOrgBillingManagersReturnsOnCall map[int]struct {
+		result1 []cfclient.User
+		result2 error
+	}
+	ListOrgManagersStub        func(string) ([]cfclient.User, error)
+	listOrgManagersMutex       sync.RWMutex
+	listOrgManagersArgsForCall []struct {
+		arg1 string
+	}
+	listOrgManagersReturns struct {
+		result1 []cfclient.User
+		result2 error
+	}
+	listOrgManagersReturnsOnCall map[int]struct {
+		result1 []cfclient.User
+		result2 error
+	}
+	ListOrgMetadataStub        func() ([]cfstore.ResourceMetadata, error)
+	listOrgMetadataMutex       sync.RWMutex
+	listOrgMetadataArgsForCall []struct {
+	}
+	listOrgMetadataReturns struct {
+		result1 []cfstore.ResourceMetadata
+		result2 error
+	}
+	listOrgMetadataReturnsOnCall map[int]struct {
+		result1 []cfstore.ResourceMetadata
+		result2 error
+	}
+	ListOrgQuotasStub        func() ([]cfclient.OrgQuota, error)
+	listOrgQuotasMutex       sync.RWMutex
+	listOrgQuotasArgsForCall []struct {
+	}
+	listOrgQuotasReturns struct {
+		result1 []cfclient.OrgQuota
+		result2 error
+	}
+	listOrgQuotasReturnsOnCall map[int]struct {
+		result1 []cfclient.OrgQuota
+		result2 error
+	}
+	ListOrgsStub        func() ([]cfclient.Org, error)
+	listOrgsMutex       sync.RWMutex
+	listOrgsArgsForCall []struct {
+	}
+	listOrgsReturns struct {
+		result1 []cfclient.Org
+		result2 error
+	}
+	listOrgsReturnsOnCall map[int]struct {
+		result1 []cfclient.Org
+		result2 error
+	}
 	ListServicePlansStub        func() ([]cfclient.ServicePlan, error)
 	listServicePlansMutex       sync.RWMutex
-	listServicePlansArgsForCall []struct{}
-	listServicePlansReturns     struct {
+	listServicePlansArgsForCall []struct {
+	}
+	listServicePlansReturns struct {
 		result1 []cfclient.ServicePlan
 		result2 error
 	}
@@ -22,8 +85,9 @@ type FakeCFDataClient struct {
 	}
 	ListServicesStub        func() ([]cfclient.Service, error)
 	listServicesMutex       sync.RWMutex
-	listServicesArgsForCall []struct{}
-	listServicesReturns     struct {
+	listServicesArgsForCall []struct {
+	}
+	listServicesReturns struct {
 		result1 []cfclient.Service
 		result2 error
 	}
@@ -31,21 +95,23 @@ type FakeCFDataClient struct {
 		result1 []cfclient.Service
 		result2 error
 	}
-	ListOrgsStub        func() ([]cfclient.Org, error)
-	listOrgsMutex       sync.RWMutex
-	listOrgsArgsForCall []struct{}
-	listOrgsReturns     struct {
-		result1 []cfclient.Org
+	ListSpaceMetadataStub        func() ([]cfstore.ResourceMetadata, error)
+	listSpaceMetadataMutex       sync.RWMutex
+	listSpaceMetadataArgsForCall []struct {
+	}
+	listSpaceMetadataReturns struct {
+		result1 []cfstore.ResourceMetadata
 		result2 error
 	}
-	listOrgsReturnsOnCall map[int]struct {
-		result1 []cfclient.Org
+	listSpaceMetadataReturnsOnCall map[int]struct {
+		result1 []cfstore.ResourceMetadata
 		result2 error
 	}
 	ListSpacesStub        func() ([]cfclient.Space, error)
 	listSpacesMutex       sync.RWMutex
-	listSpacesArgsForCall []struct{}
-	listSpacesReturns     struct {
+	listSpacesArgsForCall []struct {
+	}
+	listSpacesReturns struct {
 		result1 []cfclient.Space
 		result2 error
 	}
@@ -57,10 +123,302 @@ type FakeCFDataClient struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeCFDataClient) ListOrgBillingManagers(arg1 string) ([]cfclient.User, error) {
+	fake.listOrgBillingManagersMutex.Lock()
+	ret, specificReturn := fake.listOrgBillingManagersReturnsOnCall[len(fake.listOrgBillingManagersArgsForCall)]
+	fake.listOrgBillingManagersArgsForCall = append(fake.listOrgBillingManagersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListOrgBillingManagers", []interface{}{arg1})
+	fake.listOrgBillingManagersMutex.Unlock()
+	if fake.ListOrgBillingManagersStub != nil {
+		return fake.ListOrgBillingManagersStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOrgBillingManagersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFDataClient) ListOrgBillingManagersCallCount() int {
+	fake.listOrgBillingManagersMutex.RLock()
+	defer fake.listOrgBillingManagersMutex.RUnlock()
+	return len(fake.listOrgBillingManagersArgsForCall)
+}
+
+func (fake *FakeCFDataClient) ListOrgBillingManagersCalls(stub func(string) ([]cfclient.User, error)) {
+	fake.listOrgBillingManagersMutex.Lock()
+	defer fake.listOrgBillingManagersMutex.Unlock()
+	fake.ListOrgBillingManagersStub = stub
+}
+
+func (fake *FakeCFDataClient) ListOrgBillingManagersArgsForCall(i int) string {
+	fake.listOrgBillingManagersMutex.RLock()
+	defer fake.listOrgBillingManagersMutex.RUnlock()
+	argsForCall := fake.listOrgBillingManagersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCFDataClient) ListOrgBillingManagersReturns(result1 []cfclient.User, result2 error) {
+	fake.listOrgBillingManagersMutex.Lock()
+	defer fake.listOrgBillingManagersMutex.Unlock()
+	fake.ListOrgBillingManagersStub = nil
+	fake.listOrgBillingManagersReturns = struct {
+		result1 []cfclient.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgBillingManagersReturnsOnCall(i int, result1 []cfclient.User, result2 error) {
+	fake.listOrgBillingManagersMutex.Lock()
+	defer fake.listOrgBillingManagersMutex.Unlock()
+	fake.ListOrgBillingManagersStub = nil
+	if fake.listOrgBillingManagersReturnsOnCall == nil {
+		fake.listOrgBillingManagersReturnsOnCall = make(map[int]struct {
+			result1 []cfclient.User
+			result2 error
+		})
+	}
+	fake.listOrgBillingManagersReturnsOnCall[i] = struct {
+		result1 []cfclient.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgManagers(arg1 string) ([]cfclient.User, error) {
+	fake.listOrgManagersMutex.Lock()
+	ret, specificReturn := fake.listOrgManagersReturnsOnCall[len(fake.listOrgManagersArgsForCall)]
+	fake.listOrgManagersArgsForCall = append(fake.listOrgManagersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListOrgManagers", []interface{}{arg1})
+	fake.listOrgManagersMutex.Unlock()
+	if fake.ListOrgManagersStub != nil {
+		return fake.ListOrgManagersStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOrgManagersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFDataClient) ListOrgManagersCallCount() int {
+	fake.listOrgManagersMutex.RLock()
+	defer fake.listOrgManagersMutex.RUnlock()
+	return len(fake.listOrgManagersArgsForCall)
+}
+
+func (fake *FakeCFDataClient) ListOrgManagersCalls(stub func(string) ([]cfclient.User, error)) {
+	fake.listOrgManagersMutex.Lock()
+	defer fake.listOrgManagersMutex.Unlock()
+	fake.ListOrgManagersStub = stub
+}
+
+func (fake *FakeCFDataClient) ListOrgManagersArgsForCall(i int) string {
+	fake.listOrgManagersMutex.RLock()
+	defer fake.listOrgManagersMutex.RUnlock()
+	argsForCall := fake.listOrgManagersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCFDataClient) ListOrgManagersReturns(result1 []cfclient.User, result2 error) {
+	fake.listOrgManagersMutex.Lock()
+	defer fake.listOrgManagersMutex.Unlock()
+	fake.ListOrgManagersStub = nil
+	fake.listOrgManagersReturns = struct {
+		result1 []cfclient.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgManagersReturnsOnCall(i int, result1 []cfclient.User, result2 error) {
+	fake.listOrgManagersMutex.Lock()
+	defer fake.listOrgManagersMutex.Unlock()
+	fake.ListOrgManagersStub = nil
+	if fake.listOrgManagersReturnsOnCall == nil {
+		fake.listOrgManagersReturnsOnCall = make(map[int]struct {
+			result1 []cfclient.User
+			result2 error
+		})
+	}
+	fake.listOrgManagersReturnsOnCall[i] = struct {
+		result1 []cfclient.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgMetadata() ([]cfstore.ResourceMetadata, error) {
+	fake.listOrgMetadataMutex.Lock()
+	ret, specificReturn := fake.listOrgMetadataReturnsOnCall[len(fake.listOrgMetadataArgsForCall)]
+	fake.listOrgMetadataArgsForCall = append(fake.listOrgMetadataArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ListOrgMetadata", []interface{}{})
+	fake.listOrgMetadataMutex.Unlock()
+	if fake.ListOrgMetadataStub != nil {
+		return fake.ListOrgMetadataStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOrgMetadataReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFDataClient) ListOrgMetadataCallCount() int {
+	fake.listOrgMetadataMutex.RLock()
+	defer fake.listOrgMetadataMutex.RUnlock()
+	return len(fake.listOrgMetadataArgsForCall)
+}
+
+func (fake *FakeCFDataClient) ListOrgMetadataCalls(stub func() ([]cfstore.ResourceMetadata, error)) {
+	fake.listOrgMetadataMutex.Lock()
+	defer fake.listOrgMetadataMutex.Unlock()
+	fake.ListOrgMetadataStub = stub
+}
+
+func (fake *FakeCFDataClient) ListOrgMetadataReturns(result1 []cfstore.ResourceMetadata, result2 error) {
+	fake.listOrgMetadataMutex.Lock()
+	defer fake.listOrgMetadataMutex.Unlock()
+	fake.ListOrgMetadataStub = nil
+	fake.listOrgMetadataReturns = struct {
+		result1 []cfstore.ResourceMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgMetadataReturnsOnCall(i int, result1 []cfstore.ResourceMetadata, result2 error) {
+	fake.listOrgMetadataMutex.Lock()
+	defer fake.listOrgMetadataMutex.Unlock()
+	fake.ListOrgMetadataStub = nil
+	if fake.listOrgMetadataReturnsOnCall == nil {
+		fake.listOrgMetadataReturnsOnCall = make(map[int]struct {
+			result1 []cfstore.ResourceMetadata
+			result2 error
+		})
+	}
+	fake.listOrgMetadataReturnsOnCall[i] = struct {
+		result1 []cfstore.ResourceMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgQuotas() ([]cfclient.OrgQuota, error) {
+	fake.listOrgQuotasMutex.Lock()
+	ret, specificReturn := fake.listOrgQuotasReturnsOnCall[len(fake.listOrgQuotasArgsForCall)]
+	fake.listOrgQuotasArgsForCall = append(fake.listOrgQuotasArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ListOrgQuotas", []interface{}{})
+	fake.listOrgQuotasMutex.Unlock()
+	if fake.ListOrgQuotasStub != nil {
+		return fake.ListOrgQuotasStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOrgQuotasReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFDataClient) ListOrgQuotasCallCount() int {
+	fake.listOrgQuotasMutex.RLock()
+	defer fake.listOrgQuotasMutex.RUnlock()
+	return len(fake.listOrgQuotasArgsForCall)
+}
+
+func (fake *FakeCFDataClient) ListOrgQuotasCalls(stub func() ([]cfclient.OrgQuota, error)) {
+	fake.listOrgQuotasMutex.Lock()
+	defer fake.listOrgQuotasMutex.Unlock()
+	fake.ListOrgQuotasStub = stub
+}
+
+func (fake *FakeCFDataClient) ListOrgQuotasReturns(result1 []cfclient.OrgQuota, result2 error) {
+	fake.listOrgQuotasMutex.Lock()
+	defer fake.listOrgQuotasMutex.Unlock()
+	fake.ListOrgQuotasStub = nil
+	fake.listOrgQuotasReturns = struct {
+		result1 []cfclient.OrgQuota
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgQuotasReturnsOnCall(i int, result1 []cfclient.OrgQuota, result2 error) {
+	fake.listOrgQuotasMutex.Lock()
+	defer fake.listOrgQuotasMutex.Unlock()
+	fake.ListOrgQuotasStub = nil
+	if fake.listOrgQuotasReturnsOnCall == nil {
+		fake.listOrgQuotasReturnsOnCall = make(map[int]struct {
+			result1 []cfclient.OrgQuota
+			result2 error
+		})
+	}
+	fake.listOrgQuotasReturnsOnCall[i] = struct {
+		result1 []cfclient.OrgQuota
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgs() ([]cfclient.Org, error) {
+	fake.listOrgsMutex.Lock()
+	ret, specificReturn := fake.listOrgsReturnsOnCall[len(fake.listOrgsArgsForCall)]
+	fake.listOrgsArgsForCall = append(fake.listOrgsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ListOrgs", []interface{}{})
+	fake.listOrgsMutex.Unlock()
+	if fake.ListOrgsStub != nil {
+		return fake.ListOrgsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOrgsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFDataClient) ListOrgsCallCount() int {
+	fake.listOrgsMutex.RLock()
+	defer fake.listOrgsMutex.RUnlock()
+	return len(fake.listOrgsArgsForCall)
+}
+
+func (fake *FakeCFDataClient) ListOrgsCalls(stub func() ([]cfclient.Org, error)) {
+	fake.listOrgsMutex.Lock()
+	defer fake.listOrgsMutex.Unlock()
+	fake.ListOrgsStub = stub
+}
+
+func (fake *FakeCFDataClient) ListOrgsReturns(result1 []cfclient.Org, result2 error) {
+	fake.listOrgsMutex.Lock()
+	defer fake.listOrgsMutex.Unlock()
+	fake.ListOrgsStub = nil
+	fake.listOrgsReturns = struct {
+		result1 []cfclient.Org
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFDataClient) ListOrgsReturnsOnCall(i int, result1 []cfclient.Org, result2 error) {
+	fake.listOrgsMutex.Lock()
+	defer fake.listOrgsMutex.Unlock()
+	fake.ListOrgsStub = nil
+	if fake.listOrgsReturnsOnCall == nil {
+		fake.listOrgsReturnsOnCall = make(map[int]struct {
+			result1 []cfclient.Org
+			result2 error
+		})
+	}
+	fake.listOrgsReturnsOnCall[i] = struct {
+		result1 []cfclient.Org
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCFDataClient) ListServicePlans() ([]cfclient.ServicePlan, error) {
 	fake.listServicePlansMutex.Lock()
 	ret, specificReturn := fake.listServicePlansReturnsOnCall[len(fake.listServicePlansArgsForCall)]
-	fake.listServicePlansArgsForCall = append(fake.listServicePlansArgsForCall, struct{}{})
+	fake.listServicePlansArgsForCall = append(fake.listServicePlansArgsForCall, struct {
+	}{})
 	fake.recordInvocation("ListServicePlans", []interface{}{})
 	fake.listServicePlansMutex.Unlock()
 	if fake.ListServicePlansStub != nil {
@@ -69,7 +427,8 @@ func (fake *FakeCFDataClient) ListServicePlans() ([]cfclient.ServicePlan, error)
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.listServicePlansReturns.result1, fake.listServicePlansReturns.result2
+	fakeReturns := fake.listServicePlansReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeCFDataClient) ListServicePlansCallCount() int {
@@ -78,7 +437,15 @@ func (fake *FakeCFDataClient) ListServicePlansCallCount() int {
 	return len(fake.listServicePlansArgsForCall)
 }
 
+func (fake *FakeCFDataClient) ListServicePlansCalls(stub func() ([]cfclient.ServicePlan, error)) {
+	fake.listServicePlansMutex.Lock()
+	defer fake.listServicePlansMutex.Unlock()
+	fake.ListServicePlansStub = stub
+}
+
 func (fake *FakeCFDataClient) ListServicePlansReturns(result1 []cfclient.ServicePlan, result2 error) {
+	fake.listServicePlansMutex.Lock()
+	defer fake.listServicePlansMutex.Unlock()
 	fake.ListServicePlansStub = nil
 	fake.listServicePlansReturns = struct {
 		result1 []cfclient.ServicePlan
@@ -87,6 +454,8 @@ func (fake *FakeCFDataClient) ListServicePlansReturns(result1 []cfclient.Service
 }
 
 func (fake *FakeCFDataClient) ListServicePlansReturnsOnCall(i int, result1 []cfclient.ServicePlan, result2 error) {
+	fake.listServicePlansMutex.Lock()
+	defer fake.listServicePlansMutex.Unlock()
 	fake.ListServicePlansStub = nil
 	if fake.listServicePlansReturnsOnCall == nil {
 		fake.listServicePlansReturnsOnCall = make(map[int]struct {
@@ -103,7 +472,8 @@ func (fake *FakeCFDataClient) ListServicePlansReturnsOnCall(i int, result1 []cfc
 func (fake *FakeCFDataClient) ListServices() ([]cfclient.Service, error) {
 	fake.listServicesMutex.Lock()
 	ret, specificReturn := fake.listServicesReturnsOnCall[len(fake.listServicesArgsForCall)]
-	fake.listServicesArgsForCall = append(fake.listServicesArgsForCall, struct{}{})
+	fake.listServicesArgsForCall = append(fake.listServicesArgsForCall, struct {
+	}{})
 	fake.recordInvocation("ListServices", []interface{}{})
 	fake.listServicesMutex.Unlock()
 	if fake.ListServicesStub != nil {
@@ -112,7 +482,8 @@ func (fake *FakeCFDataClient) ListServices() ([]cfclient.Service, error) {
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.listServicesReturns.result1, fake.listServicesReturns.result2
+	fakeReturns := fake.listServicesReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeCFDataClient) ListServicesCallCount() int {
@@ -121,7 +492,15 @@ func (fake *FakeCFDataClient) ListServicesCallCount() int {
 	return len(fake.listServicesArgsForCall)
 }
 
+func (fake *FakeCFDataClient) ListServicesCalls(stub func() ([]cfclient.Service, error)) {
+	fake.listServicesMutex.Lock()
+	defer fake.listServicesMutex.Unlock()
+	fake.ListServicesStub = stub
+}
+
 func (fake *FakeCFDataClient) ListServicesReturns(result1 []cfclient.Service, result2 error) {
+	fake.listServicesMutex.Lock()
+	defer fake.listServicesMutex.Unlock()
 	fake.ListServicesStub = nil
 	fake.listServicesReturns = struct {
 		result1 []cfclient.Service
@@ -130,6 +509,8 @@ func (fake *FakeCFDataClient) ListServicesReturns(result1 []cfclient.Service, re
 }
 
 func (fake *FakeCFDataClient) ListServicesReturnsOnCall(i int, result1 []cfclient.Service, result2 error) {
+	fake.listServicesMutex.Lock()
+	defer fake.listServicesMutex.Unlock()
 	fake.ListServicesStub = nil
 	if fake.listServicesReturnsOnCall == nil {
 		fake.listServicesReturnsOnCall = make(map[int]struct {
@@ -143,45 +524,57 @@ func (fake *FakeCFDataClient) ListServicesReturnsOnCall(i int, result1 []cfclien
 	}{result1, result2}
 }
 
-func (fake *FakeCFDataClient) ListOrgs() ([]cfclient.Org, error) {
-	fake.listOrgsMutex.Lock()
-	ret, specificReturn := fake.listOrgsReturnsOnCall[len(fake.listOrgsArgsForCall)]
-	fake.listOrgsArgsForCall = append(fake.listOrgsArgsForCall, struct{}{})
-	fake.recordInvocation("ListOrgs", []interface{}{})
-	fake.listOrgsMutex.Unlock()
-	if fake.ListOrgsStub != nil {
-		return fake.ListOrgsStub()
+func (fake *FakeCFDataClient) ListSpaceMetadata() ([]cfstore.ResourceMetadata, error) {
+	fake.listSpaceMetadataMutex.Lock()
+	ret, specificReturn := fake.listSpaceMetadataReturnsOnCall[len(fake.listSpaceMetadataArgsForCall)]
+	fake.listSpaceMetadataArgsForCall = append(fake.listSpaceMetadataArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ListSpaceMetadata", []interface{}{})
+	fake.listSpaceMetadataMutex.Unlock()
+	if fake.ListSpaceMetadataStub != nil {
+		return fake.ListSpaceMetadataStub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.listOrgsReturns.result1, fake.listOrgsReturns.result2
+	fakeReturns := fake.listSpaceMetadataReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeCFDataClient) ListOrgsCallCount() int {
-	fake.listOrgsMutex.RLock()
-	defer fake.listOrgsMutex.RUnlock()
-	return len(fake.listOrgsArgsForCall)
+func (fake *FakeCFDataClient) ListSpaceMetadataCallCount() int {
+	fake.listSpaceMetadataMutex.RLock()
+	defer fake.listSpaceMetadataMutex.RUnlock()
+	return len(fake.listSpaceMetadataArgsForCall)
 }
 
-func (fake *FakeCFDataClient) ListOrgsReturns(result1 []cfclient.Org, result2 error) {
-	fake.ListOrgsStub = nil
-	fake.listOrgsReturns = struct {
-		result1 []cfclient.Org
+func (fake *FakeCFDataClient) ListSpaceMetadataCalls(stub func() ([]cfstore.ResourceMetadata, error)) {
+	fake.listSpaceMetadataMutex.Lock()
+	defer fake.listSpaceMetadataMutex.Unlock()
+	fake.ListSpaceMetadataStub = stub
+}
+
+func (fake *FakeCFDataClient) ListSpaceMetadataReturns(result1 []cfstore.ResourceMetadata, result2 error) {
+	fake.listSpaceMetadataMutex.Lock()
+	defer fake.listSpaceMetadataMutex.Unlock()
+	fake.ListSpaceMetadataStub = nil
+	fake.listSpaceMetadataReturns = struct {
+		result1 []cfstore.ResourceMetadata
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCFDataClient) ListOrgsReturnsOnCall(i int, result1 []cfclient.Org, result2 error) {
-	fake.ListOrgsStub = nil
-	if fake.listOrgsReturnsOnCall == nil {
-		fake.listOrgsReturnsOnCall = make(map[int]struct {
-			result1 []cfclient.Org
+func (fake *FakeCFDataClient) ListSpaceMetadataReturnsOnCall(i int, result1 []cfstore.ResourceMetadata, result2 error) {
+	fake.listSpaceMetadataMutex.Lock()
+	defer fake.listSpaceMetadataMutex.Unlock()
+	fake.ListSpaceMetadataStub = nil
+	if fake.listSpaceMetadataReturnsOnCall == nil {
+		fake.listSpaceMetadataReturnsOnCall = make(map[int]struct {
+			result1 []cfstore.ResourceMetadata
 			result2 error
 		})
 	}
-	fake.listOrgsReturnsOnCall[i] = struct {
-		result1 []cfclient.Org
+	fake.listSpaceMetadataReturnsOnCall[i] = struct {
+		result1 []cfstore.ResourceMetadata
 		result2 error
 	}{result1, result2}
 }
@@ -189,7 +582,8 @@ func (fake *FakeCFDataClient) ListOrgsReturnsOnCall(i int, result1 []cfclient.Or
 func (fake *FakeCFDataClient) ListSpaces() ([]cfclient.Space, error) {
 	fake.listSpacesMutex.Lock()
 	ret, specificReturn := fake.listSpacesReturnsOnCall[len(fake.listSpacesArgsForCall)]
-	fake.listSpacesArgsForCall = append(fake.listSpacesArgsForCall, struct{}{})
+	fake.listSpacesArgsForCall = append(fake.listSpacesArgsForCall, struct {
+	}{})
 	fake.recordInvocation("ListSpaces", []interface{}{})
 	fake.listSpacesMutex.Unlock()
 	if fake.ListSpacesStub != nil {
@@ -198,7 +592,8 @@ func (fake *FakeCFDataClient) ListSpaces() ([]cfclient.Space, error) {
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.listSpacesReturns.result1, fake.listSpacesReturns.result2
+	fakeReturns := fake.listSpacesReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeCFDataClient) ListSpacesCallCount() int {
@@ -207,7 +602,15 @@ func (fake *FakeCFDataClient) ListSpacesCallCount() int {
 	return len(fake.listSpacesArgsForCall)
 }
 
+func (fake *FakeCFDataClient) ListSpacesCalls(stub func() ([]cfclient.Space, error)) {
+	fake.listSpacesMutex.Lock()
+	defer fake.listSpacesMutex.Unlock()
+	fake.ListSpacesStub = stub
+}
+
 func (fake *FakeCFDataClient) ListSpacesReturns(result1 []cfclient.Space, result2 error) {
+	fake.listSpacesMutex.Lock()
+	defer fake.listSpacesMutex.Unlock()
 	fake.ListSpacesStub = nil
 	fake.listSpacesReturns = struct {
 		result1 []cfclient.Space
@@ -216,6 +619,8 @@ func (fake *FakeCFDataClient) ListSpacesReturns(result1 []cfclient.Space, result
 }
 
 func (fake *FakeCFDataClient) ListSpacesReturnsOnCall(i int, result1 []cfclient.Space, result2 error) {
+	fake.listSpacesMutex.Lock()
+	defer fake.listSpacesMutex.Unlock()
 	fake.ListSpacesStub = nil
 	if fake.listSpacesReturnsOnCall == nil {
 		fake.listSpacesReturnsOnCall = make(map[int]struct {
@@ -232,12 +637,22 @@ func (fake *FakeCFDataClient) ListSpacesReturnsOnCall(i int, result1 []cfclient.
 func (fake *FakeCFDataClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.listOrgBillingManagersMutex.RLock()
+	defer fake.listOrgBillingManagersMutex.RUnlock()
+	fake.listOrgManagersMutex.RLock()
+	defer fake.listOrgManagersMutex.RUnlock()
+	fake.listOrgMetadataMutex.RLock()
+	defer fake.listOrgMetadataMutex.RUnlock()
+	fake.listOrgQuotasMutex.RLock()
+	defer fake.listOrgQuotasMutex.RUnlock()
+	fake.listOrgsMutex.RLock()
+	defer fake.listOrgsMutex.RUnlock()
 	fake.listServicePlansMutex.RLock()
 	defer fake.listServicePlansMutex.RUnlock()
 	fake.listServicesMutex.RLock()
 	defer fake.listServicesMutex.RUnlock()
-	fake.listOrgsMutex.RLock()
-	defer fake.listOrgsMutex.RUnlock()
+	fake.listSpaceMetadataMutex.RLock()
+	defer fake.listSpaceMetadataMutex.RUnlock()
 	fake.listSpacesMutex.RLock()
 	defer fake.listSpacesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -4,11 +4,53 @@ package fakes
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/alphagov/paas-billing/eventio"
 )
 
 type FakeEventStore struct {
+	ArchiveRawEventsStub        func(context.Context, string, time.Time, eventio.RawEventExporter) (*eventio.RawEventArchive, error)
+	archiveRawEventsMutex       sync.RWMutex
+	archiveRawEventsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+		arg4 eventio.RawEventExporter
+	}
+	archiveRawEventsReturns struct {
+		result1 *eventio.RawEventArchive
+		result2 error
+	}
+	archiveRawEventsReturnsOnCall map[int]struct {
+		result1 *eventio.RawEventArchive
+		result2 error
+	}
+	CancelJobStub        func(int64) (*eventio.Job, error)
+	cancelJobMutex       sync.RWMutex
+	cancelJobArgsForCall []struct {
+		arg1 int64
+	}
+	cancelJobReturns struct {
+		result1 *eventio.Job
+		result2 error
+	}
+	cancelJobReturnsOnCall map[int]struct {
+		result1 *eventio.Job
+		result2 error
+	}
+	ClaimNextJobStub        func() (*eventio.Job, error)
+	claimNextJobMutex       sync.RWMutex
+	claimNextJobArgsForCall []struct {
+	}
+	claimNextJobReturns struct {
+		result1 *eventio.Job
+		result2 error
+	}
+	claimNextJobReturnsOnCall map[int]struct {
+		result1 *eventio.Job
+		result2 error
+	}
 	ConsolidateStub        func(eventio.EventFilter) error
 	consolidateMutex       sync.RWMutex
 	consolidateArgsForCall []struct {
@@ -30,6 +72,29 @@ type FakeEventStore struct {
 	consolidateAllReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ConsolidateAllContextStub        func(context.Context) error
+	consolidateAllContextMutex       sync.RWMutex
+	consolidateAllContextArgsForCall []struct {
+		arg1 context.Context
+	}
+	consolidateAllContextReturns struct {
+		result1 error
+	}
+	consolidateAllContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ConsolidateContextStub        func(context.Context, eventio.EventFilter) error
+	consolidateContextMutex       sync.RWMutex
+	consolidateContextArgsForCall []struct {
+		arg1 context.Context
+		arg2 eventio.EventFilter
+	}
+	consolidateContextReturns struct {
+		result1 error
+	}
+	consolidateContextReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ConsolidateFullMonthsStub        func(string, string) error
 	consolidateFullMonthsMutex       sync.RWMutex
 	consolidateFullMonthsArgsForCall []struct {
@@ -42,6 +107,86 @@ type FakeEventStore struct {
 	consolidateFullMonthsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	CreateAdjustmentStub        func(eventio.Adjustment, string) (eventio.Adjustment, error)
+	createAdjustmentMutex       sync.RWMutex
+	createAdjustmentArgsForCall []struct {
+		arg1 eventio.Adjustment
+		arg2 string
+	}
+	createAdjustmentReturns struct {
+		result1 eventio.Adjustment
+		result2 error
+	}
+	createAdjustmentReturnsOnCall map[int]struct {
+		result1 eventio.Adjustment
+		result2 error
+	}
+	DetachConsolidatedMonthsStub        func(context.Context, time.Time) ([]string, error)
+	detachConsolidatedMonthsMutex       sync.RWMutex
+	detachConsolidatedMonthsArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	detachConsolidatedMonthsReturns struct {
+		result1 []string
+		result2 error
+	}
+	detachConsolidatedMonthsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	EnqueueJobStub        func(eventio.Job) (eventio.Job, error)
+	enqueueJobMutex       sync.RWMutex
+	enqueueJobArgsForCall []struct {
+		arg1 eventio.Job
+	}
+	enqueueJobReturns struct {
+		result1 eventio.Job
+		result2 error
+	}
+	enqueueJobReturnsOnCall map[int]struct {
+		result1 eventio.Job
+		result2 error
+	}
+	FailRunningJobsStub        func(string) error
+	failRunningJobsMutex       sync.RWMutex
+	failRunningJobsArgsForCall []struct {
+		arg1 string
+	}
+	failRunningJobsReturns struct {
+		result1 error
+	}
+	failRunningJobsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FinishJobStub        func(int64, eventio.JobState, string) error
+	finishJobMutex       sync.RWMutex
+	finishJobArgsForCall []struct {
+		arg1 int64
+		arg2 eventio.JobState
+		arg3 string
+	}
+	finishJobReturns struct {
+		result1 error
+	}
+	finishJobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ForecastBillableEventDiffsStub        func(context.Context, []eventio.ResourceAdjustment, eventio.EventFilter) ([]eventio.ForecastDiff, error)
+	forecastBillableEventDiffsMutex       sync.RWMutex
+	forecastBillableEventDiffsArgsForCall []struct {
+		arg1 context.Context
+		arg2 []eventio.ResourceAdjustment
+		arg3 eventio.EventFilter
+	}
+	forecastBillableEventDiffsReturns struct {
+		result1 []eventio.ForecastDiff
+		result2 error
+	}
+	forecastBillableEventDiffsReturnsOnCall map[int]struct {
+		result1 []eventio.ForecastDiff
+		result2 error
+	}
 	ForecastBillableEventRowsStub        func(context.Context, []eventio.UsageEvent, eventio.EventFilter) (eventio.BillableEventRows, error)
 	forecastBillableEventRowsMutex       sync.RWMutex
 	forecastBillableEventRowsArgsForCall []struct {
@@ -71,6 +216,32 @@ type FakeEventStore struct {
 		result1 []eventio.BillableEvent
 		result2 error
 	}
+	GetAdjustmentAuditStub        func(string) ([]eventio.AdjustmentAuditEntry, error)
+	getAdjustmentAuditMutex       sync.RWMutex
+	getAdjustmentAuditArgsForCall []struct {
+		arg1 string
+	}
+	getAdjustmentAuditReturns struct {
+		result1 []eventio.AdjustmentAuditEntry
+		result2 error
+	}
+	getAdjustmentAuditReturnsOnCall map[int]struct {
+		result1 []eventio.AdjustmentAuditEntry
+		result2 error
+	}
+	GetAdjustmentsStub        func(eventio.AdjustmentFilter) ([]eventio.Adjustment, error)
+	getAdjustmentsMutex       sync.RWMutex
+	getAdjustmentsArgsForCall []struct {
+		arg1 eventio.AdjustmentFilter
+	}
+	getAdjustmentsReturns struct {
+		result1 []eventio.Adjustment
+		result2 error
+	}
+	getAdjustmentsReturnsOnCall map[int]struct {
+		result1 []eventio.Adjustment
+		result2 error
+	}
 	GetBillableEventRowsStub        func(context.Context, eventio.EventFilter) (eventio.BillableEventRows, error)
 	getBillableEventRowsMutex       sync.RWMutex
 	getBillableEventRowsArgsForCall []struct {
@@ -98,6 +269,19 @@ type FakeEventStore struct {
 		result1 []eventio.BillableEvent
 		result2 error
 	}
+	GetBillingContactsStub        func(eventio.BillingContactFilter) ([]eventio.OrgBillingContacts, error)
+	getBillingContactsMutex       sync.RWMutex
+	getBillingContactsArgsForCall []struct {
+		arg1 eventio.BillingContactFilter
+	}
+	getBillingContactsReturns struct {
+		result1 []eventio.OrgBillingContacts
+		result2 error
+	}
+	getBillingContactsReturnsOnCall map[int]struct {
+		result1 []eventio.OrgBillingContacts
+		result2 error
+	}
 	GetConsolidatedBillableEventRowsStub        func(context.Context, eventio.EventFilter) (eventio.BillableEventRows, error)
 	getConsolidatedBillableEventRowsMutex       sync.RWMutex
 	getConsolidatedBillableEventRowsArgsForCall []struct {
@@ -125,6 +309,45 @@ type FakeEventStore struct {
 		result1 []eventio.BillableEvent
 		result2 error
 	}
+	GetContractStatementsStub        func(eventio.EventFilter) ([]eventio.ContractStatement, error)
+	getContractStatementsMutex       sync.RWMutex
+	getContractStatementsArgsForCall []struct {
+		arg1 eventio.EventFilter
+	}
+	getContractStatementsReturns struct {
+		result1 []eventio.ContractStatement
+		result2 error
+	}
+	getContractStatementsReturnsOnCall map[int]struct {
+		result1 []eventio.ContractStatement
+		result2 error
+	}
+	GetCostAnomaliesStub        func(eventio.CostAnomalyFilter) ([]eventio.CostAnomaly, error)
+	getCostAnomaliesMutex       sync.RWMutex
+	getCostAnomaliesArgsForCall []struct {
+		arg1 eventio.CostAnomalyFilter
+	}
+	getCostAnomaliesReturns struct {
+		result1 []eventio.CostAnomaly
+		result2 error
+	}
+	getCostAnomaliesReturnsOnCall map[int]struct {
+		result1 []eventio.CostAnomaly
+		result2 error
+	}
+	GetCostProjectionsStub        func(eventio.EventFilter) ([]eventio.CostProjection, error)
+	getCostProjectionsMutex       sync.RWMutex
+	getCostProjectionsArgsForCall []struct {
+		arg1 eventio.EventFilter
+	}
+	getCostProjectionsReturns struct {
+		result1 []eventio.CostProjection
+		result2 error
+	}
+	getCostProjectionsReturnsOnCall map[int]struct {
+		result1 []eventio.CostProjection
+		result2 error
+	}
 	GetCurrencyRatesStub        func(eventio.TimeRangeFilter) ([]eventio.CurrencyRate, error)
 	getCurrencyRatesMutex       sync.RWMutex
 	getCurrencyRatesArgsForCall []struct {
@@ -138,6 +361,19 @@ type FakeEventStore struct {
 		result1 []eventio.CurrencyRate
 		result2 error
 	}
+	GetDailyCostsStub        func(eventio.DailyCostFilter) ([]eventio.DailyCost, error)
+	getDailyCostsMutex       sync.RWMutex
+	getDailyCostsArgsForCall []struct {
+		arg1 eventio.DailyCostFilter
+	}
+	getDailyCostsReturns struct {
+		result1 []eventio.DailyCost
+		result2 error
+	}
+	getDailyCostsReturnsOnCall map[int]struct {
+		result1 []eventio.DailyCost
+		result2 error
+	}
 	GetEventsStub        func(eventio.RawEventFilter) ([]eventio.RawEvent, error)
 	getEventsMutex       sync.RWMutex
 	getEventsArgsForCall []struct {
@@ -151,6 +387,57 @@ type FakeEventStore struct {
 		result1 []eventio.RawEvent
 		result2 error
 	}
+	GetIdleResourcesStub        func(eventio.IdleResourceFilter) ([]eventio.IdleResource, error)
+	getIdleResourcesMutex       sync.RWMutex
+	getIdleResourcesArgsForCall []struct {
+		arg1 eventio.IdleResourceFilter
+	}
+	getIdleResourcesReturns struct {
+		result1 []eventio.IdleResource
+		result2 error
+	}
+	getIdleResourcesReturnsOnCall map[int]struct {
+		result1 []eventio.IdleResource
+		result2 error
+	}
+	GetJobStub        func(int64) (*eventio.Job, error)
+	getJobMutex       sync.RWMutex
+	getJobArgsForCall []struct {
+		arg1 int64
+	}
+	getJobReturns struct {
+		result1 *eventio.Job
+		result2 error
+	}
+	getJobReturnsOnCall map[int]struct {
+		result1 *eventio.Job
+		result2 error
+	}
+	GetJobsStub        func(eventio.JobFilter) ([]eventio.Job, error)
+	getJobsMutex       sync.RWMutex
+	getJobsArgsForCall []struct {
+		arg1 eventio.JobFilter
+	}
+	getJobsReturns struct {
+		result1 []eventio.Job
+		result2 error
+	}
+	getJobsReturnsOnCall map[int]struct {
+		result1 []eventio.Job
+		result2 error
+	}
+	GetLastRefreshStub        func() (time.Time, error)
+	getLastRefreshMutex       sync.RWMutex
+	getLastRefreshArgsForCall []struct {
+	}
+	getLastRefreshReturns struct {
+		result1 time.Time
+		result2 error
+	}
+	getLastRefreshReturnsOnCall map[int]struct {
+		result1 time.Time
+		result2 error
+	}
 	GetPricingPlansStub        func(eventio.TimeRangeFilter) ([]eventio.PricingPlan, error)
 	getPricingPlansMutex       sync.RWMutex
 	getPricingPlansArgsForCall []struct {
@@ -164,9 +451,36 @@ type FakeEventStore struct {
 		result1 []eventio.PricingPlan
 		result2 error
 	}
-	GetTotalCostStub        func() ([]eventio.TotalCost, error)
+	GetRawEventArchivesStub        func(eventio.RawEventArchiveFilter) ([]eventio.RawEventArchive, error)
+	getRawEventArchivesMutex       sync.RWMutex
+	getRawEventArchivesArgsForCall []struct {
+		arg1 eventio.RawEventArchiveFilter
+	}
+	getRawEventArchivesReturns struct {
+		result1 []eventio.RawEventArchive
+		result2 error
+	}
+	getRawEventArchivesReturnsOnCall map[int]struct {
+		result1 []eventio.RawEventArchive
+		result2 error
+	}
+	GetResourceTimelineStub        func(string) (*eventio.ResourceTimeline, error)
+	getResourceTimelineMutex       sync.RWMutex
+	getResourceTimelineArgsForCall []struct {
+		arg1 string
+	}
+	getResourceTimelineReturns struct {
+		result1 *eventio.ResourceTimeline
+		result2 error
+	}
+	getResourceTimelineReturnsOnCall map[int]struct {
+		result1 *eventio.ResourceTimeline
+		result2 error
+	}
+	GetTotalCostStub        func(eventio.TotalCostFilter) ([]eventio.TotalCost, error)
 	getTotalCostMutex       sync.RWMutex
 	getTotalCostArgsForCall []struct {
+		arg1 eventio.TotalCostFilter
 	}
 	getTotalCostReturns struct {
 		result1 []eventio.TotalCost
@@ -176,6 +490,19 @@ type FakeEventStore struct {
 		result1 []eventio.TotalCost
 		result2 error
 	}
+	GetUsageAndAdoptionStub        func(eventio.EventFilter) (eventio.UsageAndAdoptionReport, error)
+	getUsageAndAdoptionMutex       sync.RWMutex
+	getUsageAndAdoptionArgsForCall []struct {
+		arg1 eventio.EventFilter
+	}
+	getUsageAndAdoptionReturns struct {
+		result1 eventio.UsageAndAdoptionReport
+		result2 error
+	}
+	getUsageAndAdoptionReturnsOnCall map[int]struct {
+		result1 eventio.UsageAndAdoptionReport
+		result2 error
+	}
 	GetUsageEventRowsStub        func(eventio.EventFilter) (eventio.UsageEventRows, error)
 	getUsageEventRowsMutex       sync.RWMutex
 	getUsageEventRowsArgsForCall []struct {
@@ -238,6 +565,28 @@ type FakeEventStore struct {
 		result1 bool
 		result2 error
 	}
+	MarkRawEventArchiveRestoredStub        func(context.Context, int64) error
+	markRawEventArchiveRestoredMutex       sync.RWMutex
+	markRawEventArchiveRestoredArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	markRawEventArchiveRestoredReturns struct {
+		result1 error
+	}
+	markRawEventArchiveRestoredReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PingStub        func() error
+	pingMutex       sync.RWMutex
+	pingArgsForCall []struct {
+	}
+	pingReturns struct {
+		result1 error
+	}
+	pingReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RefreshStub        func() error
 	refreshMutex       sync.RWMutex
 	refreshArgsForCall []struct {
@@ -248,6 +597,45 @@ type FakeEventStore struct {
 	refreshReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RefreshContextStub        func(context.Context) error
+	refreshContextMutex       sync.RWMutex
+	refreshContextArgsForCall []struct {
+		arg1 context.Context
+	}
+	refreshContextReturns struct {
+		result1 error
+	}
+	refreshContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RestoreRawEventsStub        func(context.Context, []eventio.ArchivedRawEvent) (int, error)
+	restoreRawEventsMutex       sync.RWMutex
+	restoreRawEventsArgsForCall []struct {
+		arg1 context.Context
+		arg2 []eventio.ArchivedRawEvent
+	}
+	restoreRawEventsReturns struct {
+		result1 int
+		result2 error
+	}
+	restoreRawEventsReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	RevokeAdjustmentStub        func(string, string) (*eventio.Adjustment, error)
+	revokeAdjustmentMutex       sync.RWMutex
+	revokeAdjustmentArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	revokeAdjustmentReturns struct {
+		result1 *eventio.Adjustment
+		result2 error
+	}
+	revokeAdjustmentReturnsOnCall map[int]struct {
+		result1 *eventio.Adjustment
+		result2 error
+	}
 	StoreEventsStub        func([]eventio.RawEvent) error
 	storeEventsMutex       sync.RWMutex
 	storeEventsArgsForCall []struct {
@@ -263,6 +651,190 @@ type FakeEventStore struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeEventStore) ArchiveRawEvents(arg1 context.Context, arg2 string, arg3 time.Time, arg4 eventio.RawEventExporter) (*eventio.RawEventArchive, error) {
+	fake.archiveRawEventsMutex.Lock()
+	ret, specificReturn := fake.archiveRawEventsReturnsOnCall[len(fake.archiveRawEventsArgsForCall)]
+	fake.archiveRawEventsArgsForCall = append(fake.archiveRawEventsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 time.Time
+		arg4 eventio.RawEventExporter
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ArchiveRawEvents", []interface{}{arg1, arg2, arg3, arg4})
+	fake.archiveRawEventsMutex.Unlock()
+	if fake.ArchiveRawEventsStub != nil {
+		return fake.ArchiveRawEventsStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.archiveRawEventsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) ArchiveRawEventsCallCount() int {
+	fake.archiveRawEventsMutex.RLock()
+	defer fake.archiveRawEventsMutex.RUnlock()
+	return len(fake.archiveRawEventsArgsForCall)
+}
+
+func (fake *FakeEventStore) ArchiveRawEventsCalls(stub func(context.Context, string, time.Time, eventio.RawEventExporter) (*eventio.RawEventArchive, error)) {
+	fake.archiveRawEventsMutex.Lock()
+	defer fake.archiveRawEventsMutex.Unlock()
+	fake.ArchiveRawEventsStub = stub
+}
+
+func (fake *FakeEventStore) ArchiveRawEventsArgsForCall(i int) (context.Context, string, time.Time, eventio.RawEventExporter) {
+	fake.archiveRawEventsMutex.RLock()
+	defer fake.archiveRawEventsMutex.RUnlock()
+	argsForCall := fake.archiveRawEventsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeEventStore) ArchiveRawEventsReturns(result1 *eventio.RawEventArchive, result2 error) {
+	fake.archiveRawEventsMutex.Lock()
+	defer fake.archiveRawEventsMutex.Unlock()
+	fake.ArchiveRawEventsStub = nil
+	fake.archiveRawEventsReturns = struct {
+		result1 *eventio.RawEventArchive
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) ArchiveRawEventsReturnsOnCall(i int, result1 *eventio.RawEventArchive, result2 error) {
+	fake.archiveRawEventsMutex.Lock()
+	defer fake.archiveRawEventsMutex.Unlock()
+	fake.ArchiveRawEventsStub = nil
+	if fake.archiveRawEventsReturnsOnCall == nil {
+		fake.archiveRawEventsReturnsOnCall = make(map[int]struct {
+			result1 *eventio.RawEventArchive
+			result2 error
+		})
+	}
+	fake.archiveRawEventsReturnsOnCall[i] = struct {
+		result1 *eventio.RawEventArchive
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) CancelJob(arg1 int64) (*eventio.Job, error) {
+	fake.cancelJobMutex.Lock()
+	ret, specificReturn := fake.cancelJobReturnsOnCall[len(fake.cancelJobArgsForCall)]
+	fake.cancelJobArgsForCall = append(fake.cancelJobArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	fake.recordInvocation("CancelJob", []interface{}{arg1})
+	fake.cancelJobMutex.Unlock()
+	if fake.CancelJobStub != nil {
+		return fake.CancelJobStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.cancelJobReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) CancelJobCallCount() int {
+	fake.cancelJobMutex.RLock()
+	defer fake.cancelJobMutex.RUnlock()
+	return len(fake.cancelJobArgsForCall)
+}
+
+func (fake *FakeEventStore) CancelJobCalls(stub func(int64) (*eventio.Job, error)) {
+	fake.cancelJobMutex.Lock()
+	defer fake.cancelJobMutex.Unlock()
+	fake.CancelJobStub = stub
+}
+
+func (fake *FakeEventStore) CancelJobArgsForCall(i int) int64 {
+	fake.cancelJobMutex.RLock()
+	defer fake.cancelJobMutex.RUnlock()
+	argsForCall := fake.cancelJobArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) CancelJobReturns(result1 *eventio.Job, result2 error) {
+	fake.cancelJobMutex.Lock()
+	defer fake.cancelJobMutex.Unlock()
+	fake.CancelJobStub = nil
+	fake.cancelJobReturns = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) CancelJobReturnsOnCall(i int, result1 *eventio.Job, result2 error) {
+	fake.cancelJobMutex.Lock()
+	defer fake.cancelJobMutex.Unlock()
+	fake.CancelJobStub = nil
+	if fake.cancelJobReturnsOnCall == nil {
+		fake.cancelJobReturnsOnCall = make(map[int]struct {
+			result1 *eventio.Job
+			result2 error
+		})
+	}
+	fake.cancelJobReturnsOnCall[i] = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) ClaimNextJob() (*eventio.Job, error) {
+	fake.claimNextJobMutex.Lock()
+	ret, specificReturn := fake.claimNextJobReturnsOnCall[len(fake.claimNextJobArgsForCall)]
+	fake.claimNextJobArgsForCall = append(fake.claimNextJobArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ClaimNextJob", []interface{}{})
+	fake.claimNextJobMutex.Unlock()
+	if fake.ClaimNextJobStub != nil {
+		return fake.ClaimNextJobStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.claimNextJobReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) ClaimNextJobCallCount() int {
+	fake.claimNextJobMutex.RLock()
+	defer fake.claimNextJobMutex.RUnlock()
+	return len(fake.claimNextJobArgsForCall)
+}
+
+func (fake *FakeEventStore) ClaimNextJobCalls(stub func() (*eventio.Job, error)) {
+	fake.claimNextJobMutex.Lock()
+	defer fake.claimNextJobMutex.Unlock()
+	fake.ClaimNextJobStub = stub
+}
+
+func (fake *FakeEventStore) ClaimNextJobReturns(result1 *eventio.Job, result2 error) {
+	fake.claimNextJobMutex.Lock()
+	defer fake.claimNextJobMutex.Unlock()
+	fake.ClaimNextJobStub = nil
+	fake.claimNextJobReturns = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) ClaimNextJobReturnsOnCall(i int, result1 *eventio.Job, result2 error) {
+	fake.claimNextJobMutex.Lock()
+	defer fake.claimNextJobMutex.Unlock()
+	fake.ClaimNextJobStub = nil
+	if fake.claimNextJobReturnsOnCall == nil {
+		fake.claimNextJobReturnsOnCall = make(map[int]struct {
+			result1 *eventio.Job
+			result2 error
+		})
+	}
+	fake.claimNextJobReturnsOnCall[i] = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) Consolidate(arg1 eventio.EventFilter) error {
 	fake.consolidateMutex.Lock()
 	ret, specificReturn := fake.consolidateReturnsOnCall[len(fake.consolidateArgsForCall)]
@@ -375,17 +947,138 @@ func (fake *FakeEventStore) ConsolidateAllReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeEventStore) ConsolidateFullMonths(arg1 string, arg2 string) error {
-	fake.consolidateFullMonthsMutex.Lock()
-	ret, specificReturn := fake.consolidateFullMonthsReturnsOnCall[len(fake.consolidateFullMonthsArgsForCall)]
-	fake.consolidateFullMonthsArgsForCall = append(fake.consolidateFullMonthsArgsForCall, struct {
-		arg1 string
-		arg2 string
-	}{arg1, arg2})
-	fake.recordInvocation("ConsolidateFullMonths", []interface{}{arg1, arg2})
-	fake.consolidateFullMonthsMutex.Unlock()
-	if fake.ConsolidateFullMonthsStub != nil {
-		return fake.ConsolidateFullMonthsStub(arg1, arg2)
+func (fake *FakeEventStore) ConsolidateAllContext(arg1 context.Context) error {
+	fake.consolidateAllContextMutex.Lock()
+	ret, specificReturn := fake.consolidateAllContextReturnsOnCall[len(fake.consolidateAllContextArgsForCall)]
+	fake.consolidateAllContextArgsForCall = append(fake.consolidateAllContextArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("ConsolidateAllContext", []interface{}{arg1})
+	fake.consolidateAllContextMutex.Unlock()
+	if fake.ConsolidateAllContextStub != nil {
+		return fake.ConsolidateAllContextStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.consolidateAllContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) ConsolidateAllContextCallCount() int {
+	fake.consolidateAllContextMutex.RLock()
+	defer fake.consolidateAllContextMutex.RUnlock()
+	return len(fake.consolidateAllContextArgsForCall)
+}
+
+func (fake *FakeEventStore) ConsolidateAllContextCalls(stub func(context.Context) error) {
+	fake.consolidateAllContextMutex.Lock()
+	defer fake.consolidateAllContextMutex.Unlock()
+	fake.ConsolidateAllContextStub = stub
+}
+
+func (fake *FakeEventStore) ConsolidateAllContextArgsForCall(i int) context.Context {
+	fake.consolidateAllContextMutex.RLock()
+	defer fake.consolidateAllContextMutex.RUnlock()
+	argsForCall := fake.consolidateAllContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) ConsolidateAllContextReturns(result1 error) {
+	fake.consolidateAllContextMutex.Lock()
+	defer fake.consolidateAllContextMutex.Unlock()
+	fake.ConsolidateAllContextStub = nil
+	fake.consolidateAllContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) ConsolidateAllContextReturnsOnCall(i int, result1 error) {
+	fake.consolidateAllContextMutex.Lock()
+	defer fake.consolidateAllContextMutex.Unlock()
+	fake.ConsolidateAllContextStub = nil
+	if fake.consolidateAllContextReturnsOnCall == nil {
+		fake.consolidateAllContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.consolidateAllContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) ConsolidateContext(arg1 context.Context, arg2 eventio.EventFilter) error {
+	fake.consolidateContextMutex.Lock()
+	ret, specificReturn := fake.consolidateContextReturnsOnCall[len(fake.consolidateContextArgsForCall)]
+	fake.consolidateContextArgsForCall = append(fake.consolidateContextArgsForCall, struct {
+		arg1 context.Context
+		arg2 eventio.EventFilter
+	}{arg1, arg2})
+	fake.recordInvocation("ConsolidateContext", []interface{}{arg1, arg2})
+	fake.consolidateContextMutex.Unlock()
+	if fake.ConsolidateContextStub != nil {
+		return fake.ConsolidateContextStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.consolidateContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) ConsolidateContextCallCount() int {
+	fake.consolidateContextMutex.RLock()
+	defer fake.consolidateContextMutex.RUnlock()
+	return len(fake.consolidateContextArgsForCall)
+}
+
+func (fake *FakeEventStore) ConsolidateContextCalls(stub func(context.Context, eventio.EventFilter) error) {
+	fake.consolidateContextMutex.Lock()
+	defer fake.consolidateContextMutex.Unlock()
+	fake.ConsolidateContextStub = stub
+}
+
+func (fake *FakeEventStore) ConsolidateContextArgsForCall(i int) (context.Context, eventio.EventFilter) {
+	fake.consolidateContextMutex.RLock()
+	defer fake.consolidateContextMutex.RUnlock()
+	argsForCall := fake.consolidateContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) ConsolidateContextReturns(result1 error) {
+	fake.consolidateContextMutex.Lock()
+	defer fake.consolidateContextMutex.Unlock()
+	fake.ConsolidateContextStub = nil
+	fake.consolidateContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) ConsolidateContextReturnsOnCall(i int, result1 error) {
+	fake.consolidateContextMutex.Lock()
+	defer fake.consolidateContextMutex.Unlock()
+	fake.ConsolidateContextStub = nil
+	if fake.consolidateContextReturnsOnCall == nil {
+		fake.consolidateContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.consolidateContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) ConsolidateFullMonths(arg1 string, arg2 string) error {
+	fake.consolidateFullMonthsMutex.Lock()
+	ret, specificReturn := fake.consolidateFullMonthsReturnsOnCall[len(fake.consolidateFullMonthsArgsForCall)]
+	fake.consolidateFullMonthsArgsForCall = append(fake.consolidateFullMonthsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("ConsolidateFullMonths", []interface{}{arg1, arg2})
+	fake.consolidateFullMonthsMutex.Unlock()
+	if fake.ConsolidateFullMonthsStub != nil {
+		return fake.ConsolidateFullMonthsStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -436,6 +1129,389 @@ func (fake *FakeEventStore) ConsolidateFullMonthsReturnsOnCall(i int, result1 er
 	}{result1}
 }
 
+func (fake *FakeEventStore) CreateAdjustment(arg1 eventio.Adjustment, arg2 string) (eventio.Adjustment, error) {
+	fake.createAdjustmentMutex.Lock()
+	ret, specificReturn := fake.createAdjustmentReturnsOnCall[len(fake.createAdjustmentArgsForCall)]
+	fake.createAdjustmentArgsForCall = append(fake.createAdjustmentArgsForCall, struct {
+		arg1 eventio.Adjustment
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("CreateAdjustment", []interface{}{arg1, arg2})
+	fake.createAdjustmentMutex.Unlock()
+	if fake.CreateAdjustmentStub != nil {
+		return fake.CreateAdjustmentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.createAdjustmentReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) CreateAdjustmentCallCount() int {
+	fake.createAdjustmentMutex.RLock()
+	defer fake.createAdjustmentMutex.RUnlock()
+	return len(fake.createAdjustmentArgsForCall)
+}
+
+func (fake *FakeEventStore) CreateAdjustmentCalls(stub func(eventio.Adjustment, string) (eventio.Adjustment, error)) {
+	fake.createAdjustmentMutex.Lock()
+	defer fake.createAdjustmentMutex.Unlock()
+	fake.CreateAdjustmentStub = stub
+}
+
+func (fake *FakeEventStore) CreateAdjustmentArgsForCall(i int) (eventio.Adjustment, string) {
+	fake.createAdjustmentMutex.RLock()
+	defer fake.createAdjustmentMutex.RUnlock()
+	argsForCall := fake.createAdjustmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) CreateAdjustmentReturns(result1 eventio.Adjustment, result2 error) {
+	fake.createAdjustmentMutex.Lock()
+	defer fake.createAdjustmentMutex.Unlock()
+	fake.CreateAdjustmentStub = nil
+	fake.createAdjustmentReturns = struct {
+		result1 eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) CreateAdjustmentReturnsOnCall(i int, result1 eventio.Adjustment, result2 error) {
+	fake.createAdjustmentMutex.Lock()
+	defer fake.createAdjustmentMutex.Unlock()
+	fake.CreateAdjustmentStub = nil
+	if fake.createAdjustmentReturnsOnCall == nil {
+		fake.createAdjustmentReturnsOnCall = make(map[int]struct {
+			result1 eventio.Adjustment
+			result2 error
+		})
+	}
+	fake.createAdjustmentReturnsOnCall[i] = struct {
+		result1 eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonths(arg1 context.Context, arg2 time.Time) ([]string, error) {
+	fake.detachConsolidatedMonthsMutex.Lock()
+	ret, specificReturn := fake.detachConsolidatedMonthsReturnsOnCall[len(fake.detachConsolidatedMonthsArgsForCall)]
+	fake.detachConsolidatedMonthsArgsForCall = append(fake.detachConsolidatedMonthsArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("DetachConsolidatedMonths", []interface{}{arg1, arg2})
+	fake.detachConsolidatedMonthsMutex.Unlock()
+	if fake.DetachConsolidatedMonthsStub != nil {
+		return fake.DetachConsolidatedMonthsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.detachConsolidatedMonthsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonthsCallCount() int {
+	fake.detachConsolidatedMonthsMutex.RLock()
+	defer fake.detachConsolidatedMonthsMutex.RUnlock()
+	return len(fake.detachConsolidatedMonthsArgsForCall)
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonthsCalls(stub func(context.Context, time.Time) ([]string, error)) {
+	fake.detachConsolidatedMonthsMutex.Lock()
+	defer fake.detachConsolidatedMonthsMutex.Unlock()
+	fake.DetachConsolidatedMonthsStub = stub
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonthsArgsForCall(i int) (context.Context, time.Time) {
+	fake.detachConsolidatedMonthsMutex.RLock()
+	defer fake.detachConsolidatedMonthsMutex.RUnlock()
+	argsForCall := fake.detachConsolidatedMonthsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonthsReturns(result1 []string, result2 error) {
+	fake.detachConsolidatedMonthsMutex.Lock()
+	defer fake.detachConsolidatedMonthsMutex.Unlock()
+	fake.DetachConsolidatedMonthsStub = nil
+	fake.detachConsolidatedMonthsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) DetachConsolidatedMonthsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.detachConsolidatedMonthsMutex.Lock()
+	defer fake.detachConsolidatedMonthsMutex.Unlock()
+	fake.DetachConsolidatedMonthsStub = nil
+	if fake.detachConsolidatedMonthsReturnsOnCall == nil {
+		fake.detachConsolidatedMonthsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.detachConsolidatedMonthsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) EnqueueJob(arg1 eventio.Job) (eventio.Job, error) {
+	fake.enqueueJobMutex.Lock()
+	ret, specificReturn := fake.enqueueJobReturnsOnCall[len(fake.enqueueJobArgsForCall)]
+	fake.enqueueJobArgsForCall = append(fake.enqueueJobArgsForCall, struct {
+		arg1 eventio.Job
+	}{arg1})
+	fake.recordInvocation("EnqueueJob", []interface{}{arg1})
+	fake.enqueueJobMutex.Unlock()
+	if fake.EnqueueJobStub != nil {
+		return fake.EnqueueJobStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.enqueueJobReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) EnqueueJobCallCount() int {
+	fake.enqueueJobMutex.RLock()
+	defer fake.enqueueJobMutex.RUnlock()
+	return len(fake.enqueueJobArgsForCall)
+}
+
+func (fake *FakeEventStore) EnqueueJobCalls(stub func(eventio.Job) (eventio.Job, error)) {
+	fake.enqueueJobMutex.Lock()
+	defer fake.enqueueJobMutex.Unlock()
+	fake.EnqueueJobStub = stub
+}
+
+func (fake *FakeEventStore) EnqueueJobArgsForCall(i int) eventio.Job {
+	fake.enqueueJobMutex.RLock()
+	defer fake.enqueueJobMutex.RUnlock()
+	argsForCall := fake.enqueueJobArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) EnqueueJobReturns(result1 eventio.Job, result2 error) {
+	fake.enqueueJobMutex.Lock()
+	defer fake.enqueueJobMutex.Unlock()
+	fake.EnqueueJobStub = nil
+	fake.enqueueJobReturns = struct {
+		result1 eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) EnqueueJobReturnsOnCall(i int, result1 eventio.Job, result2 error) {
+	fake.enqueueJobMutex.Lock()
+	defer fake.enqueueJobMutex.Unlock()
+	fake.EnqueueJobStub = nil
+	if fake.enqueueJobReturnsOnCall == nil {
+		fake.enqueueJobReturnsOnCall = make(map[int]struct {
+			result1 eventio.Job
+			result2 error
+		})
+	}
+	fake.enqueueJobReturnsOnCall[i] = struct {
+		result1 eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) FailRunningJobs(arg1 string) error {
+	fake.failRunningJobsMutex.Lock()
+	ret, specificReturn := fake.failRunningJobsReturnsOnCall[len(fake.failRunningJobsArgsForCall)]
+	fake.failRunningJobsArgsForCall = append(fake.failRunningJobsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("FailRunningJobs", []interface{}{arg1})
+	fake.failRunningJobsMutex.Unlock()
+	if fake.FailRunningJobsStub != nil {
+		return fake.FailRunningJobsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.failRunningJobsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) FailRunningJobsCallCount() int {
+	fake.failRunningJobsMutex.RLock()
+	defer fake.failRunningJobsMutex.RUnlock()
+	return len(fake.failRunningJobsArgsForCall)
+}
+
+func (fake *FakeEventStore) FailRunningJobsCalls(stub func(string) error) {
+	fake.failRunningJobsMutex.Lock()
+	defer fake.failRunningJobsMutex.Unlock()
+	fake.FailRunningJobsStub = stub
+}
+
+func (fake *FakeEventStore) FailRunningJobsArgsForCall(i int) string {
+	fake.failRunningJobsMutex.RLock()
+	defer fake.failRunningJobsMutex.RUnlock()
+	argsForCall := fake.failRunningJobsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) FailRunningJobsReturns(result1 error) {
+	fake.failRunningJobsMutex.Lock()
+	defer fake.failRunningJobsMutex.Unlock()
+	fake.FailRunningJobsStub = nil
+	fake.failRunningJobsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) FailRunningJobsReturnsOnCall(i int, result1 error) {
+	fake.failRunningJobsMutex.Lock()
+	defer fake.failRunningJobsMutex.Unlock()
+	fake.FailRunningJobsStub = nil
+	if fake.failRunningJobsReturnsOnCall == nil {
+		fake.failRunningJobsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.failRunningJobsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) FinishJob(arg1 int64, arg2 eventio.JobState, arg3 string) error {
+	fake.finishJobMutex.Lock()
+	ret, specificReturn := fake.finishJobReturnsOnCall[len(fake.finishJobArgsForCall)]
+	fake.finishJobArgsForCall = append(fake.finishJobArgsForCall, struct {
+		arg1 int64
+		arg2 eventio.JobState
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("FinishJob", []interface{}{arg1, arg2, arg3})
+	fake.finishJobMutex.Unlock()
+	if fake.FinishJobStub != nil {
+		return fake.FinishJobStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.finishJobReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) FinishJobCallCount() int {
+	fake.finishJobMutex.RLock()
+	defer fake.finishJobMutex.RUnlock()
+	return len(fake.finishJobArgsForCall)
+}
+
+func (fake *FakeEventStore) FinishJobCalls(stub func(int64, eventio.JobState, string) error) {
+	fake.finishJobMutex.Lock()
+	defer fake.finishJobMutex.Unlock()
+	fake.FinishJobStub = stub
+}
+
+func (fake *FakeEventStore) FinishJobArgsForCall(i int) (int64, eventio.JobState, string) {
+	fake.finishJobMutex.RLock()
+	defer fake.finishJobMutex.RUnlock()
+	argsForCall := fake.finishJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeEventStore) FinishJobReturns(result1 error) {
+	fake.finishJobMutex.Lock()
+	defer fake.finishJobMutex.Unlock()
+	fake.FinishJobStub = nil
+	fake.finishJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) FinishJobReturnsOnCall(i int, result1 error) {
+	fake.finishJobMutex.Lock()
+	defer fake.finishJobMutex.Unlock()
+	fake.FinishJobStub = nil
+	if fake.finishJobReturnsOnCall == nil {
+		fake.finishJobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.finishJobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffs(arg1 context.Context, arg2 []eventio.ResourceAdjustment, arg3 eventio.EventFilter) ([]eventio.ForecastDiff, error) {
+	var arg2Copy []eventio.ResourceAdjustment
+	if arg2 != nil {
+		arg2Copy = make([]eventio.ResourceAdjustment, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.forecastBillableEventDiffsMutex.Lock()
+	ret, specificReturn := fake.forecastBillableEventDiffsReturnsOnCall[len(fake.forecastBillableEventDiffsArgsForCall)]
+	fake.forecastBillableEventDiffsArgsForCall = append(fake.forecastBillableEventDiffsArgsForCall, struct {
+		arg1 context.Context
+		arg2 []eventio.ResourceAdjustment
+		arg3 eventio.EventFilter
+	}{arg1, arg2Copy, arg3})
+	fake.recordInvocation("ForecastBillableEventDiffs", []interface{}{arg1, arg2Copy, arg3})
+	fake.forecastBillableEventDiffsMutex.Unlock()
+	if fake.ForecastBillableEventDiffsStub != nil {
+		return fake.ForecastBillableEventDiffsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.forecastBillableEventDiffsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffsCallCount() int {
+	fake.forecastBillableEventDiffsMutex.RLock()
+	defer fake.forecastBillableEventDiffsMutex.RUnlock()
+	return len(fake.forecastBillableEventDiffsArgsForCall)
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffsCalls(stub func(context.Context, []eventio.ResourceAdjustment, eventio.EventFilter) ([]eventio.ForecastDiff, error)) {
+	fake.forecastBillableEventDiffsMutex.Lock()
+	defer fake.forecastBillableEventDiffsMutex.Unlock()
+	fake.ForecastBillableEventDiffsStub = stub
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffsArgsForCall(i int) (context.Context, []eventio.ResourceAdjustment, eventio.EventFilter) {
+	fake.forecastBillableEventDiffsMutex.RLock()
+	defer fake.forecastBillableEventDiffsMutex.RUnlock()
+	argsForCall := fake.forecastBillableEventDiffsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffsReturns(result1 []eventio.ForecastDiff, result2 error) {
+	fake.forecastBillableEventDiffsMutex.Lock()
+	defer fake.forecastBillableEventDiffsMutex.Unlock()
+	fake.ForecastBillableEventDiffsStub = nil
+	fake.forecastBillableEventDiffsReturns = struct {
+		result1 []eventio.ForecastDiff
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) ForecastBillableEventDiffsReturnsOnCall(i int, result1 []eventio.ForecastDiff, result2 error) {
+	fake.forecastBillableEventDiffsMutex.Lock()
+	defer fake.forecastBillableEventDiffsMutex.Unlock()
+	fake.ForecastBillableEventDiffsStub = nil
+	if fake.forecastBillableEventDiffsReturnsOnCall == nil {
+		fake.forecastBillableEventDiffsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.ForecastDiff
+			result2 error
+		})
+	}
+	fake.forecastBillableEventDiffsReturnsOnCall[i] = struct {
+		result1 []eventio.ForecastDiff
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) ForecastBillableEventRows(arg1 context.Context, arg2 []eventio.UsageEvent, arg3 eventio.EventFilter) (eventio.BillableEventRows, error) {
 	var arg2Copy []eventio.UsageEvent
 	if arg2 != nil {
@@ -575,6 +1651,132 @@ func (fake *FakeEventStore) ForecastBillableEventsReturnsOnCall(i int, result1 [
 	}{result1, result2}
 }
 
+func (fake *FakeEventStore) GetAdjustmentAudit(arg1 string) ([]eventio.AdjustmentAuditEntry, error) {
+	fake.getAdjustmentAuditMutex.Lock()
+	ret, specificReturn := fake.getAdjustmentAuditReturnsOnCall[len(fake.getAdjustmentAuditArgsForCall)]
+	fake.getAdjustmentAuditArgsForCall = append(fake.getAdjustmentAuditArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetAdjustmentAudit", []interface{}{arg1})
+	fake.getAdjustmentAuditMutex.Unlock()
+	if fake.GetAdjustmentAuditStub != nil {
+		return fake.GetAdjustmentAuditStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getAdjustmentAuditReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetAdjustmentAuditCallCount() int {
+	fake.getAdjustmentAuditMutex.RLock()
+	defer fake.getAdjustmentAuditMutex.RUnlock()
+	return len(fake.getAdjustmentAuditArgsForCall)
+}
+
+func (fake *FakeEventStore) GetAdjustmentAuditCalls(stub func(string) ([]eventio.AdjustmentAuditEntry, error)) {
+	fake.getAdjustmentAuditMutex.Lock()
+	defer fake.getAdjustmentAuditMutex.Unlock()
+	fake.GetAdjustmentAuditStub = stub
+}
+
+func (fake *FakeEventStore) GetAdjustmentAuditArgsForCall(i int) string {
+	fake.getAdjustmentAuditMutex.RLock()
+	defer fake.getAdjustmentAuditMutex.RUnlock()
+	argsForCall := fake.getAdjustmentAuditArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetAdjustmentAuditReturns(result1 []eventio.AdjustmentAuditEntry, result2 error) {
+	fake.getAdjustmentAuditMutex.Lock()
+	defer fake.getAdjustmentAuditMutex.Unlock()
+	fake.GetAdjustmentAuditStub = nil
+	fake.getAdjustmentAuditReturns = struct {
+		result1 []eventio.AdjustmentAuditEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetAdjustmentAuditReturnsOnCall(i int, result1 []eventio.AdjustmentAuditEntry, result2 error) {
+	fake.getAdjustmentAuditMutex.Lock()
+	defer fake.getAdjustmentAuditMutex.Unlock()
+	fake.GetAdjustmentAuditStub = nil
+	if fake.getAdjustmentAuditReturnsOnCall == nil {
+		fake.getAdjustmentAuditReturnsOnCall = make(map[int]struct {
+			result1 []eventio.AdjustmentAuditEntry
+			result2 error
+		})
+	}
+	fake.getAdjustmentAuditReturnsOnCall[i] = struct {
+		result1 []eventio.AdjustmentAuditEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetAdjustments(arg1 eventio.AdjustmentFilter) ([]eventio.Adjustment, error) {
+	fake.getAdjustmentsMutex.Lock()
+	ret, specificReturn := fake.getAdjustmentsReturnsOnCall[len(fake.getAdjustmentsArgsForCall)]
+	fake.getAdjustmentsArgsForCall = append(fake.getAdjustmentsArgsForCall, struct {
+		arg1 eventio.AdjustmentFilter
+	}{arg1})
+	fake.recordInvocation("GetAdjustments", []interface{}{arg1})
+	fake.getAdjustmentsMutex.Unlock()
+	if fake.GetAdjustmentsStub != nil {
+		return fake.GetAdjustmentsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getAdjustmentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetAdjustmentsCallCount() int {
+	fake.getAdjustmentsMutex.RLock()
+	defer fake.getAdjustmentsMutex.RUnlock()
+	return len(fake.getAdjustmentsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetAdjustmentsCalls(stub func(eventio.AdjustmentFilter) ([]eventio.Adjustment, error)) {
+	fake.getAdjustmentsMutex.Lock()
+	defer fake.getAdjustmentsMutex.Unlock()
+	fake.GetAdjustmentsStub = stub
+}
+
+func (fake *FakeEventStore) GetAdjustmentsArgsForCall(i int) eventio.AdjustmentFilter {
+	fake.getAdjustmentsMutex.RLock()
+	defer fake.getAdjustmentsMutex.RUnlock()
+	argsForCall := fake.getAdjustmentsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetAdjustmentsReturns(result1 []eventio.Adjustment, result2 error) {
+	fake.getAdjustmentsMutex.Lock()
+	defer fake.getAdjustmentsMutex.Unlock()
+	fake.GetAdjustmentsStub = nil
+	fake.getAdjustmentsReturns = struct {
+		result1 []eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetAdjustmentsReturnsOnCall(i int, result1 []eventio.Adjustment, result2 error) {
+	fake.getAdjustmentsMutex.Lock()
+	defer fake.getAdjustmentsMutex.Unlock()
+	fake.GetAdjustmentsStub = nil
+	if fake.getAdjustmentsReturnsOnCall == nil {
+		fake.getAdjustmentsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.Adjustment
+			result2 error
+		})
+	}
+	fake.getAdjustmentsReturnsOnCall[i] = struct {
+		result1 []eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) GetBillableEventRows(arg1 context.Context, arg2 eventio.EventFilter) (eventio.BillableEventRows, error) {
 	fake.getBillableEventRowsMutex.Lock()
 	ret, specificReturn := fake.getBillableEventRowsReturnsOnCall[len(fake.getBillableEventRowsArgsForCall)]
@@ -669,35 +1871,98 @@ func (fake *FakeEventStore) GetBillableEventsCalls(stub func(eventio.EventFilter
 	fake.GetBillableEventsStub = stub
 }
 
-func (fake *FakeEventStore) GetBillableEventsArgsForCall(i int) eventio.EventFilter {
-	fake.getBillableEventsMutex.RLock()
-	defer fake.getBillableEventsMutex.RUnlock()
-	argsForCall := fake.getBillableEventsArgsForCall[i]
+func (fake *FakeEventStore) GetBillableEventsArgsForCall(i int) eventio.EventFilter {
+	fake.getBillableEventsMutex.RLock()
+	defer fake.getBillableEventsMutex.RUnlock()
+	argsForCall := fake.getBillableEventsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetBillableEventsReturns(result1 []eventio.BillableEvent, result2 error) {
+	fake.getBillableEventsMutex.Lock()
+	defer fake.getBillableEventsMutex.Unlock()
+	fake.GetBillableEventsStub = nil
+	fake.getBillableEventsReturns = struct {
+		result1 []eventio.BillableEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetBillableEventsReturnsOnCall(i int, result1 []eventio.BillableEvent, result2 error) {
+	fake.getBillableEventsMutex.Lock()
+	defer fake.getBillableEventsMutex.Unlock()
+	fake.GetBillableEventsStub = nil
+	if fake.getBillableEventsReturnsOnCall == nil {
+		fake.getBillableEventsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.BillableEvent
+			result2 error
+		})
+	}
+	fake.getBillableEventsReturnsOnCall[i] = struct {
+		result1 []eventio.BillableEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetBillingContacts(arg1 eventio.BillingContactFilter) ([]eventio.OrgBillingContacts, error) {
+	fake.getBillingContactsMutex.Lock()
+	ret, specificReturn := fake.getBillingContactsReturnsOnCall[len(fake.getBillingContactsArgsForCall)]
+	fake.getBillingContactsArgsForCall = append(fake.getBillingContactsArgsForCall, struct {
+		arg1 eventio.BillingContactFilter
+	}{arg1})
+	fake.recordInvocation("GetBillingContacts", []interface{}{arg1})
+	fake.getBillingContactsMutex.Unlock()
+	if fake.GetBillingContactsStub != nil {
+		return fake.GetBillingContactsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getBillingContactsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetBillingContactsCallCount() int {
+	fake.getBillingContactsMutex.RLock()
+	defer fake.getBillingContactsMutex.RUnlock()
+	return len(fake.getBillingContactsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetBillingContactsCalls(stub func(eventio.BillingContactFilter) ([]eventio.OrgBillingContacts, error)) {
+	fake.getBillingContactsMutex.Lock()
+	defer fake.getBillingContactsMutex.Unlock()
+	fake.GetBillingContactsStub = stub
+}
+
+func (fake *FakeEventStore) GetBillingContactsArgsForCall(i int) eventio.BillingContactFilter {
+	fake.getBillingContactsMutex.RLock()
+	defer fake.getBillingContactsMutex.RUnlock()
+	argsForCall := fake.getBillingContactsArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeEventStore) GetBillableEventsReturns(result1 []eventio.BillableEvent, result2 error) {
-	fake.getBillableEventsMutex.Lock()
-	defer fake.getBillableEventsMutex.Unlock()
-	fake.GetBillableEventsStub = nil
-	fake.getBillableEventsReturns = struct {
-		result1 []eventio.BillableEvent
+func (fake *FakeEventStore) GetBillingContactsReturns(result1 []eventio.OrgBillingContacts, result2 error) {
+	fake.getBillingContactsMutex.Lock()
+	defer fake.getBillingContactsMutex.Unlock()
+	fake.GetBillingContactsStub = nil
+	fake.getBillingContactsReturns = struct {
+		result1 []eventio.OrgBillingContacts
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeEventStore) GetBillableEventsReturnsOnCall(i int, result1 []eventio.BillableEvent, result2 error) {
-	fake.getBillableEventsMutex.Lock()
-	defer fake.getBillableEventsMutex.Unlock()
-	fake.GetBillableEventsStub = nil
-	if fake.getBillableEventsReturnsOnCall == nil {
-		fake.getBillableEventsReturnsOnCall = make(map[int]struct {
-			result1 []eventio.BillableEvent
+func (fake *FakeEventStore) GetBillingContactsReturnsOnCall(i int, result1 []eventio.OrgBillingContacts, result2 error) {
+	fake.getBillingContactsMutex.Lock()
+	defer fake.getBillingContactsMutex.Unlock()
+	fake.GetBillingContactsStub = nil
+	if fake.getBillingContactsReturnsOnCall == nil {
+		fake.getBillingContactsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.OrgBillingContacts
 			result2 error
 		})
 	}
-	fake.getBillableEventsReturnsOnCall[i] = struct {
-		result1 []eventio.BillableEvent
+	fake.getBillingContactsReturnsOnCall[i] = struct {
+		result1 []eventio.OrgBillingContacts
 		result2 error
 	}{result1, result2}
 }
@@ -829,6 +2094,195 @@ func (fake *FakeEventStore) GetConsolidatedBillableEventsReturnsOnCall(i int, re
 	}{result1, result2}
 }
 
+func (fake *FakeEventStore) GetContractStatements(arg1 eventio.EventFilter) ([]eventio.ContractStatement, error) {
+	fake.getContractStatementsMutex.Lock()
+	ret, specificReturn := fake.getContractStatementsReturnsOnCall[len(fake.getContractStatementsArgsForCall)]
+	fake.getContractStatementsArgsForCall = append(fake.getContractStatementsArgsForCall, struct {
+		arg1 eventio.EventFilter
+	}{arg1})
+	fake.recordInvocation("GetContractStatements", []interface{}{arg1})
+	fake.getContractStatementsMutex.Unlock()
+	if fake.GetContractStatementsStub != nil {
+		return fake.GetContractStatementsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getContractStatementsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetContractStatementsCallCount() int {
+	fake.getContractStatementsMutex.RLock()
+	defer fake.getContractStatementsMutex.RUnlock()
+	return len(fake.getContractStatementsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetContractStatementsCalls(stub func(eventio.EventFilter) ([]eventio.ContractStatement, error)) {
+	fake.getContractStatementsMutex.Lock()
+	defer fake.getContractStatementsMutex.Unlock()
+	fake.GetContractStatementsStub = stub
+}
+
+func (fake *FakeEventStore) GetContractStatementsArgsForCall(i int) eventio.EventFilter {
+	fake.getContractStatementsMutex.RLock()
+	defer fake.getContractStatementsMutex.RUnlock()
+	argsForCall := fake.getContractStatementsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetContractStatementsReturns(result1 []eventio.ContractStatement, result2 error) {
+	fake.getContractStatementsMutex.Lock()
+	defer fake.getContractStatementsMutex.Unlock()
+	fake.GetContractStatementsStub = nil
+	fake.getContractStatementsReturns = struct {
+		result1 []eventio.ContractStatement
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetContractStatementsReturnsOnCall(i int, result1 []eventio.ContractStatement, result2 error) {
+	fake.getContractStatementsMutex.Lock()
+	defer fake.getContractStatementsMutex.Unlock()
+	fake.GetContractStatementsStub = nil
+	if fake.getContractStatementsReturnsOnCall == nil {
+		fake.getContractStatementsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.ContractStatement
+			result2 error
+		})
+	}
+	fake.getContractStatementsReturnsOnCall[i] = struct {
+		result1 []eventio.ContractStatement
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetCostAnomalies(arg1 eventio.CostAnomalyFilter) ([]eventio.CostAnomaly, error) {
+	fake.getCostAnomaliesMutex.Lock()
+	ret, specificReturn := fake.getCostAnomaliesReturnsOnCall[len(fake.getCostAnomaliesArgsForCall)]
+	fake.getCostAnomaliesArgsForCall = append(fake.getCostAnomaliesArgsForCall, struct {
+		arg1 eventio.CostAnomalyFilter
+	}{arg1})
+	fake.recordInvocation("GetCostAnomalies", []interface{}{arg1})
+	fake.getCostAnomaliesMutex.Unlock()
+	if fake.GetCostAnomaliesStub != nil {
+		return fake.GetCostAnomaliesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getCostAnomaliesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetCostAnomaliesCallCount() int {
+	fake.getCostAnomaliesMutex.RLock()
+	defer fake.getCostAnomaliesMutex.RUnlock()
+	return len(fake.getCostAnomaliesArgsForCall)
+}
+
+func (fake *FakeEventStore) GetCostAnomaliesCalls(stub func(eventio.CostAnomalyFilter) ([]eventio.CostAnomaly, error)) {
+	fake.getCostAnomaliesMutex.Lock()
+	defer fake.getCostAnomaliesMutex.Unlock()
+	fake.GetCostAnomaliesStub = stub
+}
+
+func (fake *FakeEventStore) GetCostAnomaliesArgsForCall(i int) eventio.CostAnomalyFilter {
+	fake.getCostAnomaliesMutex.RLock()
+	defer fake.getCostAnomaliesMutex.RUnlock()
+	argsForCall := fake.getCostAnomaliesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetCostAnomaliesReturns(result1 []eventio.CostAnomaly, result2 error) {
+	fake.getCostAnomaliesMutex.Lock()
+	defer fake.getCostAnomaliesMutex.Unlock()
+	fake.GetCostAnomaliesStub = nil
+	fake.getCostAnomaliesReturns = struct {
+		result1 []eventio.CostAnomaly
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetCostAnomaliesReturnsOnCall(i int, result1 []eventio.CostAnomaly, result2 error) {
+	fake.getCostAnomaliesMutex.Lock()
+	defer fake.getCostAnomaliesMutex.Unlock()
+	fake.GetCostAnomaliesStub = nil
+	if fake.getCostAnomaliesReturnsOnCall == nil {
+		fake.getCostAnomaliesReturnsOnCall = make(map[int]struct {
+			result1 []eventio.CostAnomaly
+			result2 error
+		})
+	}
+	fake.getCostAnomaliesReturnsOnCall[i] = struct {
+		result1 []eventio.CostAnomaly
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetCostProjections(arg1 eventio.EventFilter) ([]eventio.CostProjection, error) {
+	fake.getCostProjectionsMutex.Lock()
+	ret, specificReturn := fake.getCostProjectionsReturnsOnCall[len(fake.getCostProjectionsArgsForCall)]
+	fake.getCostProjectionsArgsForCall = append(fake.getCostProjectionsArgsForCall, struct {
+		arg1 eventio.EventFilter
+	}{arg1})
+	fake.recordInvocation("GetCostProjections", []interface{}{arg1})
+	fake.getCostProjectionsMutex.Unlock()
+	if fake.GetCostProjectionsStub != nil {
+		return fake.GetCostProjectionsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getCostProjectionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetCostProjectionsCallCount() int {
+	fake.getCostProjectionsMutex.RLock()
+	defer fake.getCostProjectionsMutex.RUnlock()
+	return len(fake.getCostProjectionsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetCostProjectionsCalls(stub func(eventio.EventFilter) ([]eventio.CostProjection, error)) {
+	fake.getCostProjectionsMutex.Lock()
+	defer fake.getCostProjectionsMutex.Unlock()
+	fake.GetCostProjectionsStub = stub
+}
+
+func (fake *FakeEventStore) GetCostProjectionsArgsForCall(i int) eventio.EventFilter {
+	fake.getCostProjectionsMutex.RLock()
+	defer fake.getCostProjectionsMutex.RUnlock()
+	argsForCall := fake.getCostProjectionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetCostProjectionsReturns(result1 []eventio.CostProjection, result2 error) {
+	fake.getCostProjectionsMutex.Lock()
+	defer fake.getCostProjectionsMutex.Unlock()
+	fake.GetCostProjectionsStub = nil
+	fake.getCostProjectionsReturns = struct {
+		result1 []eventio.CostProjection
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetCostProjectionsReturnsOnCall(i int, result1 []eventio.CostProjection, result2 error) {
+	fake.getCostProjectionsMutex.Lock()
+	defer fake.getCostProjectionsMutex.Unlock()
+	fake.GetCostProjectionsStub = nil
+	if fake.getCostProjectionsReturnsOnCall == nil {
+		fake.getCostProjectionsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.CostProjection
+			result2 error
+		})
+	}
+	fake.getCostProjectionsReturnsOnCall[i] = struct {
+		result1 []eventio.CostProjection
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) GetCurrencyRates(arg1 eventio.TimeRangeFilter) ([]eventio.CurrencyRate, error) {
 	fake.getCurrencyRatesMutex.Lock()
 	ret, specificReturn := fake.getCurrencyRatesReturnsOnCall[len(fake.getCurrencyRatesArgsForCall)]
@@ -892,6 +2346,69 @@ func (fake *FakeEventStore) GetCurrencyRatesReturnsOnCall(i int, result1 []event
 	}{result1, result2}
 }
 
+func (fake *FakeEventStore) GetDailyCosts(arg1 eventio.DailyCostFilter) ([]eventio.DailyCost, error) {
+	fake.getDailyCostsMutex.Lock()
+	ret, specificReturn := fake.getDailyCostsReturnsOnCall[len(fake.getDailyCostsArgsForCall)]
+	fake.getDailyCostsArgsForCall = append(fake.getDailyCostsArgsForCall, struct {
+		arg1 eventio.DailyCostFilter
+	}{arg1})
+	fake.recordInvocation("GetDailyCosts", []interface{}{arg1})
+	fake.getDailyCostsMutex.Unlock()
+	if fake.GetDailyCostsStub != nil {
+		return fake.GetDailyCostsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getDailyCostsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetDailyCostsCallCount() int {
+	fake.getDailyCostsMutex.RLock()
+	defer fake.getDailyCostsMutex.RUnlock()
+	return len(fake.getDailyCostsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetDailyCostsCalls(stub func(eventio.DailyCostFilter) ([]eventio.DailyCost, error)) {
+	fake.getDailyCostsMutex.Lock()
+	defer fake.getDailyCostsMutex.Unlock()
+	fake.GetDailyCostsStub = stub
+}
+
+func (fake *FakeEventStore) GetDailyCostsArgsForCall(i int) eventio.DailyCostFilter {
+	fake.getDailyCostsMutex.RLock()
+	defer fake.getDailyCostsMutex.RUnlock()
+	argsForCall := fake.getDailyCostsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetDailyCostsReturns(result1 []eventio.DailyCost, result2 error) {
+	fake.getDailyCostsMutex.Lock()
+	defer fake.getDailyCostsMutex.Unlock()
+	fake.GetDailyCostsStub = nil
+	fake.getDailyCostsReturns = struct {
+		result1 []eventio.DailyCost
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetDailyCostsReturnsOnCall(i int, result1 []eventio.DailyCost, result2 error) {
+	fake.getDailyCostsMutex.Lock()
+	defer fake.getDailyCostsMutex.Unlock()
+	fake.GetDailyCostsStub = nil
+	if fake.getDailyCostsReturnsOnCall == nil {
+		fake.getDailyCostsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.DailyCost
+			result2 error
+		})
+	}
+	fake.getDailyCostsReturnsOnCall[i] = struct {
+		result1 []eventio.DailyCost
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) GetEvents(arg1 eventio.RawEventFilter) ([]eventio.RawEvent, error) {
 	fake.getEventsMutex.Lock()
 	ret, specificReturn := fake.getEventsReturnsOnCall[len(fake.getEventsArgsForCall)]
@@ -906,51 +2423,295 @@ func (fake *FakeEventStore) GetEvents(arg1 eventio.RawEventFilter) ([]eventio.Ra
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getEventsReturns
+	fakeReturns := fake.getEventsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetEventsCallCount() int {
+	fake.getEventsMutex.RLock()
+	defer fake.getEventsMutex.RUnlock()
+	return len(fake.getEventsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetEventsCalls(stub func(eventio.RawEventFilter) ([]eventio.RawEvent, error)) {
+	fake.getEventsMutex.Lock()
+	defer fake.getEventsMutex.Unlock()
+	fake.GetEventsStub = stub
+}
+
+func (fake *FakeEventStore) GetEventsArgsForCall(i int) eventio.RawEventFilter {
+	fake.getEventsMutex.RLock()
+	defer fake.getEventsMutex.RUnlock()
+	argsForCall := fake.getEventsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetEventsReturns(result1 []eventio.RawEvent, result2 error) {
+	fake.getEventsMutex.Lock()
+	defer fake.getEventsMutex.Unlock()
+	fake.GetEventsStub = nil
+	fake.getEventsReturns = struct {
+		result1 []eventio.RawEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetEventsReturnsOnCall(i int, result1 []eventio.RawEvent, result2 error) {
+	fake.getEventsMutex.Lock()
+	defer fake.getEventsMutex.Unlock()
+	fake.GetEventsStub = nil
+	if fake.getEventsReturnsOnCall == nil {
+		fake.getEventsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.RawEvent
+			result2 error
+		})
+	}
+	fake.getEventsReturnsOnCall[i] = struct {
+		result1 []eventio.RawEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetIdleResources(arg1 eventio.IdleResourceFilter) ([]eventio.IdleResource, error) {
+	fake.getIdleResourcesMutex.Lock()
+	ret, specificReturn := fake.getIdleResourcesReturnsOnCall[len(fake.getIdleResourcesArgsForCall)]
+	fake.getIdleResourcesArgsForCall = append(fake.getIdleResourcesArgsForCall, struct {
+		arg1 eventio.IdleResourceFilter
+	}{arg1})
+	fake.recordInvocation("GetIdleResources", []interface{}{arg1})
+	fake.getIdleResourcesMutex.Unlock()
+	if fake.GetIdleResourcesStub != nil {
+		return fake.GetIdleResourcesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getIdleResourcesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetIdleResourcesCallCount() int {
+	fake.getIdleResourcesMutex.RLock()
+	defer fake.getIdleResourcesMutex.RUnlock()
+	return len(fake.getIdleResourcesArgsForCall)
+}
+
+func (fake *FakeEventStore) GetIdleResourcesCalls(stub func(eventio.IdleResourceFilter) ([]eventio.IdleResource, error)) {
+	fake.getIdleResourcesMutex.Lock()
+	defer fake.getIdleResourcesMutex.Unlock()
+	fake.GetIdleResourcesStub = stub
+}
+
+func (fake *FakeEventStore) GetIdleResourcesArgsForCall(i int) eventio.IdleResourceFilter {
+	fake.getIdleResourcesMutex.RLock()
+	defer fake.getIdleResourcesMutex.RUnlock()
+	argsForCall := fake.getIdleResourcesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetIdleResourcesReturns(result1 []eventio.IdleResource, result2 error) {
+	fake.getIdleResourcesMutex.Lock()
+	defer fake.getIdleResourcesMutex.Unlock()
+	fake.GetIdleResourcesStub = nil
+	fake.getIdleResourcesReturns = struct {
+		result1 []eventio.IdleResource
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetIdleResourcesReturnsOnCall(i int, result1 []eventio.IdleResource, result2 error) {
+	fake.getIdleResourcesMutex.Lock()
+	defer fake.getIdleResourcesMutex.Unlock()
+	fake.GetIdleResourcesStub = nil
+	if fake.getIdleResourcesReturnsOnCall == nil {
+		fake.getIdleResourcesReturnsOnCall = make(map[int]struct {
+			result1 []eventio.IdleResource
+			result2 error
+		})
+	}
+	fake.getIdleResourcesReturnsOnCall[i] = struct {
+		result1 []eventio.IdleResource
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetJob(arg1 int64) (*eventio.Job, error) {
+	fake.getJobMutex.Lock()
+	ret, specificReturn := fake.getJobReturnsOnCall[len(fake.getJobArgsForCall)]
+	fake.getJobArgsForCall = append(fake.getJobArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	fake.recordInvocation("GetJob", []interface{}{arg1})
+	fake.getJobMutex.Unlock()
+	if fake.GetJobStub != nil {
+		return fake.GetJobStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getJobReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetJobCallCount() int {
+	fake.getJobMutex.RLock()
+	defer fake.getJobMutex.RUnlock()
+	return len(fake.getJobArgsForCall)
+}
+
+func (fake *FakeEventStore) GetJobCalls(stub func(int64) (*eventio.Job, error)) {
+	fake.getJobMutex.Lock()
+	defer fake.getJobMutex.Unlock()
+	fake.GetJobStub = stub
+}
+
+func (fake *FakeEventStore) GetJobArgsForCall(i int) int64 {
+	fake.getJobMutex.RLock()
+	defer fake.getJobMutex.RUnlock()
+	argsForCall := fake.getJobArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetJobReturns(result1 *eventio.Job, result2 error) {
+	fake.getJobMutex.Lock()
+	defer fake.getJobMutex.Unlock()
+	fake.GetJobStub = nil
+	fake.getJobReturns = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetJobReturnsOnCall(i int, result1 *eventio.Job, result2 error) {
+	fake.getJobMutex.Lock()
+	defer fake.getJobMutex.Unlock()
+	fake.GetJobStub = nil
+	if fake.getJobReturnsOnCall == nil {
+		fake.getJobReturnsOnCall = make(map[int]struct {
+			result1 *eventio.Job
+			result2 error
+		})
+	}
+	fake.getJobReturnsOnCall[i] = struct {
+		result1 *eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetJobs(arg1 eventio.JobFilter) ([]eventio.Job, error) {
+	fake.getJobsMutex.Lock()
+	ret, specificReturn := fake.getJobsReturnsOnCall[len(fake.getJobsArgsForCall)]
+	fake.getJobsArgsForCall = append(fake.getJobsArgsForCall, struct {
+		arg1 eventio.JobFilter
+	}{arg1})
+	fake.recordInvocation("GetJobs", []interface{}{arg1})
+	fake.getJobsMutex.Unlock()
+	if fake.GetJobsStub != nil {
+		return fake.GetJobsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getJobsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetJobsCallCount() int {
+	fake.getJobsMutex.RLock()
+	defer fake.getJobsMutex.RUnlock()
+	return len(fake.getJobsArgsForCall)
+}
+
+func (fake *FakeEventStore) GetJobsCalls(stub func(eventio.JobFilter) ([]eventio.Job, error)) {
+	fake.getJobsMutex.Lock()
+	defer fake.getJobsMutex.Unlock()
+	fake.GetJobsStub = stub
+}
+
+func (fake *FakeEventStore) GetJobsArgsForCall(i int) eventio.JobFilter {
+	fake.getJobsMutex.RLock()
+	defer fake.getJobsMutex.RUnlock()
+	argsForCall := fake.getJobsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetJobsReturns(result1 []eventio.Job, result2 error) {
+	fake.getJobsMutex.Lock()
+	defer fake.getJobsMutex.Unlock()
+	fake.GetJobsStub = nil
+	fake.getJobsReturns = struct {
+		result1 []eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetJobsReturnsOnCall(i int, result1 []eventio.Job, result2 error) {
+	fake.getJobsMutex.Lock()
+	defer fake.getJobsMutex.Unlock()
+	fake.GetJobsStub = nil
+	if fake.getJobsReturnsOnCall == nil {
+		fake.getJobsReturnsOnCall = make(map[int]struct {
+			result1 []eventio.Job
+			result2 error
+		})
+	}
+	fake.getJobsReturnsOnCall[i] = struct {
+		result1 []eventio.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetLastRefresh() (time.Time, error) {
+	fake.getLastRefreshMutex.Lock()
+	ret, specificReturn := fake.getLastRefreshReturnsOnCall[len(fake.getLastRefreshArgsForCall)]
+	fake.getLastRefreshArgsForCall = append(fake.getLastRefreshArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetLastRefresh", []interface{}{})
+	fake.getLastRefreshMutex.Unlock()
+	if fake.GetLastRefreshStub != nil {
+		return fake.GetLastRefreshStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getLastRefreshReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeEventStore) GetEventsCallCount() int {
-	fake.getEventsMutex.RLock()
-	defer fake.getEventsMutex.RUnlock()
-	return len(fake.getEventsArgsForCall)
-}
-
-func (fake *FakeEventStore) GetEventsCalls(stub func(eventio.RawEventFilter) ([]eventio.RawEvent, error)) {
-	fake.getEventsMutex.Lock()
-	defer fake.getEventsMutex.Unlock()
-	fake.GetEventsStub = stub
+func (fake *FakeEventStore) GetLastRefreshCallCount() int {
+	fake.getLastRefreshMutex.RLock()
+	defer fake.getLastRefreshMutex.RUnlock()
+	return len(fake.getLastRefreshArgsForCall)
 }
 
-func (fake *FakeEventStore) GetEventsArgsForCall(i int) eventio.RawEventFilter {
-	fake.getEventsMutex.RLock()
-	defer fake.getEventsMutex.RUnlock()
-	argsForCall := fake.getEventsArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeEventStore) GetLastRefreshCalls(stub func() (time.Time, error)) {
+	fake.getLastRefreshMutex.Lock()
+	defer fake.getLastRefreshMutex.Unlock()
+	fake.GetLastRefreshStub = stub
 }
 
-func (fake *FakeEventStore) GetEventsReturns(result1 []eventio.RawEvent, result2 error) {
-	fake.getEventsMutex.Lock()
-	defer fake.getEventsMutex.Unlock()
-	fake.GetEventsStub = nil
-	fake.getEventsReturns = struct {
-		result1 []eventio.RawEvent
+func (fake *FakeEventStore) GetLastRefreshReturns(result1 time.Time, result2 error) {
+	fake.getLastRefreshMutex.Lock()
+	defer fake.getLastRefreshMutex.Unlock()
+	fake.GetLastRefreshStub = nil
+	fake.getLastRefreshReturns = struct {
+		result1 time.Time
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeEventStore) GetEventsReturnsOnCall(i int, result1 []eventio.RawEvent, result2 error) {
-	fake.getEventsMutex.Lock()
-	defer fake.getEventsMutex.Unlock()
-	fake.GetEventsStub = nil
-	if fake.getEventsReturnsOnCall == nil {
-		fake.getEventsReturnsOnCall = make(map[int]struct {
-			result1 []eventio.RawEvent
+func (fake *FakeEventStore) GetLastRefreshReturnsOnCall(i int, result1 time.Time, result2 error) {
+	fake.getLastRefreshMutex.Lock()
+	defer fake.getLastRefreshMutex.Unlock()
+	fake.GetLastRefreshStub = nil
+	if fake.getLastRefreshReturnsOnCall == nil {
+		fake.getLastRefreshReturnsOnCall = make(map[int]struct {
+			result1 time.Time
 			result2 error
 		})
 	}
-	fake.getEventsReturnsOnCall[i] = struct {
-		result1 []eventio.RawEvent
+	fake.getLastRefreshReturnsOnCall[i] = struct {
+		result1 time.Time
 		result2 error
 	}{result1, result2}
 }
@@ -1018,15 +2779,142 @@ func (fake *FakeEventStore) GetPricingPlansReturnsOnCall(i int, result1 []eventi
 	}{result1, result2}
 }
 
-func (fake *FakeEventStore) GetTotalCost() ([]eventio.TotalCost, error) {
+func (fake *FakeEventStore) GetRawEventArchives(arg1 eventio.RawEventArchiveFilter) ([]eventio.RawEventArchive, error) {
+	fake.getRawEventArchivesMutex.Lock()
+	ret, specificReturn := fake.getRawEventArchivesReturnsOnCall[len(fake.getRawEventArchivesArgsForCall)]
+	fake.getRawEventArchivesArgsForCall = append(fake.getRawEventArchivesArgsForCall, struct {
+		arg1 eventio.RawEventArchiveFilter
+	}{arg1})
+	fake.recordInvocation("GetRawEventArchives", []interface{}{arg1})
+	fake.getRawEventArchivesMutex.Unlock()
+	if fake.GetRawEventArchivesStub != nil {
+		return fake.GetRawEventArchivesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getRawEventArchivesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetRawEventArchivesCallCount() int {
+	fake.getRawEventArchivesMutex.RLock()
+	defer fake.getRawEventArchivesMutex.RUnlock()
+	return len(fake.getRawEventArchivesArgsForCall)
+}
+
+func (fake *FakeEventStore) GetRawEventArchivesCalls(stub func(eventio.RawEventArchiveFilter) ([]eventio.RawEventArchive, error)) {
+	fake.getRawEventArchivesMutex.Lock()
+	defer fake.getRawEventArchivesMutex.Unlock()
+	fake.GetRawEventArchivesStub = stub
+}
+
+func (fake *FakeEventStore) GetRawEventArchivesArgsForCall(i int) eventio.RawEventArchiveFilter {
+	fake.getRawEventArchivesMutex.RLock()
+	defer fake.getRawEventArchivesMutex.RUnlock()
+	argsForCall := fake.getRawEventArchivesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetRawEventArchivesReturns(result1 []eventio.RawEventArchive, result2 error) {
+	fake.getRawEventArchivesMutex.Lock()
+	defer fake.getRawEventArchivesMutex.Unlock()
+	fake.GetRawEventArchivesStub = nil
+	fake.getRawEventArchivesReturns = struct {
+		result1 []eventio.RawEventArchive
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetRawEventArchivesReturnsOnCall(i int, result1 []eventio.RawEventArchive, result2 error) {
+	fake.getRawEventArchivesMutex.Lock()
+	defer fake.getRawEventArchivesMutex.Unlock()
+	fake.GetRawEventArchivesStub = nil
+	if fake.getRawEventArchivesReturnsOnCall == nil {
+		fake.getRawEventArchivesReturnsOnCall = make(map[int]struct {
+			result1 []eventio.RawEventArchive
+			result2 error
+		})
+	}
+	fake.getRawEventArchivesReturnsOnCall[i] = struct {
+		result1 []eventio.RawEventArchive
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetResourceTimeline(arg1 string) (*eventio.ResourceTimeline, error) {
+	fake.getResourceTimelineMutex.Lock()
+	ret, specificReturn := fake.getResourceTimelineReturnsOnCall[len(fake.getResourceTimelineArgsForCall)]
+	fake.getResourceTimelineArgsForCall = append(fake.getResourceTimelineArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetResourceTimeline", []interface{}{arg1})
+	fake.getResourceTimelineMutex.Unlock()
+	if fake.GetResourceTimelineStub != nil {
+		return fake.GetResourceTimelineStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getResourceTimelineReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetResourceTimelineCallCount() int {
+	fake.getResourceTimelineMutex.RLock()
+	defer fake.getResourceTimelineMutex.RUnlock()
+	return len(fake.getResourceTimelineArgsForCall)
+}
+
+func (fake *FakeEventStore) GetResourceTimelineCalls(stub func(string) (*eventio.ResourceTimeline, error)) {
+	fake.getResourceTimelineMutex.Lock()
+	defer fake.getResourceTimelineMutex.Unlock()
+	fake.GetResourceTimelineStub = stub
+}
+
+func (fake *FakeEventStore) GetResourceTimelineArgsForCall(i int) string {
+	fake.getResourceTimelineMutex.RLock()
+	defer fake.getResourceTimelineMutex.RUnlock()
+	argsForCall := fake.getResourceTimelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetResourceTimelineReturns(result1 *eventio.ResourceTimeline, result2 error) {
+	fake.getResourceTimelineMutex.Lock()
+	defer fake.getResourceTimelineMutex.Unlock()
+	fake.GetResourceTimelineStub = nil
+	fake.getResourceTimelineReturns = struct {
+		result1 *eventio.ResourceTimeline
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetResourceTimelineReturnsOnCall(i int, result1 *eventio.ResourceTimeline, result2 error) {
+	fake.getResourceTimelineMutex.Lock()
+	defer fake.getResourceTimelineMutex.Unlock()
+	fake.GetResourceTimelineStub = nil
+	if fake.getResourceTimelineReturnsOnCall == nil {
+		fake.getResourceTimelineReturnsOnCall = make(map[int]struct {
+			result1 *eventio.ResourceTimeline
+			result2 error
+		})
+	}
+	fake.getResourceTimelineReturnsOnCall[i] = struct {
+		result1 *eventio.ResourceTimeline
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetTotalCost(arg1 eventio.TotalCostFilter) ([]eventio.TotalCost, error) {
 	fake.getTotalCostMutex.Lock()
 	ret, specificReturn := fake.getTotalCostReturnsOnCall[len(fake.getTotalCostArgsForCall)]
 	fake.getTotalCostArgsForCall = append(fake.getTotalCostArgsForCall, struct {
-	}{})
-	fake.recordInvocation("GetTotalCost", []interface{}{})
+		arg1 eventio.TotalCostFilter
+	}{arg1})
+	fake.recordInvocation("GetTotalCost", []interface{}{arg1})
 	fake.getTotalCostMutex.Unlock()
 	if fake.GetTotalCostStub != nil {
-		return fake.GetTotalCostStub()
+		return fake.GetTotalCostStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -1041,12 +2929,19 @@ func (fake *FakeEventStore) GetTotalCostCallCount() int {
 	return len(fake.getTotalCostArgsForCall)
 }
 
-func (fake *FakeEventStore) GetTotalCostCalls(stub func() ([]eventio.TotalCost, error)) {
+func (fake *FakeEventStore) GetTotalCostCalls(stub func(eventio.TotalCostFilter) ([]eventio.TotalCost, error)) {
 	fake.getTotalCostMutex.Lock()
 	defer fake.getTotalCostMutex.Unlock()
 	fake.GetTotalCostStub = stub
 }
 
+func (fake *FakeEventStore) GetTotalCostArgsForCall(i int) eventio.TotalCostFilter {
+	fake.getTotalCostMutex.RLock()
+	defer fake.getTotalCostMutex.RUnlock()
+	argsForCall := fake.getTotalCostArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeEventStore) GetTotalCostReturns(result1 []eventio.TotalCost, result2 error) {
 	fake.getTotalCostMutex.Lock()
 	defer fake.getTotalCostMutex.Unlock()
@@ -1073,6 +2968,69 @@ func (fake *FakeEventStore) GetTotalCostReturnsOnCall(i int, result1 []eventio.T
 	}{result1, result2}
 }
 
+func (fake *FakeEventStore) GetUsageAndAdoption(arg1 eventio.EventFilter) (eventio.UsageAndAdoptionReport, error) {
+	fake.getUsageAndAdoptionMutex.Lock()
+	ret, specificReturn := fake.getUsageAndAdoptionReturnsOnCall[len(fake.getUsageAndAdoptionArgsForCall)]
+	fake.getUsageAndAdoptionArgsForCall = append(fake.getUsageAndAdoptionArgsForCall, struct {
+		arg1 eventio.EventFilter
+	}{arg1})
+	fake.recordInvocation("GetUsageAndAdoption", []interface{}{arg1})
+	fake.getUsageAndAdoptionMutex.Unlock()
+	if fake.GetUsageAndAdoptionStub != nil {
+		return fake.GetUsageAndAdoptionStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getUsageAndAdoptionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) GetUsageAndAdoptionCallCount() int {
+	fake.getUsageAndAdoptionMutex.RLock()
+	defer fake.getUsageAndAdoptionMutex.RUnlock()
+	return len(fake.getUsageAndAdoptionArgsForCall)
+}
+
+func (fake *FakeEventStore) GetUsageAndAdoptionCalls(stub func(eventio.EventFilter) (eventio.UsageAndAdoptionReport, error)) {
+	fake.getUsageAndAdoptionMutex.Lock()
+	defer fake.getUsageAndAdoptionMutex.Unlock()
+	fake.GetUsageAndAdoptionStub = stub
+}
+
+func (fake *FakeEventStore) GetUsageAndAdoptionArgsForCall(i int) eventio.EventFilter {
+	fake.getUsageAndAdoptionMutex.RLock()
+	defer fake.getUsageAndAdoptionMutex.RUnlock()
+	argsForCall := fake.getUsageAndAdoptionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) GetUsageAndAdoptionReturns(result1 eventio.UsageAndAdoptionReport, result2 error) {
+	fake.getUsageAndAdoptionMutex.Lock()
+	defer fake.getUsageAndAdoptionMutex.Unlock()
+	fake.GetUsageAndAdoptionStub = nil
+	fake.getUsageAndAdoptionReturns = struct {
+		result1 eventio.UsageAndAdoptionReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) GetUsageAndAdoptionReturnsOnCall(i int, result1 eventio.UsageAndAdoptionReport, result2 error) {
+	fake.getUsageAndAdoptionMutex.Lock()
+	defer fake.getUsageAndAdoptionMutex.Unlock()
+	fake.GetUsageAndAdoptionStub = nil
+	if fake.getUsageAndAdoptionReturnsOnCall == nil {
+		fake.getUsageAndAdoptionReturnsOnCall = make(map[int]struct {
+			result1 eventio.UsageAndAdoptionReport
+			result2 error
+		})
+	}
+	fake.getUsageAndAdoptionReturnsOnCall[i] = struct {
+		result1 eventio.UsageAndAdoptionReport
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) GetUsageEventRows(arg1 eventio.EventFilter) (eventio.UsageEventRows, error) {
 	fake.getUsageEventRowsMutex.Lock()
 	ret, specificReturn := fake.getUsageEventRowsReturnsOnCall[len(fake.getUsageEventRowsArgsForCall)]
@@ -1377,6 +3335,119 @@ func (fake *FakeEventStore) IsRangeConsolidatedReturnsOnCall(i int, result1 bool
 	}{result1, result2}
 }
 
+func (fake *FakeEventStore) MarkRawEventArchiveRestored(arg1 context.Context, arg2 int64) error {
+	fake.markRawEventArchiveRestoredMutex.Lock()
+	ret, specificReturn := fake.markRawEventArchiveRestoredReturnsOnCall[len(fake.markRawEventArchiveRestoredArgsForCall)]
+	fake.markRawEventArchiveRestoredArgsForCall = append(fake.markRawEventArchiveRestoredArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	fake.recordInvocation("MarkRawEventArchiveRestored", []interface{}{arg1, arg2})
+	fake.markRawEventArchiveRestoredMutex.Unlock()
+	if fake.MarkRawEventArchiveRestoredStub != nil {
+		return fake.MarkRawEventArchiveRestoredStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.markRawEventArchiveRestoredReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) MarkRawEventArchiveRestoredCallCount() int {
+	fake.markRawEventArchiveRestoredMutex.RLock()
+	defer fake.markRawEventArchiveRestoredMutex.RUnlock()
+	return len(fake.markRawEventArchiveRestoredArgsForCall)
+}
+
+func (fake *FakeEventStore) MarkRawEventArchiveRestoredCalls(stub func(context.Context, int64) error) {
+	fake.markRawEventArchiveRestoredMutex.Lock()
+	defer fake.markRawEventArchiveRestoredMutex.Unlock()
+	fake.MarkRawEventArchiveRestoredStub = stub
+}
+
+func (fake *FakeEventStore) MarkRawEventArchiveRestoredArgsForCall(i int) (context.Context, int64) {
+	fake.markRawEventArchiveRestoredMutex.RLock()
+	defer fake.markRawEventArchiveRestoredMutex.RUnlock()
+	argsForCall := fake.markRawEventArchiveRestoredArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) MarkRawEventArchiveRestoredReturns(result1 error) {
+	fake.markRawEventArchiveRestoredMutex.Lock()
+	defer fake.markRawEventArchiveRestoredMutex.Unlock()
+	fake.MarkRawEventArchiveRestoredStub = nil
+	fake.markRawEventArchiveRestoredReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) MarkRawEventArchiveRestoredReturnsOnCall(i int, result1 error) {
+	fake.markRawEventArchiveRestoredMutex.Lock()
+	defer fake.markRawEventArchiveRestoredMutex.Unlock()
+	fake.MarkRawEventArchiveRestoredStub = nil
+	if fake.markRawEventArchiveRestoredReturnsOnCall == nil {
+		fake.markRawEventArchiveRestoredReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.markRawEventArchiveRestoredReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) Ping() error {
+	fake.pingMutex.Lock()
+	ret, specificReturn := fake.pingReturnsOnCall[len(fake.pingArgsForCall)]
+	fake.pingArgsForCall = append(fake.pingArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Ping", []interface{}{})
+	fake.pingMutex.Unlock()
+	if fake.PingStub != nil {
+		return fake.PingStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pingReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) PingCallCount() int {
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
+	return len(fake.pingArgsForCall)
+}
+
+func (fake *FakeEventStore) PingCalls(stub func() error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = stub
+}
+
+func (fake *FakeEventStore) PingReturns(result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	fake.pingReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) PingReturnsOnCall(i int, result1 error) {
+	fake.pingMutex.Lock()
+	defer fake.pingMutex.Unlock()
+	fake.PingStub = nil
+	if fake.pingReturnsOnCall == nil {
+		fake.pingReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pingReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeEventStore) Refresh() error {
 	fake.refreshMutex.Lock()
 	ret, specificReturn := fake.refreshReturnsOnCall[len(fake.refreshArgsForCall)]
@@ -1429,6 +3500,199 @@ func (fake *FakeEventStore) RefreshReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeEventStore) RefreshContext(arg1 context.Context) error {
+	fake.refreshContextMutex.Lock()
+	ret, specificReturn := fake.refreshContextReturnsOnCall[len(fake.refreshContextArgsForCall)]
+	fake.refreshContextArgsForCall = append(fake.refreshContextArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("RefreshContext", []interface{}{arg1})
+	fake.refreshContextMutex.Unlock()
+	if fake.RefreshContextStub != nil {
+		return fake.RefreshContextStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.refreshContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEventStore) RefreshContextCallCount() int {
+	fake.refreshContextMutex.RLock()
+	defer fake.refreshContextMutex.RUnlock()
+	return len(fake.refreshContextArgsForCall)
+}
+
+func (fake *FakeEventStore) RefreshContextCalls(stub func(context.Context) error) {
+	fake.refreshContextMutex.Lock()
+	defer fake.refreshContextMutex.Unlock()
+	fake.RefreshContextStub = stub
+}
+
+func (fake *FakeEventStore) RefreshContextArgsForCall(i int) context.Context {
+	fake.refreshContextMutex.RLock()
+	defer fake.refreshContextMutex.RUnlock()
+	argsForCall := fake.refreshContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEventStore) RefreshContextReturns(result1 error) {
+	fake.refreshContextMutex.Lock()
+	defer fake.refreshContextMutex.Unlock()
+	fake.RefreshContextStub = nil
+	fake.refreshContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) RefreshContextReturnsOnCall(i int, result1 error) {
+	fake.refreshContextMutex.Lock()
+	defer fake.refreshContextMutex.Unlock()
+	fake.RefreshContextStub = nil
+	if fake.refreshContextReturnsOnCall == nil {
+		fake.refreshContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.refreshContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventStore) RestoreRawEvents(arg1 context.Context, arg2 []eventio.ArchivedRawEvent) (int, error) {
+	var arg2Copy []eventio.ArchivedRawEvent
+	if arg2 != nil {
+		arg2Copy = make([]eventio.ArchivedRawEvent, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.restoreRawEventsMutex.Lock()
+	ret, specificReturn := fake.restoreRawEventsReturnsOnCall[len(fake.restoreRawEventsArgsForCall)]
+	fake.restoreRawEventsArgsForCall = append(fake.restoreRawEventsArgsForCall, struct {
+		arg1 context.Context
+		arg2 []eventio.ArchivedRawEvent
+	}{arg1, arg2Copy})
+	fake.recordInvocation("RestoreRawEvents", []interface{}{arg1, arg2Copy})
+	fake.restoreRawEventsMutex.Unlock()
+	if fake.RestoreRawEventsStub != nil {
+		return fake.RestoreRawEventsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.restoreRawEventsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) RestoreRawEventsCallCount() int {
+	fake.restoreRawEventsMutex.RLock()
+	defer fake.restoreRawEventsMutex.RUnlock()
+	return len(fake.restoreRawEventsArgsForCall)
+}
+
+func (fake *FakeEventStore) RestoreRawEventsCalls(stub func(context.Context, []eventio.ArchivedRawEvent) (int, error)) {
+	fake.restoreRawEventsMutex.Lock()
+	defer fake.restoreRawEventsMutex.Unlock()
+	fake.RestoreRawEventsStub = stub
+}
+
+func (fake *FakeEventStore) RestoreRawEventsArgsForCall(i int) (context.Context, []eventio.ArchivedRawEvent) {
+	fake.restoreRawEventsMutex.RLock()
+	defer fake.restoreRawEventsMutex.RUnlock()
+	argsForCall := fake.restoreRawEventsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) RestoreRawEventsReturns(result1 int, result2 error) {
+	fake.restoreRawEventsMutex.Lock()
+	defer fake.restoreRawEventsMutex.Unlock()
+	fake.RestoreRawEventsStub = nil
+	fake.restoreRawEventsReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) RestoreRawEventsReturnsOnCall(i int, result1 int, result2 error) {
+	fake.restoreRawEventsMutex.Lock()
+	defer fake.restoreRawEventsMutex.Unlock()
+	fake.RestoreRawEventsStub = nil
+	if fake.restoreRawEventsReturnsOnCall == nil {
+		fake.restoreRawEventsReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.restoreRawEventsReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) RevokeAdjustment(arg1 string, arg2 string) (*eventio.Adjustment, error) {
+	fake.revokeAdjustmentMutex.Lock()
+	ret, specificReturn := fake.revokeAdjustmentReturnsOnCall[len(fake.revokeAdjustmentArgsForCall)]
+	fake.revokeAdjustmentArgsForCall = append(fake.revokeAdjustmentArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("RevokeAdjustment", []interface{}{arg1, arg2})
+	fake.revokeAdjustmentMutex.Unlock()
+	if fake.RevokeAdjustmentStub != nil {
+		return fake.RevokeAdjustmentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.revokeAdjustmentReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEventStore) RevokeAdjustmentCallCount() int {
+	fake.revokeAdjustmentMutex.RLock()
+	defer fake.revokeAdjustmentMutex.RUnlock()
+	return len(fake.revokeAdjustmentArgsForCall)
+}
+
+func (fake *FakeEventStore) RevokeAdjustmentCalls(stub func(string, string) (*eventio.Adjustment, error)) {
+	fake.revokeAdjustmentMutex.Lock()
+	defer fake.revokeAdjustmentMutex.Unlock()
+	fake.RevokeAdjustmentStub = stub
+}
+
+func (fake *FakeEventStore) RevokeAdjustmentArgsForCall(i int) (string, string) {
+	fake.revokeAdjustmentMutex.RLock()
+	defer fake.revokeAdjustmentMutex.RUnlock()
+	argsForCall := fake.revokeAdjustmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeEventStore) RevokeAdjustmentReturns(result1 *eventio.Adjustment, result2 error) {
+	fake.revokeAdjustmentMutex.Lock()
+	defer fake.revokeAdjustmentMutex.Unlock()
+	fake.RevokeAdjustmentStub = nil
+	fake.revokeAdjustmentReturns = struct {
+		result1 *eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEventStore) RevokeAdjustmentReturnsOnCall(i int, result1 *eventio.Adjustment, result2 error) {
+	fake.revokeAdjustmentMutex.Lock()
+	defer fake.revokeAdjustmentMutex.Unlock()
+	fake.RevokeAdjustmentStub = nil
+	if fake.revokeAdjustmentReturnsOnCall == nil {
+		fake.revokeAdjustmentReturnsOnCall = make(map[int]struct {
+			result1 *eventio.Adjustment
+			result2 error
+		})
+	}
+	fake.revokeAdjustmentReturnsOnCall[i] = struct {
+		result1 *eventio.Adjustment
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeEventStore) StoreEvents(arg1 []eventio.RawEvent) error {
 	var arg1Copy []eventio.RawEvent
 	if arg1 != nil {
@@ -1497,32 +3761,82 @@ func (fake *FakeEventStore) StoreEventsReturnsOnCall(i int, result1 error) {
 func (fake *FakeEventStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.archiveRawEventsMutex.RLock()
+	defer fake.archiveRawEventsMutex.RUnlock()
+	fake.cancelJobMutex.RLock()
+	defer fake.cancelJobMutex.RUnlock()
+	fake.claimNextJobMutex.RLock()
+	defer fake.claimNextJobMutex.RUnlock()
 	fake.consolidateMutex.RLock()
 	defer fake.consolidateMutex.RUnlock()
 	fake.consolidateAllMutex.RLock()
 	defer fake.consolidateAllMutex.RUnlock()
+	fake.consolidateAllContextMutex.RLock()
+	defer fake.consolidateAllContextMutex.RUnlock()
+	fake.consolidateContextMutex.RLock()
+	defer fake.consolidateContextMutex.RUnlock()
 	fake.consolidateFullMonthsMutex.RLock()
 	defer fake.consolidateFullMonthsMutex.RUnlock()
+	fake.createAdjustmentMutex.RLock()
+	defer fake.createAdjustmentMutex.RUnlock()
+	fake.detachConsolidatedMonthsMutex.RLock()
+	defer fake.detachConsolidatedMonthsMutex.RUnlock()
+	fake.enqueueJobMutex.RLock()
+	defer fake.enqueueJobMutex.RUnlock()
+	fake.failRunningJobsMutex.RLock()
+	defer fake.failRunningJobsMutex.RUnlock()
+	fake.finishJobMutex.RLock()
+	defer fake.finishJobMutex.RUnlock()
+	fake.forecastBillableEventDiffsMutex.RLock()
+	defer fake.forecastBillableEventDiffsMutex.RUnlock()
 	fake.forecastBillableEventRowsMutex.RLock()
 	defer fake.forecastBillableEventRowsMutex.RUnlock()
 	fake.forecastBillableEventsMutex.RLock()
 	defer fake.forecastBillableEventsMutex.RUnlock()
+	fake.getAdjustmentAuditMutex.RLock()
+	defer fake.getAdjustmentAuditMutex.RUnlock()
+	fake.getAdjustmentsMutex.RLock()
+	defer fake.getAdjustmentsMutex.RUnlock()
 	fake.getBillableEventRowsMutex.RLock()
 	defer fake.getBillableEventRowsMutex.RUnlock()
 	fake.getBillableEventsMutex.RLock()
 	defer fake.getBillableEventsMutex.RUnlock()
+	fake.getBillingContactsMutex.RLock()
+	defer fake.getBillingContactsMutex.RUnlock()
 	fake.getConsolidatedBillableEventRowsMutex.RLock()
 	defer fake.getConsolidatedBillableEventRowsMutex.RUnlock()
 	fake.getConsolidatedBillableEventsMutex.RLock()
 	defer fake.getConsolidatedBillableEventsMutex.RUnlock()
+	fake.getContractStatementsMutex.RLock()
+	defer fake.getContractStatementsMutex.RUnlock()
+	fake.getCostAnomaliesMutex.RLock()
+	defer fake.getCostAnomaliesMutex.RUnlock()
+	fake.getCostProjectionsMutex.RLock()
+	defer fake.getCostProjectionsMutex.RUnlock()
 	fake.getCurrencyRatesMutex.RLock()
 	defer fake.getCurrencyRatesMutex.RUnlock()
+	fake.getDailyCostsMutex.RLock()
+	defer fake.getDailyCostsMutex.RUnlock()
 	fake.getEventsMutex.RLock()
 	defer fake.getEventsMutex.RUnlock()
+	fake.getIdleResourcesMutex.RLock()
+	defer fake.getIdleResourcesMutex.RUnlock()
+	fake.getJobMutex.RLock()
+	defer fake.getJobMutex.RUnlock()
+	fake.getJobsMutex.RLock()
+	defer fake.getJobsMutex.RUnlock()
+	fake.getLastRefreshMutex.RLock()
+	defer fake.getLastRefreshMutex.RUnlock()
 	fake.getPricingPlansMutex.RLock()
 	defer fake.getPricingPlansMutex.RUnlock()
+	fake.getRawEventArchivesMutex.RLock()
+	defer fake.getRawEventArchivesMutex.RUnlock()
+	fake.getResourceTimelineMutex.RLock()
+	defer fake.getResourceTimelineMutex.RUnlock()
 	fake.getTotalCostMutex.RLock()
 	defer fake.getTotalCostMutex.RUnlock()
+	fake.getUsageAndAdoptionMutex.RLock()
+	defer fake.getUsageAndAdoptionMutex.RUnlock()
 	fake.getUsageEventRowsMutex.RLock()
 	defer fake.getUsageEventRowsMutex.RUnlock()
 	fake.getUsageEventsMutex.RLock()
@@ -1533,8 +3847,18 @@ func (fake *FakeEventStore) Invocations() map[string][][]interface{} {
 	defer fake.initMutex.RUnlock()
 	fake.isRangeConsolidatedMutex.RLock()
 	defer fake.isRangeConsolidatedMutex.RUnlock()
+	fake.markRawEventArchiveRestoredMutex.RLock()
+	defer fake.markRawEventArchiveRestoredMutex.RUnlock()
+	fake.pingMutex.RLock()
+	defer fake.pingMutex.RUnlock()
 	fake.refreshMutex.RLock()
 	defer fake.refreshMutex.RUnlock()
+	fake.refreshContextMutex.RLock()
+	defer fake.refreshContextMutex.RUnlock()
+	fake.restoreRawEventsMutex.RLock()
+	defer fake.restoreRawEventsMutex.RUnlock()
+	fake.revokeAdjustmentMutex.RLock()
+	defer fake.revokeAdjustmentMutex.RUnlock()
 	fake.storeEventsMutex.RLock()
 	defer fake.storeEventsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,13 @@
+package jobqueue_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestJobQueue(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "JobQueue")
+}
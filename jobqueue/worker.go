@@ -0,0 +1,150 @@
+package jobqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventio"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	DefaultPollInterval = time.Duration(5 * time.Second)
+)
+
+// Runner executes a single job. It should abandon the work and return when
+// ctx is cancelled.
+type Runner func(ctx context.Context, job eventio.Job) error
+
+// Worker claims jobs from a JobQueue one at a time and executes them with the
+// Runner registered for their Kind. Only one Worker should run at a time.
+type Worker struct {
+	pollInterval time.Duration
+	logger       lager.Logger
+	store        eventio.JobQueue
+	runners      map[eventio.JobKind]Runner
+}
+
+// Run executes queued jobs until ctx is cancelled. Any job left running by a
+// previous worker is failed before the first job is claimed.
+func (w *Worker) Run(ctx context.Context) error {
+	w.logger.Info("started")
+	defer w.logger.Info("stopping")
+
+	if err := w.store.FailRunningJobs("worker restarted"); err != nil {
+		return err
+	}
+
+	for {
+		job, err := w.store.ClaimNextJob()
+		if err != nil {
+			w.logger.Error("claim-job-error", err)
+		}
+		if job != nil {
+			w.runJob(ctx, *job)
+			continue
+		}
+		select {
+		case <-time.After(w.pollInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// runJob executes the job and records its outcome. While the job is running
+// the store is polled so that a cancellation requested through the API stops
+// the runner.
+func (w *Worker) runJob(ctx context.Context, job eventio.Job) {
+	logger := w.logger.Session("job", lager.Data{
+		"id":     job.ID,
+		"kind":   job.Kind,
+		"params": job.Params,
+	})
+	logger.Info("started")
+	startTime := time.Now()
+
+	runner, ok := w.runners[job.Kind]
+	if !ok {
+		err := fmt.Errorf("no runner for job kind '%s'", job.Kind)
+		logger.Error("failed", err)
+		w.finish(logger, job, eventio.JobFailed, err.Error())
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- runner(jobCtx, job)
+	}()
+
+	cancelled := false
+	var err error
+	for running := true; running; {
+		select {
+		case err = <-done:
+			running = false
+		case <-time.After(w.pollInterval):
+			if cancelled {
+				continue
+			}
+			current, getErr := w.store.GetJob(job.ID)
+			if getErr != nil {
+				logger.Error("get-job-error", getErr)
+				continue
+			}
+			if current != nil && current.CancelRequested {
+				logger.Info("cancelling")
+				cancelled = true
+				cancel()
+			}
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	switch {
+	case cancelled:
+		logger.Info("cancelled", lager.Data{"elapsed": int64(elapsed)})
+		w.finish(logger, job, eventio.JobCancelled, "")
+	case ctx.Err() != nil:
+		logger.Info("interrupted", lager.Data{"elapsed": int64(elapsed)})
+		w.finish(logger, job, eventio.JobFailed, "worker stopped")
+	case err != nil:
+		logger.Error("failed", err, lager.Data{"elapsed": int64(elapsed)})
+		w.finish(logger, job, eventio.JobFailed, err.Error())
+	default:
+		logger.Info("succeeded", lager.Data{"elapsed": int64(elapsed)})
+		w.finish(logger, job, eventio.JobSucceeded, "")
+	}
+}
+
+func (w *Worker) finish(logger lager.Logger, job eventio.Job, state eventio.JobState, errorMessage string) {
+	if err := w.store.FinishJob(job.ID, state, errorMessage); err != nil {
+		logger.Error("finish-job-error", err)
+	}
+}
+
+type Config struct {
+	PollInterval time.Duration
+	Logger       lager.Logger
+	Store        eventio.JobQueue
+	Runners      map[eventio.JobKind]Runner
+}
+
+func New(cfg Config) *Worker {
+	if cfg.Logger == nil {
+		cfg.Logger = lager.NewLogger("worker")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	return &Worker{
+		pollInterval: cfg.PollInterval,
+		logger:       cfg.Logger,
+		store:        cfg.Store,
+		runners:      cfg.Runners,
+	}
+}
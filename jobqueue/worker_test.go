@@ -0,0 +1,153 @@
+package jobqueue_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+
+	. "github.com/alphagov/paas-billing/jobqueue"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Worker", func() {
+
+	var (
+		logger         = lager.NewLogger("test")
+		fakeEventStore *fakes.FakeEventStore
+		cfg            Config
+		ctx            context.Context
+		cancelFunc     context.CancelFunc
+		refreshJob     eventio.Job
+	)
+
+	BeforeEach(func() {
+		fakeEventStore = &fakes.FakeEventStore{}
+		cfg = Config{
+			Logger:       logger,
+			Store:        fakeEventStore,
+			PollInterval: 50 * time.Millisecond,
+			Runners:      map[eventio.JobKind]Runner{},
+		}
+		ctx, cancelFunc = context.WithCancel(context.Background())
+		refreshJob = eventio.Job{
+			ID:    1,
+			Kind:  eventio.RefreshJob,
+			State: eventio.JobRunning,
+		}
+	})
+
+	AfterEach(func() {
+		cancelFunc()
+	})
+
+	It("should fail abandoned jobs before claiming any", func() {
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.ClaimNextJobCallCount).Should(BeNumerically(">", 0))
+		Expect(fakeEventStore.FailRunningJobsCallCount()).To(Equal(1))
+		Expect(fakeEventStore.FailRunningJobsArgsForCall(0)).To(Equal("worker restarted"))
+	})
+
+	It("should return an error if abandoned jobs cannot be failed", func() {
+		fakeEventStore.FailRunningJobsReturns(errors.New("db-error"))
+
+		err := New(cfg).Run(ctx)
+		Expect(err).To(MatchError("db-error"))
+		Expect(fakeEventStore.ClaimNextJobCallCount()).To(Equal(0))
+	})
+
+	It("should run a claimed job and record that it succeeded", func() {
+		var ran eventio.Job
+		cfg.Runners[eventio.RefreshJob] = func(ctx context.Context, job eventio.Job) error {
+			ran = job
+			return nil
+		}
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, &refreshJob, nil)
+
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.FinishJobCallCount).Should(Equal(1))
+		id, state, errorMessage := fakeEventStore.FinishJobArgsForCall(0)
+		Expect(id).To(Equal(int64(1)))
+		Expect(state).To(Equal(eventio.JobSucceeded))
+		Expect(errorMessage).To(BeEmpty())
+		Expect(ran.ID).To(Equal(int64(1)))
+	})
+
+	It("should record the error when a job fails", func() {
+		cfg.Runners[eventio.RefreshJob] = func(ctx context.Context, job eventio.Job) error {
+			return errors.New("refresh-error")
+		}
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, &refreshJob, nil)
+
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.FinishJobCallCount).Should(Equal(1))
+		_, state, errorMessage := fakeEventStore.FinishJobArgsForCall(0)
+		Expect(state).To(Equal(eventio.JobFailed))
+		Expect(errorMessage).To(Equal("refresh-error"))
+	})
+
+	It("should fail jobs of a kind it has no runner for", func() {
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, &refreshJob, nil)
+
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.FinishJobCallCount).Should(Equal(1))
+		_, state, errorMessage := fakeEventStore.FinishJobArgsForCall(0)
+		Expect(state).To(Equal(eventio.JobFailed))
+		Expect(errorMessage).To(ContainSubstring("no runner"))
+	})
+
+	It("should cancel a running job when cancellation is requested", func() {
+		cfg.Runners[eventio.RefreshJob] = func(ctx context.Context, job eventio.Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, &refreshJob, nil)
+		cancelledJob := refreshJob
+		cancelledJob.CancelRequested = true
+		fakeEventStore.GetJobReturns(&cancelledJob, nil)
+
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.FinishJobCallCount).Should(Equal(1))
+		Expect(fakeEventStore.GetJobArgsForCall(0)).To(Equal(int64(1)))
+		_, state, _ := fakeEventStore.FinishJobArgsForCall(0)
+		Expect(state).To(Equal(eventio.JobCancelled))
+	})
+
+	It("should fail the running job when the worker is stopped", func() {
+		started := make(chan struct{})
+		cfg.Runners[eventio.RefreshJob] = func(ctx context.Context, job eventio.Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, &refreshJob, nil)
+		fakeEventStore.GetJobReturns(&refreshJob, nil)
+
+		go New(cfg).Run(ctx)
+
+		Eventually(started).Should(BeClosed())
+		cancelFunc()
+
+		Eventually(fakeEventStore.FinishJobCallCount).Should(Equal(1))
+		_, state, errorMessage := fakeEventStore.FinishJobArgsForCall(0)
+		Expect(state).To(Equal(eventio.JobFailed))
+		Expect(errorMessage).To(Equal("worker stopped"))
+	})
+
+	It("should keep polling after failing to claim a job", func() {
+		fakeEventStore.ClaimNextJobReturnsOnCall(0, nil, errors.New("db-error"))
+
+		go New(cfg).Run(ctx)
+
+		Eventually(fakeEventStore.ClaimNextJobCallCount).Should(BeNumerically(">", 2))
+	})
+})
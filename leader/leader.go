@@ -0,0 +1,197 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	DefaultRetryInterval = 10 * time.Second
+)
+
+// ErrLeadershipLost is returned by Err after the database session that held
+// the lock has died
+var ErrLeadershipLost = errors.New("leadership lost")
+
+type Config struct {
+	// DB is the database the lock is taken in (required)
+	DB *sql.DB
+	// LockID is the key of the postgres advisory lock. Every process that
+	// competes for the same leadership must use the same LockID.
+	LockID int64
+	// RetryInterval sets how often a standby tries to take the lock and how
+	// often the leader checks that it still holds it
+	RetryInterval time.Duration
+	// Logger overrides the default logger
+	Logger lager.Logger
+}
+
+// Elector elects a single leader between processes sharing a database by
+// holding a session level postgres advisory lock. The lock is released by
+// postgres as soon as the session holding it ends, so a standby takes over
+// automatically if the leader dies or loses its connection.
+type Elector struct {
+	db            *sql.DB
+	lockID        int64
+	retryInterval time.Duration
+	logger        lager.Logger
+	mu            sync.Mutex
+	conn          *sql.Conn
+	lost          chan struct{}
+	stop          chan struct{}
+	err           error
+}
+
+// Acquire blocks until this process holds the lock or ctx is done. Once
+// acquired the lock is checked every RetryInterval and the Lost channel is
+// closed if the session holding it dies.
+func (e *Elector) Acquire(ctx context.Context) error {
+	e.logger.Info("acquiring", lager.Data{
+		"lock_id": e.lockID,
+	})
+	for {
+		acquired, err := e.tryAcquire(ctx)
+		if err != nil {
+			e.logger.Error("acquire-error", err)
+		} else if acquired {
+			e.logger.Info("acquired", lager.Data{
+				"lock_id": e.lockID,
+			})
+			go e.monitor()
+			return nil
+		} else {
+			e.logger.Info("standby", lager.Data{
+				"lock_id":    e.lockID,
+				"next_retry": e.retryInterval.String(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+func (e *Elector) tryAcquire(ctx context.Context) (bool, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `select pg_try_advisory_lock($1)`, e.lockID).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		return false, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conn = conn
+	e.lost = make(chan struct{})
+	e.stop = make(chan struct{})
+	e.err = nil
+	return true, nil
+}
+
+// monitor checks that the session holding the lock is still alive
+func (e *Elector) monitor() {
+	e.mu.Lock()
+	stop := e.stop
+	e.mu.Unlock()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(e.retryInterval):
+			if err := e.check(); err != nil {
+				e.logger.Error("lost", err, lager.Data{
+					"lock_id": e.lockID,
+				})
+				return
+			}
+		}
+	}
+}
+
+func (e *Elector) check() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.retryInterval)
+	defer cancel()
+	if _, err := e.conn.ExecContext(ctx, `select 1`); err != nil {
+		e.conn.Close()
+		e.conn = nil
+		e.err = fmt.Errorf("%s: %s", ErrLeadershipLost, err)
+		close(e.lost)
+		return err
+	}
+	return nil
+}
+
+// Lost returns a channel that is closed if the lock is lost after being
+// acquired. It returns nil before the lock has been acquired.
+func (e *Elector) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
+
+// Err returns why the lock was lost or nil
+func (e *Elector) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// IsLeader returns true while this process holds the lock
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.conn != nil
+}
+
+// Release gives up the lock so that a standby can take over
+func (e *Elector) Release() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		return nil
+	}
+	close(e.stop)
+	ctx, cancel := context.WithTimeout(context.Background(), e.retryInterval)
+	defer cancel()
+	_, err := e.conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, e.lockID)
+	e.conn.Close()
+	e.conn = nil
+	e.logger.Info("released", lager.Data{
+		"lock_id": e.lockID,
+	})
+	return err
+}
+
+func New(cfg Config) (*Elector, error) {
+	if cfg.DB == nil {
+		return nil, fmt.Errorf("leader.New: must supply a DB")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = lager.NewLogger("leader")
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = DefaultRetryInterval
+	}
+	return &Elector{
+		db:            cfg.DB,
+		lockID:        cfg.LockID,
+		retryInterval: cfg.RetryInterval,
+		logger:        cfg.Logger,
+	}, nil
+}
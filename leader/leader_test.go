@@ -0,0 +1,108 @@
+package leader_test
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alphagov/paas-billing/leader"
+	"github.com/alphagov/paas-billing/testenv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Elector", func() {
+
+	var (
+		tempdb *testenv.TempDB
+		lockID = int64(42)
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempdb, err = testenv.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		tempdb.Close()
+	})
+
+	newElector := func() *leader.Elector {
+		db, err := sql.Open("postgres", tempdb.TempConnectionString)
+		Expect(err).ToNot(HaveOccurred())
+		elector, err := leader.New(leader.Config{
+			DB:            db,
+			LockID:        lockID,
+			RetryInterval: 100 * time.Millisecond,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return elector
+	}
+
+	It("should require a DB", func() {
+		_, err := leader.New(leader.Config{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only allow a single leader", func() {
+		leader1 := newElector()
+		leader2 := newElector()
+
+		Expect(leader1.Acquire(context.Background())).To(Succeed())
+		defer leader1.Release()
+		Expect(leader1.IsLeader()).To(BeTrue())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		Expect(leader2.Acquire(ctx)).To(MatchError(context.DeadlineExceeded))
+		Expect(leader2.IsLeader()).To(BeFalse())
+	})
+
+	It("should let a standby take over when the leader releases the lock", func() {
+		leader1 := newElector()
+		leader2 := newElector()
+
+		Expect(leader1.Acquire(context.Background())).To(Succeed())
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- leader2.Acquire(context.Background())
+		}()
+		Consistently(acquired, 300*time.Millisecond).ShouldNot(Receive())
+
+		Expect(leader1.Release()).To(Succeed())
+		Expect(leader1.IsLeader()).To(BeFalse())
+		Eventually(acquired, 5*time.Second).Should(Receive(BeNil()))
+		Expect(leader2.IsLeader()).To(BeTrue())
+		Expect(leader2.Release()).To(Succeed())
+	})
+
+	It("should report the loss of leadership and let a standby take over when the leader's session dies", func() {
+		leader1 := newElector()
+		leader2 := newElector()
+
+		Expect(leader1.Acquire(context.Background())).To(Succeed())
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- leader2.Acquire(context.Background())
+		}()
+		Consistently(acquired, 300*time.Millisecond).ShouldNot(Receive())
+
+		_, err := tempdb.Conn.Exec(`
+			select pg_terminate_backend(pid)
+			from pg_locks
+			where locktype = 'advisory' and objid = $1 and granted
+		`, lockID)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(leader1.Lost(), 5*time.Second).Should(BeClosed())
+		Expect(leader1.IsLeader()).To(BeFalse())
+		Expect(leader1.Err()).To(MatchError(ContainSubstring("leadership lost")))
+
+		Eventually(acquired, 5*time.Second).Should(Receive(BeNil()))
+		Expect(leader2.IsLeader()).To(BeTrue())
+		Expect(leader2.Release()).To(Succeed())
+	})
+})
@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
 )
 
 func Main(ctx context.Context, logger lager.Logger) error {
@@ -25,10 +28,19 @@ func Main(ctx context.Context, logger lager.Logger) error {
 	}
 
 	if len(os.Args) < 2 {
-		return errors.New("Please provide a command to run [api | collector]")
+		return errors.New("Please provide a command to run [api | collector | collector backfill | collector restore | collector retention]")
 	}
 	switch command := os.Args[1]; command {
 	case "collector":
+		if len(os.Args) > 2 && os.Args[2] == "backfill" {
+			return runBackfill(app, cfg, os.Args[3:])
+		}
+		if len(os.Args) > 2 && os.Args[2] == "restore" {
+			return runRestore(app, cfg, os.Args[3:])
+		}
+		if len(os.Args) > 2 && os.Args[2] == "retention" {
+			return runRetention(app, cfg, os.Args[3:])
+		}
 		return startCollector(app, cfg)
 	case "api":
 		return startAPI(app, cfg)
@@ -38,6 +50,12 @@ func Main(ctx context.Context, logger lager.Logger) error {
 }
 
 func startCollector(app *App, cfg Config) error {
+	if err := app.StartCollectorHealthServer(); err != nil {
+		return err
+	}
+	if err := app.WaitForLeadership(); err != nil {
+		return err
+	}
 	if err := app.Init(); err != nil {
 		return err
 	}
@@ -52,14 +70,162 @@ func startCollector(app *App, cfg Config) error {
 	if err := app.StartEventProcessor(); err != nil {
 		return err
 	}
+	if err := app.StartJobWorker(); err != nil {
+		return err
+	}
 	if err := app.StartHistoricDataCollector(); err != nil {
 		return err
 	}
+	if err := app.StartArchiveScheduler(); err != nil {
+		return err
+	}
 
 	cfg.Logger.Info("started collector")
 	return app.Wait()
 }
 
+type backfillOptions struct {
+	Kind      cffetcher.Kind
+	AfterGUID string
+	Since     time.Time
+}
+
+func parseBackfillArgs(args []string) (backfillOptions, error) {
+	var opts backfillOptions
+	var kind, from string
+	flags := flag.NewFlagSet("collector backfill", flag.ContinueOnError)
+	flags.StringVar(&kind, "kind", "", "kind of usage events to backfill [app | service]")
+	flags.StringVar(&opts.AfterGUID, "after-guid", "", "re-fetch every event after the event with this GUID")
+	flags.StringVar(&from, "from", "", "re-fetch every event from this date or RFC3339 time")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	switch cffetcher.Kind(kind) {
+	case cffetcher.App, cffetcher.Service:
+		opts.Kind = cffetcher.Kind(kind)
+	default:
+		return opts, fmt.Errorf("--kind must be one of [app | service]")
+	}
+	if (opts.AfterGUID == "") == (from == "") {
+		return opts, errors.New("exactly one of --after-guid or --from must be given")
+	}
+	if from != "" {
+		since, err := parseDateOrTime(from)
+		if err != nil {
+			return opts, fmt.Errorf("--from must be a date or RFC3339 time: %s", from)
+		}
+		opts.Since = since
+	}
+	return opts, nil
+}
+
+func runBackfill(app *App, cfg Config, args []string) error {
+	opts, err := parseBackfillArgs(args)
+	if err != nil {
+		return err
+	}
+	count, err := app.BackfillUsageEvents(app.ctx, opts.Kind, opts.AfterGUID, opts.Since)
+	if err != nil {
+		return err
+	}
+	cfg.Logger.Info("backfilled", lager.Data{
+		"kind":  opts.Kind,
+		"count": count,
+	})
+	return nil
+}
+
+type restoreOptions struct {
+	Kind   string
+	Since  time.Time
+	Before time.Time
+}
+
+func parseRestoreArgs(args []string) (restoreOptions, error) {
+	var opts restoreOptions
+	var from, to string
+	flags := flag.NewFlagSet("collector restore", flag.ContinueOnError)
+	flags.StringVar(&opts.Kind, "kind", "", "kind of raw events to restore [app | service]")
+	flags.StringVar(&from, "from", "", "restore events created at or after this date or RFC3339 time")
+	flags.StringVar(&to, "to", "", "restore events created before this date or RFC3339 time")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Kind != "app" && opts.Kind != "service" {
+		return opts, fmt.Errorf("--kind must be one of [app | service]")
+	}
+	var err error
+	if opts.Since, err = parseDateOrTime(from); err != nil {
+		return opts, fmt.Errorf("--from must be a date or RFC3339 time: %s", from)
+	}
+	if opts.Before, err = parseDateOrTime(to); err != nil {
+		return opts, fmt.Errorf("--to must be a date or RFC3339 time: %s", to)
+	}
+	if !opts.Since.Before(opts.Before) {
+		return opts, errors.New("--from must be before --to")
+	}
+	return opts, nil
+}
+
+func parseDateOrTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", s)
+	}
+	return t, err
+}
+
+func runRestore(app *App, cfg Config, args []string) error {
+	opts, err := parseRestoreArgs(args)
+	if err != nil {
+		return err
+	}
+	count, err := app.RestoreRawEvents(app.ctx, opts.Kind, opts.Since, opts.Before)
+	if err != nil {
+		return err
+	}
+	cfg.Logger.Info("restored", lager.Data{
+		"kind":  opts.Kind,
+		"from":  opts.Since,
+		"to":    opts.Before,
+		"count": count,
+	})
+	return nil
+}
+
+func parseRetentionArgs(args []string) (time.Time, error) {
+	var before string
+	flags := flag.NewFlagSet("collector retention", flag.ContinueOnError)
+	flags.StringVar(&before, "before", "", "detach the consolidated billable events of months ending on or before this date")
+	if err := flags.Parse(args); err != nil {
+		return time.Time{}, err
+	}
+	month, err := time.Parse("2006-01-02", before)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--before must be a date: %s", before)
+	}
+	if month.Day() != 1 {
+		return time.Time{}, errors.New("--before must be the first day of a month")
+	}
+	return month, nil
+}
+
+func runRetention(app *App, cfg Config, args []string) error {
+	before, err := parseRetentionArgs(args)
+	if err != nil {
+		return err
+	}
+	detached, err := app.store.DetachConsolidatedMonths(app.ctx, before)
+	if err != nil {
+		return err
+	}
+	cfg.Logger.Info("detached-consolidated-months", lager.Data{
+		"before":     before,
+		"partitions": detached,
+	})
+	return nil
+}
+
 func startAPI(app *App, cfg Config) error {
 	if err := app.StartAPIServer(); err != nil {
 		return err
@@ -12,22 +12,33 @@ import (
 	"code.cloudfoundry.org/lager"
 	"github.com/alphagov/paas-billing/apiserver"
 	"github.com/alphagov/paas-billing/apiserver/auth"
+	"github.com/alphagov/paas-billing/eventarchive"
 	"github.com/alphagov/paas-billing/eventcollector"
 	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
 	"github.com/alphagov/paas-billing/eventio"
 	"github.com/alphagov/paas-billing/eventstore"
+	"github.com/alphagov/paas-billing/leader"
 	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pkg/errors"
 )
 
+// collectorLockID is the postgres advisory lock that is held by the leading
+// collector process
+const collectorLockID int64 = 0x70616173 // "paas"
+
 type App struct {
-	wg                sync.WaitGroup
-	ctx               context.Context
-	store             eventio.EventStore
-	historicDataStore *cfstore.Store
-	logger            lager.Logger
-	cfg               Config
-	Shutdown          context.CancelFunc
+	wg                 sync.WaitGroup
+	ctx                context.Context
+	store              eventio.EventStore
+	historicDataStore  *cfstore.Store
+	historicDataStatus historicDataStatus
+	elector            *leader.Elector
+	mu                 sync.RWMutex
+	leaderSince        time.Time
+	collectors         []*eventcollector.EventCollector
+	logger             lager.Logger
+	cfg                Config
+	Shutdown           context.CancelFunc
 }
 
 func (app *App) Init() error {
@@ -43,6 +54,31 @@ func (app *App) Init() error {
 	return nil
 }
 
+// WaitForLeadership blocks until this process is the only collector process
+// allowed to write to the store. Standby processes wait here until the
+// leader's database session ends. If leadership is later lost the app is
+// shut down.
+func (app *App) WaitForLeadership() error {
+	if err := app.elector.Acquire(app.ctx); err != nil {
+		return err
+	}
+	app.mu.Lock()
+	app.leaderSince = time.Now()
+	app.mu.Unlock()
+	go func() {
+		select {
+		case <-app.elector.Lost():
+			app.logger.Error("leadership-lost", app.elector.Err())
+			app.Shutdown()
+		case <-app.ctx.Done():
+			if err := app.elector.Release(); err != nil {
+				app.logger.Error("release-leadership", err)
+			}
+		}
+	}()
+	return nil
+}
+
 func (app *App) StartAppEventCollector() error {
 	return app.startUsageEventCollector(cffetcher.App)
 }
@@ -54,26 +90,87 @@ func (app *App) StartServiceEventCollector() error {
 func (app *App) startUsageEventCollector(kind cffetcher.Kind) error {
 	name := fmt.Sprintf("%s-usage-event-collector", kind)
 	logger := app.logger.Session(name)
-	fetcher, err := cffetcher.New(cffetcher.Config{
+	collector, err := app.newUsageEventCollector(kind, logger)
+	if err != nil {
+		return err
+	}
+	app.mu.Lock()
+	app.collectors = append(app.collectors, collector)
+	app.mu.Unlock()
+	return app.start(name, logger, func() error {
+		return collector.Run(app.ctx)
+	})
+}
+
+// BackfillUsageEvents re-fetches the usage events of the given kind after
+// afterGUID, or after the last stored event before since if afterGUID is
+// empty, and blocks until it has caught up
+func (app *App) BackfillUsageEvents(ctx context.Context, kind cffetcher.Kind, afterGUID string, since time.Time) (int, error) {
+	name := fmt.Sprintf("%s-usage-event-backfill", kind)
+	logger := app.logger.Session(name)
+	collector, err := app.newUsageEventCollector(kind, logger)
+	if err != nil {
+		return 0, err
+	}
+	if afterGUID == "" {
+		return collector.BackfillSince(ctx, since)
+	}
+	return collector.Backfill(ctx, afterGUID)
+}
+
+func (app *App) newUsageEventCollector(kind cffetcher.Kind, logger lager.Logger) (*eventcollector.EventCollector, error) {
+	fetcher, err := cffetcher.New(app.usageEventFetcherConfig(kind, logger))
+	if err != nil {
+		return nil, err
+	}
+	return eventcollector.New(eventcollector.Config{
+		Logger:         logger,
+		Store:          app.store,
+		Fetcher:        fetcher,
+		Schedule:       app.cfg.Collector.Schedule,
+		MinWaitTime:    app.cfg.Collector.MinWaitTime,
+		InitialBackoff: app.cfg.Collector.InitialBackoff,
+		MaxBackoff:     app.cfg.Collector.MaxBackoff,
+	}), nil
+}
+
+// usageEventFetcherConfig is the config of the fetcher used to collect and
+// backfill the usage events of the given kind
+func (app *App) usageEventFetcherConfig(kind cffetcher.Kind, logger lager.Logger) cffetcher.Config {
+	return cffetcher.Config{
 		Logger:       logger,
 		Type:         kind,
 		ClientConfig: app.cfg.CFFetcher.ClientConfig,
 		FetchLimit:   app.cfg.CFFetcher.FetchLimit,
 		RecordMinAge: app.cfg.CFFetcher.RecordMinAge,
-	})
+		APIVersion:   app.cfg.CFFetcher.APIVersion,
+	}
+}
+
+// RestoreRawEvents puts the archived raw events of the given kind created
+// in the range since to before back into the database
+func (app *App) RestoreRawEvents(ctx context.Context, kind string, since, before time.Time) (int, error) {
+	archiver, err := app.newArchiver(app.logger.Session(fmt.Sprintf("%s-raw-event-restore", kind)))
 	if err != nil {
-		return err
+		return 0, err
 	}
-	collector := eventcollector.New(eventcollector.Config{
-		Logger:      logger,
-		Store:       app.store,
-		Fetcher:     fetcher,
-		Schedule:    app.cfg.Collector.Schedule,
-		MinWaitTime: app.cfg.Collector.MinWaitTime,
-	})
-	return app.start(name, logger, func() error {
-		return collector.Run(app.ctx)
-	})
+	return archiver.Restore(ctx, kind, since, before)
+}
+
+func (app *App) newArchiver(logger lager.Logger) (*eventarchive.Archiver, error) {
+	if app.cfg.Archive.Dir == "" {
+		return nil, errors.New("archiving is disabled, ARCHIVE_DIR must be set")
+	}
+	blobs, err := eventarchive.NewFileBlobStore(app.cfg.Archive.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return eventarchive.New(eventarchive.Config{
+		Logger:          logger,
+		Store:           app.store,
+		Blobs:           blobs,
+		RetentionMonths: app.cfg.Archive.RetentionMonths,
+	}), nil
 }
 
 func (app *App) StartAPIServer() error {
@@ -87,9 +184,17 @@ func (app *App) StartAPIServer() error {
 		Config: uaaConfig,
 	}
 	apiServer := apiserver.New(apiserver.Config{
-		Store:         app.store,
-		Authenticator: apiAuthenticator,
-		Logger:        logger,
+		Store:                       app.store,
+		Authenticator:               apiAuthenticator,
+		Logger:                      logger,
+		BillableEventsMaxOpenMonths: app.cfg.API.BillableEventsMaxOpenMonths,
+		HealthChecks: apiserver.HealthChecks{
+			"database": apiserver.DatabaseHealthCheck(app.store),
+			"refresh":  apiserver.RefreshHealthCheck(app.store, app.cfg.Health.MaxRefreshAge),
+		},
+		ReadinessChecks: apiserver.HealthChecks{
+			"database": apiserver.DatabaseHealthCheck(app.store),
+		},
 	})
 	addr := fmt.Sprintf(":%d", app.cfg.ServerPort)
 	return app.start(name, logger, func() error {
@@ -102,56 +207,45 @@ func (app *App) StartAPIServer() error {
 	})
 }
 
-func (app *App) StartEventProcessor() error {
-	name := "processor"
-	logger := app.logger.Session(name)
-	return app.start(name, logger, func() error {
-		runRefreshAndConsolidateLoop(app.ctx, logger, app.cfg.Processor.Schedule, app.store)
-		return nil
-	})
-}
-
-func runRefreshAndConsolidateLoop(ctx context.Context, logger lager.Logger, schedule time.Duration, store eventio.EventStore) {
-	logger.Info("started")
-	defer logger.Info("stopping")
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-time.After(schedule):
-			logger.Info("processing")
-			if err := store.Refresh(); err != nil {
-				logger.Error("refresh-error", err)
-				continue
-			}
-			if err := store.ConsolidateAll(); err != nil {
-				logger.Error("consolidate-error", err)
-				continue
-			}
-		}
-		logger.Info("processed", lager.Data{
-			"next_processing_in": schedule.String(),
-		})
-	}
-}
-
 func (app *App) StartHistoricDataCollector() error {
 	name := "historic-data-collector"
 	logger := app.logger.Session(name)
 	go func() {
 		for {
+			errs := []error{}
 			if err := app.historicDataStore.CollectServices(); err != nil {
 				logger.Error("collect-services", err)
+				errs = append(errs, err)
 			}
 			if err := app.historicDataStore.CollectServicePlans(); err != nil {
 				logger.Error("collect-service-plans", err)
+				errs = append(errs, err)
+			}
+			if err := app.historicDataStore.CollectQuotaDefinitions(); err != nil {
+				logger.Error("collect-quota-definitions", err)
+				errs = append(errs, err)
 			}
 			if err := app.historicDataStore.CollectOrgs(); err != nil {
 				logger.Error("collect-orgs", err)
+				errs = append(errs, err)
 			}
 			if err := app.historicDataStore.CollectSpaces(); err != nil {
 				logger.Error("collect-spaces", err)
+				errs = append(errs, err)
 			}
+			if err := app.historicDataStore.CollectOrgRoles(); err != nil {
+				logger.Error("collect-org-roles", err)
+				errs = append(errs, err)
+			}
+			if err := app.historicDataStore.CollectOrgMetadata(); err != nil {
+				logger.Error("collect-org-metadata", err)
+				errs = append(errs, err)
+			}
+			if err := app.historicDataStore.CollectSpaceMetadata(); err != nil {
+				logger.Error("collect-space-metadata", err)
+				errs = append(errs, err)
+			}
+			app.historicDataStatus.record(errs)
 
 			time.Sleep(app.cfg.HistoricDataCollector.Schedule)
 		}
@@ -226,6 +320,14 @@ func New(ctx context.Context, cfg Config) (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	elector, err := leader.New(leader.Config{
+		DB:     db,
+		LockID: collectorLockID,
+		Logger: cfg.Logger.Session("leader"),
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	app := &App{
 		cfg:               cfg,
@@ -233,6 +335,7 @@ func New(ctx context.Context, cfg Config) (*App, error) {
 		Shutdown:          shutdown,
 		store:             cfg.Store,
 		historicDataStore: historicDataStore,
+		elector:           elector,
 		logger:            cfg.Logger,
 	}
 
@@ -57,6 +57,7 @@ var _ = It("Should perform a smoke test against a real environment", func() {
 	By("Starting the app", func() {
 		api := exec.Command(BinaryPath, "api")
 		collector := exec.Command(BinaryPath, "collector")
+		collector.Env = append(os.Environ(), "PORT=8766")
 		session, err = Start(api, GinkgoWriter, GinkgoWriter)
 		Expect(err).ToNot(HaveOccurred())
 		session_collector, err = Start(collector, GinkgoWriter, GinkgoWriter)
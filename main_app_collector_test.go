@@ -1,16 +1,10 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"os/exec"
 	"time"
 
-	"context"
-	"sync"
-
-	"code.cloudfoundry.org/lager"
-	"github.com/alphagov/paas-billing/fakes"
 	"github.com/alphagov/paas-billing/testenv"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -104,61 +98,3 @@ var _ = It("Should perform a smoke test against a real environment", func() {
 		Eventually(session, 60*time.Second).Should(Exit(0))
 	})
 })
-
-var _ = Describe("runRefreshAndConsolidateLoop", func() {
-	var (
-		fakeStore *fakes.FakeEventStore
-		logger    lager.Logger
-	)
-
-	BeforeEach(func() {
-		fakeStore = &fakes.FakeEventStore{}
-		logger = lager.NewLogger("test")
-	})
-
-	It("should call Refresh and Consolidate every 'Schedule'", func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-
-		wg := sync.WaitGroup{}
-		defer wg.Wait()
-		defer cancel()
-
-		go func() {
-			wg.Add(1)
-			runRefreshAndConsolidateLoop(ctx, logger, 1*time.Nanosecond, fakeStore)
-			wg.Done()
-		}()
-
-		Eventually(func() int {
-			return fakeStore.RefreshCallCount()
-		}).Should(BeNumerically(">=", 1))
-
-		Eventually(func() int {
-			return fakeStore.ConsolidateAllCallCount()
-		}).Should(BeNumerically(">=", 1))
-	})
-
-	It("should not call Consolidate if Refresh fails", func() {
-		fakeStore.RefreshReturns(fmt.Errorf("some-error"))
-
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-
-		wg := sync.WaitGroup{}
-		defer wg.Wait()
-		defer cancel()
-
-		go func() {
-			wg.Add(1)
-			runRefreshAndConsolidateLoop(ctx, logger, 1*time.Nanosecond, fakeStore)
-			wg.Done()
-		}()
-
-		Eventually(func() int {
-			return fakeStore.RefreshCallCount()
-		}).Should(BeNumerically(">=", 2))
-
-		Consistently(func() int {
-			return fakeStore.ConsolidateAllCallCount()
-		}).Should(BeNumerically("==", 0))
-	})
-})
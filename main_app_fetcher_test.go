@@ -0,0 +1,24 @@
+package main
+
+import (
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("usageEventFetcherConfig", func() {
+	It("should use the configured usage events API version", func() {
+		app := &App{cfg: Config{CFFetcher: cffetcher.Config{
+			FetchLimit: 50,
+			APIVersion: cffetcher.APIVersion3,
+		}}}
+
+		for _, kind := range []cffetcher.Kind{cffetcher.App, cffetcher.Service} {
+			cfg := app.usageEventFetcherConfig(kind, lager.NewLogger("test"))
+			Expect(cfg.Type).To(Equal(kind))
+			Expect(cfg.FetchLimit).To(Equal(50))
+			Expect(cfg.APIVersion).To(Equal(cffetcher.APIVersion3))
+		}
+	})
+})
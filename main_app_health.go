@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alphagov/paas-billing/apiserver"
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+)
+
+// historicDataStatus records the outcome of the historic data collector's
+// runs so that it can be reported by the health checks
+type historicDataStatus struct {
+	mu          sync.RWMutex
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastErrors  []string
+}
+
+func (s *historicDataStatus) record(errs []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun = time.Now()
+	s.lastErrors = []string{}
+	for _, err := range errs {
+		s.lastErrors = append(s.lastErrors, err.Error())
+	}
+	if len(errs) == 0 {
+		s.lastSuccess = s.lastRun
+	}
+}
+
+// StartCollectorHealthServer serves the health endpoints for the collector
+// process. Checks of the components that only run on the leader pass on a
+// standby process.
+func (app *App) StartCollectorHealthServer() error {
+	name := "health"
+	logger := app.logger.Session(name)
+	server := apiserver.NewHealthServer(apiserver.Config{
+		Logger: logger,
+		HealthChecks: apiserver.HealthChecks{
+			"database":                      apiserver.DatabaseHealthCheck(app.store),
+			"refresh":                       apiserver.RefreshHealthCheck(app.store, app.cfg.Health.MaxRefreshAge),
+			"app-usage-event-collector":     app.collectorHealthCheck(cffetcher.App, app.cfg.Health.MaxCollectorAge),
+			"service-usage-event-collector": app.collectorHealthCheck(cffetcher.Service, app.cfg.Health.MaxCollectorAge),
+			"historic-data-collector":       app.historicDataHealthCheck(app.cfg.Health.MaxHistoricDataAge),
+		},
+		ReadinessChecks: apiserver.HealthChecks{
+			"database": apiserver.DatabaseHealthCheck(app.store),
+		},
+	})
+	addr := fmt.Sprintf(":%d", app.cfg.ServerPort)
+	return app.start(name, logger, func() error {
+		return apiserver.ListenAndServe(
+			app.ctx,
+			logger,
+			server,
+			addr,
+		)
+	})
+}
+
+// standbyDetails are reported by the checks of components that are not
+// running because this process is not the leader
+var standbyDetails = map[string]interface{}{
+	"leader": false,
+}
+
+// collectorHealthCheck fails if the collector of the given kind has not
+// successfully collected within maxAge of its last success or of this process
+// becoming the leader
+func (app *App) collectorHealthCheck(kind cffetcher.Kind, maxAge time.Duration) apiserver.HealthCheck {
+	return func() (interface{}, error) {
+		app.mu.RLock()
+		defer app.mu.RUnlock()
+		if app.leaderSince.IsZero() {
+			return standbyDetails, nil
+		}
+		for _, collector := range app.collectors {
+			status := collector.Status()
+			if status.Kind != string(kind) {
+				continue
+			}
+			lastSuccess := status.LastSuccess
+			if lastSuccess.IsZero() {
+				lastSuccess = app.leaderSince
+			}
+			if age := time.Since(lastSuccess); age > maxAge {
+				return status, fmt.Errorf("%s usage events have not been collected for %s", kind, age.Round(time.Second))
+			}
+			return status, nil
+		}
+		if age := time.Since(app.leaderSince); age > maxAge {
+			return nil, fmt.Errorf("%s usage event collector has not started for %s", kind, age.Round(time.Second))
+		}
+		return nil, nil
+	}
+}
+
+// historicDataHealthCheck fails if the historic data collector has not had a
+// fully successful run within maxAge of its last success or of this process
+// becoming the leader
+func (app *App) historicDataHealthCheck(maxAge time.Duration) apiserver.HealthCheck {
+	return func() (interface{}, error) {
+		app.mu.RLock()
+		leaderSince := app.leaderSince
+		app.mu.RUnlock()
+		if leaderSince.IsZero() {
+			return standbyDetails, nil
+		}
+		status := &app.historicDataStatus
+		status.mu.RLock()
+		defer status.mu.RUnlock()
+		details := map[string]interface{}{
+			"last_run":     status.lastRun,
+			"last_success": status.lastSuccess,
+			"last_errors":  status.lastErrors,
+		}
+		lastSuccess := status.lastSuccess
+		if lastSuccess.IsZero() {
+			lastSuccess = leaderSince
+		}
+		if age := time.Since(lastSuccess); age > maxAge {
+			return details, fmt.Errorf("historic data has not been collected for %s", age.Round(time.Second))
+		}
+		return details, nil
+	}
+}
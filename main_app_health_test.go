@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alphagov/paas-billing/eventcollector"
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+	"github.com/alphagov/paas-billing/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health checks", func() {
+
+	var app *App
+
+	BeforeEach(func() {
+		app = &App{}
+	})
+
+	Describe("collectorHealthCheck", func() {
+
+		BeforeEach(func() {
+			fakeFetcher := &fakes.FakeEventFetcher{}
+			fakeFetcher.KindReturns("app")
+			app.collectors = []*eventcollector.EventCollector{
+				eventcollector.New(eventcollector.Config{
+					Fetcher: fakeFetcher,
+					Store:   &fakes.FakeEventStore{},
+				}),
+			}
+		})
+
+		It("should pass on a standby process", func() {
+			check := app.collectorHealthCheck(cffetcher.App, time.Minute)
+			details, err := check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details).To(Equal(standbyDetails))
+		})
+
+		It("should pass while a collector that has never succeeded is within maxAge of becoming the leader", func() {
+			app.leaderSince = time.Now()
+			check := app.collectorHealthCheck(cffetcher.App, time.Minute)
+			details, err := check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details).To(BeAssignableToTypeOf(eventcollector.Status{}))
+		})
+
+		It("should fail once a collector that has never succeeded is older than maxAge", func() {
+			app.leaderSince = time.Now().Add(-2 * time.Minute)
+			check := app.collectorHealthCheck(cffetcher.App, time.Minute)
+			_, err := check()
+			Expect(err).To(MatchError(ContainSubstring("app usage events have not been collected for 2m0s")))
+		})
+
+		It("should fail if the leader has not started the collector within maxAge", func() {
+			app.leaderSince = time.Now().Add(-2 * time.Minute)
+			check := app.collectorHealthCheck(cffetcher.Service, time.Minute)
+			_, err := check()
+			Expect(err).To(MatchError(ContainSubstring("service usage event collector has not started for 2m0s")))
+		})
+	})
+
+	Describe("historicDataHealthCheck", func() {
+
+		It("should pass on a standby process", func() {
+			check := app.historicDataHealthCheck(time.Minute)
+			details, err := check()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details).To(Equal(standbyDetails))
+		})
+
+		It("should pass after a successful run", func() {
+			app.leaderSince = time.Now().Add(-2 * time.Minute)
+			app.historicDataStatus.record(nil)
+			check := app.historicDataHealthCheck(time.Minute)
+			_, err := check()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail and report the errors when runs keep failing", func() {
+			app.leaderSince = time.Now().Add(-2 * time.Minute)
+			app.historicDataStatus.record([]error{errors.New("cf api unavailable")})
+			check := app.historicDataHealthCheck(time.Minute)
+			details, err := check()
+			Expect(err).To(MatchError(ContainSubstring("historic data has not been collected for 2m0s")))
+			Expect(details).To(HaveKeyWithValue("last_errors", []string{"cf api unavailable"}))
+		})
+	})
+})
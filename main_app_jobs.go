@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/jobqueue"
+)
+
+// StartEventProcessor queues a refresh of the billable events, followed by
+// consolidation of any months that are due, every Processor.Schedule. The
+// jobs are executed by the worker started with StartJobWorker.
+func (app *App) StartEventProcessor() error {
+	name := "processor"
+	logger := app.logger.Session(name)
+	return app.start(name, logger, func() error {
+		runRefreshScheduler(app.ctx, logger, app.cfg.Processor.Schedule, app.store)
+		return nil
+	})
+}
+
+// StartArchiveScheduler queues an archive of the raw events that are older
+// than the retention window every Archive.Schedule, unless archiving is
+// disabled
+func (app *App) StartArchiveScheduler() error {
+	if app.cfg.Archive.Dir == "" {
+		app.logger.Info("archiving-disabled")
+		return nil
+	}
+	name := "archive-scheduler"
+	logger := app.logger.Session(name)
+	return app.start(name, logger, func() error {
+		runJobScheduler(app.ctx, logger, app.cfg.Archive.Schedule, app.store, eventio.Job{
+			Kind:        eventio.ArchiveJob,
+			RequestedBy: "scheduler",
+		})
+		return nil
+	})
+}
+
+// StartJobWorker executes the refresh, consolidate, backfill and archive jobs
+// queued by the schedulers or requested through the API
+func (app *App) StartJobWorker() error {
+	name := "job-worker"
+	logger := app.logger.Session(name)
+	worker := jobqueue.New(jobqueue.Config{
+		Logger: logger,
+		Store:  app.store,
+		Runners: map[eventio.JobKind]jobqueue.Runner{
+			eventio.RefreshJob:     refreshJobRunner(app.store),
+			eventio.ConsolidateJob: consolidateJobRunner(app.store),
+			eventio.BackfillJob:    app.runBackfillJob,
+			eventio.ArchiveJob:     app.runArchiveJob,
+		},
+	})
+	return app.start(name, logger, func() error {
+		return worker.Run(app.ctx)
+	})
+}
+
+func runRefreshScheduler(ctx context.Context, logger lager.Logger, schedule time.Duration, store eventio.EventStore) {
+	runJobScheduler(ctx, logger, schedule, store, eventio.Job{
+		Kind:        eventio.RefreshJob,
+		Params:      eventio.JobParams{Consolidate: true},
+		RequestedBy: "scheduler",
+	})
+}
+
+// runJobScheduler queues job every schedule unless a job of the same kind is
+// already waiting to run
+func runJobScheduler(ctx context.Context, logger lager.Logger, schedule time.Duration, store eventio.EventStore, job eventio.Job) {
+	logger.Info("started")
+	defer logger.Info("stopping")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(schedule):
+			queued, err := store.GetJobs(eventio.JobFilter{
+				Kind:  job.Kind,
+				State: eventio.JobQueued,
+				Limit: 1,
+			})
+			if err != nil {
+				logger.Error("get-jobs-error", err)
+				continue
+			}
+			if len(queued) > 0 {
+				logger.Info("already-queued", lager.Data{
+					"kind":   job.Kind,
+					"job_id": queued[0].ID,
+				})
+				continue
+			}
+			queuedJob, err := store.EnqueueJob(job)
+			if err != nil {
+				logger.Error("enqueue-job-error", err)
+				continue
+			}
+			logger.Info("queued", lager.Data{
+				"kind":               job.Kind,
+				"job_id":             queuedJob.ID,
+				"next_processing_in": schedule.String(),
+			})
+		}
+	}
+}
+
+func refreshJobRunner(store eventio.EventStore) jobqueue.Runner {
+	return func(ctx context.Context, job eventio.Job) error {
+		if err := store.RefreshContext(ctx); err != nil {
+			return err
+		}
+		if !job.Params.Consolidate {
+			return nil
+		}
+		return store.ConsolidateAllContext(ctx)
+	}
+}
+
+func consolidateJobRunner(store eventio.EventStore) jobqueue.Runner {
+	return func(ctx context.Context, job eventio.Job) error {
+		if job.Params.Month == "" {
+			return store.ConsolidateAllContext(ctx)
+		}
+		month, err := time.Parse("2006-01-02", job.Params.Month)
+		if err != nil {
+			return err
+		}
+		return store.ConsolidateContext(ctx, eventio.EventFilter{
+			RangeStart: month.Format("2006-01-02"),
+			RangeStop:  month.AddDate(0, 1, 0).Format("2006-01-02"),
+		})
+	}
+}
+
+func (app *App) runBackfillJob(ctx context.Context, job eventio.Job) error {
+	var since time.Time
+	if job.Params.From != "" {
+		var err error
+		since, err = time.Parse("2006-01-02", job.Params.From)
+		if err != nil {
+			return err
+		}
+	}
+	kind := cffetcher.Kind(job.Params.EventKind)
+	switch kind {
+	case cffetcher.App, cffetcher.Service:
+	default:
+		return fmt.Errorf("unknown event kind '%s'", kind)
+	}
+	_, err := app.BackfillUsageEvents(ctx, kind, job.Params.AfterGUID, since)
+	return err
+}
+
+func (app *App) runArchiveJob(ctx context.Context, job eventio.Job) error {
+	archiver, err := app.newArchiver(app.logger.Session("archiver"))
+	if err != nil {
+		return err
+	}
+	_, err = archiver.Archive(ctx, time.Now())
+	return err
+}
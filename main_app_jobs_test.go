@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/alphagov/paas-billing/eventio"
+	"github.com/alphagov/paas-billing/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("runRefreshScheduler", func() {
+	var (
+		fakeStore *fakes.FakeEventStore
+		logger    lager.Logger
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		logger = lager.NewLogger("test")
+	})
+
+	It("should queue a refresh and consolidate job every 'Schedule'", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		defer cancel()
+
+		wg.Add(1)
+		go func() {
+			runRefreshScheduler(ctx, logger, 1*time.Nanosecond, fakeStore)
+			wg.Done()
+		}()
+
+		Eventually(fakeStore.EnqueueJobCallCount).Should(BeNumerically(">=", 2))
+
+		job := fakeStore.EnqueueJobArgsForCall(0)
+		Expect(job.Kind).To(Equal(eventio.RefreshJob))
+		Expect(job.Params.Consolidate).To(BeTrue())
+		Expect(job.RequestedBy).To(Equal("scheduler"))
+
+		filter := fakeStore.GetJobsArgsForCall(0)
+		Expect(filter.Kind).To(Equal(eventio.RefreshJob))
+		Expect(filter.State).To(Equal(eventio.JobQueued))
+	})
+
+	It("should not queue a refresh if one is already queued", func() {
+		fakeStore.GetJobsReturns([]eventio.Job{{ID: 1, Kind: eventio.RefreshJob}}, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		defer cancel()
+
+		wg.Add(1)
+		go func() {
+			runRefreshScheduler(ctx, logger, 1*time.Nanosecond, fakeStore)
+			wg.Done()
+		}()
+
+		Eventually(fakeStore.GetJobsCallCount).Should(BeNumerically(">=", 2))
+
+		Consistently(fakeStore.EnqueueJobCallCount).Should(BeNumerically("==", 0))
+	})
+})
+
+var _ = Describe("job runners", func() {
+	var (
+		fakeStore *fakes.FakeEventStore
+		ctx       context.Context
+	)
+
+	BeforeEach(func() {
+		fakeStore = &fakes.FakeEventStore{}
+		ctx = context.Background()
+	})
+
+	It("should refresh and then consolidate when requested", func() {
+		err := refreshJobRunner(fakeStore)(ctx, eventio.Job{
+			Kind:   eventio.RefreshJob,
+			Params: eventio.JobParams{Consolidate: true},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeStore.RefreshContextCallCount()).To(Equal(1))
+		Expect(fakeStore.ConsolidateAllContextCallCount()).To(Equal(1))
+	})
+
+	It("should only refresh when consolidation is not requested", func() {
+		err := refreshJobRunner(fakeStore)(ctx, eventio.Job{Kind: eventio.RefreshJob})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeStore.RefreshContextCallCount()).To(Equal(1))
+		Expect(fakeStore.ConsolidateAllContextCallCount()).To(Equal(0))
+	})
+
+	It("should not consolidate if the refresh fails", func() {
+		fakeStore.RefreshContextReturns(fmt.Errorf("some-error"))
+
+		err := refreshJobRunner(fakeStore)(ctx, eventio.Job{
+			Kind:   eventio.RefreshJob,
+			Params: eventio.JobParams{Consolidate: true},
+		})
+		Expect(err).To(MatchError("some-error"))
+		Expect(fakeStore.ConsolidateAllContextCallCount()).To(Equal(0))
+	})
+
+	It("should consolidate a single month", func() {
+		err := consolidateJobRunner(fakeStore)(ctx, eventio.Job{
+			Kind:   eventio.ConsolidateJob,
+			Params: eventio.JobParams{Month: "2001-12-01"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeStore.ConsolidateContextCallCount()).To(Equal(1))
+		_, filter := fakeStore.ConsolidateContextArgsForCall(0)
+		Expect(filter).To(Equal(eventio.EventFilter{
+			RangeStart: "2001-12-01",
+			RangeStop:  "2002-01-01",
+		}))
+	})
+
+	It("should consolidate every month that is due if no month is given", func() {
+		err := consolidateJobRunner(fakeStore)(ctx, eventio.Job{Kind: eventio.ConsolidateJob})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeStore.ConsolidateAllContextCallCount()).To(Equal(1))
+		Expect(fakeStore.ConsolidateContextCallCount()).To(Equal(0))
+	})
+})
+
+var _ = Describe("runJobScheduler", func() {
+	It("should queue the given job every 'Schedule'", func() {
+		fakeStore := &fakes.FakeEventStore{}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		defer cancel()
+
+		wg.Add(1)
+		go func() {
+			runJobScheduler(ctx, lager.NewLogger("test"), 1*time.Nanosecond, fakeStore, eventio.Job{
+				Kind:        eventio.ArchiveJob,
+				RequestedBy: "scheduler",
+			})
+			wg.Done()
+		}()
+
+		Eventually(fakeStore.EnqueueJobCallCount).Should(BeNumerically(">=", 1))
+
+		Expect(fakeStore.EnqueueJobArgsForCall(0).Kind).To(Equal(eventio.ArchiveJob))
+		Expect(fakeStore.GetJobsArgsForCall(0).Kind).To(Equal(eventio.ArchiveJob))
+	})
+})
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"time"
+
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backfill", func() {
+
+	It("should parse a backfill after a GUID", func() {
+		opts, err := parseBackfillArgs([]string{"--kind", "app", "--after-guid", "c85e98f0-6d1b-4f45-9368-ea58263165a0"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts).To(Equal(backfillOptions{
+			Kind:      cffetcher.App,
+			AfterGUID: "c85e98f0-6d1b-4f45-9368-ea58263165a0",
+		}))
+	})
+
+	It("should parse a backfill from a date", func() {
+		opts, err := parseBackfillArgs([]string{"--kind", "service", "--from", "2018-03-01"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts).To(Equal(backfillOptions{
+			Kind:  cffetcher.Service,
+			Since: time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+		}))
+	})
+
+	DescribeTable("should reject invalid backfill arguments",
+		func(args ...string) {
+			_, err := parseBackfillArgs(args)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing kind", "--after-guid", "c85e98f0-6d1b-4f45-9368-ea58263165a0"),
+		Entry("unknown kind", "--kind", "compose", "--after-guid", "c85e98f0-6d1b-4f45-9368-ea58263165a0"),
+		Entry("missing start", "--kind", "app"),
+		Entry("both starts", "--kind", "app", "--after-guid", "c85e98f0-6d1b-4f45-9368-ea58263165a0", "--from", "2018-03-01"),
+		Entry("invalid date", "--kind", "app", "--from", "March"),
+	)
+})
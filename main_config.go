@@ -9,7 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alphagov/paas-billing/apiserver"
 	"github.com/alphagov/paas-billing/cfstore"
+	"github.com/alphagov/paas-billing/eventarchive"
 	"github.com/alphagov/paas-billing/eventcollector"
 	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
 	"github.com/alphagov/paas-billing/eventio"
@@ -29,6 +31,9 @@ type Config struct {
 	ServerPort            int
 	Processor             ProcessorConfig
 	HistoricDataCollector cfstore.Config
+	Health                HealthConfig
+	Archive               ArchiveConfig
+	API                   APIConfig
 }
 
 func (cfg Config) ConfigFile() (string, error) {
@@ -44,6 +49,28 @@ type ProcessorConfig struct {
 	Schedule time.Duration
 }
 
+// APIConfig sets how many months each request to GET /billable_events
+// queries at the same time
+type APIConfig struct {
+	BillableEventsMaxOpenMonths int
+}
+
+// ArchiveConfig sets where and how often raw events older than
+// RetentionMonths are archived. Archiving is disabled if Dir is empty.
+type ArchiveConfig struct {
+	Dir             string
+	RetentionMonths int
+	Schedule        time.Duration
+}
+
+// HealthConfig sets how old the output of each background process may be
+// before the health checks report it as stale
+type HealthConfig struct {
+	MaxRefreshAge      time.Duration
+	MaxCollectorAge    time.Duration
+	MaxHistoricDataAge time.Duration
+}
+
 func NewConfigFromEnv() (cfg Config, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -77,8 +104,10 @@ func NewConfigFromEnv() (cfg Config, err error) {
 			Schedule: getEnvWithDefaultDuration("COLLECTOR_SCHEDULE", 15*time.Minute),
 		},
 		Collector: eventcollector.Config{
-			Schedule:    getEnvWithDefaultDuration("COLLECTOR_SCHEDULE", 15*time.Minute),
-			MinWaitTime: getEnvWithDefaultDuration("COLLECTOR_MIN_WAIT_TIME", 3*time.Second),
+			Schedule:       getEnvWithDefaultDuration("COLLECTOR_SCHEDULE", 15*time.Minute),
+			MinWaitTime:    getEnvWithDefaultDuration("COLLECTOR_MIN_WAIT_TIME", 3*time.Second),
+			InitialBackoff: getEnvWithDefaultDuration("COLLECTOR_INITIAL_BACKOFF", eventcollector.DefaultInitialBackoff),
+			MaxBackoff:     getEnvWithDefaultDuration("COLLECTOR_MAX_BACKOFF", getEnvWithDefaultDuration("COLLECTOR_SCHEDULE", 15*time.Minute)),
 		},
 		CFFetcher: cffetcher.Config{
 			ClientConfig: &cfclient.Config{
@@ -96,12 +125,26 @@ func NewConfigFromEnv() (cfg Config, err error) {
 			},
 			RecordMinAge: getEnvWithDefaultDuration("CF_RECORD_MIN_AGE", 10*time.Minute),
 			FetchLimit:   getEnvWithDefaultInt("CF_FETCH_LIMIT", 50),
+			APIVersion:   cffetcher.APIVersion(getEnvWithDefaultString("CF_USAGE_EVENTS_API_VERSION", string(cffetcher.APIVersion2))),
 		},
 		Processor: ProcessorConfig{
 			Schedule: getEnvWithDefaultDuration("PROCESSOR_SCHEDULE", 120*time.Minute),
 		},
+		Archive: ArchiveConfig{
+			Dir:             os.Getenv("ARCHIVE_DIR"),
+			RetentionMonths: getEnvWithDefaultInt("ARCHIVE_RETENTION_MONTHS", eventarchive.DefaultRetentionMonths),
+			Schedule:        getEnvWithDefaultDuration("ARCHIVE_SCHEDULE", 24*time.Hour),
+		},
+		API: APIConfig{
+			BillableEventsMaxOpenMonths: getEnvWithDefaultInt("BILLABLE_EVENTS_MAX_OPEN_MONTHS", apiserver.DefaultMaxOpenBillableEventMonths),
+		},
 		ServerPort: getEnvWithDefaultInt("PORT", 8881),
 	}
+	cfg.Health = HealthConfig{
+		MaxRefreshAge:      getEnvWithDefaultDuration("HEALTH_MAX_REFRESH_AGE", 2*cfg.Processor.Schedule),
+		MaxCollectorAge:    getEnvWithDefaultDuration("HEALTH_MAX_COLLECTOR_AGE", 2*cfg.Collector.Schedule),
+		MaxHistoricDataAge: getEnvWithDefaultDuration("HEALTH_MAX_HISTORIC_DATA_AGE", 2*cfg.HistoricDataCollector.Schedule),
+	}
 	return cfg, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/alphagov/paas-billing/eventfetchers/cffetcher"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
@@ -16,8 +17,11 @@ var _ = Describe("Config", func() {
 		os.Unsetenv("DATABASE_URL")
 		os.Unsetenv("COLLECTOR_SCHEDULE")
 		os.Unsetenv("COLLECTOR_MIN_WAIT_TIME")
+		os.Unsetenv("COLLECTOR_INITIAL_BACKOFF")
+		os.Unsetenv("COLLECTOR_MAX_BACKOFF")
 		os.Unsetenv("CF_FETCH_LIMIT")
 		os.Unsetenv("CF_RECORD_MIN_AGE")
+		os.Unsetenv("CF_USAGE_EVENTS_API_VERSION")
 		os.Unsetenv("CF_API_ADDRESS")
 		os.Unsetenv("CF_USERNAME")
 		os.Unsetenv("CF_PASSWORD")
@@ -28,6 +32,9 @@ var _ = Describe("Config", func() {
 		os.Unsetenv("CF_USER_AGENT")
 		os.Unsetenv("PROCESSOR_SCHEDULE")
 		os.Unsetenv("PORT")
+		os.Unsetenv("HEALTH_MAX_REFRESH_AGE")
+		os.Unsetenv("HEALTH_MAX_COLLECTOR_AGE")
+		os.Unsetenv("HEALTH_MAX_HISTORIC_DATA_AGE")
 	})
 
 	It("should set sensible defaults for the config when no environment variables set", func() {
@@ -38,10 +45,16 @@ var _ = Describe("Config", func() {
 		Expect(cfg.AppRootDir).To(Equal(getwd()))
 		Expect(cfg.Collector.Schedule).To(Equal(15 * time.Minute))
 		Expect(cfg.Collector.MinWaitTime).To(Equal(3 * time.Second))
+		Expect(cfg.Collector.InitialBackoff).To(Equal(10 * time.Second))
+		Expect(cfg.Collector.MaxBackoff).To(Equal(15 * time.Minute))
 		Expect(cfg.CFFetcher.RecordMinAge).To(Equal(10 * time.Minute))
 		Expect(cfg.CFFetcher.FetchLimit).To(Equal(50))
+		Expect(cfg.CFFetcher.APIVersion).To(Equal(cffetcher.APIVersion2))
 		Expect(cfg.Processor.Schedule).To(Equal(120 * time.Minute))
 		Expect(cfg.ServerPort).To(Equal(8881))
+		Expect(cfg.Health.MaxRefreshAge).To(Equal(240 * time.Minute))
+		Expect(cfg.Health.MaxCollectorAge).To(Equal(30 * time.Minute))
+		Expect(cfg.Health.MaxHistoricDataAge).To(Equal(30 * time.Minute))
 	})
 
 	DescribeTable("should return error when failing to parse durations",
@@ -52,6 +65,8 @@ var _ = Describe("Config", func() {
 		},
 		Entry("bad schedule", "COLLECTOR_SCHEDULE"),
 		Entry("bad min wait time", "COLLECTOR_MIN_WAIT_TIME"),
+		Entry("bad initial backoff", "COLLECTOR_INITIAL_BACKOFF"),
+		Entry("bad max backoff", "COLLECTOR_MAX_BACKOFF"),
 		Entry("bad record min age", "CF_RECORD_MIN_AGE"),
 		Entry("bad processor schedule", "PROCESSOR_SCHEDULE"),
 	)
@@ -86,6 +101,40 @@ var _ = Describe("Config", func() {
 		Expect(cfg.Collector.MinWaitTime).To(Equal(6 * time.Minute))
 	})
 
+	It("should set Collector.InitialBackoff from COLLECTOR_INITIAL_BACKOFF", func() {
+		os.Setenv("COLLECTOR_INITIAL_BACKOFF", "30s")
+		cfg, err := NewConfigFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Collector.InitialBackoff).To(Equal(30 * time.Second))
+	})
+
+	It("should set Collector.MaxBackoff from COLLECTOR_MAX_BACKOFF", func() {
+		os.Setenv("COLLECTOR_MAX_BACKOFF", "5m")
+		cfg, err := NewConfigFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Collector.MaxBackoff).To(Equal(5 * time.Minute))
+	})
+
+	It("should default Collector.MaxBackoff to COLLECTOR_SCHEDULE", func() {
+		os.Setenv("COLLECTOR_SCHEDULE", "50m")
+		cfg, err := NewConfigFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Collector.MaxBackoff).To(Equal(50 * time.Minute))
+	})
+
+	It("should set Health from HEALTH_MAX_*_AGE", func() {
+		os.Setenv("HEALTH_MAX_REFRESH_AGE", "1h")
+		os.Setenv("HEALTH_MAX_COLLECTOR_AGE", "2h")
+		os.Setenv("HEALTH_MAX_HISTORIC_DATA_AGE", "3h")
+		cfg, err := NewConfigFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Health).To(Equal(HealthConfig{
+			MaxRefreshAge:      1 * time.Hour,
+			MaxCollectorAge:    2 * time.Hour,
+			MaxHistoricDataAge: 3 * time.Hour,
+		}))
+	})
+
 	It("should set CFFetcher.RecordMinAge from CF_RECORD_MIN_AGE", func() {
 		os.Setenv("CF_RECORD_MIN_AGE", "4s")
 		cfg, err := NewConfigFromEnv()
@@ -100,6 +149,13 @@ var _ = Describe("Config", func() {
 		Expect(cfg.CFFetcher.FetchLimit).To(Equal(30))
 	})
 
+	It("should set CFFetcher.APIVersion from CF_USAGE_EVENTS_API_VERSION", func() {
+		os.Setenv("CF_USAGE_EVENTS_API_VERSION", "v3")
+		cfg, err := NewConfigFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.CFFetcher.APIVersion).To(Equal(cffetcher.APIVersion3))
+	})
+
 	It("should set CFFetcher.ClientConfig.ApiAddress from CF_API_ADDRESS", func() {
 		os.Setenv("CF_API_ADDRESS", "set-in-test")
 		cfg, err := NewConfigFromEnv()
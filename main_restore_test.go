@@ -0,0 +1,35 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Restore", func() {
+
+	It("should parse a restore of a range", func() {
+		opts, err := parseRestoreArgs([]string{"--kind", "service", "--from", "2018-03-01", "--to", "2018-04-01T12:00:00Z"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts).To(Equal(restoreOptions{
+			Kind:   "service",
+			Since:  time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+			Before: time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC),
+		}))
+	})
+
+	DescribeTable("should reject invalid restore arguments",
+		func(args ...string) {
+			_, err := parseRestoreArgs(args)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing kind", "--from", "2018-03-01", "--to", "2018-04-01"),
+		Entry("unknown kind", "--kind", "compose", "--from", "2018-03-01", "--to", "2018-04-01"),
+		Entry("missing from", "--kind", "app", "--to", "2018-04-01"),
+		Entry("missing to", "--kind", "app", "--from", "2018-03-01"),
+		Entry("empty range", "--kind", "app", "--from", "2018-04-01", "--to", "2018-04-01"),
+		Entry("invalid date", "--kind", "app", "--from", "March", "--to", "2018-04-01"),
+	)
+})
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retention", func() {
+
+	It("should parse the month to detach before", func() {
+		before, err := parseRetentionArgs([]string{"--before", "2018-03-01"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(before).To(Equal(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	DescribeTable("should reject invalid retention arguments",
+		func(args ...string) {
+			_, err := parseRetentionArgs(args)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing date"),
+		Entry("invalid date", "--before", "March"),
+		Entry("not the start of a month", "--before", "2018-03-15"),
+	)
+})